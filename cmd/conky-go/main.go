@@ -31,6 +31,8 @@ type parsedFlags struct {
 	memProfile  string
 	convert     string
 	watchConfig bool
+	metricsAddr string
+	httpHost    string
 }
 
 // parseFlags parses command-line arguments and returns the parsed flags.
@@ -45,6 +47,8 @@ func parseFlags(args []string) (*parsedFlags, error) {
 	memProfile := fs.String("memprofile", "", "Write memory profile to file")
 	convert := fs.String("convert", "", "Convert legacy .conkyrc to Lua format and print to stdout")
 	watchConfig := fs.Bool("w", false, "Watch configuration file for changes and auto-reload")
+	metricsAddr := fs.String("metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9101)")
+	httpHost := fs.String("http-host", "", "Address out_to_http listens on (default 127.0.0.1; 0.0.0.0 serves other machines)")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -57,6 +61,8 @@ func parseFlags(args []string) (*parsedFlags, error) {
 		memProfile:  *memProfile,
 		convert:     *convert,
 		watchConfig: *watchConfig,
+		metricsAddr: *metricsAddr,
+		httpHost:    *httpHost,
 	}, nil
 }
 
@@ -130,6 +136,8 @@ func runWithArgs(args []string, stdout, stderr io.Writer) int {
 	// Create options with platform support and config watching
 	opts := &conky.Options{
 		WatchConfig: flags.watchConfig,
+		MetricsAddr: flags.metricsAddr,
+		HTTPHost:    flags.httpHost,
 	}
 	if platformWrapper != nil {
 		opts.Platform = platformWrapper
@@ -138,6 +146,9 @@ func runWithArgs(args []string, stdout, stderr io.Writer) int {
 	if flags.watchConfig {
 		fmt.Fprintln(stdout, "Configuration file watching enabled")
 	}
+	if flags.metricsAddr != "" {
+		fmt.Fprintf(stdout, "Serving Prometheus metrics on %s/metrics\n", flags.metricsAddr)
+	}
 
 	// Create and start using public API
 	c, err := conky.New(flags.configPath, opts)
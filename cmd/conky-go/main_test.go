@@ -24,6 +24,8 @@ func TestParseFlags(t *testing.T) {
 		wantMem    string
 		wantConv   string
 		wantWatch  bool
+		wantMetric string
+		wantHost   string
 		wantErr    bool
 	}{
 		{
@@ -61,6 +63,16 @@ func TestParseFlags(t *testing.T) {
 			args:      []string{"-w"},
 			wantWatch: true,
 		},
+		{
+			name:       "metrics addr flag",
+			args:       []string{"-metrics-addr", ":9101"},
+			wantMetric: ":9101",
+		},
+		{
+			name:     "http host flag",
+			args:     []string{"-http-host", "0.0.0.0"},
+			wantHost: "0.0.0.0",
+		},
 		{
 			name:       "all flags",
 			args:       []string{"-c", "cfg", "-v", "-cpuprofile", "c.prof", "-memprofile", "m.prof", "-w"},
@@ -107,6 +119,12 @@ func TestParseFlags(t *testing.T) {
 			if flags.watchConfig != tt.wantWatch {
 				t.Errorf("watchConfig = %v, want %v", flags.watchConfig, tt.wantWatch)
 			}
+			if flags.metricsAddr != tt.wantMetric {
+				t.Errorf("metricsAddr = %q, want %q", flags.metricsAddr, tt.wantMetric)
+			}
+			if flags.httpHost != tt.wantHost {
+				t.Errorf("httpHost = %q, want %q", flags.httpHost, tt.wantHost)
+			}
 		})
 	}
 }
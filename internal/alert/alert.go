@@ -0,0 +1,262 @@
+// Package alert evaluates threshold rules over system metrics and dispatches
+// alerts to pluggable sinks such as commands and desktop notifications.
+//
+// A rule fires once its condition has held for its duration, and resolves
+// once the condition no longer holds. While firing, the rule's thresholds are
+// widened by its hysteresis margin so that values hovering around a
+// threshold do not make it flap, and a rule fires at most once per cooldown.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Rule is a named condition and the sinks that receive its alerts.
+type Rule struct {
+	// Name identifies the rule in alerts.
+	Name string
+	// Condition is the condition that triggers the rule.
+	Condition *Condition
+	// Duration is how long the condition must hold before the rule fires.
+	Duration time.Duration
+	// Hysteresis is the margin by which values must recover past their
+	// thresholds before a firing rule resolves.
+	Hysteresis float64
+	// Cooldown is the minimum time between two firings of the rule.
+	Cooldown time.Duration
+	// Message is the alert text. Empty uses the condition.
+	Message string
+	// Sinks receive the rule's alerts.
+	Sinks []Sink
+}
+
+// Alert is a rule state change delivered to sinks.
+type Alert struct {
+	// Rule is the name of the rule.
+	Rule string
+	// Message is the rule's message, or its condition if it has none.
+	Message string
+	// Firing is true when the rule fired and false when it resolved.
+	Firing bool
+	// Time is when the state changed.
+	Time time.Time
+}
+
+// String formats the alert for logs and events.
+func (a Alert) String() string {
+	if a.Firing {
+		return fmt.Sprintf("alert %s: %s", a.Rule, a.Message)
+	}
+	return fmt.Sprintf("alert %s resolved: %s", a.Rule, a.Message)
+}
+
+// Sink delivers alerts. Sinks must not block for long: they are called on
+// the evaluation goroutine.
+type Sink interface {
+	Send(a Alert) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(a Alert) error
+
+// Send calls f(a).
+func (f SinkFunc) Send(a Alert) error {
+	return f(a)
+}
+
+// State is the evaluation state of a rule.
+type State int
+
+const (
+	// StateInactive means the condition does not hold.
+	StateInactive State = iota
+	// StatePending means the condition holds but the rule has not fired,
+	// because its duration has not elapsed or it is cooling down.
+	StatePending
+	// StateFiring means the rule has fired and not yet resolved.
+	StateFiring
+)
+
+// String returns a human-readable name for the state.
+func (s State) String() string {
+	switch s {
+	case StateInactive:
+		return "inactive"
+	case StatePending:
+		return "pending"
+	case StateFiring:
+		return "firing"
+	default:
+		return "unknown"
+	}
+}
+
+// ruleState tracks the evaluation state of one rule.
+type ruleState struct {
+	rule      Rule
+	state     State
+	since     time.Time // When the condition started to hold
+	lastFired time.Time
+}
+
+// Engine evaluates a set of rules. It is safe for concurrent use.
+type Engine struct {
+	mu    sync.Mutex
+	rules []*ruleState
+}
+
+// NewEngine creates an engine evaluating rules.
+func NewEngine(rules ...Rule) *Engine {
+	e := &Engine{rules: make([]*ruleState, 0, len(rules))}
+	for _, r := range rules {
+		e.rules = append(e.rules, &ruleState{rule: r})
+	}
+	return e
+}
+
+// Len returns the number of rules.
+func (e *Engine) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.rules)
+}
+
+// State returns the current state of the named rule.
+func (e *Engine) State(name string) State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, rs := range e.rules {
+		if rs.rule.Name == name {
+			return rs.state
+		}
+	}
+	return StateInactive
+}
+
+// Adopt takes over the state of the rules of old, such as the engine built
+// from the previous configuration, so that reloading the rules does not
+// make them fire again: a rule of e with the same name as a rule of old
+// continues from its state and cooldown. Firing rules of old that e no
+// longer has are resolved at time now. Old is left without rules, so a
+// concurrent Check on it has no effect. It returns the errors reported by
+// the sinks.
+func (e *Engine) Adopt(old *Engine, now time.Time) error {
+	if old == nil || old == e {
+		return nil
+	}
+	old.mu.Lock()
+	previous := old.rules
+	old.rules = nil
+	old.mu.Unlock()
+
+	e.mu.Lock()
+	byName := make(map[string]*ruleState, len(e.rules))
+	for _, rs := range e.rules {
+		byName[rs.rule.Name] = rs
+	}
+	var dropped []*ruleState
+	for _, prev := range previous {
+		if rs, ok := byName[prev.rule.Name]; ok {
+			rs.state, rs.since, rs.lastFired = prev.state, prev.since, prev.lastFired
+		} else if prev.state == StateFiring {
+			dropped = append(dropped, prev)
+		}
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, rs := range dropped {
+		a := rs.alert(now, false)
+		for _, sink := range rs.rule.Sinks {
+			if err := sink.Send(a); err != nil {
+				errs = append(errs, fmt.Errorf("alert %s: %w", a.Rule, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check evaluates every rule against values at time now and sends alerts
+// for the rules that fired or resolved. It returns the errors reported by
+// the sinks.
+func (e *Engine) Check(now time.Time, values Values) error {
+	e.mu.Lock()
+	var alerts []Alert
+	var sinks [][]Sink
+	for _, rs := range e.rules {
+		if a, ok := rs.check(now, values); ok {
+			alerts = append(alerts, a)
+			sinks = append(sinks, rs.rule.Sinks)
+		}
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for i, a := range alerts {
+		for _, sink := range sinks[i] {
+			if err := sink.Send(a); err != nil {
+				errs = append(errs, fmt.Errorf("alert %s: %w", a.Rule, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check advances the rule's state machine, returning the alert to send if
+// the rule fired or resolved.
+func (rs *ruleState) check(now time.Time, values Values) (Alert, bool) {
+	relax := 0.0
+	if rs.state == StateFiring {
+		relax = rs.rule.Hysteresis
+	}
+	held := rs.rule.Condition.Eval(values, relax)
+
+	switch rs.state {
+	case StateInactive:
+		if !held {
+			return Alert{}, false
+		}
+		rs.state = StatePending
+		rs.since = now
+		return rs.fireIfDue(now)
+	case StatePending:
+		if !held {
+			rs.state = StateInactive
+			return Alert{}, false
+		}
+		return rs.fireIfDue(now)
+	case StateFiring:
+		if held {
+			return Alert{}, false
+		}
+		rs.state = StateInactive
+		return rs.alert(now, false), true
+	}
+	return Alert{}, false
+}
+
+// fireIfDue fires a pending rule once its duration and cooldown have
+// elapsed.
+func (rs *ruleState) fireIfDue(now time.Time) (Alert, bool) {
+	if now.Sub(rs.since) < rs.rule.Duration {
+		return Alert{}, false
+	}
+	if !rs.lastFired.IsZero() && now.Sub(rs.lastFired) < rs.rule.Cooldown {
+		return Alert{}, false
+	}
+	rs.state = StateFiring
+	rs.lastFired = now
+	return rs.alert(now, true), true
+}
+
+// alert builds an alert for the rule.
+func (rs *ruleState) alert(now time.Time, firing bool) Alert {
+	msg := rs.rule.Message
+	if msg == "" {
+		msg = rs.rule.Condition.String()
+	}
+	return Alert{Rule: rs.rule.Name, Message: msg, Firing: firing, Time: now}
+}
@@ -0,0 +1,228 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink records the alerts it receives.
+type recordingSink struct {
+	alerts []Alert
+}
+
+func (s *recordingSink) Send(a Alert) error {
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+
+func mustParse(t *testing.T, s string) *Condition {
+	t.Helper()
+	c, err := ParseCondition(s)
+	if err != nil {
+		t.Fatalf("ParseCondition(%q) error = %v", s, err)
+	}
+	return c
+}
+
+func TestEngineDuration(t *testing.T) {
+	sink := &recordingSink{}
+	e := NewEngine(Rule{
+		Name:      "hot",
+		Condition: mustParse(t, "temp > 85"),
+		Duration:  30 * time.Second,
+		Sinks:     []Sink{sink},
+	})
+	start := time.Unix(1000, 0)
+	temp := 90.0
+	values := func(string) (float64, bool) { return temp, true }
+
+	steps := []struct {
+		offset time.Duration
+		temp   float64
+		state  State
+		alerts int
+	}{
+		{0, 90, StatePending, 0},
+		{20 * time.Second, 90, StatePending, 0},
+		{30 * time.Second, 90, StateFiring, 1},
+		{40 * time.Second, 90, StateFiring, 1},
+		{50 * time.Second, 70, StateInactive, 2},
+	}
+	for _, step := range steps {
+		temp = step.temp
+		if err := e.Check(start.Add(step.offset), values); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if got := e.State("hot"); got != step.state {
+			t.Errorf("at %v: state = %v, want %v", step.offset, got, step.state)
+		}
+		if len(sink.alerts) != step.alerts {
+			t.Errorf("at %v: %d alerts, want %d", step.offset, len(sink.alerts), step.alerts)
+		}
+	}
+
+	if !sink.alerts[0].Firing || sink.alerts[1].Firing {
+		t.Errorf("alerts = %+v, want firing then resolved", sink.alerts)
+	}
+	if sink.alerts[0].Message != "temp > 85" {
+		t.Errorf("Message = %q, want condition source", sink.alerts[0].Message)
+	}
+}
+
+func TestEngineHysteresis(t *testing.T) {
+	sink := &recordingSink{}
+	e := NewEngine(Rule{
+		Name:       "hot",
+		Condition:  mustParse(t, "temp > 85"),
+		Hysteresis: 5,
+		Sinks:      []Sink{sink},
+	})
+	now := time.Unix(1000, 0)
+	for _, temp := range []float64{86, 84, 86, 81, 79} {
+		values := func(string) (float64, bool) { return temp, true }
+		_ = e.Check(now, values)
+		now = now.Add(time.Second)
+	}
+
+	if len(sink.alerts) != 2 {
+		t.Fatalf("%d alerts, want 2 (fire once, resolve below 80)", len(sink.alerts))
+	}
+	if want := time.Unix(1004, 0); !sink.alerts[1].Time.Equal(want) {
+		t.Errorf("resolved at %v, want %v", sink.alerts[1].Time, want)
+	}
+}
+
+func TestEngineCooldown(t *testing.T) {
+	sink := &recordingSink{}
+	e := NewEngine(Rule{
+		Name:      "full",
+		Condition: mustParse(t, "disk > 90"),
+		Cooldown:  time.Minute,
+		Message:   "disk almost full",
+		Sinks:     []Sink{sink},
+	})
+	start := time.Unix(1000, 0)
+	check := func(offset time.Duration, disk float64) {
+		_ = e.Check(start.Add(offset), func(string) (float64, bool) { return disk, true })
+	}
+
+	check(0, 95)              // fires
+	check(10*time.Second, 80) // resolves
+	check(20*time.Second, 95) // held back by cooldown
+	check(59*time.Second, 95) // still cooling down
+	check(60*time.Second, 95) // fires again
+	check(61*time.Second, 95) // still firing
+
+	var fired int
+	for _, a := range sink.alerts {
+		if a.Firing {
+			fired++
+			if a.Message != "disk almost full" {
+				t.Errorf("Message = %q, want rule message", a.Message)
+			}
+		}
+	}
+	if fired != 2 {
+		t.Errorf("fired %d times, want 2", fired)
+	}
+}
+
+func TestEngineSinkErrors(t *testing.T) {
+	failing := SinkFunc(func(Alert) error { return errors.New("unreachable") })
+	ok := &recordingSink{}
+	e := NewEngine(Rule{
+		Name:      "any",
+		Condition: mustParse(t, "x"),
+		Sinks:     []Sink{failing, ok},
+	})
+
+	err := e.Check(time.Now(), func(string) (float64, bool) { return 1, true })
+	if err == nil || !strings.Contains(err.Error(), "alert any: unreachable") {
+		t.Errorf("Check() error = %v, want sink error", err)
+	}
+	if len(ok.alerts) != 1 {
+		t.Errorf("later sink received %d alerts, want 1", len(ok.alerts))
+	}
+}
+
+// startRecorder records the commands started through it.
+type startRecorder struct {
+	commands []string
+	envs     [][]string
+}
+
+func (r *startRecorder) Start(command string, env ...string) error {
+	r.commands = append(r.commands, command)
+	r.envs = append(r.envs, env)
+	return nil
+}
+
+func TestExecSink(t *testing.T) {
+	runner := &startRecorder{}
+	sink := &ExecSink{Command: "notify.sh", Runner: runner}
+
+	if err := sink.Send(Alert{Rule: "low", Message: "battery low", Firing: false}); err != nil {
+		t.Fatalf("Send(resolved) error = %v", err)
+	}
+	if err := sink.Send(Alert{Rule: "low", Message: "battery low", Firing: true}); err != nil {
+		t.Fatalf("Send(firing) error = %v", err)
+	}
+
+	if !slices.Equal(runner.commands, []string{"notify.sh"}) {
+		t.Fatalf("started %q, want the command once", runner.commands)
+	}
+	want := []string{"CONKY_ALERT_NAME=low", "CONKY_ALERT_MESSAGE=battery low"}
+	if !slices.Equal(runner.envs[0], want) {
+		t.Errorf("env = %q, want %q", runner.envs[0], want)
+	}
+}
+
+func TestEngineAdopt(t *testing.T) {
+	sink := &recordingSink{}
+	rule := func(name string) Rule {
+		return Rule{Name: name, Condition: mustParse(t, "x > 1"), Cooldown: time.Minute, Sinks: []Sink{sink}}
+	}
+	start := time.Unix(1000, 0)
+	high := func(string) (float64, bool) { return 2, true }
+
+	old := NewEngine(rule("kept"), rule("removed"))
+	_ = old.Check(start, high)
+	if len(sink.alerts) != 2 {
+		t.Fatalf("fired %d alerts, want 2", len(sink.alerts))
+	}
+
+	e := NewEngine(rule("kept"), rule("added"))
+	if err := e.Adopt(old, start.Add(time.Second)); err != nil {
+		t.Fatalf("Adopt() error = %v", err)
+	}
+	if old.Len() != 0 {
+		t.Errorf("old engine kept %d rules", old.Len())
+	}
+	if got := sink.alerts[2:]; len(got) != 1 || got[0].Rule != "removed" || got[0].Firing {
+		t.Fatalf("Adopt() sent %+v, want the removed rule resolved", got)
+	}
+	if state := e.State("kept"); state != StateFiring {
+		t.Errorf("State(kept) = %v, want firing", state)
+	}
+
+	// The kept rule does not fire again; only the added one does
+	sink.alerts = nil
+	_ = e.Check(start.Add(2*time.Second), high)
+	if len(sink.alerts) != 1 || sink.alerts[0].Rule != "added" {
+		t.Errorf("alerts after Adopt = %+v, want only the added rule firing", sink.alerts)
+	}
+
+	// The kept rule's cooldown still counts from its first firing
+	sink.alerts = nil
+	low := func(string) (float64, bool) { return 0, true }
+	_ = e.Check(start.Add(3*time.Second), low)
+	_ = e.Check(start.Add(4*time.Second), high)
+	for _, a := range sink.alerts {
+		if a.Rule == "kept" && a.Firing {
+			t.Errorf("kept rule fired again within its cooldown")
+		}
+	}
+}
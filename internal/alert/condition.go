@@ -0,0 +1,233 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Values looks up the current value of a metric by ID. ok is false when the
+// metric is not available.
+type Values func(id string) (value float64, ok bool)
+
+// Condition is a compiled alert condition.
+//
+// The condition language combines comparisons of metric values with
+// constants using and, or, not and parentheses:
+//
+//	battery.BAT0.percent < 10 and battery.BAT0.discharging
+//	fs./home.used_perc > 90
+//	hwmon.coretemp.temp1 >= 85 or cpu.total > 95
+//
+// Metric IDs are those recorded by the system monitor's history. The
+// comparison operators are <, <=, >, >=, == and !=, and must be separated
+// from their operands by spaces. A metric without a comparison is true when
+// its value is non-zero. Comparisons against unavailable metrics are false.
+type Condition struct {
+	source string
+	root   node
+}
+
+// ParseCondition compiles a condition expression.
+func ParseCondition(s string) (*Condition, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", s, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("condition %q: unexpected %q", s, p.tokens[p.pos])
+	}
+	return &Condition{source: s, root: root}, nil
+}
+
+// String returns the condition source.
+func (c *Condition) String() string {
+	return c.source
+}
+
+// Eval evaluates the condition. relax widens every threshold by that amount
+// in the condition's favor, so that a firing rule does not clear until its
+// values have recovered past the threshold by the hysteresis margin.
+func (c *Condition) Eval(values Values, relax float64) bool {
+	return c.root.eval(values, relax)
+}
+
+// node is a node of the condition syntax tree.
+type node interface {
+	eval(values Values, relax float64) bool
+}
+
+// andNode is true when both operands are true.
+type andNode struct{ left, right node }
+
+func (n andNode) eval(values Values, relax float64) bool {
+	return n.left.eval(values, relax) && n.right.eval(values, relax)
+}
+
+// orNode is true when either operand is true.
+type orNode struct{ left, right node }
+
+func (n orNode) eval(values Values, relax float64) bool {
+	return n.left.eval(values, relax) || n.right.eval(values, relax)
+}
+
+// notNode negates its operand. Relaxing a negated comparison would tighten
+// it, so the operand is evaluated with the opposite adjustment.
+type notNode struct{ operand node }
+
+func (n notNode) eval(values Values, relax float64) bool {
+	return !n.operand.eval(values, -relax)
+}
+
+// compareNode compares a metric with a constant. An empty op tests the
+// metric for a non-zero value.
+type compareNode struct {
+	metric    string
+	op        string
+	threshold float64
+}
+
+func (n compareNode) eval(values Values, relax float64) bool {
+	v, ok := values(n.metric)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "":
+		return v != 0
+	case "<":
+		return v < n.threshold+relax
+	case "<=":
+		return v <= n.threshold+relax
+	case ">":
+		return v > n.threshold-relax
+	case ">=":
+		return v >= n.threshold-relax
+	case "==":
+		return v == n.threshold
+	case "!=":
+		return v != n.threshold
+	}
+	return false
+}
+
+// parser is a recursive descent parser over condition tokens.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token, or "" at the end.
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// next consumes and returns the next token, or "" at the end.
+func (p *parser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses: and { "or" and }.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary { "and" unary }.
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: "not" unary | "(" or ")" | comparison.
+func (p *parser) parseUnary() (node, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	case strings.EqualFold(tok, "not"):
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case tok == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	case tok == ")" || isOperator(tok) || isKeyword(tok):
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+
+	cmp := compareNode{metric: tok}
+	if !isOperator(p.peek()) {
+		return cmp, nil
+	}
+	cmp.op = p.next()
+	operand := p.next()
+	threshold, err := strconv.ParseFloat(operand, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: expected a number, got %q", cmp.metric, cmp.op, operand)
+	}
+	cmp.threshold = threshold
+	return cmp, nil
+}
+
+// isOperator reports whether tok is a comparison operator.
+func isOperator(tok string) bool {
+	switch tok {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+// isKeyword reports whether tok is a logical keyword.
+func isKeyword(tok string) bool {
+	return strings.EqualFold(tok, "and") || strings.EqualFold(tok, "or") || strings.EqualFold(tok, "not")
+}
+
+// tokenize splits a condition at whitespace, keeping parentheses as
+// separate tokens.
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
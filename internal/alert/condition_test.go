@@ -0,0 +1,91 @@
+package alert
+
+import "testing"
+
+func mapValues(m map[string]float64) Values {
+	return func(id string) (float64, bool) {
+		v, ok := m[id]
+		return v, ok
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	values := mapValues(map[string]float64{
+		"battery.BAT0.percent":     8,
+		"battery.BAT0.discharging": 1,
+		"fs./home.used_perc":       91.5,
+		"cpu.total":                40,
+	})
+
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"battery.BAT0.percent < 10", true},
+		{"battery.BAT0.percent < 10 and battery.BAT0.discharging", true},
+		{"battery.BAT0.percent < 5 and battery.BAT0.discharging", false},
+		{"fs./home.used_perc > 90", true},
+		{"fs./home.used_perc >= 95 or cpu.total > 30", true},
+		{"not cpu.total > 30", false},
+		{"NOT (cpu.total > 50 OR fs./home.used_perc < 50)", true},
+		{"cpu.total == 40 and cpu.total != 41", true},
+		{"cpu.total <= 40", true},
+		{"missing.metric > 0", false},
+		{"not missing.metric", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			c, err := ParseCondition(tt.cond)
+			if err != nil {
+				t.Fatalf("ParseCondition() error = %v", err)
+			}
+			if got := c.Eval(values, 0); got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionRelax(t *testing.T) {
+	values := mapValues(map[string]float64{"hwmon.coretemp.temp1": 83})
+
+	tests := []struct {
+		cond  string
+		relax float64
+		want  bool
+	}{
+		{"hwmon.coretemp.temp1 > 85", 0, false},
+		{"hwmon.coretemp.temp1 > 85", 5, true},
+		{"hwmon.coretemp.temp1 < 80", 5, true},
+		{"not hwmon.coretemp.temp1 < 85", 0, false},
+		{"not hwmon.coretemp.temp1 < 85", 5, true},
+		{"not hwmon.coretemp.temp1 < 82", 0, true},
+		{"not hwmon.coretemp.temp1 < 90", 5, false},
+	}
+	for _, tt := range tests {
+		c, err := ParseCondition(tt.cond)
+		if err != nil {
+			t.Fatalf("ParseCondition(%q) error = %v", tt.cond, err)
+		}
+		if got := c.Eval(values, tt.relax); got != tt.want {
+			t.Errorf("%q Eval(relax=%v) = %v, want %v", tt.cond, tt.relax, got, tt.want)
+		}
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, cond := range []string{
+		"",
+		"cpu.total >",
+		"cpu.total > high",
+		"cpu.total > 90 and",
+		"(cpu.total > 90",
+		"cpu.total > 90)",
+		"> 90",
+		"cpu.total 90",
+	} {
+		if _, err := ParseCondition(cond); err == nil {
+			t.Errorf("ParseCondition(%q) error = nil, want error", cond)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// CommandStarter starts shell commands in the background with extra
+// environment variables. lua.ExecScheduler implements it, bounding how long
+// and how many commands run and killing them when the instance stops.
+type CommandStarter interface {
+	Start(command string, env ...string) error
+}
+
+// ExecSink runs a shell command through Runner when a rule fires, with the
+// alert in the CONKY_ALERT_NAME and CONKY_ALERT_MESSAGE environment
+// variables. Resolutions are not delivered.
+type ExecSink struct {
+	// Command is the shell command to run.
+	Command string
+	// Runner starts the command.
+	Runner CommandStarter
+}
+
+// Send starts the command for firing alerts without waiting for it to exit.
+func (s *ExecSink) Send(a Alert) error {
+	if !a.Firing {
+		return nil
+	}
+	err := s.Runner.Start(s.Command,
+		"CONKY_ALERT_NAME="+a.Rule,
+		"CONKY_ALERT_MESSAGE="+a.Message,
+	)
+	if err != nil {
+		return fmt.Errorf("exec %q: %w", s.Command, err)
+	}
+	return nil
+}
+
+// Freedesktop notification service constants.
+const (
+	notifyDest   = "org.freedesktop.Notifications"
+	notifyPath   = "/org/freedesktop/Notifications"
+	notifyMethod = "org.freedesktop.Notifications.Notify"
+	notifyApp    = "conky-go"
+	// notifyTimeout bounds a Notify call so that a stalled notification
+	// daemon cannot stall rule evaluation.
+	notifyTimeout = 2 * time.Second
+)
+
+// NotifySink shows firing alerts as desktop notifications through the
+// freedesktop Notify D-Bus method on the session bus. A rule that fires
+// again replaces its previous notification. Resolutions are not delivered.
+type NotifySink struct {
+	mu  sync.Mutex
+	ids map[string]uint32 // Notification IDs by rule name
+}
+
+// NewNotifySink creates a NotifySink.
+func NewNotifySink() *NotifySink {
+	return &NotifySink{ids: make(map[string]uint32)}
+}
+
+// Send posts a notification for firing alerts.
+func (s *NotifySink) Send(a Alert) error {
+	if !a.Firing {
+		return nil
+	}
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
+	s.mu.Lock()
+	replaces := s.ids[a.Rule]
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	var id uint32
+	err = conn.Object(notifyDest, notifyPath).CallWithContext(ctx, notifyMethod, 0,
+		notifyApp, replaces, "", a.Rule, a.Message,
+		[]string{}, map[string]dbus.Variant{}, int32(-1),
+	).Store(&id)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
+	s.mu.Lock()
+	s.ids[a.Rule] = id
+	s.mu.Unlock()
+	return nil
+}
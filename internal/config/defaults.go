@@ -17,6 +17,12 @@ const (
 	DefaultFont = "DejaVu Sans Mono"
 	// DefaultFontSize is the default font size in points.
 	DefaultFontSize = 10.0
+	// DefaultHTTPPort is the default port for out_to_http, as in Conky.
+	DefaultHTTPPort = 10080
+	// DefaultMPDHost is the default MPD server host.
+	DefaultMPDHost = "localhost"
+	// DefaultMPDPort is the default MPD server port.
+	DefaultMPDPort = 6600
 )
 
 // Default colors.
@@ -104,6 +110,14 @@ func defaultLuaConfig() LuaConfig {
 	}
 }
 
+// defaultOutputConfig returns an OutputConfig that draws to a window only.
+func defaultOutputConfig() OutputConfig {
+	return OutputConfig{
+		ToX:      true,
+		HTTPPort: DefaultHTTPPort,
+	}
+}
+
 // DefaultConfig returns a Config with sensible default values.
 // These defaults mirror typical Conky configuration defaults.
 func DefaultConfig() Config {
@@ -115,6 +129,8 @@ func DefaultConfig() Config {
 		},
 		Colors: defaultColorConfig(),
 		Lua:    defaultLuaConfig(),
+		Output: defaultOutputConfig(),
+		MPD:    MPDConfig{Host: DefaultMPDHost, Port: DefaultMPDPort},
 	}
 }
 
@@ -137,3 +153,8 @@ func DefaultColorConfig() ColorConfig {
 func DefaultLuaConfig() LuaConfig {
 	return defaultLuaConfig()
 }
+
+// DefaultOutputConfig returns an OutputConfig with default values.
+func DefaultOutputConfig() OutputConfig {
+	return defaultOutputConfig()
+}
@@ -1,9 +1,12 @@
 // Package config provides configuration parsing for conky-go.
-// This file implements environment variable expansion support for configuration values.
+// This file implements environment variable expansion support for configuration values
+// and the resolution of credentials from the environment or files.
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -145,3 +148,63 @@ func ExpandEnvConfigWithOptions(cfg *Config, opts ...EnvConfigOption) {
 		}
 	}
 }
+
+// ResolveSecret resolves a credential setting such as a mail or MPD
+// password, so that secrets need not be written into the configuration:
+//   - env:NAME - the value of environment variable NAME, which must be set
+//   - file:PATH - the first line of the file at PATH (~ is expanded)
+//
+// Any other value is returned unchanged as a literal password.
+func ResolveSecret(value string) (string, error) {
+	return SecretPolicy{}.Resolve(value)
+}
+
+// SecretPolicy restricts the sources secrets are resolved from, for
+// configurations that must not read arbitrary files or the environment.
+// The zero SecretPolicy allows every source.
+type SecretPolicy struct {
+	// CheckEnv, if set, is called with the variable name of an env: secret
+	// and refuses the secret by returning an error.
+	CheckEnv func(name string) error
+	// CheckRead, if set, is called with the expanded path of a file: secret
+	// and refuses the secret by returning an error.
+	CheckRead func(path string) error
+}
+
+// Resolve resolves value like ResolveSecret, first passing env: and file:
+// references to the policy's checks.
+func (p SecretPolicy) Resolve(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, "env:"); ok {
+		if p.CheckEnv != nil {
+			if err := p.CheckEnv(name); err != nil {
+				return "", err
+			}
+		}
+		secret, set := os.LookupEnv(name)
+		if !set {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	}
+	if path, ok := strings.CutPrefix(value, "file:"); ok {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("resolving %s: %w", path, err)
+			}
+			path = filepath.Join(home, rest)
+		}
+		if p.CheckRead != nil {
+			if err := p.CheckRead(path); err != nil {
+				return "", err
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading secret: %w", err)
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimRight(line, "\r"), nil
+	}
+	return value, nil
+}
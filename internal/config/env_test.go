@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -309,3 +311,76 @@ func TestExpandEnvVariableNameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("CONKY_TEST_SECRET", "from-env")
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\nignored\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"literal", "literal", false},
+		{"env:CONKY_TEST_SECRET", "from-env", false},
+		{"env:CONKY_TEST_UNSET_SECRET", "", true},
+		{"file:" + path, "from-file", false},
+		{"file:" + path + ".missing", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveSecret(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveSecret(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ResolveSecret(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSecretPolicyResolve(t *testing.T) {
+	t.Setenv("CONKY_TEST_SECRET", "from-env")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	errRefused := errors.New("refused")
+	var checkedEnv, checkedPath string
+	policy := SecretPolicy{
+		CheckEnv: func(name string) error {
+			checkedEnv = name
+			return errRefused
+		},
+		CheckRead: func(path string) error {
+			checkedPath = path
+			if filepath.Dir(path) != dir {
+				return errRefused
+			}
+			return nil
+		},
+	}
+
+	if _, err := policy.Resolve("env:CONKY_TEST_SECRET"); !errors.Is(err, errRefused) {
+		t.Errorf("Resolve(env:) error = %v, want the CheckEnv error", err)
+	}
+	if checkedEnv != "CONKY_TEST_SECRET" {
+		t.Errorf("CheckEnv called with %q", checkedEnv)
+	}
+	if got, err := policy.Resolve("file:" + path); err != nil || got != "from-file" {
+		t.Errorf("Resolve(file:) = %q, %v, want %q", got, err, "from-file")
+	}
+	if _, err := policy.Resolve("file:~/.ssh/id_rsa"); !errors.Is(err, errRefused) {
+		t.Errorf("Resolve(file:~/...) error = %v, want the CheckRead error", err)
+	}
+	if home, err := os.UserHomeDir(); err == nil && checkedPath != filepath.Join(home, ".ssh/id_rsa") {
+		t.Errorf("CheckRead called with %q, want the expanded path", checkedPath)
+	}
+	if got, err := policy.Resolve("literal"); err != nil || got != "literal" {
+		t.Errorf("Resolve(literal) = %q, %v", got, err)
+	}
+}
@@ -328,6 +328,68 @@ func (p *LegacyParser) parseDirective(cfg *Config, line string, lineNum int) err
 			cfg.Lua.MemoryLimit = uint64(limit)
 		}
 
+	// Lua scripts and hooks
+	case "lua_load":
+		cfg.Lua.Load = append(cfg.Lua.Load, strings.Fields(value)...)
+	case "lua_startup_hook":
+		cfg.Lua.StartupHook = value
+	case "lua_shutdown_hook":
+		cfg.Lua.ShutdownHook = value
+	case "lua_draw_hook_pre":
+		cfg.Lua.DrawHookPre = value
+	case "lua_draw_hook_post":
+		cfg.Lua.DrawHookPost = value
+
+	// Output destinations
+	case "out_to_x":
+		cfg.Output.ToX = parseBool(value)
+	case "out_to_console":
+		cfg.Output.ToConsole = parseBool(value)
+	case "out_to_stderr":
+		cfg.Output.ToStderr = parseBool(value)
+	case "out_to_http":
+		cfg.Output.ToHTTP = parseBool(value)
+	case "http_port":
+		port, err := parseInt(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid http_port: %w", lineNum, err)
+		}
+		cfg.Output.HTTPPort = port
+	case "http_refresh":
+		cfg.Output.HTTPRefresh = parseBool(value)
+
+	// Mail servers and MPD connection
+	case "current_mail_spool":
+		cfg.Mail.Spool = value
+	case "imap", "pop3":
+		server, err := ParseMailServer(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid %s: %w", lineNum, key, err)
+		}
+		if key == "imap" {
+			cfg.Mail.IMAP = &server
+		} else {
+			cfg.Mail.POP3 = &server
+		}
+	case "mpd_host":
+		cfg.MPD.Host = value
+	case "mpd_port":
+		port, err := parseInt(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid mpd_port: %w", lineNum, err)
+		}
+		cfg.MPD.Port = port
+	case "mpd_password":
+		cfg.MPD.Password = value
+
+	// Weather data source
+	case "weather_provider":
+		cfg.Weather.Provider = value
+	case "weather_url":
+		cfg.Weather.URL = value
+	case "weather_units":
+		cfg.Weather.Units = value
+
 	default:
 		// Unknown directives are silently ignored for forward compatibility
 	}
@@ -861,3 +861,121 @@ func TestLegacyParserOwnWindowColour(t *testing.T) {
 		})
 	}
 }
+
+func TestLegacyParserLuaSettings(t *testing.T) {
+	content := []byte(`lua_load ~/.conky/rings.lua clock.lua
+lua_load extra.lua
+lua_startup_hook setup
+lua_shutdown_hook teardown
+lua_draw_hook_pre conky_ring_stats 4 cpu
+lua_draw_hook_post main
+TEXT
+`)
+
+	p := NewLegacyParser()
+	cfg, err := p.Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	wantLoad := []string{"~/.conky/rings.lua", "clock.lua", "extra.lua"}
+	if len(cfg.Lua.Load) != len(wantLoad) {
+		t.Fatalf("Load = %v, want %v", cfg.Lua.Load, wantLoad)
+	}
+	for i, path := range wantLoad {
+		if cfg.Lua.Load[i] != path {
+			t.Errorf("Load[%d] = %q, want %q", i, cfg.Lua.Load[i], path)
+		}
+	}
+	if cfg.Lua.StartupHook != "setup" {
+		t.Errorf("StartupHook = %q, want %q", cfg.Lua.StartupHook, "setup")
+	}
+	if cfg.Lua.ShutdownHook != "teardown" {
+		t.Errorf("ShutdownHook = %q, want %q", cfg.Lua.ShutdownHook, "teardown")
+	}
+	if cfg.Lua.DrawHookPre != "conky_ring_stats 4 cpu" {
+		t.Errorf("DrawHookPre = %q, want %q", cfg.Lua.DrawHookPre, "conky_ring_stats 4 cpu")
+	}
+	if cfg.Lua.DrawHookPost != "main" {
+		t.Errorf("DrawHookPost = %q, want %q", cfg.Lua.DrawHookPost, "main")
+	}
+}
+
+func TestLegacyParserOutputSettings(t *testing.T) {
+	p := NewLegacyParser()
+
+	cfg, err := p.Parse([]byte("TEXT\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.Output != DefaultOutputConfig() {
+		t.Errorf("default Output = %+v, want %+v", cfg.Output, DefaultOutputConfig())
+	}
+
+	cfg, err = p.Parse([]byte(`out_to_x no
+out_to_console yes
+out_to_stderr yes
+out_to_http yes
+http_port 8080
+http_refresh yes
+TEXT
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := OutputConfig{
+		ToX: false, ToConsole: true, ToStderr: true,
+		ToHTTP: true, HTTPPort: 8080, HTTPRefresh: true,
+	}
+	if cfg.Output != want {
+		t.Errorf("Output = %+v, want %+v", cfg.Output, want)
+	}
+
+	if _, err := p.Parse([]byte("http_port web\nTEXT\n")); err == nil {
+		t.Error("expected error for invalid http_port")
+	}
+}
+
+func TestLegacyParserMailAndMPDSettings(t *testing.T) {
+	p := NewLegacyParser()
+
+	cfg, err := p.Parse([]byte(`current_mail_spool ~/Maildir
+imap imap.example.com alice file:~/.imap-pass -f 'INBOX' -s
+pop3 pop.example.com bob env:POP3_PASS
+mpd_host music.lan
+mpd_port 6601
+mpd_password env:MPD_PASS
+weather_provider open-meteo
+weather_url http://weather.lan:8080
+weather_units imperial
+TEXT
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.Mail.Spool != "~/Maildir" {
+		t.Errorf("Mail.Spool = %q, want ~/Maildir", cfg.Mail.Spool)
+	}
+	wantIMAP := MailServerConfig{Host: "imap.example.com", User: "alice", Password: "file:~/.imap-pass", Folder: "INBOX", TLS: true}
+	if cfg.Mail.IMAP == nil || *cfg.Mail.IMAP != wantIMAP {
+		t.Errorf("Mail.IMAP = %+v, want %+v", cfg.Mail.IMAP, wantIMAP)
+	}
+	if cfg.Mail.POP3 == nil || cfg.Mail.POP3.Password != "env:POP3_PASS" {
+		t.Errorf("Mail.POP3 = %+v", cfg.Mail.POP3)
+	}
+	wantMPD := MPDConfig{Host: "music.lan", Port: 6601, Password: "env:MPD_PASS"}
+	if cfg.MPD != wantMPD {
+		t.Errorf("MPD = %+v, want %+v", cfg.MPD, wantMPD)
+	}
+	wantWeather := WeatherConfig{Provider: "open-meteo", URL: "http://weather.lan:8080", Units: "imperial"}
+	if cfg.Weather != wantWeather {
+		t.Errorf("Weather = %+v, want %+v", cfg.Weather, wantWeather)
+	}
+
+	for _, bad := range []string{"imap host user\nTEXT\n", "mpd_port music\nTEXT\n"} {
+		if _, err := p.Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", bad)
+		}
+	}
+}
@@ -13,6 +13,13 @@ import (
 	"time"
 
 	"github.com/arnodel/golua/lib"
+	"github.com/arnodel/golua/lib/base"
+	"github.com/arnodel/golua/lib/coroutine"
+	"github.com/arnodel/golua/lib/mathlib"
+	"github.com/arnodel/golua/lib/packagelib"
+	"github.com/arnodel/golua/lib/stringlib"
+	"github.com/arnodel/golua/lib/tablelib"
+	"github.com/arnodel/golua/lib/utf8lib"
 	rt "github.com/arnodel/golua/runtime"
 )
 
@@ -45,6 +52,33 @@ func NewLuaConfigParserWithOutput(stdout io.Writer) (*LuaConfigParser, error) {
 	}, nil
 }
 
+// NewSandboxedLuaConfigParser creates a LuaConfigParser for configurations
+// from untrusted sources. Only the base, coroutine, string, table, math and
+// utf8 libraries are available, without dofile and loadfile, so the
+// configuration cannot run commands, use files or load modules.
+func NewSandboxedLuaConfigParser() (*LuaConfigParser, error) {
+	runtime := rt.New(io.Discard)
+	cleanup := lib.LoadLibs(
+		runtime,
+		base.LibLoader,
+		packagelib.LibLoader, // Registers the other libraries
+		coroutine.LibLoader,
+		stringlib.LibLoader,
+		tablelib.LibLoader,
+		mathlib.LibLoader,
+		utf8lib.LibLoader,
+	)
+	env := runtime.GlobalEnv()
+	for _, name := range []string{"package", "require", "dofile", "loadfile"} {
+		env.Set(rt.StringValue(name), rt.NilValue)
+	}
+
+	return &LuaConfigParser{
+		runtime: runtime,
+		cleanup: cleanup,
+	}, nil
+}
+
 // Parse parses a Lua configuration from content bytes.
 // It executes the Lua code and extracts configuration from conky.config and conky.text.
 func (p *LuaConfigParser) Parse(content []byte) (*Config, error) {
@@ -263,6 +297,91 @@ func (p *LuaConfigParser) extractConfigTable(cfg *Config, table *rt.Table) error
 		cfg.Lua.MemoryLimit = uint64(*val)
 	}
 
+	// Lua scripts and hooks
+	if val := getTableString(table, "lua_load"); val != nil {
+		cfg.Lua.Load = strings.Fields(*val)
+	}
+	luaHooks := []struct {
+		key    string
+		target *string
+	}{
+		{"lua_startup_hook", &cfg.Lua.StartupHook},
+		{"lua_shutdown_hook", &cfg.Lua.ShutdownHook},
+		{"lua_draw_hook_pre", &cfg.Lua.DrawHookPre},
+		{"lua_draw_hook_post", &cfg.Lua.DrawHookPost},
+	}
+	for _, h := range luaHooks {
+		if val := getTableString(table, h.key); val != nil {
+			*h.target = strings.TrimSpace(*val)
+		}
+	}
+
+	// Output destinations
+	if val := getTableBool(table, "out_to_x"); val != nil {
+		cfg.Output.ToX = *val
+	}
+	if val := getTableBool(table, "out_to_console"); val != nil {
+		cfg.Output.ToConsole = *val
+	}
+	if val := getTableBool(table, "out_to_stderr"); val != nil {
+		cfg.Output.ToStderr = *val
+	}
+	if val := getTableBool(table, "out_to_http"); val != nil {
+		cfg.Output.ToHTTP = *val
+	}
+	if val := getTableInt(table, "http_port"); val != nil {
+		cfg.Output.HTTPPort = *val
+	}
+	if val := getTableBool(table, "http_refresh"); val != nil {
+		cfg.Output.HTTPRefresh = *val
+	}
+
+	// Alert rules (array of nested tables)
+	if err := p.extractAlerts(cfg, table); err != nil {
+		return err
+	}
+
+	// Mail servers and MPD connection
+	if val := getTableString(table, "current_mail_spool"); val != nil {
+		cfg.Mail.Spool = *val
+	}
+	mailServers := []struct {
+		key    string
+		target **MailServerConfig
+	}{
+		{"imap", &cfg.Mail.IMAP},
+		{"pop3", &cfg.Mail.POP3},
+	}
+	for _, m := range mailServers {
+		if val := getTableString(table, m.key); val != nil {
+			server, err := ParseMailServer(*val)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", m.key, err)
+			}
+			*m.target = &server
+		}
+	}
+	if val := getTableString(table, "mpd_host"); val != nil {
+		cfg.MPD.Host = *val
+	}
+	if val := getTableInt(table, "mpd_port"); val != nil {
+		cfg.MPD.Port = *val
+	}
+	if val := getTableString(table, "mpd_password"); val != nil {
+		cfg.MPD.Password = *val
+	}
+
+	// Weather data source
+	if val := getTableString(table, "weather_provider"); val != nil {
+		cfg.Weather.Provider = *val
+	}
+	if val := getTableString(table, "weather_url"); val != nil {
+		cfg.Weather.URL = *val
+	}
+	if val := getTableString(table, "weather_units"); val != nil {
+		cfg.Weather.Units = *val
+	}
+
 	return nil
 }
 
@@ -340,6 +459,65 @@ func (p *LuaConfigParser) extractGradient(cfg *Config, table *rt.Table) error {
 	return nil
 }
 
+// extractAlerts extracts alert rules from the alerts array, e.g.
+//
+//	alerts = {
+//	    { name = 'battery', condition = 'battery.BAT0.percent < 10', notify = true },
+//	}
+//
+// Durations are given in seconds.
+func (p *LuaConfigParser) extractAlerts(cfg *Config, table *rt.Table) error {
+	alertsVal := table.Get(rt.StringValue("alerts"))
+	if alertsVal == rt.NilValue {
+		return nil
+	}
+
+	alertsTable, ok := alertsVal.TryTable()
+	if !ok {
+		return fmt.Errorf("invalid alerts: expected a table")
+	}
+
+	for i := int64(1); ; i++ {
+		ruleVal := alertsTable.Get(rt.IntValue(i))
+		if ruleVal == rt.NilValue {
+			break
+		}
+		ruleTable, ok := ruleVal.TryTable()
+		if !ok {
+			return fmt.Errorf("invalid alerts[%d]: expected a table", i)
+		}
+
+		var alert AlertConfig
+		if val := getTableString(ruleTable, "name"); val != nil {
+			alert.Name = *val
+		}
+		if val := getTableString(ruleTable, "condition"); val != nil {
+			alert.Condition = *val
+		}
+		if val := getTableFloat(ruleTable, "duration"); val != nil {
+			alert.Duration = time.Duration(*val * float64(time.Second))
+		}
+		if val := getTableFloat(ruleTable, "hysteresis"); val != nil {
+			alert.Hysteresis = *val
+		}
+		if val := getTableFloat(ruleTable, "cooldown"); val != nil {
+			alert.Cooldown = time.Duration(*val * float64(time.Second))
+		}
+		if val := getTableString(ruleTable, "message"); val != nil {
+			alert.Message = *val
+		}
+		if val := getTableString(ruleTable, "exec"); val != nil {
+			alert.Exec = *val
+		}
+		if val := getTableBool(ruleTable, "notify"); val != nil {
+			alert.Notify = *val
+		}
+		cfg.Alerts = append(cfg.Alerts, alert)
+	}
+
+	return nil
+}
+
 // Close releases resources associated with the parser's Lua runtime.
 func (p *LuaConfigParser) Close() error {
 	p.mu.Lock()
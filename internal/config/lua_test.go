@@ -2,6 +2,7 @@ package config
 
 import (
 	"image/color"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +20,36 @@ func TestNewLuaConfigParser(t *testing.T) {
 	}
 }
 
+func TestSandboxedLuaConfigParser(t *testing.T) {
+	p, err := NewSandboxedLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewSandboxedLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	content := `
+local text = string.format('${cpu}%% of %d cores', math.floor(4.5))
+conky.config = {
+    alignment = (io == nil and os == nil and package == nil and require == nil and dofile == nil and loadfile == nil) and 'top_left' or 'bottom_right',
+}
+conky.text = text
+`
+	cfg, err := p.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.Window.Alignment != AlignmentTopLeft {
+		t.Error("io, os, package, dofile or loadfile available in the sandbox")
+	}
+	if cfg.Text.Template[0] != "${cpu}% of 4 cores" {
+		t.Errorf("expected text from string and math, got %q", cfg.Text.Template)
+	}
+
+	if _, err := p.Parse([]byte(`io.open('/etc/passwd')`)); err == nil {
+		t.Error("expected an error using io in the sandbox")
+	}
+}
+
 func TestLuaConfigParserParseBasic(t *testing.T) {
 	p, err := NewLuaConfigParser()
 	if err != nil {
@@ -1063,3 +1094,152 @@ func TestLuaConfigParserLuaSandboxLimits(t *testing.T) {
 		})
 	}
 }
+
+func TestLuaConfigParserLuaSettings(t *testing.T) {
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	cfg, err := p.Parse([]byte(`conky.config = {
+    lua_load = 'rings.lua  clock.lua',
+    lua_startup_hook = 'setup',
+    lua_shutdown_hook = 'teardown',
+    lua_draw_hook_pre = 'ring_stats 4 cpu',
+    lua_draw_hook_post = 'conky_main',
+}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(cfg.Lua.Load) != 2 || cfg.Lua.Load[0] != "rings.lua" || cfg.Lua.Load[1] != "clock.lua" {
+		t.Errorf("Load = %v, want [rings.lua clock.lua]", cfg.Lua.Load)
+	}
+	if cfg.Lua.StartupHook != "setup" {
+		t.Errorf("StartupHook = %q, want %q", cfg.Lua.StartupHook, "setup")
+	}
+	if cfg.Lua.ShutdownHook != "teardown" {
+		t.Errorf("ShutdownHook = %q, want %q", cfg.Lua.ShutdownHook, "teardown")
+	}
+	if cfg.Lua.DrawHookPre != "ring_stats 4 cpu" {
+		t.Errorf("DrawHookPre = %q, want %q", cfg.Lua.DrawHookPre, "ring_stats 4 cpu")
+	}
+	if cfg.Lua.DrawHookPost != "conky_main" {
+		t.Errorf("DrawHookPost = %q, want %q", cfg.Lua.DrawHookPost, "conky_main")
+	}
+}
+
+func TestLuaConfigParserOutputSettings(t *testing.T) {
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	cfg, err := p.Parse([]byte(`conky.config = {
+    out_to_x = false,
+    out_to_console = true,
+    out_to_http = true,
+    http_port = 8080,
+}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := OutputConfig{ToX: false, ToConsole: true, ToHTTP: true, HTTPPort: 8080}
+	if cfg.Output != want {
+		t.Errorf("Output = %+v, want %+v", cfg.Output, want)
+	}
+}
+
+func TestLuaConfigParserAlerts(t *testing.T) {
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	cfg, err := p.Parse([]byte(`conky.config = {
+    alerts = {
+        {
+            name = 'battery',
+            condition = 'battery.BAT0.percent < 10 and battery.BAT0.discharging',
+            duration = 30,
+            cooldown = 300,
+            hysteresis = 2.5,
+            message = 'Battery low',
+            exec = 'paplay alarm.oga',
+            notify = true,
+        },
+        { name = 'home', condition = 'fs./home.used_perc > 90' },
+    },
+}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []AlertConfig{
+		{
+			Name:       "battery",
+			Condition:  "battery.BAT0.percent < 10 and battery.BAT0.discharging",
+			Duration:   30 * time.Second,
+			Hysteresis: 2.5,
+			Cooldown:   300 * time.Second,
+			Message:    "Battery low",
+			Exec:       "paplay alarm.oga",
+			Notify:     true,
+		},
+		{Name: "home", Condition: "fs./home.used_perc > 90"},
+	}
+	if !slices.Equal(cfg.Alerts, want) {
+		t.Errorf("Alerts = %+v, want %+v", cfg.Alerts, want)
+	}
+
+	if _, err := p.Parse([]byte(`conky.config = { alerts = { 'cpu.total > 90' } }`)); err == nil {
+		t.Error("Parse with a non-table alert: expected error")
+	}
+}
+
+func TestLuaConfigParserMailAndMPDSettings(t *testing.T) {
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	cfg, err := p.Parse([]byte(`conky.config = {
+    current_mail_spool = '/var/mail/alice',
+    imap = "imap.example.com alice env:IMAP_PASS -i 60",
+    mpd_host = 'music.lan',
+    mpd_password = 'file:/run/secrets/mpd',
+    weather_provider = 'open-meteo',
+    weather_units = 'metric',
+}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.Mail.Spool != "/var/mail/alice" {
+		t.Errorf("Mail.Spool = %q, want /var/mail/alice", cfg.Mail.Spool)
+	}
+	wantIMAP := MailServerConfig{Host: "imap.example.com", User: "alice", Password: "env:IMAP_PASS", Interval: time.Minute}
+	if cfg.Mail.IMAP == nil || *cfg.Mail.IMAP != wantIMAP {
+		t.Errorf("Mail.IMAP = %+v, want %+v", cfg.Mail.IMAP, wantIMAP)
+	}
+	if cfg.Mail.POP3 != nil {
+		t.Errorf("Mail.POP3 = %+v, want nil", cfg.Mail.POP3)
+	}
+	wantMPD := MPDConfig{Host: "music.lan", Port: DefaultMPDPort, Password: "file:/run/secrets/mpd"}
+	if cfg.MPD != wantMPD {
+		t.Errorf("MPD = %+v, want %+v", cfg.MPD, wantMPD)
+	}
+	wantWeather := WeatherConfig{Provider: "open-meteo", Units: "metric"}
+	if cfg.Weather != wantWeather {
+		t.Errorf("Weather = %+v, want %+v", cfg.Weather, wantWeather)
+	}
+
+	if _, err := p.Parse([]byte(`conky.config = { pop3 = 'pop.example.com bob *' }`)); err == nil {
+		t.Error("Parse with a password prompt: expected error")
+	}
+}
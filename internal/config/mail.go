@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseMailServer parses a mail server in Conky's imap/pop3 format:
+//
+//	host user pass [-i interval] [-f 'folder'] [-p port] [-s]
+//
+// The interval is in seconds. -s connects over TLS. The -e (command) and
+// -r (retries) options are accepted for compatibility and ignored. The
+// password may be a reference resolved by ResolveSecret; Conky's "*"
+// prompt is not supported.
+func ParseMailServer(spec string) (MailServerConfig, error) {
+	fields := splitQuoted(spec)
+	if len(fields) < 3 {
+		return MailServerConfig{}, fmt.Errorf("expected host user pass, got %q", spec)
+	}
+
+	server := MailServerConfig{
+		Host:     fields[0],
+		User:     fields[1],
+		Password: fields[2],
+	}
+	if server.Password == "*" {
+		return MailServerConfig{}, fmt.Errorf("password prompts are not supported, use env:NAME or file:PATH")
+	}
+
+	opts := fields[3:]
+	for i := 0; i < len(opts); i++ {
+		opt := opts[i]
+		if opt == "-s" {
+			server.TLS = true
+			continue
+		}
+		if i+1 >= len(opts) {
+			return MailServerConfig{}, fmt.Errorf("option %s requires a value", opt)
+		}
+		i++
+		value := opts[i]
+
+		switch opt {
+		case "-i":
+			secs, err := strconv.ParseFloat(value, 64)
+			if err != nil || secs <= 0 {
+				return MailServerConfig{}, fmt.Errorf("invalid interval %q", value)
+			}
+			server.Interval = time.Duration(secs * float64(time.Second))
+		case "-f":
+			server.Folder = value
+		case "-p":
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1 || port > 65535 {
+				return MailServerConfig{}, fmt.Errorf("invalid port %q", value)
+			}
+			server.Port = port
+		case "-e", "-r":
+			// Not supported; accepted so that Conky configs still load
+		default:
+			return MailServerConfig{}, fmt.Errorf("unknown option %s", opt)
+		}
+	}
+
+	return server, nil
+}
+
+// String formats the server in the format accepted by ParseMailServer.
+func (s MailServerConfig) String() string {
+	parts := []string{quoteField(s.Host), quoteField(s.User), quoteField(s.Password)}
+	if s.Interval > 0 {
+		parts = append(parts, "-i", strconv.FormatFloat(s.Interval.Seconds(), 'f', -1, 64))
+	}
+	if s.Folder != "" {
+		parts = append(parts, "-f", quoteField(s.Folder))
+	}
+	if s.Port > 0 {
+		parts = append(parts, "-p", strconv.Itoa(s.Port))
+	}
+	if s.TLS {
+		parts = append(parts, "-s")
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteField quotes a field for splitQuoted if it contains whitespace or
+// quotes.
+func quoteField(field string) string {
+	if field != "" && !strings.ContainsAny(field, " \t'\"") {
+		return field
+	}
+	if strings.Contains(field, "'") {
+		return `"` + field + `"`
+	}
+	return "'" + field + "'"
+}
+
+// splitQuoted splits s at whitespace, keeping single- or double-quoted
+// sections (with the quotes removed) as single fields.
+func splitQuoted(s string) []string {
+	var fields []string
+	var current strings.Builder
+	inField := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
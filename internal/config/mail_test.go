@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMailServer(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want MailServerConfig
+	}{
+		{
+			name: "minimal",
+			spec: "imap.example.com alice secret",
+			want: MailServerConfig{Host: "imap.example.com", User: "alice", Password: "secret"},
+		},
+		{
+			name: "all options",
+			spec: "imap.example.com alice env:IMAP_PASS -i 120 -f 'Work Mail' -p 993 -s",
+			want: MailServerConfig{
+				Host: "imap.example.com", User: "alice", Password: "env:IMAP_PASS",
+				Interval: 2 * time.Minute, Folder: "Work Mail", Port: 993, TLS: true,
+			},
+		},
+		{
+			name: "ignored conky options",
+			spec: `pop.example.com bob "pass word" -e 'notify-send mail' -r 3`,
+			want: MailServerConfig{Host: "pop.example.com", User: "bob", Password: "pass word"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMailServer(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseMailServer() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMailServer() = %+v, want %+v", got, tt.want)
+			}
+
+			// String must round-trip
+			again, err := ParseMailServer(got.String())
+			if err != nil || again != got {
+				t.Errorf("round trip of %q = %+v, %v", got.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestParseMailServerErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"host user",
+		"host user *",
+		"host user pass -p",
+		"host user pass -p 99999",
+		"host user pass -i soon",
+		"host user pass -x 1",
+	} {
+		if _, err := ParseMailServer(spec); err == nil {
+			t.Errorf("ParseMailServer(%q) error = nil, want error", spec)
+		}
+	}
+}
@@ -141,6 +141,96 @@ func (m *Migrator) writeConfigTable(buf *bytes.Buffer, cfg *Config) {
 		}
 		m.writeColors(buf, cfg, defaults)
 	}
+
+	// Write Lua scripts and hooks
+	if m.hasLuaSettings(cfg) {
+		if m.includeComments {
+			buf.WriteString("\n    -- Lua scripts\n")
+		}
+		m.writeLua(buf, cfg)
+	}
+
+	// Write output destinations
+	if m.preserveDefaults || cfg.Output != defaults.Output {
+		if m.includeComments {
+			buf.WriteString("\n    -- Output\n")
+		}
+		m.writeBool(buf, "out_to_x", cfg.Output.ToX)
+		m.writeBool(buf, "out_to_console", cfg.Output.ToConsole)
+		m.writeBool(buf, "out_to_stderr", cfg.Output.ToStderr)
+		m.writeBool(buf, "out_to_http", cfg.Output.ToHTTP)
+		m.writeInt(buf, "http_port", cfg.Output.HTTPPort)
+		m.writeBool(buf, "http_refresh", cfg.Output.HTTPRefresh)
+	}
+
+	// Write mail servers and MPD connection
+	if cfg.Mail != defaults.Mail || m.preserveDefaults || cfg.MPD != defaults.MPD {
+		if m.includeComments {
+			buf.WriteString("\n    -- Mail and MPD\n")
+		}
+		if cfg.Mail.Spool != "" {
+			m.writeString(buf, "current_mail_spool", cfg.Mail.Spool)
+		}
+		if cfg.Mail.IMAP != nil {
+			m.writeString(buf, "imap", cfg.Mail.IMAP.String())
+		}
+		if cfg.Mail.POP3 != nil {
+			m.writeString(buf, "pop3", cfg.Mail.POP3.String())
+		}
+		if m.preserveDefaults || cfg.MPD != defaults.MPD {
+			m.writeString(buf, "mpd_host", cfg.MPD.Host)
+			m.writeInt(buf, "mpd_port", cfg.MPD.Port)
+			if cfg.MPD.Password != "" {
+				m.writeString(buf, "mpd_password", cfg.MPD.Password)
+			}
+		}
+	}
+
+	// Write weather data source
+	if cfg.Weather != defaults.Weather {
+		if m.includeComments {
+			buf.WriteString("\n    -- Weather\n")
+		}
+		if cfg.Weather.Provider != "" {
+			m.writeString(buf, "weather_provider", cfg.Weather.Provider)
+		}
+		if cfg.Weather.URL != "" {
+			m.writeString(buf, "weather_url", cfg.Weather.URL)
+		}
+		if cfg.Weather.Units != "" {
+			m.writeString(buf, "weather_units", cfg.Weather.Units)
+		}
+	}
+}
+
+// hasLuaSettings checks if any Lua script or hook settings are configured.
+func (m *Migrator) hasLuaSettings(cfg *Config) bool {
+	return len(cfg.Lua.Load) > 0 ||
+		cfg.Lua.StartupHook != "" ||
+		cfg.Lua.ShutdownHook != "" ||
+		cfg.Lua.DrawHookPre != "" ||
+		cfg.Lua.DrawHookPost != ""
+}
+
+// writeLua writes the Lua script and hook settings.
+func (m *Migrator) writeLua(buf *bytes.Buffer, cfg *Config) {
+	if len(cfg.Lua.Load) > 0 {
+		m.writeString(buf, "lua_load", strings.Join(cfg.Lua.Load, " "))
+	}
+	hooks := []struct {
+		name  string
+		value string
+	}{
+		{"lua_startup_hook", cfg.Lua.StartupHook},
+		{"lua_shutdown_hook", cfg.Lua.ShutdownHook},
+		{"lua_draw_hook_pre", cfg.Lua.DrawHookPre},
+		{"lua_draw_hook_post", cfg.Lua.DrawHookPost},
+	}
+	for _, h := range hooks {
+		if h.value != "" {
+			m.writeString(buf, h.name, h.value)
+		}
+	}
 }
 
 // hasNonDefaultColors checks if any color settings differ from defaults.
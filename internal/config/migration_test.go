@@ -604,3 +604,110 @@ func TestMigrateActualTestConfig(t *testing.T) {
 		t.Error("expected non-empty text template")
 	}
 }
+
+func TestMigratorMigrateToLuaLuaSettings(t *testing.T) {
+	m := NewMigrator()
+	cfg := DefaultConfig()
+	cfg.Lua.Load = []string{"rings.lua", "clock.lua"}
+	cfg.Lua.DrawHookPre = "ring_stats 4"
+
+	result, err := m.MigrateToLua(&cfg)
+	if err != nil {
+		t.Fatalf("MigrateToLua failed: %v", err)
+	}
+
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	parsed, err := p.Parse(result)
+	if err != nil {
+		t.Fatalf("Parse of migrated config failed: %v\n%s", err, result)
+	}
+	if strings.Join(parsed.Lua.Load, " ") != "rings.lua clock.lua" {
+		t.Errorf("Load = %v, want [rings.lua clock.lua]", parsed.Lua.Load)
+	}
+	if parsed.Lua.DrawHookPre != "ring_stats 4" {
+		t.Errorf("DrawHookPre = %q, want %q", parsed.Lua.DrawHookPre, "ring_stats 4")
+	}
+}
+
+func TestMigratorMigrateToLuaOutputSettings(t *testing.T) {
+	m := NewMigrator()
+	cfg := DefaultConfig()
+
+	result, err := m.MigrateToLua(&cfg)
+	if err != nil {
+		t.Fatalf("MigrateToLua failed: %v", err)
+	}
+	if strings.Contains(string(result), "out_to_") {
+		t.Errorf("default output settings should not be written:\n%s", result)
+	}
+
+	cfg.Output = OutputConfig{ToX: false, ToConsole: true, ToHTTP: true, HTTPPort: 8080, HTTPRefresh: true}
+	result, err = m.MigrateToLua(&cfg)
+	if err != nil {
+		t.Fatalf("MigrateToLua failed: %v", err)
+	}
+
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	parsed, err := p.Parse(result)
+	if err != nil {
+		t.Fatalf("Parse of migrated config failed: %v\n%s", err, result)
+	}
+	if parsed.Output != cfg.Output {
+		t.Errorf("Output = %+v, want %+v", parsed.Output, cfg.Output)
+	}
+}
+
+func TestMigratorMigrateToLuaMailAndMPDSettings(t *testing.T) {
+	m := NewMigrator()
+	cfg := DefaultConfig()
+
+	result, err := m.MigrateToLua(&cfg)
+	if err != nil {
+		t.Fatalf("MigrateToLua failed: %v", err)
+	}
+	if strings.Contains(string(result), "mpd_") || strings.Contains(string(result), "weather_") {
+		t.Errorf("default MPD and weather settings should not be written:\n%s", result)
+	}
+
+	cfg.Mail.Spool = "~/Maildir"
+	cfg.Mail.IMAP = &MailServerConfig{Host: "imap.example.com", User: "alice", Password: "env:IMAP_PASS", Folder: "Work Mail"}
+	cfg.MPD = MPDConfig{Host: "music.lan", Port: 6601, Password: "env:MPD_PASS"}
+	cfg.Weather = WeatherConfig{Provider: "open-meteo", URL: "https://weather.example.com", Units: "imperial"}
+	result, err = m.MigrateToLua(&cfg)
+	if err != nil {
+		t.Fatalf("MigrateToLua failed: %v", err)
+	}
+
+	p, err := NewLuaConfigParser()
+	if err != nil {
+		t.Fatalf("NewLuaConfigParser failed: %v", err)
+	}
+	defer p.Close()
+
+	parsed, err := p.Parse(result)
+	if err != nil {
+		t.Fatalf("Parse of migrated config failed: %v\n%s", err, result)
+	}
+	if parsed.Mail.Spool != cfg.Mail.Spool {
+		t.Errorf("Mail.Spool = %q, want %q", parsed.Mail.Spool, cfg.Mail.Spool)
+	}
+	if parsed.Mail.IMAP == nil || *parsed.Mail.IMAP != *cfg.Mail.IMAP {
+		t.Errorf("Mail.IMAP = %+v, want %+v", parsed.Mail.IMAP, cfg.Mail.IMAP)
+	}
+	if parsed.MPD != cfg.MPD {
+		t.Errorf("MPD = %+v, want %+v", parsed.MPD, cfg.MPD)
+	}
+	if parsed.Weather != cfg.Weather {
+		t.Errorf("Weather = %+v, want %+v", parsed.Weather, cfg.Weather)
+	}
+}
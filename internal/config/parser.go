@@ -31,6 +31,21 @@ func NewParser() (*Parser, error) {
 	}, nil
 }
 
+// NewSandboxedParser creates a Parser for configurations from untrusted
+// sources, whose Lua configurations cannot run commands, use files or load
+// modules while they are parsed. See NewSandboxedLuaConfigParser.
+func NewSandboxedParser() (*Parser, error) {
+	luaParser, err := NewSandboxedLuaConfigParser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Lua parser: %w", err)
+	}
+
+	return &Parser{
+		legacyParser: NewLegacyParser(),
+		luaParser:    luaParser,
+	}, nil
+}
+
 // ParseFile reads and parses a configuration file, auto-detecting the format.
 // Returns a Config on success or an error if parsing fails.
 func (p *Parser) ParseFile(path string) (*Config, error) {
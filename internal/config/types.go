@@ -23,9 +23,123 @@ type Config struct {
 	Colors ColorConfig
 	// Lua contains Lua runtime sandbox settings.
 	Lua LuaConfig
+	// Output contains the output destination settings.
+	Output OutputConfig
+	// Alerts contains the alert rules evaluated against system metrics.
+	Alerts []AlertConfig
+	// Mail contains the default mail servers.
+	Mail MailConfig
+	// MPD contains the Music Player Daemon connection settings.
+	MPD MPDConfig
+	// Weather contains the weather data source settings.
+	Weather WeatherConfig
 }
 
-// LuaConfig holds Lua sandbox resource limit settings.
+// MailConfig holds the default mailboxes read by the mail variables when
+// they are given no mailbox or server of their own.
+type MailConfig struct {
+	// Spool is the local Maildir or mbox read by ${mails}, ${new_mails}
+	// and the related variables (current_mail_spool). Empty means $MAIL.
+	Spool string
+	// IMAP is the default IMAP server (imap), or nil if unset.
+	IMAP *MailServerConfig
+	// POP3 is the default POP3 server (pop3), or nil if unset.
+	POP3 *MailServerConfig
+}
+
+// MailServerConfig describes a mail server in Conky's format:
+//
+//	host user pass [-i interval] [-f 'folder'] [-p port] [-s]
+//
+// See ParseMailServer.
+type MailServerConfig struct {
+	// Host is the mail server hostname.
+	Host string
+	// User is the login name.
+	User string
+	// Password is the login password, or a reference to it resolved by
+	// ResolveSecret such as "env:IMAP_PASSWORD" or "file:~/.imap-pass".
+	Password string
+	// Port is the server port. 0 uses the protocol default.
+	Port int
+	// Folder is the IMAP folder to check. Empty means INBOX.
+	Folder string
+	// Interval is the time between checks. 0 uses the default of five
+	// minutes.
+	Interval time.Duration
+	// TLS connects over TLS (-s).
+	TLS bool
+}
+
+// MPDConfig holds the Music Player Daemon connection settings.
+type MPDConfig struct {
+	// Host is the MPD server host (mpd_host).
+	Host string
+	// Port is the MPD server port (mpd_port).
+	Port int
+	// Password is the MPD password (mpd_password), or a reference to it
+	// resolved by ResolveSecret.
+	Password string
+}
+
+// WeatherConfig holds the settings of the weather variables.
+type WeatherConfig struct {
+	// Provider is the weather data source (weather_provider): "metar"
+	// for METAR reports of ICAO stations, or "open-meteo" for current
+	// conditions and forecasts at latitude,longitude locations. Empty
+	// means metar.
+	Provider string
+	// URL is the base URL of the provider's API (weather_url). Empty
+	// uses the provider's public service.
+	URL string
+	// Units is the unit system of the weather values (weather_units):
+	// "metric" or "imperial". Empty shows values in the units of METAR
+	// reports (°C, hPa, knots and statute miles).
+	Units string
+}
+
+// AlertConfig holds one alert rule. Rules are declared in the alerts
+// array of conky.config and have no legacy .conkyrc form.
+type AlertConfig struct {
+	// Name identifies the rule in events and notifications.
+	Name string
+	// Condition is the expression over metric IDs that triggers the rule,
+	// such as "battery.BAT0.percent < 10 and battery.BAT0.discharging".
+	Condition string
+	// Duration is how long the condition must hold before the rule fires.
+	Duration time.Duration
+	// Hysteresis is the margin by which values must recover past their
+	// thresholds before the rule resolves.
+	Hysteresis float64
+	// Cooldown is the minimum time between two firings of the rule.
+	Cooldown time.Duration
+	// Message is the alert text. Empty uses the condition.
+	Message string
+	// Exec is a shell command run when the rule fires.
+	Exec string
+	// Notify shows a desktop notification when the rule fires.
+	Notify bool
+}
+
+// OutputConfig holds the settings that choose where the evaluated text is
+// shown, mirroring Conky's out_to_* options.
+type OutputConfig struct {
+	// ToX draws the text in a window (out_to_x). Defaults to true.
+	ToX bool
+	// ToConsole prints the text to stdout on every update (out_to_console).
+	ToConsole bool
+	// ToStderr prints the text to stderr on every update (out_to_stderr).
+	ToStderr bool
+	// ToHTTP serves the text as an HTML page over HTTP (out_to_http).
+	ToHTTP bool
+	// HTTPPort is the TCP port the HTTP output listens on (http_port).
+	HTTPPort int
+	// HTTPRefresh makes the HTML page reload itself every update
+	// interval (http_refresh).
+	HTTPRefresh bool
+}
+
+// LuaConfig holds Lua script and sandbox resource limit settings.
 type LuaConfig struct {
 	// CPULimit is the CPU instruction limit for Lua execution.
 	// 0 means use the default (10 million instructions).
@@ -33,6 +147,17 @@ type LuaConfig struct {
 	// MemoryLimit is the maximum memory in bytes that Lua can allocate.
 	// 0 means use the default (50 MB).
 	MemoryLimit uint64
+	// Load lists the Lua script files to load at startup (lua_load).
+	Load []string
+	// StartupHook is the function called once after the scripts are loaded
+	// (lua_startup_hook), optionally followed by space-separated arguments.
+	StartupHook string
+	// ShutdownHook is the function called when conky exits (lua_shutdown_hook).
+	ShutdownHook string
+	// DrawHookPre is the function called before each redraw (lua_draw_hook_pre).
+	DrawHookPre string
+	// DrawHookPost is the function called after each redraw (lua_draw_hook_post).
+	DrawHookPost string
 }
 
 // WindowConfig holds window-related configuration options.
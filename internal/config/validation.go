@@ -5,6 +5,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -97,10 +98,76 @@ func (v *Validator) Validate(cfg *Config) *ValidationResult {
 	v.validateDisplay(&cfg.Display, result)
 	v.validateColors(&cfg.Colors, result)
 	v.validateText(&cfg.Text, result)
+	v.validateOutput(&cfg.Output, result)
+	v.validateAlerts(cfg.Alerts, result)
+	v.validateMPD(&cfg.MPD, result)
+	v.validateWeather(&cfg.Weather, result)
 
 	return result
 }
 
+// validateMPD validates MPDConfig settings. A zero port uses the default.
+func (v *Validator) validateMPD(mc *MPDConfig, result *ValidationResult) {
+	if mc.Port < 0 || mc.Port > 65535 {
+		result.AddError("mpd.port", fmt.Sprintf("must be between 1 and 65535, got %d", mc.Port))
+	}
+}
+
+// validateWeather validates WeatherConfig settings. Empty values use the
+// defaults.
+func (v *Validator) validateWeather(wc *WeatherConfig, result *ValidationResult) {
+	switch strings.ToLower(wc.Provider) {
+	case "", "metar", "open-meteo", "openmeteo":
+	default:
+		result.AddError("weather.provider", fmt.Sprintf("unknown weather provider: %s", wc.Provider))
+	}
+	switch strings.ToLower(wc.Units) {
+	case "", "metric", "imperial":
+	default:
+		result.AddError("weather.units", fmt.Sprintf("must be metric or imperial, got %s", wc.Units))
+	}
+	if wc.URL != "" {
+		if u, err := url.Parse(wc.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.AddError("weather.url", fmt.Sprintf("must be an http or https URL, got %s", wc.URL))
+		}
+	}
+}
+
+// validateAlerts validates alert rules. Conditions are compiled when the
+// rules are loaded, so only their presence is checked here.
+func (v *Validator) validateAlerts(alerts []AlertConfig, result *ValidationResult) {
+	names := make(map[string]bool, len(alerts))
+	for i, a := range alerts {
+		field := fmt.Sprintf("alerts[%d]", i+1)
+		if a.Name == "" {
+			result.AddError(field+".name", "must not be empty")
+		} else if names[a.Name] {
+			result.AddError(field+".name", fmt.Sprintf("duplicate alert name %q", a.Name))
+		}
+		names[a.Name] = true
+		if strings.TrimSpace(a.Condition) == "" {
+			result.AddError(field+".condition", "must not be empty")
+		}
+		if a.Duration < 0 {
+			result.AddError(field+".duration", fmt.Sprintf("must be non-negative, got %v", a.Duration))
+		}
+		if a.Cooldown < 0 {
+			result.AddError(field+".cooldown", fmt.Sprintf("must be non-negative, got %v", a.Cooldown))
+		}
+		if a.Hysteresis < 0 {
+			result.AddError(field+".hysteresis", fmt.Sprintf("must be non-negative, got %v", a.Hysteresis))
+		}
+	}
+}
+
+// validateOutput validates OutputConfig settings.
+func (v *Validator) validateOutput(oc *OutputConfig, result *ValidationResult) {
+	if oc.ToHTTP && (oc.HTTPPort < 1 || oc.HTTPPort > 65535) {
+		result.AddError("output.http_port",
+			fmt.Sprintf("must be between 1 and 65535, got %d", oc.HTTPPort))
+	}
+}
+
 // validateWindow validates WindowConfig settings.
 func (v *Validator) validateWindow(wc *WindowConfig, result *ValidationResult) {
 	if wc.Width < 0 {
@@ -315,17 +382,47 @@ func (v *Validator) checkVariable(varName string, lineNum int, result *Validatio
 		return
 	}
 
-	if !v.knownVariables[varName] {
-		field := fmt.Sprintf("text.template[line %d]", lineNum)
-		msg := fmt.Sprintf("unknown variable: %s", varName)
-		if v.strictMode {
-			result.AddError(field, msg)
-		} else {
-			result.AddWarning(field, msg)
+	if strings.HasPrefix(varName, "if_") {
+		// Unknown conditionals are false, hiding their contents
+		if !knownConditionals[varName] {
+			v.reportUnknown(fmt.Sprintf("unknown conditional: %s", varName), lineNum, result)
 		}
+		return
+	}
+	if !v.knownVariables[varName] {
+		v.reportUnknown(fmt.Sprintf("unknown variable: %s", varName), lineNum, result)
 	}
 }
 
+// reportUnknown reports an unknown variable or conditional, as an error in
+// strict mode and a warning otherwise.
+func (v *Validator) reportUnknown(msg string, lineNum int, result *ValidationResult) {
+	field := fmt.Sprintf("text.template[line %d]", lineNum)
+	if v.strictMode {
+		result.AddError(field, msg)
+	} else {
+		result.AddWarning(field, msg)
+	}
+}
+
+// knownConditionals is the set of ${if_*} conditionals the text evaluates.
+var knownConditionals = map[string]bool{
+	"if_empty":               true,
+	"if_existing":            true,
+	"if_gw":                  true,
+	"if_match":               true,
+	"if_mixer_mute":          true,
+	"if_mounted":             true,
+	"if_mpd_playing":         true,
+	"if_pa_sink_muted":       true,
+	"if_pa_source_muted":     true,
+	"if_pa_source_running":   true,
+	"if_running":             true,
+	"if_smapi_bat_installed": true,
+	"if_up":                  true,
+	"if_updatenr":            true,
+}
+
 // knownConkyVariables is the set of recognized Conky template variables.
 // Initialized once at package load time to avoid repeated allocations.
 var knownConkyVariables = map[string]bool{
@@ -383,6 +480,9 @@ var knownConkyVariables = map[string]bool{
 	"wireless_link_qual":      true,
 	"wireless_link_qual_max":  true,
 	"wireless_link_qual_perc": true,
+	"tcp_portmon":             true,
+	"listening_ports":         true,
+	"socket_count":            true,
 
 	// Filesystem variables
 	"fs_used":      true,
@@ -409,6 +509,49 @@ var knownConkyVariables = map[string]bool{
 	"top_time":          true,
 	"top_io":            true,
 
+	// Per-process variables
+	"pidof":               true,
+	"pid_chroot":          true,
+	"pid_cmdline":         true,
+	"pid_cwd":             true,
+	"pid_environ":         true,
+	"pid_environ_list":    true,
+	"pid_exe":             true,
+	"pid_nice":            true,
+	"pid_openfiles":       true,
+	"pid_parent":          true,
+	"pid_priority":        true,
+	"pid_state":           true,
+	"pid_state_short":     true,
+	"pid_stdin":           true,
+	"pid_stdout":          true,
+	"pid_stderr":          true,
+	"pid_threads":         true,
+	"pid_thread_list":     true,
+	"pid_time":            true,
+	"pid_time_kernelmode": true,
+	"pid_time_usermode":   true,
+	"pid_uid":             true,
+	"pid_euid":            true,
+	"pid_suid":            true,
+	"pid_fsuid":           true,
+	"pid_gid":             true,
+	"pid_egid":            true,
+	"pid_sgid":            true,
+	"pid_fsgid":           true,
+	"pid_read":            true,
+	"pid_write":           true,
+	"pid_vmpeak":          true,
+	"pid_vmsize":          true,
+	"pid_vmlck":           true,
+	"pid_vmhwm":           true,
+	"pid_vmrss":           true,
+	"pid_vmdata":          true,
+	"pid_vmstk":           true,
+	"pid_vmexe":           true,
+	"pid_vmlib":           true,
+	"pid_vmpte":           true,
+
 	// Battery variables
 	"battery":         true,
 	"battery_bar":     true,
@@ -422,6 +565,59 @@ var knownConkyVariables = map[string]bool{
 	"acpitemp": true,
 	"platform": true,
 
+	// GPU variables
+	"gpu_count":      true,
+	"gpu_name":       true,
+	"gpu_vendor":     true,
+	"gpu_util":       true,
+	"gpu_mem_util":   true,
+	"gpu_temp":       true,
+	"gpu_vram_used":  true,
+	"gpu_vram_total": true,
+	"gpu_vram_perc":  true,
+	"gpu_power":      true,
+	"gpu_fan":        true,
+	"gpu_freq":       true,
+
+	// Mail variables
+	"mails":           true,
+	"new_mails":       true,
+	"seen_mails":      true,
+	"unseen_mails":    true,
+	"flagged_mails":   true,
+	"replied_mails":   true,
+	"forwarded_mails": true,
+	"trashed_mails":   true,
+	"draft_mails":     true,
+	"imap_unseen":     true,
+	"imap_messages":   true,
+	"pop3_unseen":     true,
+	"pop3_used":       true,
+
+	// UPS variables
+	"apcupsd":          true,
+	"apcupsd_model":    true,
+	"apcupsd_status":   true,
+	"apcupsd_linev":    true,
+	"apcupsd_load":     true,
+	"apcupsd_charge":   true,
+	"apcupsd_timeleft": true,
+	"apcupsd_temp":     true,
+	"apcupsd_battv":    true,
+	"apcupsd_cable":    true,
+	"apcupsd_driver":   true,
+	"apcupsd_upsmode":  true,
+	"apcupsd_name":     true,
+	"apcupsd_hostname": true,
+
+	// Web content variables
+	"curl": true,
+	"rss":  true,
+
+	// Weather variables
+	"weather":          true,
+	"weather_forecast": true,
+
 	// Audio variables
 	"mixer":     true,
 	"mixerbar":  true,
@@ -430,6 +626,18 @@ var knownConkyVariables = map[string]bool{
 	"mixerlbar": true,
 	"mixerrbar": true,
 
+	"pa_sink_volume":                  true,
+	"pa_sink_volumebar":               true,
+	"pa_sink_name":                    true,
+	"pa_sink_description":             true,
+	"pa_sink_active_port_name":        true,
+	"pa_sink_active_port_description": true,
+	"pa_source_volume":                true,
+	"pa_source_name":                  true,
+	"pa_source_description":           true,
+	"pa_card_name":                    true,
+	"pa_stream_volume":                true,
+
 	// Time and date variables
 	"time":        true,
 	"utime":       true,
@@ -452,12 +660,6 @@ var knownConkyVariables = map[string]bool{
 	"desktop_number": true,
 
 	// Display control (not data variables, but commonly used)
-	"if_empty":     true,
-	"if_match":     true,
-	"if_existing":  true,
-	"if_running":   true,
-	"if_mounted":   true,
-	"if_updatenr":  true,
 	"else":         true,
 	"endif":        true,
 	"template":     true,
@@ -468,6 +670,9 @@ var knownConkyVariables = map[string]bool{
 	"execbar":      true,
 	"execgauge":    true,
 	"execgraph":    true,
+	"execibar":     true,
+	"execigauge":   true,
+	"execigraph":   true,
 	"texeci":       true,
 	"lua":          true,
 	"lua_parse":    true,
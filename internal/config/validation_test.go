@@ -396,6 +396,25 @@ func TestValidatorValidateText(t *testing.T) {
 			expectErrors: 1,
 			expectWarns:  0,
 		},
+		{
+			name:         "known conditionals",
+			template:     []string{"${if_up eth0}${if_gw}${if_match ${cpu} > 50}hot${endif}${endif}${endif}"},
+			expectErrors: 0,
+			expectWarns:  0,
+		},
+		{
+			name:         "unknown conditional warning",
+			template:     []string{"${if_charging}plugged${endif}"},
+			expectErrors: 0,
+			expectWarns:  1,
+		},
+		{
+			name:         "unknown conditional error in strict mode",
+			template:     []string{"${if_charging}plugged${endif}"},
+			strictMode:   true,
+			expectErrors: 1,
+			expectWarns:  0,
+		},
 		{
 			name:         "color is not a warning",
 			template:     []string{"${color grey}Test$color"},
@@ -910,3 +929,87 @@ func TestValidatorGradientModeTriggersValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatorOutputSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		toHTTP     bool
+		port       int
+		wantErrors bool
+	}{
+		{"http disabled ignores port", false, 0, false},
+		{"default port", true, DefaultHTTPPort, false},
+		{"port zero", true, 0, true},
+		{"port too large", true, 70000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Output.ToHTTP = tt.toHTTP
+			cfg.Output.HTTPPort = tt.port
+
+			result := NewValidator().Validate(&cfg)
+			if hasErrors := len(result.Errors) > 0; hasErrors != tt.wantErrors {
+				t.Errorf("expected hasErrors=%v, got %v; errors: %v", tt.wantErrors, hasErrors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidatorAlerts(t *testing.T) {
+	valid := AlertConfig{Name: "battery", Condition: "battery.BAT0.percent < 10"}
+	tests := []struct {
+		name       string
+		alerts     []AlertConfig
+		wantErrors int
+	}{
+		{"valid", []AlertConfig{valid}, 0},
+		{"missing name", []AlertConfig{{Condition: "cpu.total > 90"}}, 1},
+		{"missing condition", []AlertConfig{{Name: "cpu", Condition: " "}}, 1},
+		{"duplicate name", []AlertConfig{valid, valid}, 1},
+		{"negative durations", []AlertConfig{{
+			Name: "cpu", Condition: "cpu.total > 90",
+			Duration: -time.Second, Cooldown: -time.Second, Hysteresis: -1,
+		}}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Alerts = tt.alerts
+
+			result := NewValidator().Validate(&cfg)
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(result.Errors), tt.wantErrors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidatorWeather(t *testing.T) {
+	tests := []struct {
+		name       string
+		weather    WeatherConfig
+		wantErrors int
+	}{
+		{"defaults", WeatherConfig{}, 0},
+		{"open-meteo", WeatherConfig{Provider: "open-meteo", URL: "http://weather.lan:8080", Units: "imperial"}, 0},
+		{"metric", WeatherConfig{Provider: "METAR", Units: "Metric"}, 0},
+		{"unknown provider", WeatherConfig{Provider: "openweathermap"}, 1},
+		{"unknown units", WeatherConfig{Units: "kelvin"}, 1},
+		{"bad url", WeatherConfig{URL: "weather.lan"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Weather = tt.weather
+
+			result := NewValidator().Validate(&cfg)
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(result.Errors), tt.wantErrors, result.Errors)
+			}
+		})
+	}
+}
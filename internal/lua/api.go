@@ -5,16 +5,20 @@ package lua
 
 import (
 	"fmt"
+	"hash/fnv"
+	"maps"
 	"os"
-	"os/exec"
-	"regexp"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	rt "github.com/arnodel/golua/runtime"
 
+	"github.com/opd-ai/go-conky/internal/config"
 	"github.com/opd-ai/go-conky/internal/monitor"
 	"github.com/opd-ai/go-conky/internal/render"
 )
@@ -37,23 +41,28 @@ type SystemDataProvider interface {
 	Audio() monitor.AudioStats
 	SysInfo() monitor.SystemInfo
 	GPU() monitor.GPUStats
+	GPUs() []monitor.GPUStats
 	Mail() monitor.MailStats
 	MailUnseenCount(name string) int
 	MailTotalCount(name string) int
 	MailTotalUnseen() int
 	MailTotalMessages() int
+	MailAccount(cfg monitor.MailConfig) (monitor.MailAccountStats, error)
+	Mailbox(path string) (monitor.MailboxStats, error)
+	MailboxPath(path string) string
+	PIDInfo(pid int) (monitor.PIDInfo, error)
+	Pidof(name string) (int, bool)
 	Weather(stationID string) monitor.WeatherStats
 	TCP() monitor.TCPStats
 	TCPCountInRange(minPort, maxPort int) int
 	TCPConnectionByIndex(minPort, maxPort, index int) *monitor.TCPConnection
+	SocketOwner(inode uint64) (int, string)
+	Sockets() monitor.SocketStats
 	MPD() monitor.MPDStats
-}
-
-// execCacheEntry stores cached output from execi commands.
-type execCacheEntry struct {
-	output       string
-	expiresAt    time.Time
-	lastAccessed time.Time // Tracks when this entry was last accessed
+	APCUPSD(host string, port int) (monitor.APCUPSDStats, error)
+	Curl(url string, interval time.Duration) (string, error)
+	RSS(url string, interval time.Duration) (monitor.RSSFeed, error)
+	History(id string, n int) []monitor.Sample
 }
 
 // scrollState tracks the current scroll position for a scroll instance.
@@ -84,13 +93,19 @@ func DefaultCacheCleanupConfig() CacheCleanupConfig {
 type ConkyAPI struct {
 	runtime        *ConkyRuntime
 	sysProvider    SystemDataProvider
-	execCache      map[string]*execCacheEntry
+	exec           *ExecScheduler // Runs ${exec} and ${execi} commands
 	scrollStates   map[string]*scrollState
 	templates      [10]string // template0-template9 definitions
 	mu             sync.RWMutex
 	cleanupConfig  CacheCleanupConfig
 	cleanupStop    chan struct{}
 	cleanupRunning bool
+	updates        atomic.Int64 // update cycles completed, for ${updates}
+	updateCycle    atomic.Int64 // highest n of ${if_updatenr n}
+	apcupsdHost    string       // apcupsd server selected by ${apcupsd}
+	apcupsdPort    int
+	compileMu      sync.Mutex
+	compiled       map[string]*Template // Templates compiled by Parse
 }
 
 // NewConkyAPI creates a new ConkyAPI instance and registers all Conky functions
@@ -104,12 +119,14 @@ func NewConkyAPI(runtime *ConkyRuntime, provider SystemDataProvider) (*ConkyAPI,
 	api := &ConkyAPI{
 		runtime:       runtime,
 		sysProvider:   provider,
-		execCache:     make(map[string]*execCacheEntry),
+		exec:          NewExecScheduler(DefaultExecConfig()),
 		scrollStates:  make(map[string]*scrollState),
+		compiled:      make(map[string]*Template),
 		cleanupConfig: DefaultCacheCleanupConfig(),
 		cleanupStop:   make(chan struct{}),
 	}
 
+	api.exec.SetCommandCheck(runtime.CheckExec)
 	api.registerFunctions()
 
 	// Automatically start cache cleanup to prevent unbounded memory growth
@@ -128,11 +145,34 @@ func (api *ConkyAPI) SetSystemDataProvider(provider SystemDataProvider) {
 }
 
 // SetTemplates sets the template0-template9 definitions.
-// Templates can use \1, \2, etc. as argument placeholders.
+// Templates can use \1, \2, etc. as argument placeholders. Setting them
+// starts a new configuration, so the ${if_updatenr} cycle restarts and is
+// extended only by templates compiled afterwards.
 func (api *ConkyAPI) SetTemplates(templates [10]string) {
 	api.mu.Lock()
 	defer api.mu.Unlock()
 	api.templates = templates
+	api.updateCycle.Store(0)
+}
+
+// IncrementUpdates records a completed update cycle. The count is reported
+// by ${updates}, which Lua scripts commonly check before drawing.
+func (api *ConkyAPI) IncrementUpdates() {
+	api.updates.Add(1)
+}
+
+// SetExecErrorHandler sets the function that commands run by ${exec},
+// ${execi} and related variables are reported to when they fail, time out
+// or write to stderr.
+func (api *ConkyAPI) SetExecErrorHandler(handler ExecErrorHandler) {
+	api.exec.SetErrorHandler(handler)
+}
+
+// ExecScheduler returns the scheduler that runs the API's shell commands,
+// so that other commands of the instance share its timeout, concurrency
+// limit and policy and are killed by Close.
+func (api *ConkyAPI) ExecScheduler() *ExecScheduler {
+	return api.exec
 }
 
 // GetTemplate returns the template at the given index (0-9).
@@ -150,6 +190,9 @@ func (api *ConkyAPI) registerFunctions() {
 	// Register conky_parse function
 	api.runtime.SetGoFunction("conky_parse", api.conkyParseLua, 1, false)
 
+	// Register conky_history for reading recorded metric history
+	api.runtime.SetGoFunction("conky_history", api.conkyHistoryLua, 2, false)
+
 	// Setup the conky global table with info subtable
 	api.setupConkyTable()
 }
@@ -182,14 +225,13 @@ func (api *ConkyAPI) conkyParseLua(t *rt.Thread, c *rt.GoCont) (rt.Cont, error)
 	return c.PushingNext1(t.Runtime, rt.StringValue(result)), nil
 }
 
-// variablePattern matches Conky variables in the format ${variable} or ${variable arg}
-var variablePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
-
 // Parse parses a Conky template string and replaces variables with their values.
 // Supported formats:
-//   - ${variable} - simple variable
+//   - ${variable} and $variable - simple variable
 //   - ${variable arg} - variable with argument
-//   - ${variable arg1 arg2} - variable with multiple arguments
+//   - ${variable arg1 "arg 2"} - variable with multiple arguments
+//   - ${variable ${nested}} - variable with an argument read from another
+//   - $$ - a literal $
 //
 // Conditional blocks are also supported:
 //   - ${if_up interface}content${endif}
@@ -200,30 +242,57 @@ var variablePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
 //   - ${if_empty value}content${endif}
 //   - ${if_mounted path}content${endif}
 //
-// Thread safety: This function does not hold locks during parsing to avoid
+// The template is compiled on first use and the compiled form is reused
+// while it stays in the cache; see Compile.
+//
+// Thread safety: This function does not hold locks during evaluation to avoid
 // deadlocks when resolving variables that need to acquire locks (e.g., execi
 // with cache). The sysProvider is accessed via resolveVariable which does a
 // brief RLock to read the pointer. The provider's methods are expected to be
 // thread-safe. SetSystemDataProvider should not be called concurrently with
 // Parse in production; it's intended for initialization and testing.
 func (api *ConkyAPI) Parse(template string) string {
-	// First, process conditional blocks
-	processed := api.parseConditionals(template)
-
-	// Then replace variables
-	return variablePattern.ReplaceAllStringFunc(processed, func(match string) string {
-		// Extract variable name and arguments from ${variable args...}
-		inner := match[2 : len(match)-1] // Remove ${ and }
-		parts := strings.Fields(inner)
-		if len(parts) == 0 {
-			return match
-		}
+	return api.compileCached(template).Execute()
+}
 
-		varName := parts[0]
-		args := parts[1:]
+// parsePixelArg returns the first argument as a pixel amount, or 0 if it is
+// missing or not a number.
+func parsePixelArg(args []string) float64 {
+	if len(args) == 0 {
+		return 0
+	}
+	v, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
 
-		return api.resolveVariable(varName, args)
-	})
+// layoutMarker returns the layout marker applied by the renderer for the
+// text formatting variables, such as ${color} and ${goto}.
+func layoutMarker(name string, args []string) (string, bool) {
+	switch name {
+	case "color":
+		return render.EncodeColorMarker(strings.Join(args, " ")), true
+	case "color0", "color1", "color2", "color3", "color4",
+		"color5", "color6", "color7", "color8", "color9":
+		return render.EncodeColorIndexMarker(int(name[len(name)-1] - '0')), true
+	case "font":
+		return render.EncodeFontMarker(strings.Join(args, " ")), true
+	case "alignr":
+		return render.EncodeAlignRMarker(), true
+	case "alignc":
+		return render.EncodeAlignCMarker(), true
+	case "voffset":
+		return render.EncodeVOffsetMarker(parsePixelArg(args)), true
+	case "offset":
+		return render.EncodeOffsetMarker(parsePixelArg(args)), true
+	case "goto":
+		return render.EncodeGotoMarker(parsePixelArg(args)), true
+	case "tab":
+		return render.EncodeTabMarker(parsePixelArg(args)), true
+	}
+	return "", false
 }
 
 // formatUnknownVariable formats an unknown variable back to its original template form.
@@ -245,6 +314,11 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	provider := api.sysProvider
 	api.mu.RUnlock()
 
+	// Text formatting variables need no system data
+	if marker, ok := layoutMarker(name, args); ok {
+		return marker
+	}
+
 	// Handle case where there's no system data provider
 	if provider == nil {
 		return formatUnknownVariable(name, args)
@@ -354,6 +428,11 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	// Audio variables
 	case "mixer":
 		return api.resolveMixer(args)
+	case "pa_sink_volume", "pa_sink_volumebar", "pa_sink_name", "pa_sink_description",
+		"pa_sink_active_port_name", "pa_sink_active_port_description",
+		"pa_source_volume", "pa_source_name", "pa_source_description",
+		"pa_card_name", "pa_stream_volume":
+		return api.resolvePulse(name, args)
 
 	// System info variables
 	case "kernel":
@@ -371,6 +450,17 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	case "conky_build_arch":
 		return api.sysProvider.SysInfo().Machine
 
+	case "updates":
+		return strconv.FormatInt(api.updates.Load(), 10)
+
+	// Metric history statistics
+	case "history_min":
+		return api.resolveHistoryStat("min", args)
+	case "history_max":
+		return api.resolveHistoryStat("max", args)
+	case "history_avg":
+		return api.resolveHistoryStat("avg", args)
+
 	// Load average variables
 	case "loadavg":
 		return api.resolveLoadAvg(args)
@@ -381,9 +471,9 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 
 	// Top process variables
 	case "top":
-		return api.resolveTop(args, false)
+		return api.resolveTop(args, api.sysProvider.Process().TopCPU)
 	case "top_mem":
-		return api.resolveTop(args, true)
+		return api.resolveTop(args, api.sysProvider.Process().TopMem)
 
 	// Exec variables - execute shell commands
 	case "exec":
@@ -394,25 +484,12 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 		return api.resolveExeci(args)
 	case "execpi":
 		return api.resolveExeci(args) // Same as execi, parsing handled elsewhere
+	case "execbar", "execgauge", "execgraph":
+		return api.resolveExecWidget(strings.TrimPrefix(name, "exec"), args)
+	case "execibar", "execigauge", "execigraph":
+		return api.resolveExeciWidget(strings.TrimPrefix(name, "execi"), args)
 
-	// Text formatting variables (return empty/spacing - actual formatting in renderer)
-	case "color", "color0", "color1", "color2", "color3", "color4",
-		"color5", "color6", "color7", "color8", "color9":
-		return "" // Colors handled by renderer
-	case "font":
-		return "" // Font changes handled by renderer
-	case "alignr":
-		return "" // Right alignment marker
-	case "alignc":
-		return "" // Center alignment marker
-	case "voffset":
-		return "" // Vertical offset handled by renderer
-	case "offset":
-		return "" // Horizontal offset handled by renderer
-	case "goto":
-		return "" // Goto position handled by renderer
-	case "tab":
-		return "\t"
+	// Text formatting variables
 	case "hr":
 		return api.resolveHR(args)
 
@@ -478,6 +555,10 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	// Network packet/error variables
 	case "tcp_portmon":
 		return api.resolveTCPPortMon(args)
+	case "listening_ports":
+		return api.resolveListeningPorts(args)
+	case "socket_count":
+		return api.resolveSocketCount(args)
 	case "if_existing":
 		return api.resolveIfExisting(args)
 	case "if_running":
@@ -487,7 +568,18 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	case "running_threads":
 		return strconv.Itoa(api.sysProvider.Process().TotalThreads)
 	case "top_io":
-		return api.resolveTop(args, false) // Alias to top for now
+		return api.resolveTop(args, api.sysProvider.Process().TopIO)
+	case "pidof":
+		return api.resolvePidof(args)
+	case "pid_chroot", "pid_cmdline", "pid_cwd", "pid_environ", "pid_environ_list",
+		"pid_exe", "pid_nice", "pid_openfiles", "pid_parent", "pid_priority",
+		"pid_state", "pid_state_short", "pid_stdin", "pid_stdout", "pid_stderr",
+		"pid_threads", "pid_thread_list", "pid_time", "pid_time_kernelmode",
+		"pid_time_usermode", "pid_uid", "pid_euid", "pid_suid", "pid_fsuid",
+		"pid_gid", "pid_egid", "pid_sgid", "pid_fsgid", "pid_read", "pid_write",
+		"pid_vmpeak", "pid_vmsize", "pid_vmlck", "pid_vmhwm", "pid_vmrss",
+		"pid_vmdata", "pid_vmstk", "pid_vmexe", "pid_vmlib", "pid_vmpte":
+		return api.resolvePID(strings.TrimPrefix(name, "pid_"), args)
 
 	// Entropy/random variables
 	case "entropy_avail":
@@ -514,32 +606,12 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 		return api.resolveLua(args, false)
 	case "lua_parse":
 		return api.resolveLua(args, true)
-	case "template0":
-		return api.resolveTemplate(0, args)
-	case "template1":
-		return api.resolveTemplate(1, args)
-	case "template2":
-		return api.resolveTemplate(2, args)
-	case "template3":
-		return api.resolveTemplate(3, args)
-	case "template4":
-		return api.resolveTemplate(4, args)
-	case "template5":
-		return api.resolveTemplate(5, args)
-	case "template6":
-		return api.resolveTemplate(6, args)
-	case "template7":
-		return api.resolveTemplate(7, args)
-	case "template8":
-		return api.resolveTemplate(8, args)
-	case "template9":
-		return api.resolveTemplate(9, args)
 
 	// Pre/post text markers
 	case "pre_exec":
 		return api.resolveExec(args)
 	case "texeci":
-		return api.resolveExec(args) // Threaded exec - treat as regular exec
+		return api.resolveExeci(args) // execi always runs in the background
 
 	// Inode variables
 	case "fs_inodes":
@@ -614,6 +686,13 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	case "nvidia_name":
 		return api.resolveNvidia([]string{"name"})
 
+	// GPU monitoring for all vendors, indexed by GPU number
+	case "gpu_count":
+		return strconv.Itoa(len(api.sysProvider.GPUs()))
+	case "gpu_name", "gpu_vendor", "gpu_util", "gpu_mem_util", "gpu_temp",
+		"gpu_vram_used", "gpu_vram_total", "gpu_vram_perc", "gpu_power", "gpu_fan", "gpu_freq":
+		return api.resolveGPU(strings.TrimPrefix(name, "gpu_"), args)
+
 	// MPD (Music Player Daemon) variables
 	case "mpd_artist":
 		return api.sysProvider.MPD().Artist
@@ -658,13 +737,14 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 	case "mpd_bar":
 		return api.resolveMPDBar(args)
 
-	// Apcupsd (UPS) stubs - not implemented; requires APCUPSD daemon and NIS protocol.
-	// Users should use ${execi} with apcaccess command. See docs/migration.md.
-	case "apcupsd", "apcupsd_model", "apcupsd_status", "apcupsd_linev",
+	// Apcupsd (UPS) variables
+	case "apcupsd":
+		return api.setAPCUPSDTarget(args)
+	case "apcupsd_model", "apcupsd_status", "apcupsd_linev",
 		"apcupsd_load", "apcupsd_charge", "apcupsd_timeleft", "apcupsd_temp",
 		"apcupsd_battv", "apcupsd_cable", "apcupsd_driver", "apcupsd_upsmode",
 		"apcupsd_name", "apcupsd_hostname":
-		return "N/A"
+		return api.resolveAPCUPSD(strings.TrimPrefix(name, "apcupsd_"))
 
 	// IMAP/POP3/mail variables
 	case "imap_unseen":
@@ -675,12 +755,21 @@ func (api *ConkyAPI) resolveVariable(name string, args []string) string {
 		return api.resolvePop3Unseen(args)
 	case "pop3_used":
 		return api.resolvePop3Used(args)
-	case "new_mails", "mails":
-		return api.resolveTotalMails(args)
+	case "mails", "new_mails", "seen_mails", "unseen_mails", "flagged_mails",
+		"replied_mails", "forwarded_mails", "trashed_mails", "draft_mails":
+		return api.resolveMailbox(strings.TrimSuffix(name, "_mails"), args)
 
 	// Weather variables
 	case "weather":
 		return api.resolveWeather(args)
+	case "weather_forecast":
+		return api.resolveWeatherForecast(args)
+
+	// Web content variables
+	case "curl":
+		return api.resolveCurl(args)
+	case "rss":
+		return api.resolveRSS(args)
 
 	// Image variable
 	case "image":
@@ -914,10 +1003,31 @@ func (api *ConkyAPI) resolveBatteryShort(_ []string) string {
 	return fmt.Sprintf("%s %.0f%%", status, batStats.TotalCapacity)
 }
 
-// resolveHwmon resolves ${hwmon} variable.
+// hwmonFormats holds the output format of each ${hwmon} sensor type.
+var hwmonFormats = map[string]string{
+	"temp":  "%.0f",
+	"tempf": "%.0f",
+	"fan":   "%.0f",
+	"in":    "%.2f",
+	"vol":   "%.2f",
+	"power": "%.1f",
+	"curr":  "%.2f",
+}
+
+// resolveHwmon resolves ${hwmon} variables.
+// Usage: ${hwmon [n]} for the nth temperature sensor, or
+// ${hwmon [dev] type n|label [min|max|crit|label|factor offset]} where dev is
+// a device name, hwmon directory or its number, type is temp, tempf, fan, in
+// (or vol), power or curr, and the sensor is given by its number or by its
+// label, quoted when it contains spaces. Without dev the first device with
+// a matching sensor is used.
 func (api *ConkyAPI) resolveHwmon(args []string) string {
 	hwmonStats := api.sysProvider.Hwmon()
 
+	if len(args) >= 2 {
+		return api.resolveHwmonSensor(hwmonStats, args)
+	}
+
 	// Default to first temperature sensor if no args
 	if len(hwmonStats.TempSensors) == 0 {
 		return "0"
@@ -937,6 +1047,95 @@ func (api *ConkyAPI) resolveHwmon(args []string) string {
 	return fmt.Sprintf("%.0f", hwmonStats.TempSensors[idx].InputCelsius)
 }
 
+// resolveHwmonSensor resolves the ${hwmon [dev] type n|label ...} form.
+func (api *ConkyAPI) resolveHwmonSensor(hwmonStats monitor.HwmonStats, args []string) string {
+	var devID string
+	if _, ok := hwmonFormats[args[0]]; !ok {
+		devID, args = args[0], args[1:]
+	}
+	if len(args) < 2 {
+		return "0"
+	}
+	kind := args[0]
+	format, ok := hwmonFormats[kind]
+	if !ok {
+		return "0"
+	}
+	id, opts := splitQuotedArg(args[1:])
+	lookupKind := kind
+	if kind == "tempf" {
+		lookupKind = "temp"
+	}
+
+	var sensor monitor.HwmonSensor
+	if devID != "" {
+		device, ok := hwmonStats.Device(devID)
+		if !ok {
+			return "0"
+		}
+		if sensor, ok = device.Sensor(lookupKind, id); !ok {
+			return "0"
+		}
+	} else {
+		found := false
+		devices := slices.SortedFunc(maps.Values(hwmonStats.Devices), func(a, b monitor.HwmonDevice) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		for _, device := range devices {
+			if sensor, found = device.Sensor(lookupKind, id); found {
+				break
+			}
+		}
+		if !found {
+			return "0"
+		}
+	}
+
+	value := sensor.Input
+	if len(opts) > 0 {
+		switch opts[0] {
+		case "label":
+			return sensor.Label
+		case "min":
+			value = sensor.Min
+		case "max":
+			value = sensor.Max
+		case "crit":
+			value = sensor.Crit
+		}
+	}
+	if kind == "tempf" {
+		value = value*9/5 + 32
+	}
+	if len(opts) == 2 {
+		// Conky's factor and offset arguments rescale the reading
+		factor, errFactor := strconv.ParseFloat(opts[0], 64)
+		offset, errOffset := strconv.ParseFloat(opts[1], 64)
+		if errFactor == nil && errOffset == nil {
+			value = value*factor + offset
+		}
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// splitQuotedArg returns the first argument in args, joining the words of an
+// argument enclosed in double quotes such as "Package id 0", and the
+// arguments that follow it.
+func splitQuotedArg(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	if !strings.HasPrefix(args[0], `"`) {
+		return args[0], args[1:]
+	}
+	for i, arg := range args {
+		if (i > 0 || len(arg) > 1) && strings.HasSuffix(arg, `"`) {
+			return strings.Trim(strings.Join(args[:i+1], " "), `"`), args[i+1:]
+		}
+	}
+	return strings.Trim(strings.Join(args, " "), `"`), nil
+}
+
 // resolveDiskIO resolves ${diskio} variable.
 // Returns total disk I/O speed (read + write) for a device.
 // If no device is specified, returns total for all devices.
@@ -1026,6 +1225,57 @@ func (api *ConkyAPI) resolveMixer(_ []string) string {
 	return fmt.Sprintf("%.0f", audioStats.MasterVolume)
 }
 
+// resolvePulse resolves the ${pa_*} variables from the PulseAudio or
+// PipeWire server. Without a sound server volumes are 0 and names empty.
+// ${pa_stream_volume app} shows the volume of the named application's
+// playback stream, and ${pa_sink_volumebar} takes a height and width.
+func (api *ConkyAPI) resolvePulse(name string, args []string) string {
+	pulse := api.sysProvider.Audio().Pulse
+
+	switch name {
+	case "pa_sink_volume":
+		return fmt.Sprintf("%.0f", pulse.DefaultSink.Volume)
+	case "pa_sink_volumebar":
+		height, width := 8.0, 100.0
+		if len(args) > 0 {
+			if h, err := strconv.ParseFloat(args[0], 64); err == nil {
+				height = h
+			}
+		}
+		if len(args) > 1 {
+			if w, err := strconv.ParseFloat(args[1], 64); err == nil {
+				width = w
+			}
+		}
+		return render.EncodeBarMarker(min(pulse.DefaultSink.Volume, 100), width, height)
+	case "pa_sink_name":
+		return pulse.DefaultSink.Name
+	case "pa_sink_description":
+		return pulse.DefaultSink.Description
+	case "pa_sink_active_port_name":
+		return pulse.DefaultSink.ActivePort
+	case "pa_sink_active_port_description":
+		return pulse.DefaultSink.ActivePortDescription
+	case "pa_source_volume":
+		return fmt.Sprintf("%.0f", pulse.DefaultSource.Volume)
+	case "pa_source_name":
+		return pulse.DefaultSource.Name
+	case "pa_source_description":
+		return pulse.DefaultSource.Description
+	case "pa_card_name":
+		return pulse.DefaultSink.CardName()
+	case "pa_stream_volume":
+		if len(args) == 0 {
+			return "0"
+		}
+		if stream, ok := pulse.Stream(strings.Join(args, " ")); ok {
+			return fmt.Sprintf("%.0f", stream.Volume)
+		}
+		return "0"
+	}
+	return ""
+}
+
 // formatBytes formats bytes to human-readable format (e.g., "1.5GiB").
 func formatBytes(bytes uint64) string {
 	const (
@@ -1298,9 +1548,12 @@ func (api *ConkyAPI) resolveNameserver(args []string) string {
 	return netStats.Nameservers[index]
 }
 
-// resolveTop resolves ${top} and ${top_mem} variables.
-// Format: ${top field index} where field is name/pid/cpu/mem and index is 1-based.
-func (api *ConkyAPI) resolveTop(args []string, byMem bool) string {
+// resolveTop resolves ${top}, ${top_mem} and ${top_io} variables from the
+// matching list of top processes.
+// Format: ${top field index} where field is name/pid/cpu/mem/io_read/...
+// and index is 1-based. io_read and io_write are the bytes transferred in
+// the last update interval.
+func (api *ConkyAPI) resolveTop(args []string, processes []monitor.ProcessInfo) string {
 	if len(args) < 2 {
 		return ""
 	}
@@ -1312,14 +1565,6 @@ func (api *ConkyAPI) resolveTop(args []string, byMem bool) string {
 	}
 	index-- // Convert to 0-based
 
-	procStats := api.sysProvider.Process()
-	var processes []monitor.ProcessInfo
-	if byMem {
-		processes = procStats.TopMem
-	} else {
-		processes = procStats.TopCPU
-	}
-
 	if index >= len(processes) {
 		return ""
 	}
@@ -1340,87 +1585,308 @@ func (api *ConkyAPI) resolveTop(args []string, byMem bool) string {
 		return formatBytes(proc.VirtBytes)
 	case "threads", "time":
 		return strconv.Itoa(proc.Threads)
+	case "io_read":
+		return formatBytes(proc.IOReadBytes)
+	case "io_write":
+		return formatBytes(proc.IOWriteBytes)
+	case "io_perc":
+		return fmt.Sprintf("%.1f", proc.IOPercent)
 	default:
 		return ""
 	}
 }
 
+// resolvePidof resolves ${pidof name}, the lowest PID of the processes
+// with that command name, or "" if none is running.
+func (api *ConkyAPI) resolvePidof(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	pid, ok := api.sysProvider.Pidof(args[0])
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(pid)
+}
+
+// resolvePID resolves the ${pid_*} variables.
+// Format: ${pid_cmdline pid} or ${pid_environ pid NAME}. The pid may also
+// be a command name, as in ${pid_vmrss postgres}, which is looked up as by
+// ${pidof}. Times are in seconds and sizes human-readable. Details of
+// processes that have exited, or that the user may not inspect, are "".
+func (api *ConkyAPI) resolvePID(field string, args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		var ok bool
+		if pid, ok = api.sysProvider.Pidof(args[0]); !ok {
+			return ""
+		}
+	}
+
+	if api.checkPIDField(pid, field, args) != nil {
+		return ""
+	}
+	info, err := api.sysProvider.PIDInfo(pid)
+	if err != nil {
+		return ""
+	}
+
+	switch field {
+	case "chroot":
+		return info.Root
+	case "cmdline":
+		return info.Cmdline
+	case "cwd":
+		return info.Cwd
+	case "exe":
+		return info.Exe
+	case "environ":
+		if len(args) < 2 {
+			return ""
+		}
+		value, _ := info.Getenv(args[1])
+		return value
+	case "environ_list":
+		return strings.Join(info.Environ, ", ")
+	case "openfiles":
+		return strings.Join(info.OpenFiles, "; ")
+	case "stdin":
+		return info.Stdin
+	case "stdout":
+		return info.Stdout
+	case "stderr":
+		return info.Stderr
+	case "parent":
+		return strconv.Itoa(info.Parent)
+	case "nice":
+		return strconv.Itoa(info.Nice)
+	case "priority":
+		return strconv.Itoa(info.Priority)
+	case "state":
+		return info.StateName
+	case "state_short":
+		return info.State
+	case "threads":
+		return strconv.Itoa(len(info.Threads))
+	case "thread_list":
+		tids := make([]string, len(info.Threads))
+		for i, tid := range info.Threads {
+			tids[i] = strconv.Itoa(tid)
+		}
+		return strings.Join(tids, ",")
+	case "time":
+		return fmt.Sprintf("%.2f", info.UserTime+info.SystemTime)
+	case "time_usermode":
+		return fmt.Sprintf("%.2f", info.UserTime)
+	case "time_kernelmode":
+		return fmt.Sprintf("%.2f", info.SystemTime)
+	case "uid", "euid", "suid", "fsuid":
+		return strconv.Itoa(info.UIDs[idIndex(field)])
+	case "gid", "egid", "sgid", "fsgid":
+		return strconv.Itoa(info.GIDs[idIndex(field)])
+	case "read":
+		return formatBytes(info.ReadBytes)
+	case "write":
+		return formatBytes(info.WriteBytes)
+	}
+
+	// vmpeak, vmsize, vmrss, ...
+	size, ok := info.Memory[strings.TrimPrefix(field, "vm")]
+	if !ok {
+		return ""
+	}
+	return formatBytes(size)
+}
+
+// checkPIDField applies the policy to the ${pid_*} fields that expose a
+// process's environment, command line or files: the environment is
+// treated as environment variables of conky-go itself, and the rest as
+// reads of the /proc files they come from.
+func (api *ConkyAPI) checkPIDField(pid int, field string, args []string) error {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	switch field {
+	case "environ":
+		if len(args) < 2 {
+			return nil
+		}
+		return api.runtime.CheckEnv(args[1])
+	case "environ_list":
+		return api.runtime.CheckEnv("*")
+	case "cmdline", "cwd", "exe":
+		return api.runtime.CheckRead(filepath.Join(dir, field))
+	case "chroot":
+		return api.runtime.CheckRead(filepath.Join(dir, "root"))
+	case "openfiles", "stdin", "stdout", "stderr":
+		return api.runtime.CheckRead(filepath.Join(dir, "fd"))
+	}
+	return nil
+}
+
+// idIndex returns the position of a uid or gid variant in the real,
+// effective, saved, filesystem order of /proc/[pid]/status.
+func idIndex(field string) int {
+	switch field[0] {
+	case 'e':
+		return 1
+	case 's':
+		return 2
+	case 'f':
+		return 3
+	}
+	return 0
+}
+
 // resolveExec executes a shell command and returns its output.
 // Usage: ${exec command}
+// The command runs on every update, bounded by the exec timeout.
 func (api *ConkyAPI) resolveExec(args []string) string {
 	if len(args) == 0 {
 		return ""
 	}
 
-	cmdStr := strings.Join(args, " ")
-	cmd := exec.Command("sh", "-c", cmdStr)
-	output, err := cmd.Output()
+	output, err := api.exec.Run(strings.Join(args, " "))
 	if err != nil {
 		return ""
 	}
-
-	// Trim trailing newlines
-	return strings.TrimRight(string(output), "\n\r")
+	return output
 }
 
-// resolveExeci executes a shell command with interval-based caching.
-// Usage: ${execi interval command}
-// The command output is cached for 'interval' seconds before re-execution.
+// resolveExeci executes a shell command in the background every interval
+// seconds. Usage: ${execi interval command}
+// The output of the last successful run is shown, which is empty until the
+// command first completes, so a slow command never holds up the update.
 func (api *ConkyAPI) resolveExeci(args []string) string {
-	if len(args) < 2 {
+	interval, command, ok := parseExeciArgs(args)
+	if !ok || command == "" {
 		return ""
 	}
+	output, _ := api.exec.Output(command, interval)
+	return output
+}
 
-	// Parse interval from first argument
-	interval, err := strconv.Atoi(args[0])
-	if err != nil || interval < 0 {
-		return ""
+// parseExeciArgs splits the arguments of an interval command into the
+// interval and the rest of the arguments joined as a command line.
+func parseExeciArgs(args []string) (time.Duration, string, bool) {
+	if len(args) < 2 {
+		return 0, "", false
 	}
+	seconds, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || seconds < 0 {
+		return 0, "", false
+	}
+	return time.Duration(seconds * float64(time.Second)), strings.Join(args[1:], " "), true
+}
 
-	// Build command string from remaining arguments
-	cmdStr := strings.Join(args[1:], " ")
-
-	now := time.Now()
+// resolveExecWidget draws the numeric output of a command run on every
+// update as a widget, where kind is "bar", "gauge" or "graph".
+// Usage: ${execbar [height,width] command}, and likewise for execgauge
+// and execgraph.
+func (api *ConkyAPI) resolveExecWidget(kind string, args []string) string {
+	size, command := splitExecWidgetArgs(args)
+	if command == "" {
+		return ""
+	}
+	output, err := api.exec.Run(command)
+	if err != nil {
+		return ""
+	}
+	return encodeExecWidget(kind, size, command, output)
+}
 
-	// Check cache with read lock
-	api.mu.RLock()
-	entry, exists := api.execCache[cmdStr]
-	api.mu.RUnlock()
+// resolveExeciWidget is resolveExecWidget for commands run in the
+// background every interval seconds.
+// Usage: ${execibar interval [height,width] command}, and likewise for
+// execigauge and execigraph.
+func (api *ConkyAPI) resolveExeciWidget(kind string, args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	interval, _, ok := parseExeciArgs(args)
+	if !ok {
+		return ""
+	}
+	size, command := splitExecWidgetArgs(args[1:])
+	if command == "" {
+		return ""
+	}
+	output, _ := api.exec.Output(command, interval)
+	return encodeExecWidget(kind, size, command, output)
+}
 
-	if exists && now.Before(entry.expiresAt) {
-		// Update lastAccessed time
-		api.mu.Lock()
-		entry.lastAccessed = now
-		api.mu.Unlock()
-		return entry.output
+// splitExecWidgetArgs splits the arguments of an exec widget into the
+// optional "height,width" size before the command and the command line.
+// The -t and -l graph options are accepted and ignored.
+func splitExecWidgetArgs(args []string) ([]float64, string) {
+	for len(args) > 0 && (args[0] == "-t" || args[0] == "-l") {
+		args = args[1:]
 	}
+	var size []float64
+	if len(args) > 1 {
+		for _, part := range strings.Split(args[0], ",") {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil || v <= 0 {
+				size = nil
+				break
+			}
+			size = append(size, v)
+		}
+		if len(size) > 0 && len(size) <= 2 {
+			args = args[1:]
+		} else {
+			size = nil
+		}
+	}
+	return size, strings.Join(args, " ")
+}
 
-	// Cache miss or expired - execute command
-	cmd := exec.Command("sh", "-c", cmdStr)
-	output, err := cmd.Output()
-	if err != nil {
-		// On error, return cached value if available, otherwise empty
-		if exists {
-			api.mu.Lock()
-			entry.lastAccessed = now
-			api.mu.Unlock()
-			return entry.output
+// encodeExecWidget encodes the widget marker of kind for the output of
+// command, whose first field is read as a percentage. Output that is not
+// a number draws an empty widget.
+func encodeExecWidget(kind string, size []float64, command, output string) string {
+	value := 0.0
+	if fields := strings.Fields(output); len(fields) > 0 {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64); err == nil {
+			value = max(0, min(v, 100))
 		}
-		return ""
 	}
 
-	result := strings.TrimRight(string(output), "\n\r")
+	switch kind {
+	case "gauge":
+		height, width := execWidgetSize(size, 30, 30)
+		if len(size) == 1 {
+			width = height // A single size is the gauge diameter
+		}
+		return render.EncodeGaugeMarker(value, width, height)
+	case "graph":
+		height, width := execWidgetSize(size, 20, 100)
+		return render.EncodeGraphMarkerWithID(value, width, height, execGraphID(command))
+	default:
+		height, width := execWidgetSize(size, 8, 100)
+		return render.EncodeBarMarker(value, width, height)
+	}
+}
 
-	// Update cache with write lock
-	api.mu.Lock()
-	api.execCache[cmdStr] = &execCacheEntry{
-		output:       result,
-		expiresAt:    now.Add(time.Duration(interval) * time.Second),
-		lastAccessed: now,
+// execWidgetSize returns the height and width given in size, or the
+// defaults for those not given.
+func execWidgetSize(size []float64, height, width float64) (float64, float64) {
+	if len(size) > 0 {
+		height = size[0]
 	}
-	api.mu.Unlock()
+	if len(size) > 1 {
+		width = size[1]
+	}
+	return height, width
+}
 
-	return result
+// execGraphID returns the graph history ID of command. Commands are
+// hashed because widget markers cannot hold arbitrary text.
+func execGraphID(command string) string {
+	h := fnv.New64a()
+	h.Write([]byte(command))
+	return fmt.Sprintf("exec.%016x", h.Sum64())
 }
 
 // resolveHR returns a horizontal rule of specified length.
@@ -1736,7 +2202,7 @@ func (api *ConkyAPI) resolveWirelessMode(args []string) string {
 
 // resolveTCPPortMon monitors TCP connections in a port range.
 // Syntax: ${tcp_portmon port_begin port_end item [index]}
-// Items: count, lip, lport, lservice, rip, rport, rservice, rhost
+// Items: count, lip, lport, lservice, rip, rport, rservice, rhost, pname, pid
 func (api *ConkyAPI) resolveTCPPortMon(args []string) string {
 	// Minimum args: port_begin, port_end, item
 	if len(args) < 3 {
@@ -1792,9 +2258,80 @@ func (api *ConkyAPI) resolveTCPPortMon(args []string) string {
 	case "rhost":
 		// Return remote IP as hostname (DNS lookup could be added)
 		return conn.RemoteIP
+	case "pname":
+		_, name := api.sysProvider.SocketOwner(conn.Inode)
+		return name
+	case "pid":
+		pid, _ := api.sysProvider.SocketOwner(conn.Inode)
+		if pid == 0 {
+			return ""
+		}
+		return strconv.Itoa(pid)
+	default:
+		return ""
+	}
+}
+
+// resolveListeningPorts lists the listening ports in ascending order, each
+// followed by the name of its owning process when known.
+// Syntax: ${listening_ports [tcp|udp|all]}, e.g. "22(sshd) 631(cupsd)"
+func (api *ConkyAPI) resolveListeningPorts(args []string) string {
+	protocol := monitor.SocketTCP
+	if len(args) > 0 {
+		protocol = strings.ToLower(args[0])
+	}
+	switch protocol {
+	case "all":
+		protocol = ""
+	case monitor.SocketTCP, monitor.SocketUDP:
 	default:
 		return ""
 	}
+
+	// A port may be bound on several addresses and by both protocols
+	processes := make(map[int]string)
+	for _, sock := range api.sysProvider.Sockets().Listening(protocol) {
+		if sock.Protocol == monitor.SocketUnix {
+			continue
+		}
+		if name, ok := processes[sock.LocalPort]; !ok || name == "" {
+			processes[sock.LocalPort] = sock.Process
+		}
+	}
+
+	ports := slices.Sorted(maps.Keys(processes))
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+		if name := processes[port]; name != "" {
+			parts[i] += "(" + name + ")"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// resolveSocketCount counts open sockets by protocol and state.
+// Syntax: ${socket_count [tcp|udp|unix|all] [state]}
+// States are the TCP state names (established, listen, time_wait, ...)
+// plus unconn for unconnected UDP and UNIX sockets.
+func (api *ConkyAPI) resolveSocketCount(args []string) string {
+	protocol := ""
+	if len(args) > 0 {
+		protocol = strings.ToLower(args[0])
+	}
+	switch protocol {
+	case "all":
+		protocol = ""
+	case "", monitor.SocketTCP, monitor.SocketUDP, monitor.SocketUnix:
+	default:
+		return "0"
+	}
+
+	state := ""
+	if len(args) > 1 {
+		state = args[1]
+	}
+	return strconv.Itoa(api.sysProvider.Sockets().Count(protocol, state))
 }
 
 // portToService maps well-known port numbers to service names.
@@ -1847,7 +2384,7 @@ func portToService(port int) string {
 
 // resolveIfExisting checks if a file or path exists.
 func (api *ConkyAPI) resolveIfExisting(args []string) string {
-	if len(args) == 0 {
+	if len(args) == 0 || api.runtime.CheckRead(args[0]) != nil {
 		return "0"
 	}
 	if _, err := os.Stat(args[0]); err == nil {
@@ -2035,26 +2572,6 @@ func (api *ConkyAPI) resolveScroll(args []string) string {
 	return string(doubledRunes[start:end])
 }
 
-// resolveTemplate resolves a template variable by index with argument substitution.
-// Templates are defined as template0-template9 in the config and can contain
-// \1, \2, etc. placeholders that are replaced with the provided arguments.
-func (api *ConkyAPI) resolveTemplate(index int, args []string) string {
-	template := api.GetTemplate(index)
-	if template == "" {
-		return ""
-	}
-
-	// Substitute \1, \2, etc. with the provided arguments
-	result := template
-	for i, arg := range args {
-		placeholder := fmt.Sprintf("\\%d", i+1)
-		result = strings.ReplaceAll(result, placeholder, arg)
-	}
-
-	// Parse the result to resolve any embedded Conky variables
-	return api.Parse(result)
-}
-
 // padRight pads a string with spaces to reach the desired length.
 func padRight(s string, length int) string {
 	runes := []rune(s)
@@ -2278,7 +2795,7 @@ func (api *ConkyAPI) resolveLoadGraph(args []string) string {
 		loadPerc = 100
 	}
 
-	return render.EncodeGraphMarkerWithID(loadPerc, width, height, "load")
+	return render.EncodeGraphMarkerWithID(loadPerc, width, height, "load.1")
 }
 
 // resolveCPUGraph returns a graphical representation of CPU usage with historical tracking.
@@ -2299,7 +2816,7 @@ func (api *ConkyAPI) resolveCPUGraph(args []string) string {
 	}
 
 	cpuInfo := api.sysProvider.CPU()
-	return render.EncodeGraphMarkerWithID(cpuInfo.UsagePercent, width, height, "cpu")
+	return render.EncodeGraphMarkerWithID(cpuInfo.UsagePercent, width, height, "cpu.total")
 }
 
 // resolveMemGraph returns a graphical representation of memory usage with historical tracking.
@@ -2324,7 +2841,7 @@ func (api *ConkyAPI) resolveMemGraph(args []string) string {
 	if memInfo.Total > 0 {
 		memPerc = float64(memInfo.Used) / float64(memInfo.Total) * 100
 	}
-	return render.EncodeGraphMarkerWithID(memPerc, width, height, "mem")
+	return render.EncodeGraphMarkerWithID(memPerc, width, height, "mem.used_perc")
 }
 
 // resolveNetworkSpeedGraph returns a graphical representation of network speed with historical tracking.
@@ -2358,10 +2875,10 @@ func (api *ConkyAPI) resolveNetworkSpeedGraph(args []string, isDown bool) string
 			var graphID string
 			if isDown {
 				speed = netIface.RxBytesPerSec
-				graphID = "net_" + netIface.Name + "_down"
+				graphID = "net." + netIface.Name + ".down"
 			} else {
 				speed = netIface.TxBytesPerSec
-				graphID = "net_" + netIface.Name + "_up"
+				graphID = "net." + netIface.Name + ".up"
 			}
 			// Normalize to percentage (0-100) based on max observed speed
 			// For now, normalize to 100 Mbps (12.5 MB/s) as baseline
@@ -2375,9 +2892,9 @@ func (api *ConkyAPI) resolveNetworkSpeedGraph(args []string, isDown bool) string
 	}
 
 	// No matching interface found
-	graphID := "net_unknown_down"
+	graphID := "net.unknown.down"
 	if !isDown {
-		graphID = "net_unknown_up"
+		graphID = "net.unknown.up"
 	}
 	return render.EncodeGraphMarkerWithID(0, width, height, graphID)
 }
@@ -2389,6 +2906,11 @@ func (api *ConkyAPI) resolveACPIFan() string {
 		if strings.Contains(strings.ToLower(dev.Name), "fan") {
 			return "running"
 		}
+		for _, fan := range dev.Fans {
+			if fan.Input > 0 {
+				return "running"
+			}
+		}
 	}
 	return "unknown"
 }
@@ -2427,6 +2949,52 @@ func (api *ConkyAPI) resolveNvidiaGraph(args []string) string {
 	return strconv.Itoa(gpuStats.UtilGPU)
 }
 
+// resolveGPU resolves the ${gpu_*} variables for one GPU of any vendor.
+// Usage: ${gpu_util [n]} where n is the GPU number from 0 in the order of
+// SystemMonitor.GPUs (default 0). Unknown GPUs yield "N/A".
+func (api *ConkyAPI) resolveGPU(field string, args []string) string {
+	index := 0
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			index = n
+		}
+	}
+	gpus := api.sysProvider.GPUs()
+	if index < 0 || index >= len(gpus) {
+		return "N/A"
+	}
+	gpu := gpus[index]
+
+	switch field {
+	case "name":
+		return gpu.Name
+	case "vendor":
+		return gpu.Vendor
+	case "util":
+		return strconv.Itoa(gpu.UtilGPU)
+	case "mem_util":
+		return strconv.Itoa(gpu.UtilMem)
+	case "temp":
+		return strconv.Itoa(gpu.Temperature)
+	case "vram_used":
+		return formatBytes(gpu.MemUsed)
+	case "vram_total":
+		return formatBytes(gpu.MemTotal)
+	case "vram_perc":
+		if gpu.MemTotal == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%.0f", float64(gpu.MemUsed)*100/float64(gpu.MemTotal))
+	case "power":
+		return fmt.Sprintf("%.1f", gpu.PowerDraw)
+	case "fan":
+		return strconv.Itoa(gpu.FanSpeed)
+	case "freq":
+		return strconv.Itoa(gpu.FreqMHz)
+	}
+	return ""
+}
+
 // formatNumber formats a number with commas for readability.
 func formatNumber(n uint64) string {
 	s := strconv.FormatUint(n, 10)
@@ -2445,9 +3013,14 @@ func formatNumber(n uint64) string {
 }
 
 // resolveImapUnseen resolves the ${imap_unseen} variable.
-// Accepts an optional account name argument.
-// Without argument, returns the total unseen count across all accounts.
+// Accepts an optional account name argument, or a server in the imap
+// setting's format (host user pass [-f folder] ...). Without argument,
+// returns the configured imap server's count, or the total unseen count
+// across all accounts when none is configured.
 func (api *ConkyAPI) resolveImapUnseen(args []string) string {
+	if stats, ok := api.mailAccount("imap", args); ok {
+		return strconv.Itoa(stats.Unseen)
+	}
 	if len(args) > 0 {
 		// Get unseen count for specific IMAP account
 		return strconv.Itoa(api.sysProvider.MailUnseenCount(args[0]))
@@ -2457,9 +3030,12 @@ func (api *ConkyAPI) resolveImapUnseen(args []string) string {
 }
 
 // resolveImapMessages resolves the ${imap_messages} variable.
-// Accepts an optional account name argument.
+// Accepts the same arguments as ${imap_unseen}.
 // Without argument, returns the total message count across all accounts.
 func (api *ConkyAPI) resolveImapMessages(args []string) string {
+	if stats, ok := api.mailAccount("imap", args); ok {
+		return strconv.Itoa(stats.Total)
+	}
 	if len(args) > 0 {
 		return strconv.Itoa(api.sysProvider.MailTotalCount(args[0]))
 	}
@@ -2467,9 +3043,13 @@ func (api *ConkyAPI) resolveImapMessages(args []string) string {
 }
 
 // resolvePop3Unseen resolves the ${pop3_unseen} variable.
-// Accepts an optional account name argument.
+// Accepts an optional account name argument, or a server in the pop3
+// setting's format.
 // For POP3, unseen and total are the same since POP3 doesn't track read status.
 func (api *ConkyAPI) resolvePop3Unseen(args []string) string {
+	if stats, ok := api.mailAccount("pop3", args); ok {
+		return strconv.Itoa(stats.Unseen)
+	}
 	if len(args) > 0 {
 		return strconv.Itoa(api.sysProvider.MailUnseenCount(args[0]))
 	}
@@ -2477,24 +3057,119 @@ func (api *ConkyAPI) resolvePop3Unseen(args []string) string {
 }
 
 // resolvePop3Used resolves the ${pop3_used} variable.
-// Accepts an optional account name argument.
+// Accepts the same arguments as ${pop3_unseen}.
 // Returns the total message count for the POP3 account.
 func (api *ConkyAPI) resolvePop3Used(args []string) string {
+	if stats, ok := api.mailAccount("pop3", args); ok {
+		return strconv.Itoa(stats.Total)
+	}
 	if len(args) > 0 {
 		return strconv.Itoa(api.sysProvider.MailTotalCount(args[0]))
 	}
 	return strconv.Itoa(api.sysProvider.MailTotalMessages())
 }
 
-// resolveTotalMails resolves the ${new_mails} and ${mails} variables.
-// ${new_mails} returns the total unseen count.
-// ${mails} returns the total message count.
-func (api *ConkyAPI) resolveTotalMails(args []string) string {
+// mailAccount returns the statistics of the server given inline in args
+// (host user pass [options]), or without args those of the account
+// configured by the imap or pop3 setting, which is named after its type.
+// ok is false when args name an account or nothing is configured. Servers
+// that cannot be parsed or reached report zero messages.
+func (api *ConkyAPI) mailAccount(mailType string, args []string) (monitor.MailAccountStats, bool) {
+	if len(args) == 0 {
+		stats, ok := api.sysProvider.Mail().Accounts[mailType]
+		return stats, ok
+	}
+	if len(args) < 3 {
+		return monitor.MailAccountStats{}, false
+	}
+
+	server, err := config.ParseMailServer(strings.Join(args, " "))
+	if err != nil {
+		return monitor.MailAccountStats{}, true
+	}
+	password, err := api.runtime.SecretPolicy().Resolve(server.Password)
+	if err != nil {
+		return monitor.MailAccountStats{}, true
+	}
+	stats, err := api.sysProvider.MailAccount(monitor.MailConfig{
+		Name:     mailType + ":" + server.User + "@" + server.Host + "/" + server.Folder,
+		Type:     mailType,
+		Host:     server.Host,
+		Port:     server.Port,
+		Username: server.User,
+		Password: password,
+		UseTLS:   server.TLS,
+		Folder:   server.Folder,
+		Interval: server.Interval,
+	})
+	if err != nil {
+		return monitor.MailAccountStats{}, true
+	}
+	return stats, true
+}
+
+// resolveMailbox resolves ${mails}, ${new_mails} and the other local
+// mailbox variables. Syntax: ${new_mails [mailbox] [interval]}
+// The mailbox is a Maildir or mbox path and defaults to the mail spool
+// (current_mail_spool or $MAIL). The interval is accepted for compatibility;
+// mailboxes are reread when they change. When the mailbox cannot be read,
+// ${mails} and ${new_mails} fall back to the IMAP and POP3 accounts, with
+// the argument naming an account.
+func (api *ConkyAPI) resolveMailbox(field string, args []string) string {
+	var path string
 	if len(args) > 0 {
-		// First arg could be account name
-		return strconv.Itoa(api.sysProvider.MailUnseenCount(args[0]))
+		path = args[0]
 	}
-	return strconv.Itoa(api.sysProvider.MailTotalUnseen())
+
+	var stats monitor.MailboxStats
+	err := api.checkMailbox(path)
+	if err == nil {
+		stats, err = api.sysProvider.Mailbox(path)
+	}
+	if err != nil {
+		switch {
+		case field == "new" && path != "":
+			return strconv.Itoa(api.sysProvider.MailUnseenCount(path))
+		case field == "new":
+			return strconv.Itoa(api.sysProvider.MailTotalUnseen())
+		case field == "mails" && path != "":
+			return strconv.Itoa(api.sysProvider.MailTotalCount(path))
+		case field == "mails":
+			return strconv.Itoa(api.sysProvider.MailTotalMessages())
+		}
+		return "0"
+	}
+
+	switch field {
+	case "mails":
+		return strconv.Itoa(stats.Total)
+	case "new":
+		return strconv.Itoa(stats.New)
+	case "seen":
+		return strconv.Itoa(stats.Seen())
+	case "unseen":
+		return strconv.Itoa(stats.Unseen)
+	case "flagged":
+		return strconv.Itoa(stats.Flagged)
+	case "replied":
+		return strconv.Itoa(stats.Replied)
+	case "forwarded":
+		return strconv.Itoa(stats.Forwarded)
+	case "trashed":
+		return strconv.Itoa(stats.Trashed)
+	case "draft":
+		return strconv.Itoa(stats.Draft)
+	}
+	return "0"
+}
+
+// checkMailbox applies the policy to reading the mailbox at path.
+func (api *ConkyAPI) checkMailbox(path string) error {
+	resolved := api.sysProvider.MailboxPath(path)
+	if resolved == "" {
+		return nil
+	}
+	return api.runtime.CheckRead(resolved)
 }
 
 // resolveWeather resolves the ${weather} variable.
@@ -2514,7 +3189,7 @@ func (api *ConkyAPI) resolveWeather(args []string) string {
 	}
 
 	weather := api.sysProvider.Weather(stationID)
-	if weather.Error != "" && weather.RawMETAR == "" {
+	if weather.Error != "" && weather.LastUpdate.IsZero() {
 		return "N/A"
 	}
 
@@ -2525,6 +3200,108 @@ func (api *ConkyAPI) resolveWeather(args []string) string {
 	return result
 }
 
+// resolveWeatherForecast resolves the ${weather_forecast} variable.
+// Syntax: ${weather_forecast station day field}
+// Example: ${weather_forecast 52.52,13.42 1 max} returns tomorrow's maximum
+// temperature in Berlin. Day 0 is today. Forecasts require a forecast
+// provider such as open-meteo.
+// Supported fields: min, max, condition, precip, precip_chance, wind, date, day
+func (api *ConkyAPI) resolveWeatherForecast(args []string) string {
+	if len(args) < 3 {
+		return ""
+	}
+
+	day, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "N/A"
+	}
+
+	weather := api.sysProvider.Weather(args[0])
+	result := weather.GetForecastField(day, args[2])
+	if result == "" {
+		return "N/A"
+	}
+	return result
+}
+
+// defaultWebInterval is the refresh interval of ${curl} when none is given.
+const defaultWebInterval = 15 * time.Minute
+
+// parseWebInterval parses the refresh interval of ${curl} and ${rss}, which
+// is given in minutes.
+func parseWebInterval(arg string) time.Duration {
+	minutes, err := strconv.ParseFloat(arg, 64)
+	if err != nil || minutes <= 0 {
+		return defaultWebInterval
+	}
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+// resolveCurl resolves the ${curl} variable.
+// Syntax: ${curl url [interval_in_minutes]}
+// The body is shown without its trailing newlines, and nothing is shown
+// until the first successful fetch.
+func (api *ConkyAPI) resolveCurl(args []string) string {
+	if len(args) < 1 {
+		return ""
+	}
+
+	interval := defaultWebInterval
+	if len(args) >= 2 {
+		interval = parseWebInterval(args[1])
+	}
+
+	body, _ := api.sysProvider.Curl(args[0], interval)
+	return strings.TrimRight(body, "\r\n")
+}
+
+// resolveRSS resolves the ${rss} variable.
+// Syntax: ${rss url interval_in_minutes action [num [spaces_in_front]]}
+// Actions: feed_title, item_title num, item_desc num (items count from 0),
+// and item_titles num spaces_in_front, which lists the first num titles
+// (all when num is 0) one per line, indented by spaces_in_front spaces.
+func (api *ConkyAPI) resolveRSS(args []string) string {
+	if len(args) < 3 {
+		return ""
+	}
+
+	feed, _ := api.sysProvider.RSS(args[0], parseWebInterval(args[1]))
+	num := 0
+	if len(args) >= 4 {
+		num, _ = strconv.Atoi(args[3])
+	}
+
+	switch args[2] {
+	case "feed_title":
+		return feed.Title
+	case "item_title", "item_desc":
+		if num < 0 || num >= len(feed.Items) {
+			return ""
+		}
+		if args[2] == "item_title" {
+			return feed.Items[num].Title
+		}
+		return feed.Items[num].Description
+	case "item_titles":
+		items := feed.Items
+		if num > 0 && num < len(items) {
+			items = items[:num]
+		}
+		indent := ""
+		if len(args) >= 5 {
+			if spaces, err := strconv.Atoi(args[4]); err == nil && spaces > 0 {
+				indent = strings.Repeat(" ", spaces)
+			}
+		}
+		titles := make([]string, len(items))
+		for i, item := range items {
+			titles[i] = indent + item.Title
+		}
+		return strings.Join(titles, "\n")
+	}
+	return ""
+}
+
 // resolveLua calls a Lua function and returns its result.
 // Usage: ${lua function_name arg1 arg2 ...}
 // If parse is true (${lua_parse}), the result is parsed for Conky variables.
@@ -2573,6 +3350,9 @@ func (api *ConkyAPI) resolveImage(args []string) string {
 	}
 
 	path := args[0]
+	if api.runtime.CheckRead(path) != nil {
+		return ""
+	}
 	var width, height float64
 	x, y := float64(-1), float64(-1) // -1 means inline
 	noCache := false
@@ -2652,7 +3432,7 @@ func (api *ConkyAPI) SetCacheCleanupConfig(cfg CacheCleanupConfig) {
 	api.cleanupConfig = cfg
 }
 
-// CleanupCaches removes stale entries from both execCache and scrollStates.
+// CleanupCaches removes stale interval commands and scroll states.
 // An entry is considered stale if it hasn't been accessed within MaxAge.
 // Returns the number of entries removed from each cache.
 func (api *ConkyAPI) CleanupCaches() (execRemoved, scrollRemoved int) {
@@ -2662,13 +3442,8 @@ func (api *ConkyAPI) CleanupCaches() (execRemoved, scrollRemoved int) {
 	now := time.Now()
 	maxAge := api.cleanupConfig.MaxAge
 
-	// Cleanup execCache - remove entries that are expired AND haven't been accessed recently
-	for key, entry := range api.execCache {
-		if now.Sub(entry.lastAccessed) > maxAge {
-			delete(api.execCache, key)
-			execRemoved++
-		}
-	}
+	// Stop scheduling interval commands that are no longer displayed
+	execRemoved = api.exec.Cleanup(maxAge)
 
 	// Cleanup scrollStates - remove states that haven't been accessed recently
 	for key, state := range api.scrollStates {
@@ -2725,14 +3500,16 @@ func (api *ConkyAPI) StopCacheCleanup() {
 func (api *ConkyAPI) CacheStats() (execCount, scrollCount int) {
 	api.mu.RLock()
 	defer api.mu.RUnlock()
-	return len(api.execCache), len(api.scrollStates)
+	return api.exec.Len(), len(api.scrollStates)
 }
 
-// Close stops the cache cleanup goroutine and releases resources.
-// This method should be called when the ConkyAPI is no longer needed
-// to prevent goroutine leaks. It is safe to call Close multiple times.
+// Close stops the cache cleanup goroutine, kills running commands and
+// releases resources. This method should be called when the ConkyAPI is no
+// longer needed to prevent goroutine leaks. It is safe to call Close
+// multiple times.
 func (api *ConkyAPI) Close() error {
 	api.StopCacheCleanup()
+	api.exec.Stop()
 	return nil
 }
 
@@ -2756,3 +3533,66 @@ func (api *ConkyAPI) resolveMPDBar(args []string) string {
 	pct := api.sysProvider.MPD().Percent()
 	return render.EncodeBarMarker(pct, width, height)
 }
+
+// setAPCUPSDTarget handles ${apcupsd host port}, which selects the apcupsd
+// server queried by the ${apcupsd_*} variables that follow it. Without
+// arguments it selects localhost:3551. It produces no output.
+func (api *ConkyAPI) setAPCUPSDTarget(args []string) string {
+	host, port := "", 0
+	if len(args) > 0 {
+		host = args[0]
+	}
+	if len(args) > 1 {
+		port, _ = strconv.Atoi(args[1])
+	}
+
+	api.mu.Lock()
+	api.apcupsdHost, api.apcupsdPort = host, port
+	api.mu.Unlock()
+	return ""
+}
+
+// resolveAPCUPSD resolves ${apcupsd_<field>} from the selected apcupsd
+// server. Numeric fields are shown without their units. The monitor
+// queries the server in the background, so this only reads its last status
+// and shows "N/A" until the first query completes.
+func (api *ConkyAPI) resolveAPCUPSD(field string) string {
+	api.mu.RLock()
+	host, port := api.apcupsdHost, api.apcupsdPort
+	api.mu.RUnlock()
+
+	ups, err := api.sysProvider.APCUPSD(host, port)
+	if err != nil {
+		return "N/A"
+	}
+
+	switch field {
+	case "model":
+		return ups.Model
+	case "status":
+		return ups.Status
+	case "linev":
+		return fmt.Sprintf("%.1f", ups.LineV)
+	case "load":
+		return fmt.Sprintf("%.1f", ups.Load)
+	case "charge":
+		return fmt.Sprintf("%.1f", ups.Charge)
+	case "timeleft":
+		return fmt.Sprintf("%.1f", ups.TimeLeft)
+	case "temp":
+		return fmt.Sprintf("%.1f", ups.Temp)
+	case "battv":
+		return fmt.Sprintf("%.1f", ups.BattV)
+	case "cable":
+		return ups.Cable
+	case "driver":
+		return ups.Driver
+	case "upsmode":
+		return ups.UPSMode
+	case "name":
+		return ups.Name
+	case "hostname":
+		return ups.Hostname
+	}
+	return ""
+}
@@ -27,10 +27,21 @@ type mockSystemDataProvider struct {
 	audio      monitor.AudioStats
 	sysInfo    monitor.SystemInfo
 	tcp        monitor.TCPStats
+	sockets    monitor.SocketStats
+	owners     map[uint64]monitor.PIDInfo
 	gpu        monitor.GPUStats
+	gpus       []monitor.GPUStats
 	mail       monitor.MailStats
+	mailConfig []monitor.MailConfig
+	mailboxes  map[string]monitor.MailboxStats
+	pids       map[int]monitor.PIDInfo
 	weather    monitor.WeatherStats
 	mpd        monitor.MPDStats
+	ups        map[string]monitor.APCUPSDStats
+	web        map[string]string
+	webTimes   map[string]time.Duration
+	feeds      map[string]monitor.RSSFeed
+	history    map[string][]monitor.Sample
 }
 
 func (m *mockSystemDataProvider) CPU() monitor.CPUStats               { return m.cpu }
@@ -67,8 +78,14 @@ func (m *mockSystemDataProvider) TCPConnectionByIndex(minPort, maxPort, index in
 	}
 	return nil
 }
-func (m *mockSystemDataProvider) GPU() monitor.GPUStats   { return m.gpu }
-func (m *mockSystemDataProvider) Mail() monitor.MailStats { return m.mail }
+func (m *mockSystemDataProvider) SocketOwner(inode uint64) (int, string) {
+	owner := m.owners[inode]
+	return owner.PID, owner.Name
+}
+func (m *mockSystemDataProvider) Sockets() monitor.SocketStats { return m.sockets }
+func (m *mockSystemDataProvider) GPU() monitor.GPUStats        { return m.gpu }
+func (m *mockSystemDataProvider) GPUs() []monitor.GPUStats     { return m.gpus }
+func (m *mockSystemDataProvider) Mail() monitor.MailStats      { return m.mail }
 func (m *mockSystemDataProvider) MailUnseenCount(name string) int {
 	if m.mail.Accounts == nil {
 		return 0
@@ -100,6 +117,81 @@ func (m *mockSystemDataProvider) MailTotalUnseen() int {
 	return total
 }
 
+func (m *mockSystemDataProvider) MailAccount(cfg monitor.MailConfig) (monitor.MailAccountStats, error) {
+	m.mailConfig = append(m.mailConfig, cfg)
+	return m.mail.Accounts[cfg.Name], nil
+}
+
+func (m *mockSystemDataProvider) Mailbox(path string) (monitor.MailboxStats, error) {
+	stats, ok := m.mailboxes[path]
+	if !ok {
+		return monitor.MailboxStats{}, fmt.Errorf("no mailbox %q", path)
+	}
+	return stats, nil
+}
+
+func (m *mockSystemDataProvider) MailboxPath(path string) string {
+	if path == "" {
+		return "/var/mail/test"
+	}
+	return path
+}
+
+func (m *mockSystemDataProvider) PIDInfo(pid int) (monitor.PIDInfo, error) {
+	info, ok := m.pids[pid]
+	if !ok {
+		return monitor.PIDInfo{}, fmt.Errorf("no process %d", pid)
+	}
+	return info, nil
+}
+
+func (m *mockSystemDataProvider) Pidof(name string) (int, bool) {
+	for pid, info := range m.pids {
+		if info.Name == name {
+			return pid, true
+		}
+	}
+	return 0, false
+}
+
+// APCUPSD returns the UPS keyed by "host:port", applying the same defaults as
+// the monitor.
+func (m *mockSystemDataProvider) APCUPSD(host string, port int) (monitor.APCUPSDStats, error) {
+	if host == "" {
+		host = monitor.DefaultAPCUPSDHost
+	}
+	if port == 0 {
+		port = monitor.DefaultAPCUPSDPort
+	}
+	ups, ok := m.ups[fmt.Sprintf("%s:%d", host, port)]
+	if !ok {
+		return monitor.APCUPSDStats{}, fmt.Errorf("no apcupsd at %s:%d", host, port)
+	}
+	return ups, nil
+}
+
+// Curl returns the body keyed by url and records the requested interval in
+// webTimes.
+func (m *mockSystemDataProvider) Curl(url string, interval time.Duration) (string, error) {
+	if m.webTimes == nil {
+		m.webTimes = make(map[string]time.Duration)
+	}
+	m.webTimes[url] = interval
+	body, ok := m.web[url]
+	if !ok {
+		return "", fmt.Errorf("fetch %s failed", url)
+	}
+	return body, nil
+}
+
+func (m *mockSystemDataProvider) RSS(url string, interval time.Duration) (monitor.RSSFeed, error) {
+	feed, ok := m.feeds[url]
+	if !ok {
+		return monitor.RSSFeed{}, fmt.Errorf("fetch %s failed", url)
+	}
+	return feed, nil
+}
+
 func (m *mockSystemDataProvider) MailTotalMessages() int {
 	if m.mail.Accounts == nil {
 		return 0
@@ -119,6 +211,14 @@ func (m *mockSystemDataProvider) MPD() monitor.MPDStats {
 	return m.mpd
 }
 
+func (m *mockSystemDataProvider) History(id string, n int) []monitor.Sample {
+	samples := m.history[id]
+	if n > 0 && n < len(samples) {
+		samples = samples[len(samples)-n:]
+	}
+	return samples
+}
+
 func newMockProvider() *mockSystemDataProvider {
 	return &mockSystemDataProvider{
 		cpu: monitor.CPUStats{
@@ -253,6 +353,10 @@ func newMockProvider() *mockSystemDataProvider {
 				{PID: 1234, Name: "firefox", CPUPercent: 25.5, MemPercent: 10.2, MemBytes: 512 * 1024 * 1024, Threads: 50},
 				{PID: 5678, Name: "chrome", CPUPercent: 15.3, MemPercent: 8.5, MemBytes: 400 * 1024 * 1024, Threads: 30},
 			},
+			TopIO: []monitor.ProcessInfo{
+				{PID: 4321, Name: "rsync", IOReadBytes: 48 * 1024 * 1024, IOWriteBytes: 16 * 1024 * 1024, IOPercent: 80},
+				{PID: 1234, Name: "firefox", IOWriteBytes: 16 * 1024 * 1024, IOPercent: 20},
+			},
 		},
 		battery: monitor.BatteryStats{
 			Batteries: map[string]monitor.BatteryInfo{
@@ -287,6 +391,7 @@ func newMockProvider() *mockSystemDataProvider {
 					RemoteIP:   "10.0.0.1",
 					RemotePort: 52345,
 					State:      "ESTABLISHED",
+					Inode:      54321,
 				},
 				{
 					LocalIP:    "0.0.0.0",
@@ -306,6 +411,9 @@ func newMockProvider() *mockSystemDataProvider {
 			TotalCount:  3,
 			ListenCount: 1,
 		},
+		owners: map[uint64]monitor.PIDInfo{
+			54321: {PID: 812, Name: "sshd"},
+		},
 		gpu: monitor.GPUStats{
 			Name:        "NVIDIA GeForce RTX 3080",
 			DriverVer:   "535.154.05",
@@ -923,6 +1031,31 @@ func TestParseProcessVariables(t *testing.T) {
 			template: "${top_mem mem 1}",
 			expected: "12.0",
 		},
+		{
+			name:     "top_io name 1",
+			template: "${top_io name 1}",
+			expected: "rsync",
+		},
+		{
+			name:     "top_io io_read 1",
+			template: "${top_io io_read 1}",
+			expected: "48.0MiB",
+		},
+		{
+			name:     "top_io io_write 2",
+			template: "${top_io io_write 2}",
+			expected: "16.0MiB",
+		},
+		{
+			name:     "top_io io_perc 2",
+			template: "${top_io io_perc 2}",
+			expected: "20.0",
+		},
+		{
+			name:     "top_io out of range",
+			template: "${top_io name 3}",
+			expected: "",
+		},
 		{
 			name:     "top out of range",
 			template: "${top name 100}",
@@ -940,6 +1073,89 @@ func TestParseProcessVariables(t *testing.T) {
 	}
 }
 
+func TestParsePIDVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.pids = map[int]monitor.PIDInfo{
+		4242: {
+			PID:        4242,
+			Name:       "postgres",
+			Cmdline:    "postgres -D /var/lib/postgres/data",
+			Cwd:        "/var/lib/postgres/data",
+			Exe:        "/usr/bin/postgres",
+			Root:       "/",
+			Environ:    []string{"PGDATA=/var/lib/postgres/data", "LANG=C"},
+			OpenFiles:  []string{"/dev/null", "/var/log/postgres.log"},
+			Stdout:     "/var/log/postgres.log",
+			Parent:     1,
+			State:      "S",
+			StateName:  "sleeping",
+			Nice:       -5,
+			Priority:   20,
+			Threads:    []int{4242, 4250},
+			UserTime:   2.5,
+			SystemTime: 0.5,
+			UIDs:       [4]int{999, 998, 997, 996},
+			GIDs:       [4]int{990, 991, 992, 993},
+			Memory:     map[string]uint64{"rss": 20 * 1024 * 1024, "peak": 512 * 1024},
+			ReadBytes:  4096,
+			WriteBytes: 3 * 1024 * 1024,
+		},
+	}
+
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${pidof postgres}", "4242"},
+		{"${pidof dockerd}", ""},
+		{"${pid_cmdline 4242}", "postgres -D /var/lib/postgres/data"},
+		{"${pid_cwd 4242}", "/var/lib/postgres/data"},
+		{"${pid_exe postgres}", "/usr/bin/postgres"},
+		{"${pid_chroot 4242}", "/"},
+		{"${pid_environ 4242 PGDATA}", "/var/lib/postgres/data"},
+		{"${pid_environ 4242 HOME}", ""},
+		{"${pid_environ_list 4242}", "PGDATA=/var/lib/postgres/data, LANG=C"},
+		{"${pid_openfiles 4242}", "/dev/null; /var/log/postgres.log"},
+		{"${pid_stdout 4242}", "/var/log/postgres.log"},
+		{"${pid_parent 4242}", "1"},
+		{"${pid_state 4242}", "sleeping"},
+		{"${pid_state_short 4242}", "S"},
+		{"${pid_nice 4242}", "-5"},
+		{"${pid_priority 4242}", "20"},
+		{"${pid_threads 4242}", "2"},
+		{"${pid_thread_list 4242}", "4242,4250"},
+		{"${pid_time 4242}", "3.00"},
+		{"${pid_time_usermode 4242}", "2.50"},
+		{"${pid_uid 4242}", "999"},
+		{"${pid_euid 4242}", "998"},
+		{"${pid_fsgid 4242}", "993"},
+		{"${pid_vmrss 4242}", "20.0MiB"},
+		{"${pid_vmpeak 4242}", "512.0KiB"},
+		{"${pid_vmlck 4242}", ""},
+		{"${pid_read 4242}", "4.0KiB"},
+		{"${pid_write postgres}", "3.0MiB"},
+		{"${pid_cmdline 1}", ""},
+		{"${pid_cmdline dockerd}", ""},
+		{"${pid_cmdline}", ""},
+	}
+	for _, tt := range tests {
+		if result := api.Parse(tt.template); result != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+	}
+}
+
 func TestParseBatteryVariables(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -1138,6 +1354,31 @@ func TestParseHwmonVariables(t *testing.T) {
 	defer runtime.Close()
 
 	provider := newMockProvider()
+	provider.hwmon.Devices = map[string]monitor.HwmonDevice{
+		"coretemp": {
+			Name: "coretemp",
+			Path: "/sys/class/hwmon/hwmon1",
+			Temps: map[string]monitor.TempSensor{
+				"temp1": {Label: "Package id 0", Type: "temp1", InputCelsius: 48, MaxCelsius: 80, CritCelsius: 100},
+			},
+		},
+		"nct6775": {
+			Name: "nct6775",
+			Path: "/sys/class/hwmon/hwmon2",
+			Fans: map[string]monitor.HwmonSensor{
+				"fan1": {Label: "CPU Fan", Type: "fan1", Input: 1234, Min: 300},
+			},
+			Voltages: map[string]monitor.HwmonSensor{
+				"in0": {Label: "Vcore", Type: "in0", Input: 1.104},
+			},
+			Power: map[string]monitor.HwmonSensor{
+				"power1": {Label: "power1", Type: "power1", Input: 35.5, Max: 65},
+			},
+			Currents: map[string]monitor.HwmonSensor{
+				"curr1": {Label: "curr1", Type: "curr1", Input: 2.5, Crit: 10},
+			},
+		},
+	}
 	api, err := NewConkyAPI(runtime, provider)
 	if err != nil {
 		t.Fatalf("failed to create API: %v", err)
@@ -1163,6 +1404,24 @@ func TestParseHwmonVariables(t *testing.T) {
 			template: "${hwmon 1}",
 			expected: "65",
 		},
+		{name: "device number", template: "${hwmon 1 temp 1}", expected: "48"},
+		{name: "device name", template: "${hwmon coretemp temp 1}", expected: "48"},
+		{name: "quoted label", template: `${hwmon coretemp temp "Package id 0"}`, expected: "48"},
+		{name: "label crit", template: `${hwmon coretemp temp "Package id 0" crit}`, expected: "100"},
+		{name: "max", template: "${hwmon coretemp temp 1 max}", expected: "80"},
+		{name: "fahrenheit", template: "${hwmon coretemp tempf 1}", expected: "118"},
+		{name: "fan", template: "${hwmon hwmon2 fan 1}", expected: "1234"},
+		{name: "fan label", template: `${hwmon nct6775 fan "CPU Fan" label}`, expected: "CPU Fan"},
+		{name: "fan min", template: "${hwmon nct6775 fan 1 min}", expected: "300"},
+		{name: "voltage", template: "${hwmon nct6775 in 0}", expected: "1.10"},
+		{name: "voltage by label", template: "${hwmon nct6775 vol Vcore}", expected: "1.10"},
+		{name: "power", template: "${hwmon nct6775 power 1}", expected: "35.5"},
+		{name: "power cap", template: "${hwmon nct6775 power 1 max}", expected: "65.0"},
+		{name: "current crit", template: "${hwmon nct6775 curr 1 crit}", expected: "10.00"},
+		{name: "factor and offset", template: "${hwmon nct6775 in 0 10 1}", expected: "12.04"},
+		{name: "without device", template: "${hwmon fan 1}", expected: "1234"},
+		{name: "missing sensor", template: "${hwmon nct6775 fan 9}", expected: "0"},
+		{name: "missing device", template: "${hwmon it87 temp 1}", expected: "0"},
 	}
 
 	for _, tt := range tests {
@@ -1195,6 +1454,81 @@ func TestParseMixerVariable(t *testing.T) {
 	}
 }
 
+func TestParsePulseVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.audio = monitor.AudioStats{
+		HasAudio:     true,
+		MasterVolume: 60,
+		Backend:      monitor.AudioBackendPulse,
+		Pulse: monitor.PulseStats{
+			DefaultSink: monitor.PulseDevice{
+				Name:                  "alsa_output.analog-stereo",
+				Description:           "Built-in Audio Analog Stereo",
+				Volume:                60,
+				ActivePort:            "analog-output-headphones",
+				ActivePortDescription: "Headphones",
+				Properties:            map[string]string{"alsa.card_name": "HDA Intel PCH"},
+			},
+			DefaultSource: monitor.PulseDevice{
+				Name:        "bluez_input.headset",
+				Description: "Headset Microphone",
+				Volume:      45.4,
+				Muted:       true,
+				State:       "running",
+			},
+			Streams: []monitor.PulseStream{
+				{Application: "Firefox", Volume: 80},
+				{Application: "Google Chrome", Volume: 35},
+			},
+		},
+	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${pa_sink_volume}", "60"},
+		{"${pa_sink_name}", "alsa_output.analog-stereo"},
+		{"${pa_sink_description}", "Built-in Audio Analog Stereo"},
+		{"${pa_sink_active_port_name}", "analog-output-headphones"},
+		{"${pa_sink_active_port_description}", "Headphones"},
+		{"${pa_source_volume}", "45"},
+		{"${pa_source_name}", "bluez_input.headset"},
+		{"${pa_source_description}", "Headset Microphone"},
+		{"${pa_card_name}", "HDA Intel PCH"},
+		{"${pa_stream_volume firefox}", "80"},
+		{"${pa_stream_volume Google Chrome}", "35"},
+		{"${pa_stream_volume mpv}", "0"},
+		{"${mixer}", "60"},
+		{"${if_pa_sink_muted}muted${else}unmuted${endif}", "unmuted"},
+		{"${if_pa_source_muted}mic off${endif}", "mic off"},
+		{"${if_pa_source_running}recording${endif}", "recording"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if result := api.Parse(tt.template); result != tt.expected {
+				t.Errorf("Parse(%q) = %q, want %q", tt.template, result, tt.expected)
+			}
+		})
+	}
+
+	// Without a sound server the variables are empty
+	provider.audio = monitor.AudioStats{HasAudio: true, MasterVolume: 75, Backend: monitor.AudioBackendALSA}
+	if result := api.Parse("[${pa_sink_description}] ${pa_sink_volume}"); result != "[] 0" {
+		t.Errorf("Parse() without PulseAudio = %q, want %q", result, "[] 0")
+	}
+}
+
 func TestParseSystemInfoVariables(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -1344,6 +1678,29 @@ func TestParseTimeVariable(t *testing.T) {
 	}
 }
 
+func TestParseUpdatesVariable(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	api, err := NewConkyAPI(runtime, newMockProvider())
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	if result := api.Parse("${updates}"); result != "0" {
+		t.Errorf("expected 0 updates, got %q", result)
+	}
+	for i := 0; i < 6; i++ {
+		api.IncrementUpdates()
+	}
+	if result := api.Parse("${updates}"); result != "6" {
+		t.Errorf("expected 6 updates, got %q", result)
+	}
+}
+
 func TestStrftimeSpecifiers(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -1718,6 +2075,49 @@ func TestParseUptimeShortFormats(t *testing.T) {
 	}
 }
 
+func TestParseLayoutVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	api, err := NewConkyAPI(runtime, newMockProvider())
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected *render.LayoutMarker
+	}{
+		{"${color}", &render.LayoutMarker{Type: render.LayoutTypeColor}},
+		{"${color #ff8800}", &render.LayoutMarker{Type: render.LayoutTypeColor, Arg: "#ff8800"}},
+		{"${color7}", &render.LayoutMarker{Type: render.LayoutTypeColorIndex, Value: 7}},
+		{"${font}", &render.LayoutMarker{Type: render.LayoutTypeFont}},
+		{"${font Ubuntu:bold:size=12}", &render.LayoutMarker{Type: render.LayoutTypeFont, Arg: "Ubuntu:bold:size=12"}},
+		{"${alignr}", &render.LayoutMarker{Type: render.LayoutTypeAlignR}},
+		{"${alignc}", &render.LayoutMarker{Type: render.LayoutTypeAlignC}},
+		{"${offset 15}", &render.LayoutMarker{Type: render.LayoutTypeOffset, Value: 15}},
+		{"${voffset -4}", &render.LayoutMarker{Type: render.LayoutTypeVOffset, Value: -4}},
+		{"${goto 120}", &render.LayoutMarker{Type: render.LayoutTypeGoto, Value: 120}},
+		{"${goto}", &render.LayoutMarker{Type: render.LayoutTypeGoto}},
+		{"${tab 40}", &render.LayoutMarker{Type: render.LayoutTypeTab, Value: 40}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got := render.DecodeLayoutMarker(api.Parse(tt.template))
+			if got == nil {
+				t.Fatalf("Parse(%q) did not produce a layout marker", tt.template)
+			}
+			if *got != *tt.expected {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.template, *got, *tt.expected)
+			}
+		})
+	}
+}
+
 func TestParseMiscVariables(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -1759,17 +2159,17 @@ func TestParseMiscVariables(t *testing.T) {
 		{
 			name:     "tab",
 			template: "${tab}",
-			expected: "\t",
+			expected: render.EncodeTabMarker(0),
 		},
 		{
-			name:     "color returns empty",
+			name:     "color emits layout marker",
 			template: "${color red}",
-			expected: "",
+			expected: render.EncodeColorMarker("red"),
 		},
 		{
-			name:     "font returns empty",
-			template: "${font Monospace}",
-			expected: "",
+			name:     "font emits layout marker",
+			template: "${font DejaVu Sans:size=10}",
+			expected: render.EncodeFontMarker("DejaVu Sans:size=10"),
 		},
 		{
 			name:     "if_up existing",
@@ -1841,53 +2241,49 @@ func TestExeciVariable(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create API: %v", err)
 	}
+	defer api.Close()
 
-	// Test basic execi command with interval
-	result := api.Parse("${execi 60 echo cached}")
-	if result != "cached" {
-		t.Errorf("expected 'cached', got %q", result)
-	}
-
-	// Test that result is cached - second call should return cached value
-	result2 := api.Parse("${execi 60 echo cached}")
-	if result2 != "cached" {
-		t.Errorf("expected cached 'cached', got %q", result2)
-	}
-
-	// Test with missing interval (should return empty)
-	result = api.Parse("${execi echo only}")
-	if result != "" {
-		t.Errorf("expected empty for missing interval, got %q", result)
-	}
-
-	// Test with interval 0 (always re-execute)
-	result = api.Parse("${execi 0 echo fresh}")
-	if result != "fresh" {
-		t.Errorf("expected 'fresh', got %q", result)
-	}
-
-	// Test with multi-word command
-	result = api.Parse("${execi 30 echo hello world}")
-	if result != "hello world" {
-		t.Errorf("expected 'hello world', got %q", result)
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${execi 60 echo cached}", "cached"},
+		{"${execi 0 echo fresh}", "fresh"},
+		{"${execi 30 echo hello world}", "hello world"},
+		{"${execi 0.5 echo fraction}", "fraction"},
+		{"${execpi 60 echo parsed}", "parsed"},
+		{"${texeci 60 echo threaded}", "threaded"},
 	}
-
-	// Test execpi (same as execi, parsing handled elsewhere)
-	result = api.Parse("${execpi 60 echo parsed}")
-	if result != "parsed" {
-		t.Errorf("expected 'parsed', got %q", result)
+	for _, tt := range tests {
+		// The command runs in the background: the output is empty until
+		// the first run completes
+		if got := waitForParse(api, tt.template, tt.expected); got != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.template, got, tt.expected)
+		}
+		// The output is cached until the interval elapses
+		if got := api.Parse(tt.template); got != tt.expected {
+			t.Errorf("cached Parse(%q) = %q, want %q", tt.template, got, tt.expected)
+		}
 	}
 
-	// Test invalid interval
-	result = api.Parse("${execi abc echo test}")
-	if result != "" {
-		t.Errorf("expected empty for invalid interval, got %q", result)
+	// Missing, invalid and negative intervals yield nothing
+	for _, template := range []string{"${execi echo only}", "${execi abc echo test}", "${execi -5 echo test}"} {
+		if got := api.Parse(template); got != "" {
+			t.Errorf("Parse(%q) = %q, want empty", template, got)
+		}
 	}
+}
 
-	// Test negative interval
-	result = api.Parse("${execi -5 echo test}")
-	if result != "" {
-		t.Errorf("expected empty for negative interval, got %q", result)
+// waitForParse parses template until it yields want or a second passes, and
+// returns the last result.
+func waitForParse(api *ConkyAPI, template, want string) string {
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := api.Parse(template)
+		if got == want || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
 	}
 }
 
@@ -2024,9 +2420,9 @@ func TestGraphWidgetHistoricalID(t *testing.T) {
 		variable string
 		wantID   string
 	}{
-		{"cpugraph has cpu ID", "${cpugraph}", "cpu"},
-		{"memgraph has mem ID", "${memgraph}", "mem"},
-		{"loadgraph has load ID", "${loadgraph}", "load"},
+		{"cpugraph has cpu ID", "${cpugraph}", "cpu.total"},
+		{"memgraph has mem ID", "${memgraph}", "mem.used_perc"},
+		{"loadgraph has load ID", "${loadgraph}", "load.1"},
 	}
 
 	for _, tt := range tests {
@@ -2299,6 +2695,21 @@ func TestParseTCPPortMonVariables(t *testing.T) {
 			template: "${tcp_portmon 1 1024 rport 0}",
 			expected: "52345",
 		},
+		{
+			name:     "tcp portmon process name",
+			template: "${tcp_portmon 1 1024 pname 0}",
+			expected: "sshd",
+		},
+		{
+			name:     "tcp portmon pid",
+			template: "${tcp_portmon 1 1024 pid 0}",
+			expected: "812",
+		},
+		{
+			name:     "tcp portmon unknown owner",
+			template: "[${tcp_portmon 1 1024 pname 1}${tcp_portmon 1 1024 pid 1}]",
+			expected: "[]",
+		},
 		{
 			name:     "tcp portmon insufficient args",
 			template: "${tcp_portmon 1}",
@@ -2321,6 +2732,58 @@ func TestParseTCPPortMonVariables(t *testing.T) {
 	}
 }
 
+func TestParseSocketVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.sockets = monitor.SocketStats{Sockets: []monitor.Socket{
+		{Protocol: monitor.SocketTCP, LocalPort: 8080, State: "LISTEN", Process: "node"},
+		{Protocol: monitor.SocketTCP, LocalPort: 22, State: "LISTEN"},
+		{Protocol: monitor.SocketTCP, IPv6: true, LocalPort: 22, State: "LISTEN", Process: "sshd"},
+		{Protocol: monitor.SocketTCP, LocalPort: 22, RemotePort: 52345, State: "ESTABLISHED", Process: "sshd"},
+		{Protocol: monitor.SocketTCP, LocalPort: 41000, RemotePort: 443, State: "ESTABLISHED"},
+		{Protocol: monitor.SocketUDP, LocalPort: 68, State: "UNCONN", Process: "dhclient"},
+		{Protocol: monitor.SocketUDP, LocalPort: 5353, State: "UNCONN"},
+		{Protocol: monitor.SocketUnix, Path: "/run/dbus/system_bus_socket", State: "LISTEN"},
+		{Protocol: monitor.SocketUnix, State: "ESTABLISHED"},
+	}}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"listening tcp ports", "${listening_ports}", "22(sshd) 8080(node)"},
+		{"listening udp ports", "${listening_ports udp}", "68(dhclient) 5353"},
+		{"listening ports all", "${listening_ports all}", "22(sshd) 68(dhclient) 5353 8080(node)"},
+		{"listening ports unknown protocol", "[${listening_ports sctp}]", "[]"},
+		{"socket count all", "${socket_count}", "9"},
+		{"socket count tcp", "${socket_count tcp}", "5"},
+		{"socket count tcp established", "${socket_count tcp established}", "2"},
+		{"socket count listening", "${socket_count all listen}", "4"},
+		{"socket count udp unconnected", "${socket_count udp unconn}", "2"},
+		{"socket count unix", "${socket_count unix}", "2"},
+		{"socket count unknown protocol", "${socket_count sctp}", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := api.Parse(tt.template)
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
 // TestParseNvidiaVariables tests NVIDIA GPU variable parsing.
 // NOTE: NVIDIA GPU support is currently a stub implementation.
 // This test is skipped because resolveNvidiaVariable always returns "".
@@ -2440,6 +2903,52 @@ func TestParseNvidiaVariables(t *testing.T) {
 	}
 }
 
+func TestParseGPUVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.gpus = []monitor.GPUStats{
+		{Name: "Radeon RX 6800", Vendor: monitor.GPUVendorAMD, UtilGPU: 37, Temperature: 61,
+			MemUsed: 4 << 30, MemTotal: 16 << 30, PowerDraw: 152, FanSpeed: 40, FreqMHz: 1815, Available: true},
+		{Name: "Intel Graphics", Vendor: monitor.GPUVendorIntel, Temperature: 48, FreqMHz: 950, Available: true},
+	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${gpu_count}", "2"},
+		{"${gpu_util}", "37"},
+		{"${gpu_util 0}", "37"},
+		{"${gpu_temp 1}", "48"},
+		{"${gpu_name 1}", "Intel Graphics"},
+		{"${gpu_vendor}", "amd"},
+		{"${gpu_vram_perc}", "25"},
+		{"${gpu_vram_perc 1}", "0"},
+		{"${gpu_vram_used}", "4.0GiB"},
+		{"${gpu_power}", "152.0"},
+		{"${gpu_fan}", "40"},
+		{"${gpu_freq 1}", "950"},
+		{"${gpu_util 2}", "N/A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if result := api.Parse(tt.template); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestParseMailVariables(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -2529,7 +3038,7 @@ func TestParseMailVariables(t *testing.T) {
 		{
 			name:     "mails total",
 			template: "${mails}",
-			expected: "8",
+			expected: "150",
 		},
 	}
 
@@ -2590,6 +3099,119 @@ func TestParseMailVariablesNoAccounts(t *testing.T) {
 	}
 }
 
+func TestParseMailServerVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	t.Setenv("TEST_MAIL_PASSWORD", "hunter2")
+	provider := newMockProvider()
+	provider.mail = monitor.MailStats{
+		Accounts: map[string]monitor.MailAccountStats{
+			"imap":                           {Name: "imap", Type: "imap", Unseen: 2, Total: 20},
+			"pop3":                           {Name: "pop3", Type: "pop3", Unseen: 6, Total: 6},
+			"imap:bob@mail.example.com/Work": {Type: "imap", Unseen: 4, Total: 40},
+		},
+	}
+
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${imap_unseen}", "2"},
+		{"${imap_messages}", "20"},
+		{"${pop3_used}", "6"},
+		{"${imap_unseen mail.example.com bob env:TEST_MAIL_PASSWORD -f Work -s}", "4"},
+		{"${imap_messages mail.example.com bob env:TEST_MAIL_PASSWORD -f Work -s}", "40"},
+		{"${imap_unseen mail.example.com bob *}", "0"},
+	}
+	for _, tt := range tests {
+		if result := api.Parse(tt.template); result != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+	}
+
+	if len(provider.mailConfig) == 0 {
+		t.Fatal("MailAccount() was not called")
+	}
+	got := provider.mailConfig[0]
+	want := monitor.MailConfig{
+		Name:     "imap:bob@mail.example.com/Work",
+		Type:     "imap",
+		Host:     "mail.example.com",
+		Username: "bob",
+		Password: "hunter2",
+		UseTLS:   true,
+		Folder:   "Work",
+	}
+	if got != want {
+		t.Errorf("MailAccount() config = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMailboxVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.mailboxes = map[string]monitor.MailboxStats{
+		"": {
+			Format: monitor.MailboxMbox, Total: 12, New: 2, Unseen: 3,
+			Flagged: 1, Replied: 4, Trashed: 1, Draft: 1,
+		},
+		"~/Maildir": {
+			Format: monitor.MailboxMaildir, Total: 30, New: 5, Unseen: 6,
+			Flagged: 2, Replied: 7, Forwarded: 3,
+		},
+	}
+	provider.mail = monitor.MailStats{
+		Accounts: map[string]monitor.MailAccountStats{
+			"work": {Name: "work", Type: "imap", Unseen: 9, Total: 90},
+		},
+	}
+
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${mails}", "12"},
+		{"${new_mails}", "2"},
+		{"${seen_mails}", "9"},
+		{"${unseen_mails}", "3"},
+		{"${flagged_mails}", "1"},
+		{"${replied_mails}", "4"},
+		{"${trashed_mails}", "1"},
+		{"${draft_mails}", "1"},
+		{"${mails ~/Maildir}", "30"},
+		{"${new_mails ~/Maildir 30}", "5"},
+		{"${forwarded_mails ~/Maildir}", "3"},
+		// Unreadable mailboxes fall back to the mail accounts
+		{"${new_mails work}", "9"},
+		{"${mails work}", "90"},
+		{"${unseen_mails /missing}", "0"},
+	}
+	for _, tt := range tests {
+		if result := api.Parse(tt.template); result != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+	}
+}
+
 func TestParseWeatherVariables(t *testing.T) {
 	runtime, err := New(DefaultConfig())
 	if err != nil {
@@ -2680,6 +3302,70 @@ func TestParseWeatherVariables(t *testing.T) {
 	}
 }
 
+func TestParseWeatherForecastVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.weather = monitor.WeatherStats{
+		StationID:   "52.52,13.42",
+		Temperature: 20,
+		WindSpeed:   10,
+		Condition:   "partly cloudy",
+		Units:       monitor.WeatherImperial,
+		LastUpdate:  time.Now(),
+		Forecast: []monitor.WeatherForecast{
+			{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TempMin: 0, TempMax: 10, Condition: "light rain", PrecipitationChance: 90},
+			{Date: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), TempMin: -5, TempMax: 25, Condition: "clear", Precipitation: 25.4},
+		},
+	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${weather 52.52,13.42 temp}", "68"},
+		{"${weather 52.52,13.42 wind}", "12"},
+		{"${weather 52.52,13.42}", "partly cloudy"},
+		{"${weather_forecast 52.52,13.42 0 max}", "50"},
+		{"${weather_forecast 52.52,13.42 0 precip_chance}", "90"},
+		{"${weather_forecast 52.52,13.42 1 min}", "23"},
+		{"${weather_forecast 52.52,13.42 1 condition}", "clear"},
+		{"${weather_forecast 52.52,13.42 1 precip}", "1.00"},
+		{"${weather_forecast 52.52,13.42 1 day}", "Sat"},
+		{"${weather_forecast 52.52,13.42 1 date}", "2024-03-02"},
+		{"${weather_forecast 52.52,13.42 7 max}", "N/A"},
+		{"${weather_forecast 52.52,13.42 tomorrow max}", "N/A"},
+		{"${weather_forecast 52.52,13.42 0 sunrise}", "N/A"},
+		{"[${weather_forecast 52.52,13.42 0}]", "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if result := api.Parse(tt.template); result != tt.expected {
+				t.Errorf("Parse(%q) = %q, want %q", tt.template, result, tt.expected)
+			}
+		})
+	}
+
+	// Stale data is shown when a refresh fails
+	provider.weather.Error = "HTTP 503"
+	if result := api.Parse("${weather 52.52,13.42 temp}"); result != "68" {
+		t.Errorf("Parse() with stale data = %q, want %q", result, "68")
+	}
+	provider.weather = monitor.WeatherStats{Error: "HTTP 503"}
+	if result := api.Parse("${weather 52.52,13.42 temp}"); result != "N/A" {
+		t.Errorf("Parse() without data = %q, want %q", result, "N/A")
+	}
+}
+
 // TestScrollAnimation tests the scroll variable animation.
 func TestScrollAnimation(t *testing.T) {
 	runtime, err := New(DefaultConfig())
@@ -3125,78 +3811,126 @@ func TestUnsupportedVariables(t *testing.T) {
 			template: "${stockquote AAPL}",
 			expected: "N/A",
 		},
-		// APCUPSD - not implemented; requires APCUPSD daemon and NIS protocol
-		{
-			name:     "apcupsd returns N/A",
-			template: "${apcupsd}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_model returns N/A",
-			template: "${apcupsd_model}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_status returns N/A",
-			template: "${apcupsd_status}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_linev returns N/A",
-			template: "${apcupsd_linev}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_load returns N/A",
-			template: "${apcupsd_load}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_charge returns N/A",
-			template: "${apcupsd_charge}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_timeleft returns N/A",
-			template: "${apcupsd_timeleft}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_temp returns N/A",
-			template: "${apcupsd_temp}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_battv returns N/A",
-			template: "${apcupsd_battv}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_cable returns N/A",
-			template: "${apcupsd_cable}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_driver returns N/A",
-			template: "${apcupsd_driver}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_upsmode returns N/A",
-			template: "${apcupsd_upsmode}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_name returns N/A",
-			template: "${apcupsd_name}",
-			expected: "N/A",
-		},
-		{
-			name:     "apcupsd_hostname returns N/A",
-			template: "${apcupsd_hostname}",
-			expected: "N/A",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := api.Parse(tt.template)
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseAPCUPSDVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.ups = map[string]monitor.APCUPSDStats{
+		"localhost:3551": {
+			Model:    "Back-UPS RS 900G",
+			Status:   "ONLINE",
+			LineV:    230,
+			Load:     12,
+			Charge:   100,
+			TimeLeft: 45.2,
+			Temp:     29.2,
+			BattV:    27.3,
+			Cable:    "USB Cable",
+			Driver:   "USB UPS Driver",
+			UPSMode:  "Stand Alone",
+			Name:     "desk-ups",
+			Hostname: "desktop",
+		},
+		"nas:3552": {Name: "rack-ups", Status: "ONBATT", Charge: 64.5},
+	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"model", "${apcupsd_model}", "Back-UPS RS 900G"},
+		{"status", "${apcupsd_status}", "ONLINE"},
+		{"linev", "${apcupsd_linev}", "230.0"},
+		{"load", "${apcupsd_load}", "12.0"},
+		{"charge", "${apcupsd_charge}", "100.0"},
+		{"timeleft", "${apcupsd_timeleft}", "45.2"},
+		{"temp", "${apcupsd_temp}", "29.2"},
+		{"battv", "${apcupsd_battv}", "27.3"},
+		{"cable", "${apcupsd_cable}", "USB Cable"},
+		{"driver", "${apcupsd_driver}", "USB UPS Driver"},
+		{"upsmode", "${apcupsd_upsmode}", "Stand Alone"},
+		{"name", "${apcupsd_name}", "desk-ups"},
+		{"hostname", "${apcupsd_hostname}", "desktop"},
+		{"target selects server", "${apcupsd nas 3552}${apcupsd_name} ${apcupsd_status} ${apcupsd_charge}", "rack-ups ONBATT 64.5"},
+		{"unreachable server", "${apcupsd ups.example.com}${apcupsd_status}", "N/A"},
+		{"default target", "${apcupsd}${apcupsd_name}", "desk-ups"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := api.Parse(tt.template)
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseWebVariables(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	provider := newMockProvider()
+	provider.web = map[string]string{
+		"https://status.example.com/health": "All systems operational\n",
+	}
+	provider.feeds = map[string]monitor.RSSFeed{
+		"https://example.com/feed.xml": {
+			Title: "Release Notes",
+			Items: []monitor.RSSItem{
+				{Title: "v2.0.0", Description: "Major release"},
+				{Title: "v1.9.1", Description: "Bug fixes"},
+				{Title: "v1.9.0", Description: "New widgets"},
+			},
 		},
 	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"curl", "${curl https://status.example.com/health 5}", "All systems operational"},
+		{"curl failure", "[${curl https://down.example.com/}]", "[]"},
+		{"curl without url", "[${curl}]", "[]"},
+		{"feed title", "${rss https://example.com/feed.xml 30 feed_title}", "Release Notes"},
+		{"item title", "${rss https://example.com/feed.xml 30 item_title 1}", "v1.9.1"},
+		{"item title default", "${rss https://example.com/feed.xml 30 item_title}", "v2.0.0"},
+		{"item desc", "${rss https://example.com/feed.xml 30 item_desc 2}", "New widgets"},
+		{"item out of range", "[${rss https://example.com/feed.xml 30 item_title 3}]", "[]"},
+		{"item titles", "${rss https://example.com/feed.xml 30 item_titles 2 2}", "  v2.0.0\n  v1.9.1"},
+		{"all item titles", "${rss https://example.com/feed.xml 30 item_titles 0}", "v2.0.0\nv1.9.1\nv1.9.0"},
+		{"unknown action", "[${rss https://example.com/feed.xml 30 item_link 0}]", "[]"},
+		{"rss failure", "[${rss https://down.example.com/ 30 feed_title}]", "[]"},
+	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -3206,6 +3940,16 @@ func TestUnsupportedVariables(t *testing.T) {
 			}
 		})
 	}
+
+	// Intervals are given in minutes
+	api.Parse("${curl https://status.example.com/health 0.5}")
+	if got := provider.webTimes["https://status.example.com/health"]; got != 30*time.Second {
+		t.Errorf("curl interval = %v, want 30s", got)
+	}
+	api.Parse("${curl https://status.example.com/health}")
+	if got := provider.webTimes["https://status.example.com/health"]; got != 15*time.Minute {
+		t.Errorf("default curl interval = %v, want 15m", got)
+	}
 }
 
 func TestParseImageVariables(t *testing.T) {
@@ -3572,6 +4316,15 @@ func TestResolveACPIFan(t *testing.T) {
 			},
 			want: "unknown",
 		},
+		{
+			name: "spinning fan sensor",
+			hwmon: monitor.HwmonStats{
+				Devices: map[string]monitor.HwmonDevice{
+					"nct6775": {Name: "nct6775", Fans: map[string]monitor.HwmonSensor{"fan1": {Input: 1200}}},
+				},
+			},
+			want: "running",
+		},
 		{
 			name:  "empty devices",
 			hwmon: monitor.HwmonStats{},
@@ -3719,14 +4472,14 @@ func TestCacheCleanupRemovesStaleEntries(t *testing.T) {
 	// Add some entries directly to the caches with old lastAccessed times
 	api.mu.Lock()
 	oldTime := time.Now().Add(-1 * time.Hour)
-	api.execCache["old_cmd"] = &execCacheEntry{
+	api.exec.jobs[execKey{command: "old_cmd"}] = &execJob{
 		output:       "old output",
-		expiresAt:    time.Now().Add(1 * time.Hour),
+		nextRun:      time.Now().Add(1 * time.Hour),
 		lastAccessed: oldTime,
 	}
-	api.execCache["recent_cmd"] = &execCacheEntry{
+	api.exec.jobs[execKey{command: "recent_cmd"}] = &execJob{
 		output:       "recent output",
-		expiresAt:    time.Now().Add(1 * time.Hour),
+		nextRun:      time.Now().Add(1 * time.Hour),
 		lastAccessed: time.Now(),
 	}
 	api.scrollStates["old_scroll"] = &scrollState{
@@ -3796,9 +4549,9 @@ func TestCacheCleanupBackground(t *testing.T) {
 	// Add an old entry
 	api.mu.Lock()
 	oldTime := time.Now().Add(-1 * time.Hour)
-	api.execCache["stale_entry"] = &execCacheEntry{
+	api.exec.jobs[execKey{command: "stale_entry"}] = &execJob{
 		output:       "stale",
-		expiresAt:    time.Now().Add(1 * time.Hour),
+		nextRun:      time.Now().Add(1 * time.Hour),
 		lastAccessed: oldTime,
 	}
 	api.mu.Unlock()
@@ -3845,8 +4598,8 @@ func TestCacheStatsReturnsCorrectCounts(t *testing.T) {
 	// Add entries
 	now := time.Now()
 	api.mu.Lock()
-	api.execCache["cmd1"] = &execCacheEntry{output: "1", expiresAt: now, lastAccessed: now}
-	api.execCache["cmd2"] = &execCacheEntry{output: "2", expiresAt: now, lastAccessed: now}
+	api.exec.jobs[execKey{command: "cmd1"}] = &execJob{output: "1", nextRun: now, lastAccessed: now}
+	api.exec.jobs[execKey{command: "cmd2"}] = &execJob{output: "2", nextRun: now, lastAccessed: now}
 	api.scrollStates["s1"] = &scrollState{position: 0, lastUpdate: now, lastAccessed: now}
 	api.mu.Unlock()
 
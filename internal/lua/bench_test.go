@@ -0,0 +1,63 @@
+package lua
+
+import (
+	"testing"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+// benchmarkText resembles a typical conky.text.
+const benchmarkText = "${color grey}Uptime:$color ${uptime}\n" +
+	"${color grey}CPU Usage:$color ${cpu}% ${cpubar 4}\n" +
+	"${color grey}RAM Usage:$color $mem/$memmax - $memperc% ${membar 4}\n" +
+	"${if_up eth0}eth0 ${addr eth0} ${downspeed eth0}/${upspeed eth0}${else}eth0 down${endif}\n" +
+	"${color grey}File systems:\n / $color${fs_used /}/${fs_size /} ${fs_bar 6 /}\n" +
+	"${color grey}Name              PID   CPU%   MEM%\n" +
+	"${color lightgrey} ${top name 1} ${top pid 1} ${top cpu 1} ${top mem 1}\n"
+
+// newBenchmarkAPI creates a ConkyAPI over the mock provider with eth0 up.
+func newBenchmarkAPI(b *testing.B) *ConkyAPI {
+	b.Helper()
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		b.Fatalf("failed to create runtime: %v", err)
+	}
+	b.Cleanup(func() { runtime.Close() })
+
+	provider := newMockProvider()
+	provider.network.Interfaces["eth0"] = monitor.InterfaceStats{Name: "eth0", IPv4Addrs: []string{"192.168.1.100"}}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		b.Fatalf("failed to create API: %v", err)
+	}
+	b.Cleanup(func() { api.Close() })
+	return api
+}
+
+// BenchmarkParse benchmarks evaluating conky.text once per update.
+func BenchmarkParse(b *testing.B) {
+	api := newBenchmarkAPI(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		api.Parse(benchmarkText)
+	}
+}
+
+// BenchmarkTemplateExecute benchmarks evaluating an already compiled conky.text.
+func BenchmarkTemplateExecute(b *testing.B) {
+	api := newBenchmarkAPI(b)
+	tmpl := api.Compile(benchmarkText)
+	b.ReportAllocs()
+	for b.Loop() {
+		tmpl.Execute()
+	}
+}
+
+// BenchmarkCompile benchmarks compiling conky.text into a node tree.
+func BenchmarkCompile(b *testing.B) {
+	api := newBenchmarkAPI(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		api.Compile(benchmarkText)
+	}
+}
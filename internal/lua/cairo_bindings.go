@@ -178,6 +178,15 @@ func (cb *CairoBindings) registerFunctions() {
 	cb.runtime.SetGoFunction("cairo_copy_path", cb.copyPath, 0, true)
 	cb.runtime.SetGoFunction("cairo_append_path", cb.appendPath, 1, true)
 
+	// Mask, group and surface source functions
+	cb.runtime.SetGoFunction("cairo_mask", cb.mask, 2, false)
+	cb.runtime.SetGoFunction("cairo_mask_surface", cb.maskSurface, 4, false)
+	cb.runtime.SetGoFunction("cairo_push_group", cb.pushGroup, 0, true)
+	cb.runtime.SetGoFunction("cairo_pop_group", cb.popGroup, 0, true)
+	cb.runtime.SetGoFunction("cairo_pop_group_to_source", cb.popGroupToSource, 0, true)
+	cb.runtime.SetGoFunction("cairo_set_source_surface", cb.setSourceSurface, 4, false)
+	cb.runtime.SetGoFunction("cairo_pattern_create_for_surface", cb.patternCreateForSurface, 1, false)
+
 	// Register Cairo constants
 	cb.registerConstants()
 
@@ -256,6 +265,9 @@ func (cb *CairoBindings) registerSurfaceFunctions() {
 	cb.runtime.SetGoFunction("cairo_surface_flush", cb.surfaceFlush, 1, false)
 	cb.runtime.SetGoFunction("cairo_surface_mark_dirty", cb.surfaceMarkDirty, 1, false)
 	cb.runtime.SetGoFunction("cairo_surface_mark_dirty_rectangle", cb.surfaceMarkDirtyRectangle, 5, false)
+	cb.runtime.SetGoFunction("cairo_image_surface_get_width", cb.imageSurfaceGetWidth, 1, false)
+	cb.runtime.SetGoFunction("cairo_image_surface_get_height", cb.imageSurfaceGetHeight, 1, false)
+	cb.runtime.SetGoFunction("cairo_image_surface_get_data", cb.imageSurfaceGetData, 1, false)
 }
 
 // --- Surface Management Functions (CairoBindings) ---
@@ -284,11 +296,22 @@ func (cb *CairoBindings) xlibSurfaceCreate(t *rt.Thread, c *rt.GoCont) (rt.Cont,
 		return nil, fmt.Errorf("cairo_xlib_surface_create: height: %w", err)
 	}
 
-	surface := render.NewCairoXlibSurface(0, 0, 0, int(width), int(height))
+	surface := newWindowSurface(cb.renderer, int(width), int(height))
 	ud := rt.NewUserData(surface, nil)
 	return c.PushingNext1(t.Runtime, rt.UserDataValue(ud)), nil
 }
 
+// newWindowSurface returns the surface cairo_xlib_surface_create hands to
+// scripts. While a draw hook runs, the renderer's screen is the window layer
+// and the surface draws straight onto it; otherwise an offscreen surface of
+// the requested size is created.
+func newWindowSurface(renderer *render.CairoRenderer, width, height int) *render.CairoSurface {
+	if screen := renderer.Screen(); screen != nil {
+		return render.NewCairoSurfaceForImage(screen)
+	}
+	return render.NewCairoXlibSurface(0, 0, 0, width, height)
+}
+
 // imageSurfaceCreate handles cairo_image_surface_create(format, width, height)
 func (cb *CairoBindings) imageSurfaceCreate(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
 	_, err := c.IntArg(0) // format - we always use ARGB32
@@ -1758,6 +1781,9 @@ func (cb *CairoBindings) imageSurfaceCreateFromPNG(t *rt.Thread, c *rt.GoCont) (
 	if err != nil {
 		return nil, fmt.Errorf("cairo_image_surface_create_from_png: filename: %w", err)
 	}
+	if err := cb.runtime.CheckRead(filename); err != nil {
+		return c.PushingNext(t.Runtime, rt.NilValue, rt.StringValue(err.Error())), nil
+	}
 
 	surface, err := render.NewCairoSurfaceFromPNG(filename)
 	if err != nil {
@@ -1791,6 +1817,9 @@ func (cb *CairoBindings) surfaceWriteToPNG(t *rt.Thread, c *rt.GoCont) (rt.Cont,
 	if !ok {
 		return nil, fmt.Errorf("cairo_surface_write_to_png: filename must be a string")
 	}
+	if cb.runtime.CheckWrite(filename) != nil {
+		return c.PushingNext1(t.Runtime, rt.IntValue(1)), nil
+	}
 
 	err := surface.WriteToPNG(filename)
 	if err != nil {
@@ -2278,3 +2307,162 @@ func (cb *CairoBindings) appendPath(t *rt.Thread, c *rt.GoCont) (rt.Cont, error)
 	renderer.AppendPath(segments)
 	return c.Next(), nil
 }
+
+// --- Mask, Group and Surface Source Functions ---
+
+// getSurfaceArg extracts a CairoSurface from a userdata argument.
+func getSurfaceArg(args []rt.Value, idx int) (*render.CairoSurface, error) {
+	if idx >= len(args) {
+		return nil, fmt.Errorf("missing argument at index %d", idx)
+	}
+	ud, ok := args[idx].TryUserData()
+	if !ok {
+		return nil, fmt.Errorf("argument at index %d is not a surface", idx)
+	}
+	surface, ok := ud.Value().(*render.CairoSurface)
+	if !ok {
+		return nil, fmt.Errorf("argument at index %d is not a surface", idx)
+	}
+	return surface, nil
+}
+
+// mask handles cairo_mask(cr, pattern)
+// Paints the current source using the alpha channel of pattern as a mask.
+func (cb *CairoBindings) mask(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	args := getAllArgs(c)
+	renderer, offset := cb.getRendererFromArgs(args)
+
+	pattern, err := getPatternArg(args, offset)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_mask: pattern: %w", err)
+	}
+
+	renderer.Mask(pattern)
+	return c.Next(), nil
+}
+
+// maskSurface handles cairo_mask_surface(cr, surface, x, y)
+// Paints the current source using the alpha channel of surface, placed at
+// (x, y), as a mask.
+func (cb *CairoBindings) maskSurface(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	args := getAllArgs(c)
+	renderer, offset := cb.getRendererFromArgs(args)
+
+	surface, err := getSurfaceArg(args, offset)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_mask_surface: surface: %w", err)
+	}
+	x, err := getFloatArg(args, offset+1)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_mask_surface: x: %w", err)
+	}
+	y, err := getFloatArg(args, offset+2)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_mask_surface: y: %w", err)
+	}
+
+	renderer.MaskSurface(surface, x, y)
+	return c.Next(), nil
+}
+
+// pushGroup handles cairo_push_group(cr)
+// Redirects drawing to a temporary group surface until the group is popped.
+func (cb *CairoBindings) pushGroup(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	renderer, _ := cb.getRendererFromContext(c)
+	renderer.PushGroup()
+	return c.Next(), nil
+}
+
+// popGroup handles cairo_pop_group(cr)
+// Ends the current group and returns its contents as a pattern, or nil if
+// no group was pushed.
+func (cb *CairoBindings) popGroup(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	renderer, _ := cb.getRendererFromContext(c)
+	pattern := renderer.PopGroup()
+	if pattern == nil {
+		return c.PushingNext1(t.Runtime, rt.NilValue), nil
+	}
+	ud := rt.NewUserData(pattern, nil)
+	return c.PushingNext1(t.Runtime, rt.UserDataValue(ud)), nil
+}
+
+// popGroupToSource handles cairo_pop_group_to_source(cr)
+// Ends the current group and makes its contents the source.
+func (cb *CairoBindings) popGroupToSource(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	renderer, _ := cb.getRendererFromContext(c)
+	renderer.PopGroupToSource()
+	return c.Next(), nil
+}
+
+// setSourceSurface handles cairo_set_source_surface(cr, surface, x, y)
+// Makes surface, placed at (x, y), the source for drawing operations.
+func (cb *CairoBindings) setSourceSurface(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	args := getAllArgs(c)
+	renderer, offset := cb.getRendererFromArgs(args)
+
+	surface, err := getSurfaceArg(args, offset)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_set_source_surface: surface: %w", err)
+	}
+	x, err := getFloatArg(args, offset+1)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_set_source_surface: x: %w", err)
+	}
+	y, err := getFloatArg(args, offset+2)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_set_source_surface: y: %w", err)
+	}
+
+	renderer.SetSourceSurface(surface, x, y)
+	return c.Next(), nil
+}
+
+// patternCreateForSurface handles cairo_pattern_create_for_surface(surface)
+// Creates a pattern painting surface, or returns nil for a destroyed surface.
+func (cb *CairoBindings) patternCreateForSurface(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	surface, err := getSurfaceArg(getAllArgs(c), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_pattern_create_for_surface: surface: %w", err)
+	}
+
+	pattern := render.NewSurfacePattern(surface)
+	if pattern == nil {
+		return c.PushingNext1(t.Runtime, rt.NilValue), nil
+	}
+	ud := rt.NewUserData(pattern, nil)
+	return c.PushingNext1(t.Runtime, rt.UserDataValue(ud)), nil
+}
+
+// imageSurfaceGetWidth handles cairo_image_surface_get_width(surface)
+func (cb *CairoBindings) imageSurfaceGetWidth(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	surface, err := getSurfaceArg(getAllArgs(c), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_image_surface_get_width: surface: %w", err)
+	}
+	return c.PushingNext1(t.Runtime, rt.IntValue(int64(surface.Width()))), nil
+}
+
+// imageSurfaceGetHeight handles cairo_image_surface_get_height(surface)
+func (cb *CairoBindings) imageSurfaceGetHeight(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	surface, err := getSurfaceArg(getAllArgs(c), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_image_surface_get_height: surface: %w", err)
+	}
+	return c.PushingNext1(t.Runtime, rt.IntValue(int64(surface.Height()))), nil
+}
+
+// imageSurfaceGetData handles cairo_image_surface_get_data(surface)
+// Returns the surface pixels as a string in CAIRO_FORMAT_ARGB32 layout,
+// width*4 bytes per row, or nil and an error message if they cannot be read.
+func (cb *CairoBindings) imageSurfaceGetData(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	surface, err := getSurfaceArg(getAllArgs(c), 0)
+	if err != nil {
+		return nil, fmt.Errorf("cairo_image_surface_get_data: surface: %w", err)
+	}
+
+	data, err := surface.Data()
+	if err != nil {
+		return c.PushingNext(t.Runtime, rt.NilValue, rt.StringValue(err.Error())), nil
+	}
+	return c.PushingNext1(t.Runtime, rt.StringValue(string(data))), nil
+}
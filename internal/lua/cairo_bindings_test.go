@@ -4,6 +4,7 @@ package lua
 import (
 	"math"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/opd-ai/go-conky/internal/render"
@@ -2860,7 +2861,9 @@ func TestCairoBindings_PNG_RoundTrip(t *testing.T) {
 			error("Failed to load PNG")
 		end
 		
-		-- Note: We can't query dimensions in Lua, but the surface should be usable
+		if cairo_image_surface_get_width(surface2) ~= 123 or cairo_image_surface_get_height(surface2) ~= 456 then
+			error("Loaded surface has the wrong size")
+		end
 		local cr2 = cairo_create(surface2)
 		if cr2 == nil then
 			error("Failed to create context from loaded surface")
@@ -2874,3 +2877,170 @@ func TestCairoBindings_PNG_RoundTrip(t *testing.T) {
 		t.Fatalf("Failed to execute PNG round-trip test: %v", err)
 	}
 }
+
+func TestCairoBindings_MaskAndGroups(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	_, err = NewCairoBindings(runtime)
+	if err != nil {
+		t.Fatalf("Failed to create CairoBindings: %v", err)
+	}
+
+	// Pixels can only be read back inside the game loop; the rendered
+	// output is covered by the golden image tests in test/golden
+	result, err := runtime.ExecuteString("test", `
+		local cs = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, 64, 64)
+		local cr = cairo_create(cs)
+		local art = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, 32, 32)
+
+		cairo_set_source_surface(cr, art, 16, 16)
+		cairo_paint(cr)
+		cairo_set_source(cr, cairo_pattern_create_for_surface(art))
+		local disc = cairo_pattern_create_radial(32, 32, 0, 32, 32, 32)
+		cairo_pattern_add_color_stop_rgba(disc, 0, 0, 0, 0, 1)
+		cairo_pattern_add_color_stop_rgba(disc, 1, 0, 0, 0, 0)
+		cairo_mask(cr, disc)
+		cairo_mask_surface(cr, art, 8, 8)
+
+		cairo_push_group(cr)
+		cairo_push_group(cr)
+		cairo_rectangle(cr, 0, 0, 10, 10)
+		cairo_fill(cr)
+		local inner = cairo_pop_group(cr)
+		cairo_set_source(cr, inner)
+		cairo_paint(cr)
+		cairo_pop_group_to_source(cr)
+		cairo_paint_with_alpha(cr, 0.5)
+		local none = cairo_pop_group(cr)
+
+		cairo_destroy(cr)
+		cairo_surface_destroy(art)
+		cairo_surface_destroy(cs)
+		return type(inner) == "userdata" and none == nil
+	`)
+	if err != nil {
+		t.Fatalf("Failed to execute mask and group functions: %v", err)
+	}
+	if !result.AsBool() {
+		t.Error("Expected cairo_pop_group to return a pattern, and nil without a group")
+	}
+}
+
+func TestCairoBindings_SetSourceSurface(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	cb, err := NewCairoBindings(runtime)
+	if err != nil {
+		t.Fatalf("Failed to create CairoBindings: %v", err)
+	}
+
+	// Without cr the shared renderer is used
+	_, err = runtime.ExecuteString("test", `
+		surface = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, 10, 10)
+		cairo_set_source_surface(surface, 5, 5)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to execute cairo_set_source_surface: %v", err)
+	}
+	source := cb.Renderer().GetSource()
+	if source == nil || source.Type() != render.PatternTypeSurface {
+		t.Fatalf("Expected a surface source, got %v", source)
+	}
+
+	// A color replaces the surface source
+	_, err = runtime.ExecuteString("test2", `cairo_set_source_rgb(1, 0, 0)`)
+	if err != nil {
+		t.Fatalf("Failed to execute cairo_set_source_rgb: %v", err)
+	}
+	if source := cb.Renderer().GetSource(); source != nil {
+		t.Errorf("Expected cairo_set_source_rgb to replace the surface source, got %v", source)
+	}
+
+	// A destroyed surface has no pattern
+	result, err := runtime.ExecuteString("test3", `
+		cairo_surface_destroy(surface)
+		return cairo_pattern_create_for_surface(surface)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to execute cairo_pattern_create_for_surface: %v", err)
+	}
+	if !result.IsNil() {
+		t.Errorf("Expected nil pattern for a destroyed surface, got %v", result)
+	}
+}
+
+func TestCairoBindings_ImageSurfaceGetters(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	_, err = NewCairoBindings(runtime)
+	if err != nil {
+		t.Fatalf("Failed to create CairoBindings: %v", err)
+	}
+
+	result, err := runtime.ExecuteString("test", `
+		local surface = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, 120, 45)
+		return cairo_image_surface_get_width(surface) * 1000 + cairo_image_surface_get_height(surface)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to execute image surface getters: %v", err)
+	}
+	if got, _ := result.TryInt(); got != 120045 {
+		t.Errorf("Expected width 120 and height 45, got %d", got)
+	}
+
+	// Outside the game loop the pixels cannot be read back
+	result, err = runtime.ExecuteString("test2", `
+		local surface = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, 4, 4)
+		local data, msg = cairo_image_surface_get_data(surface)
+		return data == nil and msg
+	`)
+	if err != nil {
+		t.Fatalf("Failed to execute cairo_image_surface_get_data: %v", err)
+	}
+	if msg, ok := result.TryString(); !ok || !strings.Contains(msg, "cairo_image_surface_get_data") {
+		t.Errorf("Expected nil and an error message, got %v", result)
+	}
+}
+
+func TestCairoBindings_SurfaceArgumentErrors(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	_, err = NewCairoBindings(runtime)
+	if err != nil {
+		t.Fatalf("Failed to create CairoBindings: %v", err)
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`cairo_mask(cairo_create(), 1)`, "cairo_mask: pattern"},
+		{`cairo_mask_surface(cairo_create(), cairo_pattern_create_rgb(1, 1, 1), 0, 0)`, "cairo_mask_surface: surface"},
+		{`cairo_mask_surface(cairo_image_surface_create(0, 4, 4), 0)`, "cairo_mask_surface: y"},
+		{`cairo_set_source_surface(cairo_create(), "image.png", 0, 0)`, "cairo_set_source_surface: surface"},
+		{`cairo_pattern_create_for_surface()`, "cairo_pattern_create_for_surface: surface"},
+		{`cairo_image_surface_get_width(cairo_create())`, "cairo_image_surface_get_width: surface"},
+	}
+	for _, tt := range tests {
+		_, err := runtime.ExecuteString("test", tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
@@ -648,7 +648,7 @@ func (cm *CairoModule) xlibSurfaceCreate(t *rt.Thread, c *rt.GoCont) (rt.Cont, e
 		return nil, err
 	}
 
-	surface := render.NewCairoXlibSurface(0, 0, 0, int(width), int(height))
+	surface := newWindowSurface(cm.renderer, int(width), int(height))
 	ud := rt.NewUserData(surface, nil)
 	return c.PushingNext1(t.Runtime, rt.UserDataValue(ud)), nil
 }
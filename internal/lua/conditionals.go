@@ -1,243 +1,34 @@
 // Package lua provides Golua integration for conky-go.
-// This file implements conditional variable parsing for ${if_*}...${else}...${endif} blocks.
+// This file implements the conditions of ${if_*}...${else}...${endif} blocks.
 package lua
 
 import (
+	"bufio"
 	"os"
-	"regexp"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
-// conditionalPattern matches conditional blocks: ${if_*...}...${endif}
-// It captures the conditional type and arguments in group 1, and the body in group 2.
-// The regex is non-greedy to handle nested conditionals properly.
-var conditionalPattern = regexp.MustCompile(`\$\{(if_[^}]+)\}`)
+// smapiRoot is the sysfs directory of the ThinkPad SMAPI driver.
+var smapiRoot = "/sys/devices/platform/smapi"
 
-// endifPattern matches the ${endif} marker.
-var endifPattern = regexp.MustCompile(`\$\{endif\}`)
-
-// elsePattern matches the ${else} marker.
-var elsePattern = regexp.MustCompile(`\$\{else\}`)
-
-// parseConditionals processes conditional blocks in a template string.
-// It handles ${if_up}, ${if_existing}, ${if_running}, ${if_match}, ${if_empty},
-// and their corresponding ${else} and ${endif} blocks.
+// evaluateCondition evaluates a conditional such as ${if_up eth0} and
+// returns true or false. The blocks themselves are compiled by Compile.
 //
 // Conditional syntax:
 //   - ${if_up interface}content${endif}
 //   - ${if_up interface}content${else}alternative${endif}
 //   - ${if_existing path}content${endif}
+//   - ${if_existing path string}content${endif}
 //   - ${if_running process}content${endif}
-//   - ${if_match value pattern}content${endif}
+//   - ${if_match lhs op rhs}content${endif}
 //   - ${if_empty value}content${endif}
+//   - ${if_gw}content${endif}
+//   - ${if_updatenr n}content${endif}
 //
-// Conditionals can be nested.
-func (api *ConkyAPI) parseConditionals(template string) string {
-	// Process conditionals iteratively until no more are found
-	// This handles nested conditionals by processing from innermost to outermost
-	result := template
-	maxIterations := 100 // Prevent infinite loops
-
-	for i := 0; i < maxIterations; i++ {
-		processed := api.processOneConditional(result)
-		if processed == result {
-			// No changes, we're done
-			break
-		}
-		result = processed
-	}
-
-	return result
-}
-
-// processOneConditional finds and processes one conditional block.
-// It finds the first ${if_*} and its matching ${endif}, then evaluates the condition.
-func (api *ConkyAPI) processOneConditional(template string) string {
-	// Find first conditional
-	loc := conditionalPattern.FindStringIndex(template)
-	if loc == nil {
-		return template
-	}
-
-	ifStart := loc[0]
-	ifEnd := loc[1]
-
-	// Extract the conditional expression (e.g., "if_up eth0")
-	match := conditionalPattern.FindStringSubmatch(template[ifStart:ifEnd])
-	if len(match) < 2 {
-		return template
-	}
-	condExpr := match[1]
-
-	// Find matching ${endif} - need to count nesting
-	endifIdx := api.findMatchingEndif(template[ifEnd:])
-	if endifIdx == -1 {
-		// No matching endif found, return as-is
-		return template
-	}
-	endifIdx += ifEnd // Adjust to absolute position
-
-	// Extract the body between ${if_*} and ${endif}
-	body := template[ifEnd:endifIdx]
-
-	// Find ${else} in the body (if present, only at our nesting level)
-	elseIdx := api.findElseAtLevel(body)
-
-	var thenPart, elsePart string
-	if elseIdx == -1 {
-		// No else clause
-		thenPart = body
-		elsePart = ""
-	} else {
-		thenPart = body[:elseIdx]
-		// Skip past ${else} (7 characters)
-		elsePart = body[elseIdx+7:]
-	}
-
-	// Evaluate the condition
-	condResult := api.evaluateCondition(condExpr)
-
-	// Select the appropriate content
-	var replacement string
-	if condResult {
-		replacement = thenPart
-	} else {
-		replacement = elsePart
-	}
-
-	// Find the end of ${endif} (7 characters: ${endif})
-	endifEnd := endifIdx + 8
-
-	// Rebuild the template
-	return template[:ifStart] + replacement + template[endifEnd:]
-}
-
-// findMatchingEndif finds the position of the matching ${endif} for a conditional,
-// accounting for nested conditionals.
-func (api *ConkyAPI) findMatchingEndif(s string) int {
-	depth := 1
-	pos := 0
-
-	for pos < len(s) {
-		// Find next ${if_* or ${endif}
-		ifLoc := conditionalPattern.FindStringIndex(s[pos:])
-		endifLoc := endifPattern.FindStringIndex(s[pos:])
-
-		// Determine which comes first
-		ifIdx := -1
-		endifIdx := -1
-		if ifLoc != nil {
-			ifIdx = pos + ifLoc[0]
-		}
-		if endifLoc != nil {
-			endifIdx = pos + endifLoc[0]
-		}
-
-		if ifIdx == -1 && endifIdx == -1 {
-			// Neither found
-			return -1
-		}
-
-		if endifIdx != -1 && (ifIdx == -1 || endifIdx < ifIdx) {
-			// Found ${endif} first
-			depth--
-			if depth == 0 {
-				return endifIdx
-			}
-			pos = endifIdx + 8 // Skip past ${endif}
-		} else if ifIdx != -1 {
-			// Found another ${if_*}
-			depth++
-			// Find end of this ${if_*}
-			endBrace := strings.Index(s[ifIdx:], "}")
-			if endBrace == -1 {
-				return -1
-			}
-			pos = ifIdx + endBrace + 1
-		}
-	}
-
-	return -1
-}
-
-// findElseAtLevel finds ${else} at the current nesting level (not inside nested conditionals).
-func (api *ConkyAPI) findElseAtLevel(s string) int {
-	depth := 0
-	pos := 0
-
-	for pos < len(s) {
-		// Find next ${if_*, ${else}, or ${endif}
-		ifLoc := conditionalPattern.FindStringIndex(s[pos:])
-		elseLoc := elsePattern.FindStringIndex(s[pos:])
-		endifLoc := endifPattern.FindStringIndex(s[pos:])
-
-		// Determine which comes first
-		type marker struct {
-			idx  int
-			kind string
-		}
-		var markers []marker
-
-		if ifLoc != nil {
-			markers = append(markers, marker{pos + ifLoc[0], "if"})
-		}
-		if elseLoc != nil {
-			markers = append(markers, marker{pos + elseLoc[0], "else"})
-		}
-		if endifLoc != nil {
-			markers = append(markers, marker{pos + endifLoc[0], "endif"})
-		}
-
-		if len(markers) == 0 {
-			// No more markers
-			return -1
-		}
-
-		// Find the earliest marker
-		earliest := markers[0]
-		for _, m := range markers[1:] {
-			if m.idx < earliest.idx {
-				earliest = m
-			}
-		}
-
-		switch earliest.kind {
-		case "if":
-			depth++
-			// Find end of this ${if_*}
-			endBrace := strings.Index(s[earliest.idx:], "}")
-			if endBrace == -1 {
-				return -1
-			}
-			pos = earliest.idx + endBrace + 1
-		case "else":
-			if depth == 0 {
-				// Found ${else} at our level
-				return earliest.idx
-			}
-			pos = earliest.idx + 7 // Skip past ${else}
-		case "endif":
-			if depth > 0 {
-				depth--
-			}
-			pos = earliest.idx + 8 // Skip past ${endif}
-		}
-	}
-
-	return -1
-}
-
-// evaluateCondition evaluates a conditional expression and returns true or false.
-// Supports: if_up, if_existing, if_running, if_match, if_empty
-func (api *ConkyAPI) evaluateCondition(condExpr string) bool {
-	parts := strings.Fields(condExpr)
-	if len(parts) == 0 {
-		return false
-	}
-
-	condType := parts[0]
-	args := parts[1:]
-
+// Unknown conditionals are false; config.Validator warns about them.
+func (api *ConkyAPI) evaluateCondition(condType string, args []string) bool {
 	switch condType {
 	case "if_up":
 		return api.evalIfUp(args)
@@ -251,10 +42,18 @@ func (api *ConkyAPI) evaluateCondition(condExpr string) bool {
 		return api.evalIfEmpty(args)
 	case "if_mounted":
 		return api.evalIfMounted(args)
+	case "if_gw":
+		return api.evalIfGateway()
+	case "if_updatenr":
+		return api.evalIfUpdateNr(args)
+	case "if_smapi_bat_installed":
+		return api.evalIfSMAPIBatInstalled(args)
 	case "if_mpd_playing":
 		return api.evalIfMPDPlaying()
 	case "if_mixer_mute":
 		return api.evalIfMixerMute(args)
+	case "if_pa_sink_muted", "if_pa_source_muted", "if_pa_source_running":
+		return api.evalIfPulse(condType)
 	default:
 		// Unknown conditional, treat as false
 		return false
@@ -285,14 +84,33 @@ func (api *ConkyAPI) evalIfUp(args []string) bool {
 	return len(iface.IPv4Addrs) > 0
 }
 
-// evalIfExisting checks if a file or path exists.
+// evalIfExisting checks if a file or path exists and, when further
+// arguments are given, whether a line of the file contains them.
+// Syntax: ${if_existing path [string]}
+// Paths the policy does not allow reading do not exist.
 func (api *ConkyAPI) evalIfExisting(args []string) bool {
-	if len(args) == 0 {
+	if len(args) == 0 || api.runtime.CheckRead(args[0]) != nil {
 		return false
 	}
+	if len(args) == 1 {
+		_, err := os.Stat(args[0])
+		return err == nil
+	}
 
-	_, err := os.Stat(args[0])
-	return err == nil
+	f, err := os.Open(args[0])
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	want := strings.Join(args[1:], " ")
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), want) {
+			return true
+		}
+	}
+	return false
 }
 
 // evalIfRunning checks if a process with the given name is running.
@@ -329,27 +147,85 @@ func (api *ConkyAPI) evalIfRunning(args []string) bool {
 	return false
 }
 
-// evalIfMatch checks if a value matches a pattern.
-// Syntax: ${if_match value pattern}
-// Pattern is a string comparison (not regex for simplicity).
+// evalIfMatch evaluates a comparison after variable expansion.
+// Syntax: ${if_match lhs op rhs}, where op is <, >, <=, >=, == or !=.
+// Operands are compared as numbers when both are numbers, and otherwise as
+// strings; quote string operands, as in ${if_match "${mpd_status}" == "Playing"}.
+// An operand that expands to nothing is the empty string, like "".
+// Without an operator the first two words are compared for equality.
 func (api *ConkyAPI) evalIfMatch(args []string) bool {
-	if len(args) < 2 {
-		return false
+	lhs, op, rhs, ok := splitComparison(strings.Join(args, " "))
+	if !ok {
+		return len(args) >= 2 && args[0] == args[1]
 	}
+	return compareOperands(lhs, op, rhs)
+}
 
-	value := args[0]
-	pattern := args[1]
+// splitComparison splits an if_match expression at its first operator
+// outside double quotes.
+func splitComparison(expr string) (lhs, op, rhs string, ok bool) {
+	inQuotes := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if c == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if inQuotes || (c != '<' && c != '>' && c != '=' && c != '!') {
+			continue
+		}
+		op = expr[i : i+1]
+		if i+1 < len(expr) && expr[i+1] == '=' {
+			op = expr[i : i+2]
+		}
+		if op == "=" || op == "!" {
+			return "", "", "", false
+		}
+		return strings.TrimSpace(expr[:i]), op, strings.TrimSpace(expr[i+len(op):]), true
+	}
+	return "", "", "", false
+}
 
-	// Support simple comparison operators
-	if strings.HasPrefix(pattern, "==") {
-		return value == strings.TrimPrefix(pattern, "==")
+// compareOperands applies a comparison operator to two if_match operands.
+func compareOperands(lhs, op, rhs string) bool {
+	var cmp int
+	l, lErr := strconv.ParseFloat(lhs, 64)
+	r, rErr := strconv.ParseFloat(rhs, 64)
+	switch {
+	case lErr == nil && rErr == nil:
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	default:
+		cmp = strings.Compare(unquoteOperand(lhs), unquoteOperand(rhs))
 	}
-	if strings.HasPrefix(pattern, "!=") {
-		return value != strings.TrimPrefix(pattern, "!=")
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case ">=":
+		return cmp >= 0
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
 	}
+	return false
+}
 
-	// Default to equality check
-	return value == pattern
+// unquoteOperand removes the double quotes around a string operand.
+func unquoteOperand(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
 }
 
 // evalIfEmpty checks if a string is empty.
@@ -382,6 +258,60 @@ func (api *ConkyAPI) evalIfMounted(args []string) bool {
 	return ok
 }
 
+// evalIfGateway checks if there is a default gateway.
+func (api *ConkyAPI) evalIfGateway() bool {
+	api.mu.RLock()
+	provider := api.sysProvider
+	api.mu.RUnlock()
+
+	if provider == nil {
+		return false
+	}
+	return provider.Network().GatewayIP != ""
+}
+
+// evalIfUpdateNr checks if this is the n-th update of a cycle as long as
+// the highest n in the templates, so that ${if_updatenr 1} and
+// ${if_updatenr 2} alternate.
+// Syntax: ${if_updatenr n}
+func (api *ConkyAPI) evalIfUpdateNr(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	n, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || n < 1 {
+		return false
+	}
+	cycle := max(api.updateCycle.Load(), n)
+	return api.updates.Load()%cycle == n-1
+}
+
+// noteUpdateNr records the n of an ${if_updatenr n} conditional, which
+// extends the cycle to n updates.
+func (api *ConkyAPI) noteUpdateNr(n int64) {
+	for {
+		cycle := api.updateCycle.Load()
+		if n <= cycle || api.updateCycle.CompareAndSwap(cycle, n) {
+			return
+		}
+	}
+}
+
+// evalIfSMAPIBatInstalled checks if a ThinkPad battery is installed,
+// according to the SMAPI driver.
+// Syntax: ${if_smapi_bat_installed index}
+func (api *ConkyAPI) evalIfSMAPIBatInstalled(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 0 {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(smapiRoot, "BAT"+strconv.Itoa(index), "installed"))
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
 // evalIfMPDPlaying checks if MPD is playing.
 func (api *ConkyAPI) evalIfMPDPlaying() bool {
 	api.mu.RLock()
@@ -409,3 +339,26 @@ func (api *ConkyAPI) evalIfMixerMute(_ []string) bool {
 	audioStats := provider.Audio()
 	return audioStats.MasterMuted
 }
+
+// evalIfPulse evaluates the PulseAudio conditionals on the default sink and
+// source. All are false without a sound server.
+func (api *ConkyAPI) evalIfPulse(condType string) bool {
+	api.mu.RLock()
+	provider := api.sysProvider
+	api.mu.RUnlock()
+
+	if provider == nil {
+		return false
+	}
+
+	pulse := provider.Audio().Pulse
+	switch condType {
+	case "if_pa_sink_muted":
+		return pulse.DefaultSink.Muted
+	case "if_pa_source_muted":
+		return pulse.DefaultSource.Muted
+	case "if_pa_source_running":
+		return pulse.DefaultSource.State == "running"
+	}
+	return false
+}
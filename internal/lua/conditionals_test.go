@@ -3,6 +3,7 @@ package lua
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/opd-ai/go-conky/internal/monitor"
@@ -243,9 +244,14 @@ func TestEvaluateCondition(t *testing.T) {
 			Interfaces: map[string]monitor.InterfaceStats{
 				"eth0": {IPv4Addrs: []string{"192.168.1.100"}},
 			},
+			GatewayIP: "192.168.1.1",
 		},
 		audio: monitor.AudioStats{
 			MasterMuted: true,
+			Pulse: monitor.PulseStats{
+				DefaultSink:   monitor.PulseDevice{Muted: true},
+				DefaultSource: monitor.PulseDevice{State: "suspended"},
+			},
 		},
 	}
 
@@ -269,12 +275,20 @@ func TestEvaluateCondition(t *testing.T) {
 		{"if_empty empty", "if_empty ", true},
 		{"if_empty not empty", "if_empty hello", false},
 		{"if_mixer_mute muted", "if_mixer_mute", true},
+		{"if_pa_sink_muted muted", "if_pa_sink_muted", true},
+		{"if_pa_source_muted unmuted", "if_pa_source_muted", false},
+		{"if_pa_source_running suspended", "if_pa_source_running", false},
+		{"if_gw with gateway", "if_gw", true},
+		{"if_match numeric", "if_match 10 > 9", true},
+		{"if_updatenr first", "if_updatenr 1", true},
+		{"if_updatenr second", "if_updatenr 2", false},
 		{"unknown conditional", "if_unknown arg", false},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := api.evaluateCondition(tc.condExpr)
+			parts := strings.Fields(tc.condExpr)
+			result := api.evaluateCondition(parts[0], parts[1:])
 			if result != tc.expected {
 				t.Errorf("evaluateCondition(%q) = %v, want %v", tc.condExpr, result, tc.expected)
 			}
@@ -282,148 +296,228 @@ func TestEvaluateCondition(t *testing.T) {
 	}
 }
 
-// TestFindMatchingEndif tests the endif finding logic.
-func TestFindMatchingEndif(t *testing.T) {
+// TestConditionalEdgeCases tests edge cases in conditional parsing.
+func TestConditionalEdgeCases(t *testing.T) {
 	runtime, err := New(RuntimeConfig{})
 	if err != nil {
 		t.Fatalf("Failed to create runtime: %v", err)
 	}
 
-	api, err := NewConkyAPI(runtime, nil)
+	provider := &mockSystemDataProvider{
+		network: monitor.NetworkStats{
+			Interfaces: map[string]monitor.InterfaceStats{
+				"eth0": {IPv4Addrs: []string{"192.168.1.1"}},
+			},
+		},
+	}
+
+	api, err := NewConkyAPI(runtime, provider)
 	if err != nil {
 		t.Fatalf("Failed to create API: %v", err)
 	}
 
 	tests := []struct {
 		name     string
-		input    string
-		expected int
+		template string
+		expected string
 	}{
 		{
-			name:     "simple endif",
-			input:    "content${endif}",
-			expected: 7,
+			name:     "unclosed if falls back to variable",
+			template: "${if_up eth0}no endif",
+			// Unclosed conditional: ${if_up eth0} is resolved as variable (returns "1" when up)
+			expected: "1no endif",
+		},
+		{
+			name:     "orphan endif",
+			template: "orphan ${endif}",
+			expected: "orphan ${endif}",
+		},
+		{
+			name:     "orphan else",
+			template: "orphan ${else}",
+			expected: "orphan ${else}",
 		},
 		{
-			name:     "nested endif",
-			input:    "${if_up eth0}inner${endif}outer${endif}",
-			expected: 31,
+			name:     "empty if",
+			template: "${if_up eth0}${endif}",
+			expected: "",
 		},
 		{
-			name:     "no endif",
-			input:    "no endif here",
-			expected: -1,
+			name:     "empty else",
+			template: "${if_up wlan0}then${else}${endif}",
+			expected: "",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := api.findMatchingEndif(tc.input)
+			result := api.Parse(tc.template)
 			if result != tc.expected {
-				t.Errorf("findMatchingEndif(%q) = %d, want %d", tc.input, result, tc.expected)
+				t.Errorf("Parse(%q) = %q, want %q", tc.template, result, tc.expected)
 			}
 		})
 	}
 }
 
-// TestFindElseAtLevel tests the else finding logic.
-func TestFindElseAtLevel(t *testing.T) {
+// TestIfMatchExpressions tests if_match comparisons after variable expansion.
+func TestIfMatchExpressions(t *testing.T) {
 	runtime, err := New(RuntimeConfig{})
 	if err != nil {
 		t.Fatalf("Failed to create runtime: %v", err)
 	}
 
-	api, err := NewConkyAPI(runtime, nil)
+	provider := newMockProvider()
+	provider.network.GatewayInterface = "wlan0"
+	api, err := NewConkyAPI(runtime, provider)
 	if err != nil {
 		t.Fatalf("Failed to create API: %v", err)
 	}
+	defer api.Close()
 
 	tests := []struct {
-		name     string
-		input    string
-		expected int
+		expr     string
+		expected bool
 	}{
-		{
-			name:     "simple else",
-			input:    "then${else}else",
-			expected: 4,
-		},
-		{
-			name:     "no else",
-			input:    "no else here",
-			expected: -1,
-		},
+		{"${cpu} > 40", true},
+		{"${cpu} < 40", false},
+		{"${cpu}>=46", true},
+		{"${cpu} <= 45.9", false},
+		{"${cpu} == 46.0", true},
+		{"${cpu} != 46", false},
+		{"9 < 10", true}, // Numbers, not strings
+		{`"9" < "10"`, false},
+		{`"${gw_iface}" == "wlan0"`, true},
+		{`"${gw_iface}" != "wlan0"`, false},
+		{`"a b" == "a b"`, true},
+		{`"${exec true}" == ""`, true},
+		{`"a  b" == "a b"`, false}, // Spaces inside quotes are kept
+		{`"${exec printf 'a\040\040b'}" == "a  b"`, true},
+		{`"${exec printf 'a\040\040b'}" == "a b"`, false},
+		{`"a<b" == "a<b"`, true},
+		{`"abc" < "abd"`, true},
+		{"-5 < 3", true},
+		{"${cpu} = 46", false}, // Not an operator
+		{"${cpu} > ", true},    // "46" > "" as strings
+		{"${exec true} == ", true},
+		{`${exec true} == ""`, true},
+		{"${exec true} != Playing", true},
 	}
 
 	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := api.findElseAtLevel(tc.input)
-			if result != tc.expected {
-				t.Errorf("findElseAtLevel(%q) = %d, want %d", tc.input, result, tc.expected)
+		t.Run(tc.expr, func(t *testing.T) {
+			template := "${if_match " + tc.expr + "}yes${else}no${endif}"
+			want := "no"
+			if tc.expected {
+				want = "yes"
+			}
+			if got := api.Parse(template); got != want {
+				t.Errorf("Parse(%q) = %q, want %q", template, got, want)
 			}
 		})
 	}
 }
 
-// TestConditionalEdgeCases tests edge cases in conditional parsing.
-func TestConditionalEdgeCases(t *testing.T) {
+// TestIfUpdateNr tests that if_updatenr cycles through the highest n.
+func TestIfUpdateNr(t *testing.T) {
 	runtime, err := New(RuntimeConfig{})
 	if err != nil {
 		t.Fatalf("Failed to create runtime: %v", err)
 	}
+	api, err := NewConkyAPI(runtime, &mockSystemDataProvider{})
+	if err != nil {
+		t.Fatalf("Failed to create API: %v", err)
+	}
+	defer api.Close()
 
-	provider := &mockSystemDataProvider{
-		network: monitor.NetworkStats{
-			Interfaces: map[string]monitor.InterfaceStats{
-				"eth0": {IPv4Addrs: []string{"192.168.1.1"}},
-			},
-		},
+	tmpl := api.Compile("${if_updatenr 1}a${endif}${if_updatenr 2}b${endif}${if_updatenr 4}${endif}")
+	var got []string
+	for range 8 {
+		got = append(got, tmpl.Execute())
+		api.IncrementUpdates()
+	}
+	want := []string{"a", "b", "", "", "a", "b", "", ""}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("outputs = %q, want %q", got, want)
 	}
 
-	api, err := NewConkyAPI(runtime, provider)
+	// A new configuration drops the 4-update cycle of the old template
+	api.SetTemplates([10]string{})
+	tmpl = api.Compile("${if_updatenr 1}a${endif}${if_updatenr 2}b${endif}")
+	got = got[:0]
+	for range 4 {
+		got = append(got, tmpl.Execute())
+		api.IncrementUpdates()
+	}
+	want = []string{"a", "b", "a", "b"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("outputs after reload = %q, want %q", got, want)
+	}
+}
+
+// TestIfExistingContent tests if_existing with a string to find in the file.
+func TestIfExistingContent(t *testing.T) {
+	runtime, err := New(RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	api, err := NewConkyAPI(runtime, &mockSystemDataProvider{})
 	if err != nil {
 		t.Fatalf("Failed to create API: %v", err)
 	}
+	defer api.Close()
+
+	file := filepath.Join(t.TempDir(), "state")
+	if err := os.WriteFile(file, []byte("status: charging\nlevel: 80\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
 
 	tests := []struct {
-		name     string
-		template string
+		args     string
 		expected string
 	}{
-		{
-			name:     "unclosed if falls back to variable",
-			template: "${if_up eth0}no endif",
-			// Unclosed conditional: ${if_up eth0} is resolved as variable (returns "1" when up)
-			expected: "1no endif",
-		},
-		{
-			name:     "orphan endif",
-			template: "orphan ${endif}",
-			expected: "orphan ${endif}",
-		},
-		{
-			name:     "orphan else",
-			template: "orphan ${else}",
-			expected: "orphan ${else}",
-		},
-		{
-			name:     "empty if",
-			template: "${if_up eth0}${endif}",
-			expected: "",
-		},
-		{
-			name:     "empty else",
-			template: "${if_up wlan0}then${else}${endif}",
-			expected: "",
-		},
+		{file + " charging", "yes"},
+		{file + ` "status: charging"`, "yes"},
+		{file + " discharging", "no"},
+		{file + ` "charging level"`, "no"}, // Matched within a line
+		{file + ".missing charging", "no"},
 	}
+	for _, tc := range tests {
+		template := "${if_existing " + tc.args + "}yes${else}no${endif}"
+		if got := api.Parse(template); got != tc.expected {
+			t.Errorf("Parse(%q) = %q, want %q", template, got, tc.expected)
+		}
+	}
+}
 
+// TestIfSMAPIBatInstalled tests if_smapi_bat_installed against a fake sysfs.
+func TestIfSMAPIBatInstalled(t *testing.T) {
+	root := t.TempDir()
+	for bat, installed := range map[string]string{"BAT0": "1\n", "BAT1": "0\n"} {
+		if err := os.MkdirAll(filepath.Join(root, bat), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, bat, "installed"), []byte(installed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := smapiRoot
+	smapiRoot = root
+	defer func() { smapiRoot = old }()
+
+	api := &ConkyAPI{}
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"0"}, true},
+		{[]string{"1"}, false},
+		{[]string{"2"}, false},
+		{[]string{"../BAT0"}, false},
+		{nil, false},
+	}
 	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := api.Parse(tc.template)
-			if result != tc.expected {
-				t.Errorf("Parse(%q) = %q, want %q", tc.template, result, tc.expected)
-			}
-		})
+		if got := api.evaluateCondition("if_smapi_bat_installed", tc.args); got != tc.expected {
+			t.Errorf("if_smapi_bat_installed %v = %v, want %v", tc.args, got, tc.expected)
+		}
 	}
 }
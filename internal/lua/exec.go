@@ -0,0 +1,363 @@
+// Package lua provides Golua integration for conky-go.
+// This file implements the scheduler that runs the shell commands of
+// ${exec}, ${execi}, ${texeci}, the exec widgets and alert exec commands.
+package lua
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrExecStopped is returned when a command is run after the scheduler has
+// been stopped.
+var ErrExecStopped = errors.New("exec scheduler stopped")
+
+// maxExecStderr bounds the stderr kept from a single command run.
+const maxExecStderr = 4096
+
+// ExecConfig configures how shell commands are run.
+type ExecConfig struct {
+	// Timeout bounds a single command run. The command and the processes
+	// it started are killed when it expires. Zero disables the timeout.
+	Timeout time.Duration
+	// MaxConcurrent is the maximum number of interval commands running in
+	// the background at once. Values below 1 are treated as 1.
+	MaxConcurrent int
+}
+
+// DefaultExecConfig returns sensible defaults for running commands.
+func DefaultExecConfig() ExecConfig {
+	return ExecConfig{
+		Timeout:       10 * time.Second,
+		MaxConcurrent: 4,
+	}
+}
+
+// ExecError describes a command that failed, timed out or wrote to stderr.
+type ExecError struct {
+	// Command is the shell command line.
+	Command string
+	// Stderr is what the command wrote to stderr, truncated to 4 KiB.
+	Stderr string
+	// Err is the error the command failed with, or nil if it succeeded but
+	// wrote to stderr. It is context.DeadlineExceeded on timeouts.
+	Err error
+}
+
+// Error returns the command and the reason it is reported.
+func (e *ExecError) Error() string {
+	switch {
+	case e.Err != nil && e.Stderr != "":
+		return fmt.Sprintf("exec %q: %v: %s", e.Command, e.Err, e.Stderr)
+	case e.Err != nil:
+		return fmt.Sprintf("exec %q: %v", e.Command, e.Err)
+	default:
+		return fmt.Sprintf("exec %q: stderr: %s", e.Command, e.Stderr)
+	}
+}
+
+// Unwrap returns the underlying error.
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// ExecErrorHandler is called with an *ExecError for every command run that
+// fails or writes to stderr. It is called from the goroutine running the
+// command and must not block.
+type ExecErrorHandler func(err *ExecError)
+
+// execKey identifies an interval command. The same command shown at two
+// intervals is run on both schedules.
+type execKey struct {
+	command  string
+	interval time.Duration
+}
+
+// execJob is the state of an interval command.
+type execJob struct {
+	output       string    // Output of the last successful run
+	ok           bool      // Whether a run has succeeded
+	running      bool      // Whether a run is queued or in progress
+	nextRun      time.Time // When the command is next due
+	lastAccessed time.Time // When the output was last requested
+}
+
+// ExecScheduler runs shell commands with a timeout. Interval commands run
+// in the background with bounded concurrency, so a slow command never
+// blocks the update that displays it; their last output is shown until a
+// new run completes. Stop kills every running command.
+type ExecScheduler struct {
+	cfg    ExecConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[execKey]*execJob
+	onError ExecErrorHandler
+	check   func(command string) error
+	stopped bool
+}
+
+// NewExecScheduler creates an ExecScheduler. Call Stop to kill running
+// commands and release resources.
+func NewExecScheduler(cfg ExecConfig) *ExecScheduler {
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExecScheduler{
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, cfg.MaxConcurrent),
+		jobs:   make(map[execKey]*execJob),
+	}
+}
+
+// SetErrorHandler sets the function failed commands are reported to.
+func (s *ExecScheduler) SetErrorHandler(handler ExecErrorHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = handler
+}
+
+// SetCommandCheck sets a function that decides whether a command may run.
+// Commands it returns an error for are not run and fail with that error.
+func (s *ExecScheduler) SetCommandCheck(check func(command string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.check = check
+}
+
+// Run runs command synchronously and returns its output without trailing
+// newlines. It is used for commands that run on every update, such as
+// ${exec}; the timeout bounds how long they can hold the update up.
+func (s *ExecScheduler) Run(command string) (string, error) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return "", ErrExecStopped
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	defer s.wg.Done()
+	return s.run(command, nil)
+}
+
+// Start runs command in the background once a concurrency slot is free,
+// with env added to its environment, and discards its output. It is used
+// for commands triggered by events, such as alert exec commands, and
+// returns without waiting for the command to run.
+func (s *ExecScheduler) Start(command string, env ...string) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return ErrExecStopped
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		select {
+		case s.sem <- struct{}{}:
+		case <-s.ctx.Done():
+			return
+		}
+		_, _ = s.run(command, env)
+		<-s.sem
+	}()
+	return nil
+}
+
+// Output returns the last output of command run every interval, and
+// whether the command has completed a successful run yet. When the
+// command is due, a background run is started and the previous output is
+// returned; a failed run keeps the previous output.
+func (s *ExecScheduler) Output(command string, interval time.Duration) (string, bool) {
+	key := execKey{command: command, interval: interval}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[key]
+	if !exists {
+		job = &execJob{}
+		s.jobs[key] = job
+	}
+	job.lastAccessed = now
+
+	if !job.running && !s.stopped && !now.Before(job.nextRun) {
+		job.running = true
+		s.wg.Add(1)
+		go s.runJob(key, job)
+	}
+	return job.output, job.ok
+}
+
+// runJob runs an interval command once a concurrency slot is free and
+// records its output.
+func (s *ExecScheduler) runJob(key execKey, job *execJob) {
+	defer s.wg.Done()
+
+	select {
+	case s.sem <- struct{}{}:
+	case <-s.ctx.Done():
+		s.mu.Lock()
+		job.running = false
+		s.mu.Unlock()
+		return
+	}
+	start := time.Now()
+	output, err := s.run(key.command, nil)
+	<-s.sem
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		job.output = output
+		job.ok = true
+	}
+	job.running = false
+	job.nextRun = start.Add(key.interval)
+}
+
+// run runs command under the scheduler's context and timeout, with env
+// added to its environment, reporting failures and stderr output to the
+// error handler.
+func (s *ExecScheduler) run(command string, env []string) (string, error) {
+	s.mu.Lock()
+	check := s.check
+	s.mu.Unlock()
+	if check != nil {
+		if err := check(command); err != nil {
+			return "", err
+		}
+	}
+
+	ctx := s.ctx
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	configureCommand(cmd)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stdout bytes.Buffer
+	stderr := &limitedBuffer{limit: maxExecStderr}
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		// Report the timeout or Stop rather than "signal: killed"
+		err = ctxErr
+	}
+	if errors.Is(err, context.Canceled) {
+		// Stopped: nothing to report
+		return "", ErrExecStopped
+	}
+	if err != nil || stderr.Len() > 0 {
+		s.report(&ExecError{
+			Command: command,
+			Stderr:  strings.TrimSpace(stderr.String()),
+			Err:     err,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\n\r"), nil
+}
+
+// report passes err to the error handler, if any.
+func (s *ExecScheduler) report(err *ExecError) {
+	s.mu.Lock()
+	handler := s.onError
+	s.mu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// Cleanup removes interval commands whose output has not been requested
+// within maxAge and returns how many were removed. Running commands finish
+// but their output is discarded.
+func (s *ExecScheduler) Cleanup(maxAge time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, job := range s.jobs {
+		if now.Sub(job.lastAccessed) > maxAge {
+			delete(s.jobs, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of interval commands being scheduled.
+func (s *ExecScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Stop kills all running commands, waits for them to exit and stops
+// scheduling new runs. Outputs already recorded remain available. It is
+// safe to call Stop multiple times.
+func (s *ExecScheduler) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+}
+
+// limitedBuffer is an io.Writer that keeps the first limit bytes written
+// to it and discards the rest.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer. It never fails, so the command is not
+// disturbed by a full buffer.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// Len returns the number of bytes kept.
+func (b *limitedBuffer) Len() int {
+	return b.buf.Len()
+}
+
+// String returns the bytes kept.
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
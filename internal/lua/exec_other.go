@@ -0,0 +1,15 @@
+//go:build !unix
+// +build !unix
+
+package lua
+
+import (
+	"os/exec"
+	"time"
+)
+
+// configureCommand bounds how long cmd's output is waited for after it is
+// killed, in case processes it started still hold the pipes open.
+func configureCommand(cmd *exec.Cmd) {
+	cmd.WaitDelay = time.Second
+}
@@ -0,0 +1,293 @@
+package lua
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// waitForOutput polls s for the output of command until it has completed a
+// run or a second passes.
+func waitForOutput(s *ExecScheduler, command string, interval time.Duration) (string, bool) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		output, ok := s.Output(command, interval)
+		if ok || time.Now().After(deadline) {
+			return output, ok
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// errorRecorder collects the errors reported by an ExecScheduler.
+type errorRecorder struct {
+	mu     sync.Mutex
+	errors []*ExecError
+}
+
+func (r *errorRecorder) record(err *ExecError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+func (r *errorRecorder) all() []*ExecError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*ExecError(nil), r.errors...)
+}
+
+func TestExecSchedulerRun(t *testing.T) {
+	s := NewExecScheduler(DefaultExecConfig())
+	defer s.Stop()
+	var recorder errorRecorder
+	s.SetErrorHandler(recorder.record)
+
+	output, err := s.Run("printf 'a\\nb\\n\\n'")
+	if err != nil || output != "a\nb" {
+		t.Errorf("Run() = %q, %v, want %q", output, err, "a\nb")
+	}
+	if errs := recorder.all(); len(errs) != 0 {
+		t.Errorf("reported %v for a successful command", errs)
+	}
+
+	output, err = s.Run("echo out; echo warning >&2")
+	if err != nil || output != "out" {
+		t.Errorf("Run() = %q, %v, want %q", output, err, "out")
+	}
+	if _, err := s.Run("echo broken >&2; exit 3"); err == nil {
+		t.Error("Run() of a failing command returned no error")
+	}
+
+	errs := recorder.all()
+	if len(errs) != 2 {
+		t.Fatalf("reported %d errors, want 2", len(errs))
+	}
+	if errs[0].Err != nil || errs[0].Stderr != "warning" {
+		t.Errorf("stderr report = %+v, want stderr %q and no error", errs[0], "warning")
+	}
+	if errs[1].Err == nil || errs[1].Stderr != "broken" || !strings.Contains(errs[1].Error(), "exit status 3") {
+		t.Errorf("failure report = %v, want exit status 3 with stderr %q", errs[1], "broken")
+	}
+}
+
+func TestExecSchedulerTimeout(t *testing.T) {
+	s := NewExecScheduler(ExecConfig{Timeout: 100 * time.Millisecond, MaxConcurrent: 1})
+	defer s.Stop()
+	var recorder errorRecorder
+	s.SetErrorHandler(recorder.record)
+
+	// The shell's child keeps the output pipe open; it must be killed too
+	start := time.Now()
+	_, err := s.Run("sleep 10; echo late")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %v, want it killed after the timeout", elapsed)
+	}
+	if errs := recorder.all(); len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("reported %v, want one timeout", errs)
+	}
+}
+
+func TestExecSchedulerOutput(t *testing.T) {
+	s := NewExecScheduler(DefaultExecConfig())
+	defer s.Stop()
+
+	counter := filepath.Join(t.TempDir(), "runs")
+	command := "echo run >> " + counter + "; wc -l < " + counter
+
+	if output, ok := s.Output(command, time.Hour); ok || output != "" {
+		t.Errorf("first Output() = %q, %v, want empty until the command runs", output, ok)
+	}
+	output, ok := waitForOutput(s, command, time.Hour)
+	if !ok || strings.TrimSpace(output) != "1" {
+		t.Fatalf("Output() = %q, %v, want 1", output, ok)
+	}
+
+	// Not due again for an hour
+	time.Sleep(20 * time.Millisecond)
+	if output, _ := s.Output(command, time.Hour); strings.TrimSpace(output) != "1" {
+		t.Errorf("Output() before the interval = %q, want 1", output)
+	}
+
+	// A different interval is scheduled separately
+	if output, ok := waitForOutput(s, command, 0); !ok || strings.TrimSpace(output) != "2" {
+		t.Errorf("Output() at interval 0 = %q, %v, want 2", output, ok)
+	}
+	if n := s.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2", n)
+	}
+	if removed := s.Cleanup(0); removed != 2 || s.Len() != 0 {
+		t.Errorf("Cleanup(0) removed %d, left %d, want all removed", removed, s.Len())
+	}
+}
+
+func TestExecSchedulerKeepsOutputOnFailure(t *testing.T) {
+	s := NewExecScheduler(DefaultExecConfig())
+	defer s.Stop()
+
+	flag := filepath.Join(t.TempDir(), "fail")
+	command := "test -e " + flag + " && exit 1; echo good"
+	if output, ok := waitForOutput(s, command, 0); !ok || output != "good" {
+		t.Fatalf("Output() = %q, %v, want good", output, ok)
+	}
+	if err := os.WriteFile(flag, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Let several failing runs complete
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if output, _ := s.Output(command, 0); output != "good" {
+			t.Fatalf("Output() after a failed run = %q, want the previous output", output)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExecSchedulerConcurrencyBound(t *testing.T) {
+	s := NewExecScheduler(ExecConfig{Timeout: 5 * time.Second, MaxConcurrent: 1})
+	defer s.Stop()
+
+	log := filepath.Join(t.TempDir(), "log")
+	commands := make([]string, 3)
+	for i := range commands {
+		// Each command is distinct so each gets its own job
+		commands[i] = "echo start >> " + log + "; sleep 0.05; echo end >> " + log + "; echo " + string(rune('a'+i))
+		s.Output(commands[i], time.Hour)
+	}
+	for _, command := range commands {
+		if _, ok := waitForOutput(s, command, time.Hour); !ok {
+			t.Fatalf("command %q did not complete", command)
+		}
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Repeat("start\nend\n", len(commands))
+	if string(data) != want {
+		t.Errorf("runs overlapped with MaxConcurrent 1:\n%s", data)
+	}
+}
+
+func TestExecSchedulerStop(t *testing.T) {
+	s := NewExecScheduler(ExecConfig{Timeout: time.Minute, MaxConcurrent: 2})
+	var recorder errorRecorder
+	s.SetErrorHandler(recorder.record)
+
+	s.Output("echo done", time.Hour)
+	waitForOutput(s, "echo done", time.Hour)
+	s.Output("sleep 10", time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	s.Stop()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop() took %v, want running commands killed", elapsed)
+	}
+	s.Stop() // Safe to call again
+
+	if errs := recorder.all(); len(errs) != 0 {
+		t.Errorf("Stop() reported %v, want nothing", errs)
+	}
+	if _, err := s.Run("echo x"); !errors.Is(err, ErrExecStopped) {
+		t.Errorf("Run() after Stop() error = %v, want ErrExecStopped", err)
+	}
+	if output, ok := s.Output("echo done", time.Hour); !ok || output != "done" {
+		t.Errorf("Output() after Stop() = %q, %v, want the recorded output", output, ok)
+	}
+}
+
+func TestExecSchedulerStart(t *testing.T) {
+	s := NewExecScheduler(ExecConfig{Timeout: time.Minute, MaxConcurrent: 1})
+	out := filepath.Join(t.TempDir(), "out")
+
+	if err := s.Start(`printf '%s' "$ALERT" > `+out, "ALERT=disk full"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if data, _ := os.ReadFile(out); string(data) == "disk full" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command did not run with its environment")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A long command is killed by Stop, and later starts are refused
+	if err := s.Start("sleep 10"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	s.Stop()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop() took %v, want the command killed", elapsed)
+	}
+	if err := s.Start("true"); !errors.Is(err, ErrExecStopped) {
+		t.Errorf("Start() after Stop() error = %v, want ErrExecStopped", err)
+	}
+}
+
+func TestExecWidgetVariables(t *testing.T) {
+	api := newTestTemplateAPI(t)
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"${execbar echo 42}", render.EncodeBarMarker(42, 100, 8)},
+		{"${execbar 6,50 echo 120}", render.EncodeBarMarker(100, 50, 6)},
+		{"${execbar echo 37.5%}", render.EncodeBarMarker(37.5, 100, 8)},
+		{"${execbar echo none}", render.EncodeBarMarker(0, 100, 8)},
+		{"${execgauge 20 echo 30}", render.EncodeGaugeMarker(30, 20, 20)},
+		{"${execgauge echo -5}", render.EncodeGaugeMarker(0, 30, 30)},
+		{"${execgraph -t 10,80 echo 75}", render.EncodeGraphMarkerWithID(75, 80, 10, execGraphID("echo 75"))},
+		{"${execbar}", ""},
+		{"${execibar 60 echo 42}", render.EncodeBarMarker(42, 100, 8)},
+		{"${execigauge 60 echo 64}", render.EncodeGaugeMarker(64, 30, 30)},
+		{"${execigraph 60 5,50 echo 10}", render.EncodeGraphMarkerWithID(10, 50, 5, execGraphID("echo 10"))},
+		{"${execibar x echo 42}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := waitForParse(api, tt.template, tt.expected); got != tt.expected {
+				t.Errorf("Parse(%q) = %q, want %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitExecWidgetArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		size    int
+		command string
+	}{
+		{[]string{"echo", "1"}, 0, "echo 1"},
+		{[]string{"8", "echo", "1"}, 1, "echo 1"},
+		{[]string{"8,100", "echo", "1"}, 2, "echo 1"},
+		{[]string{"8,100,3", "echo", "1"}, 0, "8,100,3 echo 1"},
+		{[]string{"-l", "-t", "8,100", "cmd"}, 2, "cmd"},
+		{[]string{"42"}, 0, "42"},
+	}
+	for _, tt := range tests {
+		size, command := splitExecWidgetArgs(tt.args)
+		if len(size) != tt.size || command != tt.command {
+			t.Errorf("splitExecWidgetArgs(%q) = %v, %q, want %d sizes and %q", tt.args, size, command, tt.size, tt.command)
+		}
+	}
+}
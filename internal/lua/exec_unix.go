@@ -0,0 +1,21 @@
+//go:build unix
+// +build unix
+
+package lua
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// configureCommand runs cmd in its own process group and makes cancelling
+// it kill the whole group, so processes started by the shell do not
+// outlive a timeout.
+func configureCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+}
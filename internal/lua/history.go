@@ -0,0 +1,73 @@
+package lua
+
+import (
+	"fmt"
+	"strconv"
+
+	rt "github.com/arnodel/golua/runtime"
+)
+
+// conkyHistoryLua is the Lua-callable implementation of conky_history.
+// Usage: conky_history(id [, n]) returns a table of up to n of the most
+// recent values recorded for the metric, oldest first (all retained values
+// if n is omitted). Unknown metrics yield an empty table.
+func (api *ConkyAPI) conkyHistoryLua(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+	id, err := c.StringArg(0)
+	if err != nil {
+		return nil, fmt.Errorf("conky_history: %w", err)
+	}
+	var n int64
+	if c.NArgs() > 1 {
+		if n, err = c.IntArg(1); err != nil {
+			return nil, fmt.Errorf("conky_history: %w", err)
+		}
+	}
+
+	values := rt.NewTable()
+	api.mu.RLock()
+	provider := api.sysProvider
+	api.mu.RUnlock()
+	if provider != nil {
+		for i, s := range provider.History(id, int(n)) {
+			values.Set(rt.IntValue(int64(i+1)), rt.FloatValue(s.Value))
+		}
+	}
+	return c.PushingNext1(t.Runtime, rt.TableValue(values)), nil
+}
+
+// resolveHistoryStat returns the minimum, maximum or average of the recent
+// history of a metric.
+// Usage: ${history_min id [n]}, ${history_max id [n]}, ${history_avg id [n]}
+// where n limits the statistic to the n most recent samples.
+func (api *ConkyAPI) resolveHistoryStat(stat string, args []string) string {
+	if len(args) == 0 {
+		return "0"
+	}
+	n := 0
+	if len(args) > 1 {
+		if v, err := strconv.Atoi(args[1]); err == nil {
+			n = v
+		}
+	}
+
+	samples := api.sysProvider.History(args[0], n)
+	if len(samples) == 0 {
+		return "0"
+	}
+
+	result := samples[0].Value
+	sum := 0.0
+	for _, s := range samples {
+		switch stat {
+		case "min":
+			result = min(result, s.Value)
+		case "max":
+			result = max(result, s.Value)
+		}
+		sum += s.Value
+	}
+	if stat == "avg" {
+		result = sum / float64(len(samples))
+	}
+	return fmt.Sprintf("%.1f", result)
+}
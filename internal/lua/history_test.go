@@ -0,0 +1,90 @@
+package lua
+
+import (
+	"testing"
+	"time"
+
+	rt "github.com/arnodel/golua/runtime"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+func newHistoryTestAPI(t *testing.T) (*ConkyRuntime, *ConkyAPI) {
+	t.Helper()
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+
+	provider := newMockProvider()
+	now := time.Now()
+	provider.history = map[string][]monitor.Sample{
+		"cpu.total": {
+			{Time: now.Add(-3 * time.Second), Value: 10},
+			{Time: now.Add(-2 * time.Second), Value: 40},
+			{Time: now.Add(-time.Second), Value: 25},
+			{Time: now, Value: 5},
+		},
+	}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+	return runtime, api
+}
+
+func TestHistoryStatVariables(t *testing.T) {
+	_, api := newHistoryTestAPI(t)
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"${history_min cpu.total}", "5.0"},
+		{"${history_max cpu.total}", "40.0"},
+		{"${history_avg cpu.total}", "20.0"},
+		{"${history_max cpu.total 2}", "25.0"},
+		{"${history_avg cpu.total 2}", "15.0"},
+		{"${history_avg missing}", "0"},
+		{"${history_avg}", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := api.Parse(tt.template); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConkyHistoryLua(t *testing.T) {
+	runtime, _ := newHistoryTestAPI(t)
+
+	tests := []struct {
+		name string
+		code string
+		want float64
+	}{
+		{"all samples", `local h = conky_history("cpu.total"); return #h`, 4},
+		{"oldest first", `local h = conky_history("cpu.total"); return h[1]`, 10},
+		{"last n", `local h = conky_history("cpu.total", 2); return h[1] + h[2] * 100`, 525},
+		{"unknown metric", `return #conky_history("missing", 10)`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runtime.ExecuteString("history", tt.code)
+			if err != nil {
+				t.Fatalf("ExecuteString failed: %v", err)
+			}
+			got, ok := rt.ToFloat(result)
+			if !ok {
+				t.Fatalf("result %v is not a number", result)
+			}
+			if got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package lua
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	rt "github.com/arnodel/golua/runtime"
@@ -88,7 +89,8 @@ func ParseHookType(s string) (HookType, error) {
 // It provides thread-safe hook registration and invocation.
 type HookManager struct {
 	runtime *ConkyRuntime
-	hooks   map[HookType]string // Maps hook type to function name
+	hooks   map[HookType]string   // Maps hook type to function name
+	args    map[HookType][]string // Maps hook type to configured arguments
 	mu      sync.RWMutex
 }
 
@@ -101,6 +103,7 @@ func NewHookManager(runtime *ConkyRuntime) (*HookManager, error) {
 	return &HookManager{
 		runtime: runtime,
 		hooks:   make(map[HookType]string),
+		args:    make(map[HookType][]string),
 	}, nil
 }
 
@@ -126,6 +129,31 @@ func (hm *HookManager) RegisterHook(hookType HookType, funcName string) error {
 	}
 
 	hm.hooks[hookType] = funcName
+	delete(hm.args, hookType)
+	return nil
+}
+
+// RegisterHookSpec registers a hook from a configuration value such as
+// lua_draw_hook_pre, which has the form "function [arg1 arg2 ...]".
+// The "conky_" prefix on the function name is optional, matching Conky.
+// The arguments are passed to the function as strings on every call,
+// ahead of any arguments given to Call.
+func (hm *HookManager) RegisterHookSpec(hookType HookType, spec string) error {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty %s hook specification", hookType.String())
+	}
+
+	funcName := strings.TrimPrefix(fields[0], "conky_")
+	if err := hm.RegisterHook(hookType, funcName); err != nil {
+		return err
+	}
+
+	if len(fields) > 1 {
+		hm.mu.Lock()
+		hm.args[hookType] = fields[1:]
+		hm.mu.Unlock()
+	}
 	return nil
 }
 
@@ -135,6 +163,7 @@ func (hm *HookManager) UnregisterHook(hookType HookType) {
 	defer hm.mu.Unlock()
 
 	delete(hm.hooks, hookType)
+	delete(hm.args, hookType)
 }
 
 // IsRegistered returns true if a hook is registered for the given type.
@@ -161,12 +190,22 @@ func (hm *HookManager) GetRegisteredFunctionName(hookType HookType) string {
 func (hm *HookManager) Call(hookType HookType, args ...rt.Value) (rt.Value, error) {
 	hm.mu.RLock()
 	funcName, ok := hm.hooks[hookType]
+	configured := hm.args[hookType]
 	hm.mu.RUnlock()
 
 	if !ok {
 		return rt.NilValue, nil // No hook registered, not an error
 	}
 
+	// Configured arguments come first, as in Conky's hook settings
+	if len(configured) > 0 {
+		callArgs := make([]rt.Value, 0, len(configured)+len(args))
+		for _, arg := range configured {
+			callArgs = append(callArgs, rt.StringValue(arg))
+		}
+		args = append(callArgs, args...)
+	}
+
 	// Call the hook function using the runtime's CallFunction method
 	fullName := "conky_" + funcName
 	result, err := hm.runtime.CallFunction(fullName, args...)
@@ -225,6 +264,7 @@ func (hm *HookManager) AutoRegisterHooks() []HookType {
 	hm.mu.Lock()
 	for _, hookType := range foundHooks {
 		hm.hooks[hookType] = hookType.String()
+		delete(hm.args, hookType)
 	}
 	hm.mu.Unlock()
 
@@ -249,4 +289,5 @@ func (hm *HookManager) Clear() {
 	defer hm.mu.Unlock()
 
 	hm.hooks = make(map[HookType]string)
+	hm.args = make(map[HookType][]string)
 }
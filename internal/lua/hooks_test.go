@@ -672,3 +672,100 @@ func TestMultipleHookRegistrations(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterHookSpec(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	_, err = runtime.ExecuteString("setup", `
+		function conky_ring_stats(a, b)
+			return a .. "," .. b
+		end
+	`)
+	if err != nil {
+		t.Fatalf("failed to define Lua function: %v", err)
+	}
+
+	hm, err := NewHookManager(runtime)
+	if err != nil {
+		t.Fatalf("failed to create hook manager: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"without prefix", "ring_stats 4 cpu", "4,cpu"},
+		{"with prefix", "conky_ring_stats x y", "x,y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hm.RegisterHookSpec(HookDrawPre, tt.spec); err != nil {
+				t.Fatalf("RegisterHookSpec(%q) failed: %v", tt.spec, err)
+			}
+			if name := hm.GetRegisteredFunctionName(HookDrawPre); name != "ring_stats" {
+				t.Errorf("registered function = %q, want %q", name, "ring_stats")
+			}
+
+			result, err := hm.Call(HookDrawPre)
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			if got, _ := result.TryString(); got != tt.want {
+				t.Errorf("Call returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Configured arguments precede call arguments
+	if err := hm.RegisterHookSpec(HookDrawPost, "ring_stats first"); err != nil {
+		t.Fatalf("RegisterHookSpec failed: %v", err)
+	}
+	result, err := hm.Call(HookDrawPost, rt.StringValue("second"))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got, _ := result.TryString(); got != "first,second" {
+		t.Errorf("Call returned %q, want %q", got, "first,second")
+	}
+
+	// Re-registering without arguments drops the old ones
+	if err := hm.RegisterHook(HookDrawPost, "ring_stats"); err != nil {
+		t.Fatalf("RegisterHook failed: %v", err)
+	}
+	result, err = hm.Call(HookDrawPost, rt.StringValue("a"), rt.StringValue("b"))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got, _ := result.TryString(); got != "a,b" {
+		t.Errorf("Call returned %q, want %q", got, "a,b")
+	}
+}
+
+func TestRegisterHookSpecInvalid(t *testing.T) {
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	hm, err := NewHookManager(runtime)
+	if err != nil {
+		t.Fatalf("failed to create hook manager: %v", err)
+	}
+
+	if err := hm.RegisterHookSpec(HookStartup, "   "); err == nil {
+		t.Error("expected error for empty specification")
+	}
+	if err := hm.RegisterHookSpec(HookStartup, "missing 1 2"); err == nil {
+		t.Error("expected error for undefined function")
+	}
+	if hm.IsRegistered(HookStartup) {
+		t.Error("hook should not be registered after failure")
+	}
+}
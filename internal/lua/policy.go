@@ -0,0 +1,334 @@
+// Package lua provides Golua integration for conky-go.
+// This file implements the policy restricting the commands and files a
+// configuration can use, and its enforcement in the Lua standard library.
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	rt "github.com/arnodel/golua/runtime"
+
+	"github.com/opd-ai/go-conky/internal/config"
+)
+
+// ErrBlocked is wrapped by the errors of operations blocked by a Policy.
+var ErrBlocked = errors.New("blocked by policy")
+
+// ExecMode selects which shell commands a Policy allows.
+type ExecMode int
+
+const (
+	// ExecAllowAll allows every command.
+	ExecAllowAll ExecMode = iota
+	// ExecDenyAll allows no commands.
+	ExecDenyAll
+	// ExecAllowListed allows only the commands in Policy.AllowedCommands.
+	ExecAllowListed
+)
+
+// Policy restricts the commands and files available to a configuration
+// through ${exec} and related variables, ${image}, ${if_existing}, local
+// mailboxes, ${pid_*}, Cairo PNG files, lua_load scripts and the Lua io,
+// os and package libraries. It does not restrict network access. The zero
+// Policy allows everything.
+type Policy struct {
+	// Exec selects which shell commands may run.
+	Exec ExecMode
+	// AllowedCommands lists the commands ExecAllowListed allows. An entry
+	// matches a command line exactly, or the program of a command line
+	// without shell syntax such as pipes, redirections or quotes: "date"
+	// allows "date +%H:%M" but not "date; rm x".
+	AllowedCommands []string
+	// RestrictFiles limits file access to reading files under ReadRoots.
+	// No file can be written.
+	RestrictFiles bool
+	// ReadRoots lists the directories files may be read from when
+	// RestrictFiles is set.
+	ReadRoots []string
+}
+
+// restricted reports whether the policy restricts anything.
+func (p Policy) restricted() bool {
+	return p.Exec != ExecAllowAll || p.RestrictFiles
+}
+
+// AllowsCommand reports whether the policy allows the shell command line.
+func (p Policy) AllowsCommand(command string) bool {
+	switch p.Exec {
+	case ExecAllowAll:
+		return true
+	case ExecAllowListed:
+		command = strings.TrimSpace(command)
+		if slices.Contains(p.AllowedCommands, command) {
+			return true
+		}
+		if strings.ContainsAny(command, shellSyntax) {
+			return false
+		}
+		fields := strings.Fields(command)
+		return len(fields) > 0 && slices.Contains(p.AllowedCommands, fields[0])
+	default:
+		return false
+	}
+}
+
+// shellSyntax holds the characters that make a command line more than a
+// program and plain arguments.
+const shellSyntax = "`$&|;<>(){}[]*?~!#\\\"'\n"
+
+// PolicyError is the error of an operation blocked by a Policy.
+type PolicyError struct {
+	// Op is "exec", "read", "write" or "env".
+	Op string
+	// Target is the command line or path.
+	Target string
+}
+
+// Error returns the operation and its target.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Op, e.Target, ErrBlocked)
+}
+
+// Unwrap returns ErrBlocked.
+func (e *PolicyError) Unwrap() error {
+	return ErrBlocked
+}
+
+// PolicyHandler is called with every operation a Policy blocks. It may be
+// called from any goroutine, including while Lua code runs, and must not
+// call back into the runtime.
+type PolicyHandler func(err *PolicyError)
+
+// SetPolicyHandler sets the function blocked operations are reported to.
+func (cr *ConkyRuntime) SetPolicyHandler(handler PolicyHandler) {
+	cr.policyMu.Lock()
+	defer cr.policyMu.Unlock()
+	cr.onBlocked = handler
+}
+
+// CheckExec returns a *PolicyError if the policy does not allow the shell
+// command line.
+func (cr *ConkyRuntime) CheckExec(command string) error {
+	if cr.config.Policy.AllowsCommand(command) {
+		return nil
+	}
+	return cr.blocked("exec", command)
+}
+
+// CheckRead returns a *PolicyError if the policy does not allow reading
+// path. Symbolic links are resolved, so a link under a read root cannot
+// point outside it.
+func (cr *ConkyRuntime) CheckRead(path string) error {
+	if !cr.config.Policy.RestrictFiles {
+		return nil
+	}
+	resolved := resolvePolicyPath(path)
+	for _, root := range cr.readRoots {
+		if rel, err := filepath.Rel(root, resolved); err == nil && filepath.IsLocal(rel) {
+			return nil
+		}
+	}
+	return cr.blocked("read", path)
+}
+
+// CheckEnv returns a *PolicyError if the policy does not allow reading the
+// environment variable name. A policy that restricts anything allows none,
+// since the environment commonly holds credentials.
+func (cr *ConkyRuntime) CheckEnv(name string) error {
+	if !cr.config.Policy.restricted() {
+		return nil
+	}
+	return cr.blocked("env", name)
+}
+
+// SecretPolicy returns the policy for resolving env: and file: secrets,
+// which applies CheckEnv and CheckRead.
+func (cr *ConkyRuntime) SecretPolicy() config.SecretPolicy {
+	return config.SecretPolicy{CheckEnv: cr.CheckEnv, CheckRead: cr.CheckRead}
+}
+
+// CheckWrite returns a *PolicyError if the policy does not allow writing
+// path.
+func (cr *ConkyRuntime) CheckWrite(path string) error {
+	if !cr.config.Policy.RestrictFiles {
+		return nil
+	}
+	return cr.blocked("write", path)
+}
+
+// blocked reports a blocked operation and returns its error.
+func (cr *ConkyRuntime) blocked(op, target string) error {
+	err := &PolicyError{Op: op, Target: target}
+	cr.policyMu.RLock()
+	handler := cr.onBlocked
+	cr.policyMu.RUnlock()
+	if handler != nil {
+		handler(err)
+	}
+	return err
+}
+
+// resolvePolicyPath returns the absolute form of path with symbolic links
+// resolved where it exists.
+func resolvePolicyPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// applyPolicy guards the Lua standard library functions that run commands,
+// use files or read the environment with the runtime's policy. golib and
+// debug are removed, since importing Go packages or reaching into other
+// functions would bypass the policy entirely, and so is os.exit, which
+// would end the host application.
+func (cr *ConkyRuntime) applyPolicy() {
+	if !cr.config.Policy.restricted() {
+		return
+	}
+	env := cr.runtime.GlobalEnv()
+	for _, name := range []string{"golib", "debug"} {
+		env.Set(rt.StringValue(name), rt.NilValue)
+	}
+
+	readArg := func(args []rt.Value) error {
+		if path, ok := stringArg(args, 0); ok {
+			return cr.CheckRead(path)
+		}
+		return nil
+	}
+	writeArg := func(args []rt.Value) error {
+		if path, ok := stringArg(args, 0); ok {
+			return cr.CheckWrite(path)
+		}
+		return nil
+	}
+
+	if io, ok := env.Get(rt.StringValue("io")).TryTable(); ok {
+		guardFunction(io, "open", true, func(args []rt.Value) error {
+			path, _ := stringArg(args, 0)
+			if mode, _ := stringArg(args, 1); strings.ContainsAny(mode, "wa+") {
+				return cr.CheckWrite(path)
+			}
+			return cr.CheckRead(path)
+		})
+		guardFunction(io, "popen", true, func(args []rt.Value) error {
+			command, _ := stringArg(args, 0)
+			return cr.CheckExec(command)
+		})
+		guardFunction(io, "lines", false, readArg)
+		guardFunction(io, "input", false, readArg)
+		guardFunction(io, "output", false, writeArg)
+		guardFunction(io, "tmpfile", false, func([]rt.Value) error {
+			return cr.CheckWrite("tmpfile")
+		})
+	}
+	if os, ok := env.Get(rt.StringValue("os")).TryTable(); ok {
+		os.Set(rt.StringValue("exit"), rt.NilValue)
+		guardFunction(os, "getenv", true, func(args []rt.Value) error {
+			name, _ := stringArg(args, 0)
+			return cr.CheckEnv(name)
+		})
+		guardFunction(os, "remove", false, writeArg)
+		guardFunction(os, "rename", false, writeArg)
+		guardFunction(os, "tmpname", false, func([]rt.Value) error {
+			return cr.CheckWrite("tmpname")
+		})
+	}
+	guardFunction(env, "dofile", false, readArg)
+	guardFunction(env, "loadfile", false, readArg)
+
+	// The Lua module searcher returns a loader and the path it found, and
+	// require returns the removed libraries from package.loaded
+	if pkg, ok := env.Get(rt.StringValue("package")).TryTable(); ok {
+		if loaded, ok := pkg.Get(rt.StringValue("loaded")).TryTable(); ok {
+			for _, name := range []string{"golib", "debug"} {
+				loaded.Set(rt.StringValue(name), rt.NilValue)
+			}
+		}
+		if searchers, ok := pkg.Get(rt.StringValue("searchers")).TryTable(); ok {
+			guardResults(searchers, rt.IntValue(2), func(results []rt.Value) error {
+				if path, ok := stringArg(results, 1); ok {
+					return cr.CheckRead(path)
+				}
+				return nil
+			})
+		}
+	}
+}
+
+// guardFunction replaces the function name in table with one that calls
+// check with the arguments first. When check fails the call raises the
+// error, or returns nil and the message if soft is set, as io.open does
+// for files it cannot open.
+func guardFunction(table *rt.Table, name string, soft bool, check func(args []rt.Value) error) {
+	key := rt.StringValue(name)
+	original := table.Get(key)
+	if original.IsNil() {
+		return
+	}
+	guarded := rt.NewGoFunction(func(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+		args := getAllArgs(c)
+		if err := check(args); err != nil {
+			if soft {
+				return c.PushingNext(t.Runtime, rt.NilValue, rt.StringValue(err.Error())), nil
+			}
+			return nil, err
+		}
+		return callThrough(t, c, original, args)
+	}, name, 0, true)
+	declareGuardCompliance(guarded)
+	table.Set(key, rt.FunctionValue(guarded))
+}
+
+// guardResults replaces the function at key in table with one that calls
+// check with the function's results before returning them.
+func guardResults(table *rt.Table, key rt.Value, check func(results []rt.Value) error) {
+	original := table.Get(key)
+	if original.IsNil() {
+		return
+	}
+	guarded := rt.NewGoFunction(func(t *rt.Thread, c *rt.GoCont) (rt.Cont, error) {
+		results := rt.NewTerminationWith(c, 0, true)
+		if err := rt.Call(t, original, getAllArgs(c), results); err != nil {
+			return nil, err
+		}
+		if err := check(results.Etc()); err != nil {
+			return nil, err
+		}
+		return c.PushingNext(t.Runtime, results.Etc()...), nil
+	}, "guarded", 0, true)
+	declareGuardCompliance(guarded)
+	table.Set(key, rt.FunctionValue(guarded))
+}
+
+// declareGuardCompliance declares every compliance flag for a guard, so it
+// can run under resource limits. The guarded function is still checked
+// against the limits' required flags when the guard calls it.
+func declareGuardCompliance(guard *rt.GoFunction) {
+	guard.SolemnlyDeclareCompliance(rt.ComplyCpuSafe | rt.ComplyMemSafe | rt.ComplyTimeSafe | rt.ComplyIoSafe)
+}
+
+// callThrough calls fn with args and returns all its results from c.
+func callThrough(t *rt.Thread, c *rt.GoCont, fn rt.Value, args []rt.Value) (rt.Cont, error) {
+	results := rt.NewTerminationWith(c, 0, true)
+	if err := rt.Call(t, fn, args, results); err != nil {
+		return nil, err
+	}
+	return c.PushingNext(t.Runtime, results.Etc()...), nil
+}
+
+// stringArg returns args[i] if it is a string.
+func stringArg(args []rt.Value, i int) (string, bool) {
+	if i >= len(args) {
+		return "", false
+	}
+	return args[i].TryString()
+}
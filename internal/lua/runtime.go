@@ -32,6 +32,9 @@ type RuntimeConfig struct {
 	// Stdout is the writer for Lua print output.
 	// If nil, os.Stdout is used.
 	Stdout io.Writer
+	// Policy restricts the commands and files Lua code and Conky variables
+	// can use. The zero Policy allows everything.
+	Policy Policy
 }
 
 // DefaultConfig returns a RuntimeConfig with sensible default values.
@@ -55,10 +58,18 @@ type ConkyRuntime struct {
 	fsys    fs.FS // Optional embedded filesystem for require() support
 	closed  bool  // Tracks if Close() has been called
 	mu      sync.RWMutex
+
+	// Policy enforcement. Checks run while Lua code holds mu, so the
+	// handler has its own lock.
+	readRoots []string // Policy.ReadRoots with symbolic links resolved
+	policyMu  sync.RWMutex
+	onBlocked PolicyHandler
 }
 
 // New creates a new ConkyRuntime with the specified configuration.
-// The runtime is initialized with Lua standard libraries and resource limits.
+// The runtime is initialized with Lua standard libraries and resource limits,
+// and the library functions that run commands or use files are guarded by
+// the configured Policy.
 func New(config RuntimeConfig) (*ConkyRuntime, error) {
 	output := &bytes.Buffer{}
 	stdout := config.Stdout
@@ -80,6 +91,10 @@ func New(config RuntimeConfig) (*ConkyRuntime, error) {
 		output:  output,
 		cleanup: cleanup,
 	}
+	for _, root := range config.Policy.ReadRoots {
+		cr.readRoots = append(cr.readRoots, resolvePolicyPath(root))
+	}
+	cr.applyPolicy()
 
 	return cr, nil
 }
@@ -102,9 +117,12 @@ func (cr *ConkyRuntime) LoadString(name, code string) (*rt.Closure, error) {
 	return closure, nil
 }
 
-// LoadFile reads and loads a Lua file from disk.
-// The returned Closure can be executed using Execute.
+// LoadFile reads and loads a Lua file from disk, if the policy allows
+// reading it. The returned Closure can be executed using Execute.
 func (cr *ConkyRuntime) LoadFile(path string) (*rt.Closure, error) {
+	if err := cr.CheckRead(path); err != nil {
+		return nil, err
+	}
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Lua file %s: %w", path, err)
@@ -159,7 +177,8 @@ func (cr *ConkyRuntime) SetFS(fsys fs.FS) {
 }
 
 // Execute runs a compiled Lua closure within resource limits.
-// Returns the result value and any error that occurred.
+// Returns the result value and any error that occurred, including
+// exceeding the CPU or memory limit.
 func (cr *ConkyRuntime) Execute(closure *rt.Closure) (rt.Value, error) {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
@@ -172,13 +191,15 @@ func (cr *ConkyRuntime) Execute(closure *rt.Closure) (rt.Value, error) {
 		},
 	}
 
-	// Push the context before execution
-	cr.runtime.PushContext(ctx)
-	defer cr.runtime.PopContext()
-
-	// Execute the closure
+	// Execute the closure. CallContext turns a limit being exceeded into
+	// an error instead of a panic.
 	thread := cr.runtime.MainThread()
-	result, err := rt.Call1(thread, rt.FunctionValue(closure))
+	var result rt.Value
+	_, err := thread.CallContext(ctx, func() error {
+		var callErr error
+		result, callErr = rt.Call1(thread, rt.FunctionValue(closure))
+		return callErr
+	})
 	if err != nil {
 		return rt.NilValue, fmt.Errorf("Lua execution error: %w", err)
 	}
@@ -236,7 +257,8 @@ func (cr *ConkyRuntime) SetGoFunction(name string, fn rt.GoFunctionFunc, nArgs i
 }
 
 // CallFunction calls a Lua function by name with the given arguments.
-// Returns the result value and any error that occurred.
+// Returns the result value and any error that occurred, including
+// exceeding the CPU or memory limit.
 func (cr *ConkyRuntime) CallFunction(name string, args ...rt.Value) (rt.Value, error) {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
@@ -254,11 +276,13 @@ func (cr *ConkyRuntime) CallFunction(name string, args ...rt.Value) (rt.Value, e
 		},
 	}
 
-	cr.runtime.PushContext(ctx)
-	defer cr.runtime.PopContext()
-
 	thread := cr.runtime.MainThread()
-	result, err := rt.Call1(thread, fn, args...)
+	var result rt.Value
+	_, err := thread.CallContext(ctx, func() error {
+		var callErr error
+		result, callErr = rt.Call1(thread, fn, args...)
+		return callErr
+	})
 	if err != nil {
 		return rt.NilValue, fmt.Errorf("failed to call function %s: %w", name, err)
 	}
@@ -497,7 +497,6 @@ func TestResourceLimits(t *testing.T) {
 	defer runtime.Close()
 
 	// This should hit CPU limits with a tight loop
-	// golua panics when limits are exceeded, so we need to recover
 	code := `
 		local sum = 0
 		for i = 1, 100000 do
@@ -506,16 +505,44 @@ func TestResourceLimits(t *testing.T) {
 		return sum
 	`
 
-	// Expect the execution to panic due to CPU limit
-	defer func() {
-		if r := recover(); r == nil {
-			t.Error("expected panic due to CPU limit, but got none")
-		} else {
-			t.Logf("Caught expected panic: %v", r)
-		}
-	}()
+	// Exceeding the limit is reported as an error rather than a panic
+	_, err = runtime.ExecuteString("heavy", code)
+	var termErr rt.ContextTerminationError
+	if !errors.As(err, &termErr) {
+		t.Fatalf("expected CPU limit error, got %v", err)
+	}
+
+	// The runtime remains usable after a terminated execution
+	result, err := runtime.ExecuteString("light", "return 1 + 1")
+	if err != nil {
+		t.Fatalf("runtime unusable after limit error: %v", err)
+	}
+	if got, ok := rt.ToInt(result); !ok || got != 2 {
+		t.Errorf("expected 2, got %v", result)
+	}
+}
+
+func TestCallFunctionResourceLimits(t *testing.T) {
+	config := RuntimeConfig{
+		CPULimit:    10000,
+		MemoryLimit: 1 * 1024 * 1024,
+	}
 
-	_, _ = runtime.ExecuteString("heavy", code)
+	runtime, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	if _, err := runtime.ExecuteString("setup", "function spin() while true do end end"); err != nil {
+		t.Fatalf("failed to define function: %v", err)
+	}
+
+	_, err = runtime.CallFunction("spin")
+	var termErr rt.ContextTerminationError
+	if !errors.As(err, &termErr) {
+		t.Fatalf("expected CPU limit error, got %v", err)
+	}
 }
 
 func TestLoadFileFromFS(t *testing.T) {
@@ -0,0 +1,603 @@
+// Package lua provides Golua integration for conky-go.
+// This file implements the compiler that turns conky.text into a tree of
+// nodes once, so that each update only evaluates the variables.
+package lua
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// nodeKind identifies what a templateNode produces.
+type nodeKind uint8
+
+const (
+	textNode         nodeKind = iota // Literal text
+	variableNode                     // ${name args} or $name
+	conditionalNode                  // ${if_* args}...${else}...${endif}
+	templateCallNode                 // ${template0 args} to ${template9 args}
+	widgetNode                       // Variables drawn as bars, graphs or layout markers
+)
+
+// maxTemplateDepth bounds how deeply ${templateN} calls may nest, so that
+// a template referring to itself cannot recurse forever.
+const maxTemplateDepth = 8
+
+// maxCompiledTemplates bounds the cache of templates compiled by Parse.
+const maxCompiledTemplates = 256
+
+// templateNode is a node of a compiled template.
+type templateNode struct {
+	kind nodeKind
+	// text is the literal text of a text node, the variable name of the
+	// other kinds, or the output of a static widget.
+	text string
+	// source is the node as written, such as "${cpu 0}" or "$cpu".
+	source string
+	// bare is set for the $name form.
+	bare bool
+	// static is set for widgets whose output is text, computed at compile time.
+	static bool
+	args   templateArgs
+	// then and otherwise are the branches of a conditional node.
+	then, otherwise []templateNode
+	// index is the template number of a template-call node.
+	index int
+	// body caches the expansion of a template-call node with static arguments.
+	body *atomic.Pointer[templateExpansion]
+}
+
+// templateArgs holds the arguments of a variable.
+type templateArgs struct {
+	// static is the split arguments when they contain no variables.
+	static []string
+	// parts are the nodes of arguments containing variables, evaluated and
+	// split on every update.
+	parts []templateNode
+	// quoted keeps quotes in the split arguments for variables that
+	// interpret them themselves.
+	quoted bool
+}
+
+// templateExpansion is a ${templateN} body with its arguments substituted.
+type templateExpansion struct {
+	definition string
+	template   *Template
+}
+
+// literalArgVariables take their arguments as written: shell commands may
+// contain $, braces and quotes meant for the shell.
+var literalArgVariables = map[string]bool{
+	"exec":       true,
+	"execp":      true,
+	"execi":      true,
+	"execpi":     true,
+	"texeci":     true,
+	"pre_exec":   true,
+	"execbar":    true,
+	"execgauge":  true,
+	"execgraph":  true,
+	"execibar":   true,
+	"execigauge": true,
+	"execigraph": true,
+}
+
+// sideEffectVariables run commands, fetch URLs or call Lua functions when
+// evaluated, so Template.Preview leaves them empty.
+var sideEffectVariables = map[string]bool{
+	"exec": true, "execp": true, "execi": true, "execpi": true, "texeci": true,
+	"pre_exec": true, "execbar": true, "execgauge": true, "execgraph": true,
+	"execibar": true, "execigauge": true, "execigraph": true,
+	"curl": true, "rss": true, "weather": true, "weather_forecast": true,
+	"lua": true, "lua_parse": true,
+}
+
+// quotedArgVariables evaluate variables in their arguments but keep the
+// quotes, which distinguish strings from numbers in comparisons.
+var quotedArgVariables = map[string]bool{
+	"if_match": true,
+}
+
+// staticWidgetVariables emit layout markers that depend only on their
+// arguments, so they are evaluated once when compiled.
+var staticWidgetVariables = map[string]bool{
+	"color": true, "color0": true, "color1": true, "color2": true, "color3": true,
+	"color4": true, "color5": true, "color6": true, "color7": true, "color8": true,
+	"color9": true, "font": true, "alignr": true, "alignc": true, "voffset": true,
+	"offset": true, "goto": true, "tab": true,
+}
+
+// isWidgetVariable reports whether a variable is drawn rather than printed.
+func isWidgetVariable(name string) bool {
+	return staticWidgetVariables[name] || name == "image" ||
+		strings.HasSuffix(name, "bar") || strings.HasSuffix(name, "gauge") ||
+		strings.HasSuffix(name, "graph")
+}
+
+// Template is a compiled Conky template. It is safe for concurrent use.
+type Template struct {
+	api    *ConkyAPI
+	source string
+	nodes  []templateNode
+	size   atomic.Int64 // Length of the last output, to size the next
+}
+
+// Compile compiles a Conky template such as conky.text. Syntax errors are
+// not fatal: text that does not form a variable, such as an unclosed "${",
+// is kept as written, as are ${else} and ${endif} outside conditionals.
+//
+// Supported syntax:
+//   - ${variable args} and $variable
+//   - variables nested in arguments, e.g. ${addr ${gw_iface}}
+//   - "quoted arguments" and backslash-escaped spaces
+//   - $$ for a literal $
+//   - ${if_*}...${else}...${endif} blocks, which may span lines and nest
+//   - ${template0} to ${template9} calls
+func (api *ConkyAPI) Compile(text string) *Template {
+	p := &templateParser{src: text}
+	nodes, _ := p.parseNodes(false, false)
+	api.noteUpdateNr(p.maxUpdateNr)
+	return &Template{api: api, source: text, nodes: nodes}
+}
+
+// String returns the source of the template.
+func (t *Template) String() string {
+	return t.source
+}
+
+// Execute evaluates the template with the current system data.
+func (t *Template) Execute() string {
+	return t.execute(0, false)
+}
+
+// Preview evaluates the template like Execute but leaves variables with
+// side effects empty, so that no command is run or scheduled, no URL is
+// fetched and no Lua function is called. It is used to show text before
+// the first update.
+func (t *Template) Preview() string {
+	return t.execute(0, true)
+}
+
+// execute evaluates the template within depth nested template calls,
+// skipping variables with side effects if preview is set.
+func (t *Template) execute(depth int, preview bool) string {
+	var b strings.Builder
+	b.Grow(int(t.size.Load()))
+	t.api.executeNodes(&b, t.nodes, depth, preview)
+	t.size.Store(int64(b.Len()))
+	return b.String()
+}
+
+// compileCached returns the compiled form of text, compiling it on first
+// use. Parse uses it for templates such as conky_parse() arguments that are
+// evaluated repeatedly.
+func (api *ConkyAPI) compileCached(text string) *Template {
+	api.compileMu.Lock()
+	defer api.compileMu.Unlock()
+
+	if tmpl, ok := api.compiled[text]; ok {
+		return tmpl
+	}
+	if len(api.compiled) >= maxCompiledTemplates {
+		clear(api.compiled)
+	}
+	tmpl := api.Compile(text)
+	api.compiled[text] = tmpl
+	return tmpl
+}
+
+// executeNodes writes the output of nodes to b.
+func (api *ConkyAPI) executeNodes(b *strings.Builder, nodes []templateNode, depth int, preview bool) {
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.kind {
+		case textNode:
+			b.WriteString(n.text)
+		case variableNode, widgetNode:
+			if n.static {
+				b.WriteString(n.text)
+				continue
+			}
+			if preview && sideEffectVariables[n.text] {
+				continue
+			}
+			out := api.resolveVariable(n.text, api.evaluateArgs(&n.args, depth, preview))
+			if n.bare && isUnknownOutput(out, n.text) {
+				// Keep text such as "$5" that only looks like a variable
+				out = n.source
+			}
+			b.WriteString(out)
+		case conditionalNode:
+			if api.evaluateCondition(n.text, api.evaluateArgs(&n.args, depth, preview)) {
+				api.executeNodes(b, n.then, depth, preview)
+			} else {
+				api.executeNodes(b, n.otherwise, depth, preview)
+			}
+		case templateCallNode:
+			if depth >= maxTemplateDepth {
+				continue
+			}
+			if body := api.expandTemplate(n, depth, preview); body != nil {
+				api.executeNodes(b, body.nodes, depth+1, preview)
+			}
+		}
+	}
+}
+
+// evaluateArgs returns the arguments of a node, evaluating any variables in
+// them.
+func (api *ConkyAPI) evaluateArgs(args *templateArgs, depth int, preview bool) []string {
+	if args.parts == nil {
+		return args.static
+	}
+	var b strings.Builder
+	api.executeNodes(&b, args.parts, depth, preview)
+	if args.quoted {
+		return splitQuotedArgs(b.String())
+	}
+	return splitArgs(b.String())
+}
+
+// expandTemplate returns the compiled body of a ${templateN} call with its
+// arguments substituted for \1, \2 and so on, or nil if the template is
+// not defined.
+func (api *ConkyAPI) expandTemplate(n *templateNode, depth int, preview bool) *Template {
+	definition := api.GetTemplate(n.index)
+	if definition == "" {
+		return nil
+	}
+	if n.args.parts != nil {
+		return api.compileCached(substituteTemplateArgs(definition, api.evaluateArgs(&n.args, depth, preview)))
+	}
+
+	// Static arguments expand the same way until the definition changes
+	if cached := n.body.Load(); cached != nil && cached.definition == definition {
+		return cached.template
+	}
+	tmpl := api.Compile(substituteTemplateArgs(definition, n.args.static))
+	n.body.Store(&templateExpansion{definition: definition, template: tmpl})
+	return tmpl
+}
+
+// substituteTemplateArgs replaces \1 to \9 in a template definition with
+// the corresponding arguments. Placeholders without an argument are kept.
+func substituteTemplateArgs(definition string, args []string) string {
+	if len(args) == 0 || !strings.Contains(definition, `\`) {
+		return definition
+	}
+	var b strings.Builder
+	for i := 0; i < len(definition); i++ {
+		c := definition[i]
+		if c == '\\' && i+1 < len(definition) && definition[i+1] >= '1' && definition[i+1] <= '9' {
+			if n := int(definition[i+1] - '1'); n < len(args) {
+				b.WriteString(args[n])
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isUnknownOutput reports whether out is how resolveVariable prints an
+// unknown variable called name without arguments.
+func isUnknownOutput(out, name string) bool {
+	return len(out) == len(name)+3 && out[:2] == "${" && out[2:len(out)-1] == name && out[len(out)-1] == '}'
+}
+
+// splitArgs splits arguments on whitespace. Double quotes group words into
+// one argument and are removed; a backslash escapes a space or a quote.
+func splitArgs(s string) []string {
+	if !strings.ContainsAny(s, `"\`) {
+		return strings.Fields(s)
+	}
+
+	var args []string
+	var arg strings.Builder
+	inArg, inQuotes := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '"'):
+			i++
+			arg.WriteByte(s[i])
+			inArg = true
+		case c == '"':
+			inQuotes = !inQuotes
+			inArg = true
+		case !inQuotes && (c == ' ' || c == '\t' || c == '\n'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// splitQuotedArgs splits arguments on whitespace outside double quotes.
+// Unlike splitArgs it keeps the quotes, so that a quoted operand with
+// spaces stays one argument that is still compared as a string.
+func splitQuotedArgs(s string) []string {
+	if !strings.Contains(s, `"`) {
+		return strings.Fields(s)
+	}
+
+	var args []string
+	start, inQuotes := -1, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !inQuotes && (c == ' ' || c == '\t' || c == '\n') {
+			if start >= 0 {
+				args = append(args, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		args = append(args, s[start:])
+	}
+	return args
+}
+
+// templateParser compiles template source into nodes.
+type templateParser struct {
+	src string
+	pos int
+	// maxUpdateNr is the highest n of the ${if_updatenr n} conditionals
+	// parsed, which sets how many updates the conditionals cycle through.
+	maxUpdateNr int64
+}
+
+// Terminators returned by parseNodes.
+const (
+	termEOF   = ""
+	termElse  = "else"
+	termEndif = "endif"
+)
+
+// parseNodes parses nodes up to the end of the source or, inside a
+// conditional, up to the ${else} (if inElse is false) or ${endif} that
+// closes it, which is returned as the terminator.
+func (p *templateParser) parseNodes(inConditional, inElse bool) ([]templateNode, string) {
+	var nodes []templateNode
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, templateNode{kind: textNode, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for {
+		name, args, source, ok := p.next(&text)
+		if !ok {
+			break
+		}
+		bare := source[1] != '{'
+
+		switch {
+		case name == "else" && inConditional && !inElse:
+			flush()
+			return nodes, termElse
+		case name == "endif" && inConditional:
+			flush()
+			return nodes, termEndif
+		case name == "else" || name == "endif":
+			// Outside a conditional, or a second ${else}
+			text.WriteString(source)
+			continue
+		}
+
+		flush()
+		if strings.HasPrefix(name, "if_") {
+			nodes = append(nodes, p.parseConditional(name, args, source, bare)...)
+			continue
+		}
+		nodes = append(nodes, newVariableNode(name, args, source, bare))
+	}
+
+	flush()
+	return nodes, termEOF
+}
+
+// parseArgNodes parses the arguments of a variable. Arguments hold no
+// conditional blocks, so ${if_*}, ${else} and ${endif} are plain variables.
+func (p *templateParser) parseArgNodes() []templateNode {
+	var nodes []templateNode
+	var text strings.Builder
+	for {
+		name, args, source, ok := p.next(&text)
+		if !ok {
+			break
+		}
+		if text.Len() > 0 {
+			nodes = append(nodes, templateNode{kind: textNode, text: text.String()})
+			text.Reset()
+		}
+		nodes = append(nodes, newVariableNode(name, args, source, source[1] != '{'))
+	}
+	if text.Len() > 0 {
+		nodes = append(nodes, templateNode{kind: textNode, text: text.String()})
+	}
+	return nodes
+}
+
+// next writes the literal text up to the next variable to text and parses
+// the variable. It returns false at the end of the source. "$$" is written
+// as "$", as are a '$' that starts no variable and an unclosed "${".
+func (p *templateParser) next(text *strings.Builder) (name, args, source string, ok bool) {
+	for p.pos < len(p.src) {
+		dollar := strings.IndexByte(p.src[p.pos:], '$')
+		if dollar < 0 {
+			text.WriteString(p.src[p.pos:])
+			p.pos = len(p.src)
+			break
+		}
+		text.WriteString(p.src[p.pos : p.pos+dollar])
+		p.pos += dollar
+
+		start := p.pos
+		if name, args, ok = p.parseVariable(); ok {
+			return name, args, p.src[start:p.pos], true
+		}
+		if strings.HasPrefix(p.src[start:], "$$") {
+			p.pos = start + 2
+		} else {
+			p.pos = start + 1
+		}
+		text.WriteByte('$')
+	}
+	return "", "", "", false
+}
+
+// parseConditional parses the branches of a conditional that starts with
+// ${name args}. A conditional without ${endif} is kept as a variable
+// followed by its contents.
+func (p *templateParser) parseConditional(name, args, source string, bare bool) []templateNode {
+	cond := templateNode{kind: conditionalNode, text: name, source: source, bare: bare, args: compileArgs(name, args)}
+	if name == "if_updatenr" && len(cond.args.static) > 0 {
+		if n, err := strconv.ParseInt(cond.args.static[0], 10, 64); err == nil {
+			p.maxUpdateNr = max(p.maxUpdateNr, n)
+		}
+	}
+
+	then, term := p.parseNodes(true, false)
+	cond.then = then
+	if term == termElse {
+		otherwise, elseTerm := p.parseNodes(true, true)
+		if elseTerm == termEOF {
+			nodes := append([]templateNode{newVariableNode(name, args, source, bare)}, then...)
+			nodes = append(nodes, templateNode{kind: textNode, text: "${else}"})
+			return append(nodes, otherwise...)
+		}
+		cond.otherwise = otherwise
+		return []templateNode{cond}
+	}
+	if term == termEOF {
+		return append([]templateNode{newVariableNode(name, args, source, bare)}, then...)
+	}
+	return []templateNode{cond}
+}
+
+// parseVariable parses "${name args}" or "$name" at the current position,
+// which holds a '$', and advances past it.
+func (p *templateParser) parseVariable() (name, args string, ok bool) {
+	rest := p.src[p.pos+1:]
+	if strings.HasPrefix(rest, "{") {
+		end := matchingBrace(rest)
+		if end < 0 {
+			return "", "", false
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		if inner == "" {
+			return "", "", false
+		}
+		name = inner
+		if i := strings.IndexAny(inner, " \t\n"); i >= 0 {
+			name, args = inner[:i], strings.TrimSpace(inner[i+1:])
+		}
+		p.pos += 1 + end + 1
+		return name, args, true
+	}
+
+	n := 0
+	for n < len(rest) && isNameByte(rest[n]) {
+		n++
+	}
+	if n == 0 {
+		return "", "", false
+	}
+	p.pos += 1 + n
+	return rest[:n], "", true
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at the start
+// of s, counting nested braces, or -1 if there is none.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isNameByte reports whether c may appear in a $name variable.
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// newVariableNode creates the node for ${name args}.
+func newVariableNode(name, args, source string, bare bool) templateNode {
+	n := templateNode{kind: variableNode, text: name, source: source, bare: bare, args: compileArgs(name, args)}
+
+	if len(name) == len("template0") && strings.HasPrefix(name, "template") && name[8] >= '0' && name[8] <= '9' {
+		n.kind = templateCallNode
+		n.index = int(name[8] - '0')
+		n.body = new(atomic.Pointer[templateExpansion])
+		return n
+	}
+
+	if isWidgetVariable(name) {
+		n.kind = widgetNode
+		if staticWidgetVariables[name] && n.args.parts == nil {
+			n.text, _ = layoutMarker(name, n.args.static)
+			n.static = true
+		}
+	}
+	return n
+}
+
+// compileArgs compiles the arguments of variable name.
+func compileArgs(name, text string) templateArgs {
+	if text == "" {
+		return templateArgs{}
+	}
+	if literalArgVariables[name] {
+		return templateArgs{static: strings.Fields(text)}
+	}
+
+	quoted := quotedArgVariables[name]
+	if !strings.Contains(text, "$") {
+		if quoted {
+			return templateArgs{static: splitQuotedArgs(text), quoted: true}
+		}
+		return templateArgs{static: splitArgs(text)}
+	}
+
+	p := &templateParser{src: text}
+	parts := p.parseArgNodes()
+	if len(parts) == 1 && parts[0].kind == textNode {
+		// Only "$$" escapes
+		if quoted {
+			return templateArgs{static: splitQuotedArgs(parts[0].text), quoted: true}
+		}
+		return templateArgs{static: splitArgs(parts[0].text)}
+	}
+	return templateArgs{parts: parts, quoted: quoted}
+}
@@ -0,0 +1,202 @@
+package lua
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+func newTestTemplateAPI(t testing.TB) *ConkyAPI {
+	t.Helper()
+	runtime, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+
+	provider := newMockProvider()
+	provider.network.Interfaces["eth0"] = monitor.InterfaceStats{Name: "eth0", IPv4Addrs: []string{"192.168.1.100"}}
+	api, err := NewConkyAPI(runtime, provider)
+	if err != nil {
+		t.Fatalf("failed to create API: %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+	return api
+}
+
+func TestCompileSyntax(t *testing.T) {
+	api := newTestTemplateAPI(t)
+	api.SetTemplates([10]string{
+		`\1 at \2%`,
+		`${template0 \1 ${cpu}}`,
+		`${template2}`,
+	})
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"bare variable", "CPU $cpu%", "CPU 46%"},
+		{"bare variable before text", "$memperc%used", "50%used"},
+		{"dollar escape", "Price: $$5", "Price: $5"},
+		{"dollar escape before brace", "$${cpu}", "${cpu}"},
+		{"lone dollar", "50$ off", "50$ off"},
+		{"trailing dollar", "cost $", "cost $"},
+		{"bare unknown kept", "$5.00 and $nope", "$5.00 and $nope"},
+		{"braced unknown kept", "${nope arg}", "${nope arg}"},
+		{"unclosed brace", "a ${cpu b", "a ${cpu b"},
+		{"empty braces", "${} ${ }", "${} ${ }"},
+		{"extra spaces", "${  cpu   1 }", "50"},
+		{"nested variable", "${if_match ${cpu} 46}high${else}low${endif}", "high"},
+		{"nested in plain variable", "${addr ${gw_iface}}", "192.168.1.100"},
+		{"quoted argument", `${template0 "Core one" 10}`, "Core one at 10%"},
+		{"escaped space", `${template0 Core\ two 20}`, "Core two at 20%"},
+		{"nested template", "${template1 CPU}", "CPU at 46%"},
+		{"recursive template", "[${template2}]", "[]"},
+		{"undefined template", "[${template5 x}]", "[]"},
+		{"bare endif", "${if_up eth0}up$endif", "up"},
+		{"else after else", "${if_up wlan9}a${else}b${else}c${endif}", "b${else}c"},
+		{"unclosed with else", "${if_up eth0}a${else}b", "1a${else}b"},
+		{"multi-line conditional", "a\n${if_up wlan9}\nhidden\n${else}\nshown\n${endif}", "a\n\nshown\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := api.Parse(tt.template); got != tt.expected {
+				t.Errorf("Parse(%q) = %q, want %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompileNodes(t *testing.T) {
+	api := newTestTemplateAPI(t)
+	tmpl := api.Compile("CPU ${cpu 1} ${cpubar}${color red}${if_up eth0}${template0 a}${endif}")
+
+	var kinds []nodeKind
+	for _, n := range tmpl.nodes {
+		kinds = append(kinds, n.kind)
+	}
+	want := []nodeKind{textNode, variableNode, textNode, widgetNode, widgetNode, conditionalNode}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("node kinds = %v, want %v", kinds, want)
+	}
+
+	color := tmpl.nodes[4]
+	if !color.static || color.text != render.EncodeColorMarker("red") {
+		t.Errorf("${color red} = %+v, want a static color marker", color)
+	}
+	if cpubar := tmpl.nodes[3]; cpubar.static {
+		t.Error("${cpubar} is static, want it evaluated on every update")
+	}
+	if cond := tmpl.nodes[5]; len(cond.then) != 1 || cond.then[0].kind != templateCallNode || cond.then[0].index != 0 {
+		t.Errorf("conditional body = %+v, want a template0 call", cond.then)
+	}
+	if got := tmpl.String(); !strings.HasPrefix(got, "CPU ${cpu 1}") {
+		t.Errorf("String() = %q, want the source", got)
+	}
+}
+
+func TestCompileArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		varName string
+		text    string
+		want    []string
+		dynamic bool
+	}{
+		{"plain", "fs_used", "/home", []string{"/home"}, false},
+		{"quoted", "lua", `draw "a b" c`, []string{"draw", "a b", "c"}, false},
+		{"empty quotes", "lua", `f ""`, []string{"f", ""}, false},
+		{"escaped quote", "lua", `f \"x\"`, []string{"f", `"x"`}, false},
+		{"exec is literal", "exec", `awk '{print $1}' "f g"`, []string{"awk", "'{print", "$1}'", `"f`, `g"`}, false},
+		{"if_match keeps quotes", "if_match", `"ext4" == "ext4"`, []string{`"ext4"`, "==", `"ext4"`}, false},
+		{"if_match quoted spaces", "if_match", `"a  b" ==	"c d e"`, []string{`"a  b"`, "==", `"c d e"`}, false},
+		{"dollar escape only", "lua", "f $$", []string{"f", "$"}, false},
+		{"nested variable", "addr", "${gw_iface}", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := compileArgs(tt.varName, tt.text)
+			if (args.parts != nil) != tt.dynamic {
+				t.Fatalf("dynamic = %v, want %v", args.parts != nil, tt.dynamic)
+			}
+			if !tt.dynamic && !slices.Equal(args.static, tt.want) {
+				t.Errorf("args = %q, want %q", args.static, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteTemplateArgs(t *testing.T) {
+	tests := []struct {
+		definition string
+		args       []string
+		want       string
+	}{
+		{`\1 and \2`, []string{"a", "b"}, "a and b"},
+		{`\1 and \2`, []string{"a"}, `a and \2`},
+		{`path\to`, []string{"a"}, `path\to`},
+		{`\1\1`, []string{`\2`, "x"}, `\2\2`},
+		{`none`, nil, "none"},
+	}
+	for _, tt := range tests {
+		if got := substituteTemplateArgs(tt.definition, tt.args); got != tt.want {
+			t.Errorf("substituteTemplateArgs(%q, %q) = %q, want %q", tt.definition, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateFollowsDefinitionChanges(t *testing.T) {
+	api := newTestTemplateAPI(t)
+	tmpl := api.Compile("${template0 x}")
+
+	api.SetTemplates([10]string{`old \1`})
+	if got := tmpl.Execute(); got != "old x" {
+		t.Errorf("Execute() = %q, want %q", got, "old x")
+	}
+	api.SetTemplates([10]string{`new \1`})
+	if got := tmpl.Execute(); got != "new x" {
+		t.Errorf("Execute() after SetTemplates = %q, want %q", got, "new x")
+	}
+}
+
+func TestTemplatePreviewSkipsSideEffects(t *testing.T) {
+	api := newTestTemplateAPI(t)
+	marker := filepath.Join(t.TempDir(), "ran")
+	tmpl := api.Compile("a${exec touch " + marker + "}b ${execi 60 echo x}${if_match \"${exec echo y}\" == \"\"}empty${endif} ${lua conky_x}")
+
+	if got := tmpl.Preview(); got != "ab empty " {
+		t.Errorf("Preview() = %q, want %q", got, "ab empty ")
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("Preview ran ${exec}: stat = %v", err)
+	}
+	if n := api.exec.Len(); n != 0 {
+		t.Errorf("Preview scheduled %d interval commands, want 0", n)
+	}
+
+	tmpl.Execute()
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("Execute did not run ${exec}: %v", err)
+	}
+}
+
+func TestParseCacheBounded(t *testing.T) {
+	api := newTestTemplateAPI(t)
+	for i := 0; i < maxCompiledTemplates*2; i++ {
+		api.Parse(strings.Repeat("x", i))
+	}
+	api.compileMu.Lock()
+	defer api.compileMu.Unlock()
+	if len(api.compiled) > maxCompiledTemplates {
+		t.Errorf("cache holds %d templates, want at most %d", len(api.compiled), maxCompiledTemplates)
+	}
+}
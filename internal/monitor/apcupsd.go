@@ -0,0 +1,215 @@
+// Package monitor provides system monitoring functionality.
+// This file implements an apcupsd Network Information Server (NIS) client.
+package monitor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPCUPSDHost and DefaultAPCUPSDPort are the address of the apcupsd
+// Network Information Server queried when no other is given.
+const (
+	DefaultAPCUPSDHost = "localhost"
+	DefaultAPCUPSDPort = 3551
+)
+
+// APCUPSDStats contains the UPS status reported by apcupsd.
+type APCUPSDStats struct {
+	// Model is the UPS model (MODEL).
+	Model string
+	// Status is the UPS status, such as "ONLINE" or "ONBATT" (STATUS).
+	Status string
+	// LineV is the input line voltage in volts (LINEV).
+	LineV float64
+	// Load is the load in percent of capacity (LOADPCT).
+	Load float64
+	// Charge is the battery charge in percent (BCHARGE).
+	Charge float64
+	// TimeLeft is the estimated runtime on battery in minutes (TIMELEFT).
+	TimeLeft float64
+	// Temp is the internal temperature in degrees Celsius (ITEMP).
+	Temp float64
+	// BattV is the battery voltage in volts (BATTV).
+	BattV float64
+	// Cable is the cable type (CABLE).
+	Cable string
+	// Driver is the apcupsd driver (DRIVER).
+	Driver string
+	// UPSMode is the apcupsd mode, such as "Stand Alone" (UPSMODE).
+	UPSMode string
+	// Name is the configured UPS name (UPSNAME).
+	Name string
+	// Hostname is the host running apcupsd (HOSTNAME).
+	Hostname string
+	// Fields holds every record of the status report keyed by its name.
+	Fields map[string]string
+}
+
+// errAPCUPSDPending is returned for a server until its first query
+// completes.
+var errAPCUPSDPending = errors.New("apcupsd status not received yet")
+
+// apcupsdReader queries apcupsd daemons in the background, caching each
+// server's status for the poll interval.
+type apcupsdReader struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	interval time.Duration
+	cache    map[string]*apcupsdCacheEntry
+}
+
+// apcupsdCacheEntry holds the last status read from a server.
+type apcupsdCacheEntry struct {
+	stats     APCUPSDStats
+	err       error
+	fetchTime time.Time
+	fetching  bool // Whether a query is in progress
+}
+
+// newAPCUPSDReader creates an apcupsdReader with default settings.
+func newAPCUPSDReader() *apcupsdReader {
+	return &apcupsdReader{
+		timeout:  3 * time.Second,
+		interval: 10 * time.Second,
+		cache:    make(map[string]*apcupsdCacheEntry),
+	}
+}
+
+// Read returns the last status of the UPS served by apcupsd at host:port
+// without waiting on the network. When the status is older than the poll
+// interval, a query is started in the background and the previous status
+// is returned; before the first query completes the error is
+// errAPCUPSDPending. Failed queries are cached like successful ones so that
+// an unreachable daemon is not contacted on every update.
+func (r *apcupsdReader) Read(host string, port int) (APCUPSDStats, error) {
+	if host == "" {
+		host = DefaultAPCUPSDHost
+	}
+	if port == 0 {
+		port = DefaultAPCUPSDPort
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[addr]
+	if !ok {
+		entry = &apcupsdCacheEntry{err: errAPCUPSDPending}
+		r.cache[addr] = entry
+	}
+	if !entry.fetching && time.Since(entry.fetchTime) >= r.interval {
+		entry.fetching = true
+		go r.refresh(addr, entry)
+	}
+	return entry.stats, entry.err
+}
+
+// refresh queries the server at addr and records the result in entry.
+func (r *apcupsdReader) refresh(addr string, entry *apcupsdCacheEntry) {
+	stats, err := r.fetch(addr)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.stats, entry.err = stats, err
+	entry.fetchTime = time.Now()
+	entry.fetching = false
+}
+
+// fetch sends a status request to the server at addr and parses the reply.
+func (r *apcupsdReader) fetch(addr string) (APCUPSDStats, error) {
+	conn, err := net.DialTimeout("tcp", addr, r.timeout)
+	if err != nil {
+		return APCUPSDStats{}, fmt.Errorf("connect to apcupsd: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(r.timeout)); err != nil {
+		return APCUPSDStats{}, fmt.Errorf("set deadline: %w", err)
+	}
+	if err := writeNISRecord(conn, "status"); err != nil {
+		return APCUPSDStats{}, fmt.Errorf("send status: %w", err)
+	}
+
+	fields := make(map[string]string)
+	reader := bufio.NewReader(conn)
+	for {
+		record, err := readNISRecord(reader)
+		if err != nil {
+			return APCUPSDStats{}, fmt.Errorf("read status: %w", err)
+		}
+		if record == "" {
+			break
+		}
+		key, value, ok := strings.Cut(record, ":")
+		if !ok {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if len(fields) == 0 {
+		return APCUPSDStats{}, fmt.Errorf("empty status from %s", addr)
+	}
+	return parseAPCUPSDStatus(fields), nil
+}
+
+// writeNISRecord writes a record prefixed by its length as a big-endian
+// 16-bit integer, as the NIS protocol requires.
+func writeNISRecord(w io.Writer, record string) error {
+	buf := make([]byte, 2+len(record))
+	binary.BigEndian.PutUint16(buf, uint16(len(record)))
+	copy(buf[2:], record)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readNISRecord reads one length-prefixed record. The zero-length record
+// that ends a reply is returned as an empty string.
+func readNISRecord(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// parseAPCUPSDStatus converts the records of a status report, such as
+// "LINEV : 230.0 Volts", into APCUPSDStats.
+func parseAPCUPSDStatus(fields map[string]string) APCUPSDStats {
+	return APCUPSDStats{
+		Model:    fields["MODEL"],
+		Status:   fields["STATUS"],
+		LineV:    apcupsdNumber(fields["LINEV"]),
+		Load:     apcupsdNumber(fields["LOADPCT"]),
+		Charge:   apcupsdNumber(fields["BCHARGE"]),
+		TimeLeft: apcupsdNumber(fields["TIMELEFT"]),
+		Temp:     apcupsdNumber(fields["ITEMP"]),
+		BattV:    apcupsdNumber(fields["BATTV"]),
+		Cable:    fields["CABLE"],
+		Driver:   fields["DRIVER"],
+		UPSMode:  fields["UPSMODE"],
+		Name:     fields["UPSNAME"],
+		Hostname: fields["HOSTNAME"],
+		Fields:   fields,
+	}
+}
+
+// apcupsdNumber parses the number that starts a value such as "13.5 Volts".
+func apcupsdNumber(value string) float64 {
+	number, _, _ := strings.Cut(value, " ")
+	f, _ := strconv.ParseFloat(number, 64)
+	return f
+}
@@ -0,0 +1,221 @@
+package monitor
+
+import (
+	"bufio"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// apcupsdStatus is a status report as sent by apcupsd 3.14.
+var apcupsdStatus = []string{
+	"APC      : 001,036,0869\n",
+	"DATE     : 2024-01-01 12:00:00 +0000  \n",
+	"HOSTNAME : nas\n",
+	"VERSION  : 3.14.14 (31 May 2016) debian\n",
+	"UPSNAME  : rack-ups\n",
+	"CABLE    : USB Cable\n",
+	"DRIVER   : USB UPS Driver\n",
+	"UPSMODE  : Stand Alone\n",
+	"MODEL    : Back-UPS RS 900G \n",
+	"STATUS   : ONLINE \n",
+	"LINEV    : 230.0 Volts\n",
+	"LOADPCT  : 12.0 Percent\n",
+	"BCHARGE  : 100.0 Percent\n",
+	"TIMELEFT : 45.2 Minutes\n",
+	"ITEMP    : 29.2 C\n",
+	"BATTV    : 27.3 Volts\n",
+	"END APC  : 2024-01-01 12:00:05 +0000  \n",
+}
+
+// serveAPCUPSD starts a stand-in apcupsd NIS server replying to status
+// requests with records and returns its port and a request counter.
+func serveAPCUPSD(t *testing.T, records []string) (int, *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var requests atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request, err := readNISRecord(bufio.NewReader(conn))
+				if err != nil || request != "status" {
+					return
+				}
+				requests.Add(1)
+				for _, record := range records {
+					_ = writeNISRecord(conn, record)
+				}
+				_ = writeNISRecord(conn, "")
+			}()
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, &requests
+}
+
+func TestAPCUPSDReaderRead(t *testing.T) {
+	port, _ := serveAPCUPSD(t, apcupsdStatus)
+
+	reader := newAPCUPSDReader()
+	if _, err := reader.Read("127.0.0.1", port); err != errAPCUPSDPending {
+		t.Errorf("first Read() error = %v, want errAPCUPSDPending", err)
+	}
+	stats, err := readAPCUPSD(t, reader, port)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	wantStrings := map[string][2]string{
+		"Model":    {stats.Model, "Back-UPS RS 900G"},
+		"Status":   {stats.Status, "ONLINE"},
+		"Cable":    {stats.Cable, "USB Cable"},
+		"Driver":   {stats.Driver, "USB UPS Driver"},
+		"UPSMode":  {stats.UPSMode, "Stand Alone"},
+		"Name":     {stats.Name, "rack-ups"},
+		"Hostname": {stats.Hostname, "nas"},
+	}
+	for field, v := range wantStrings {
+		if v[0] != v[1] {
+			t.Errorf("%s = %q, want %q", field, v[0], v[1])
+		}
+	}
+
+	wantNumbers := map[string][2]float64{
+		"LineV":    {stats.LineV, 230},
+		"Load":     {stats.Load, 12},
+		"Charge":   {stats.Charge, 100},
+		"TimeLeft": {stats.TimeLeft, 45.2},
+		"Temp":     {stats.Temp, 29.2},
+		"BattV":    {stats.BattV, 27.3},
+	}
+	for field, v := range wantNumbers {
+		if v[0] != v[1] {
+			t.Errorf("%s = %v, want %v", field, v[0], v[1])
+		}
+	}
+	if stats.Fields["VERSION"] != "3.14.14 (31 May 2016) debian" {
+		t.Errorf("Fields[VERSION] = %q", stats.Fields["VERSION"])
+	}
+}
+
+func TestAPCUPSDReaderCache(t *testing.T) {
+	port, requests := serveAPCUPSD(t, apcupsdStatus)
+
+	reader := newAPCUPSDReader()
+	reader.interval = time.Hour
+	if _, err := readAPCUPSD(t, reader, port); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := reader.Read("127.0.0.1", port); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 within the poll interval", n)
+	}
+
+	// A stale status is still returned while the server is queried again
+	reader.interval = 0
+	if stats, err := reader.Read("127.0.0.1", port); err != nil || stats.Status != "ONLINE" {
+		t.Fatalf("Read() = %q, %v, want the cached status", stats.Status, err)
+	}
+	waitFor(t, func() bool { return requests.Load() == 2 })
+}
+
+func TestAPCUPSDReaderErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	closedPort := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	reader := newAPCUPSDReader()
+	reader.timeout = time.Second
+	if _, err := readAPCUPSD(t, reader, closedPort); err == nil {
+		t.Error("Read() from a closed port succeeded, want error")
+	}
+	// The failure is cached for the poll interval
+	if _, err := reader.Read("127.0.0.1", closedPort); err == nil || err == errAPCUPSDPending {
+		t.Errorf("cached Read() error = %v, want the cached error", err)
+	}
+
+	emptyPort, _ := serveAPCUPSD(t, nil)
+	if _, err := readAPCUPSD(t, reader, emptyPort); err == nil {
+		t.Error("Read() of an empty status succeeded, want error")
+	}
+}
+
+func TestAPCUPSDReaderDoesNotBlock(t *testing.T) {
+	// A server that accepts connections but never replies
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-done
+				conn.Close()
+			}()
+		}
+	}()
+
+	reader := newAPCUPSDReader()
+	reader.timeout = 500 * time.Millisecond
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := reader.Read("127.0.0.1", ln.Addr().(*net.TCPAddr).Port); err != errAPCUPSDPending {
+			t.Errorf("Read() error = %v, want errAPCUPSDPending", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > reader.timeout/2 {
+		t.Errorf("Read() took %v waiting on the server", elapsed)
+	}
+}
+
+// readAPCUPSD reads from the apcupsd server at 127.0.0.1:port, waiting for
+// the background query to complete.
+func readAPCUPSD(t *testing.T, reader *apcupsdReader, port int) (APCUPSDStats, error) {
+	t.Helper()
+	var stats APCUPSDStats
+	var err error
+	waitFor(t, func() bool {
+		stats, err = reader.Read("127.0.0.1", port)
+		return err != errAPCUPSDPending
+	})
+	return stats, err
+}
+
+// waitFor polls cond until it holds, failing the test after five seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
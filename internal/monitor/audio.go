@@ -57,48 +57,93 @@ type MixerInfo struct {
 	HasSwitch bool
 }
 
+// Audio backends reported in AudioStats.Backend.
+const (
+	// AudioBackendPulse means the volume was read from a PulseAudio or
+	// PipeWire server.
+	AudioBackendPulse = "pulseaudio"
+	// AudioBackendALSA means the volume was read from /proc/asound.
+	AudioBackendALSA = "alsa"
+)
+
 // AudioStats contains audio system statistics.
 type AudioStats struct {
 	// Cards contains audio card information keyed by card index.
 	Cards map[int]AudioCard
 	// DefaultCard is the index of the default audio card.
 	DefaultCard int
-	// MasterVolume is the master volume percentage (0-100) of the default card.
+	// MasterVolume is the master volume percentage (0-100) of the default
+	// sink, or of the default card without a sound server.
 	MasterVolume float64
 	// MasterMuted indicates if the master volume is muted.
 	MasterMuted bool
 	// HasAudio indicates if any audio hardware was detected.
 	HasAudio bool
+	// Backend is AudioBackendPulse, AudioBackendALSA, or empty if no audio
+	// was found.
+	Backend string
+	// Pulse holds the sound server state when Backend is AudioBackendPulse.
+	Pulse PulseStats
 }
 
-// audioReader reads audio information from /proc/asound.
+// audioReader reads audio information from a PulseAudio or PipeWire server,
+// falling back to /proc/asound.
 type audioReader struct {
 	asoundPath string
+	// pulse reads the sound server; nil reads ALSA only.
+	pulse *pulseReader
 }
 
 // newAudioReader creates a new audioReader with default paths.
 func newAudioReader() *audioReader {
 	return &audioReader{
 		asoundPath: "/proc/asound",
+		pulse:      newPulseReader(),
 	}
 }
 
-// ReadStats reads current audio system statistics.
+// ReadStats reads current audio system statistics. The ALSA cards are
+// always listed; the master volume comes from the default sink of the sound
+// server when one is running.
 func (r *audioReader) ReadStats() (AudioStats, error) {
 	stats := AudioStats{
 		Cards:       make(map[int]AudioCard),
 		DefaultCard: -1,
 	}
 
+	alsaErr := r.readALSA(&stats)
+
+	if r.pulse != nil {
+		if pulse, err := r.pulse.ReadStats(); err == nil {
+			stats.Backend = AudioBackendPulse
+			stats.Pulse = pulse
+			stats.HasAudio = true
+			stats.MasterVolume = pulse.DefaultSink.Volume
+			stats.MasterMuted = pulse.DefaultSink.Muted
+			return stats, nil
+		}
+	}
+	return stats, alsaErr
+}
+
+// Close releases the connection to the sound server.
+func (r *audioReader) Close() {
+	if r.pulse != nil {
+		r.pulse.Close()
+	}
+}
+
+// readALSA fills in the cards and master volume from /proc/asound.
+func (r *audioReader) readALSA(stats *AudioStats) error {
 	// Check if asound directory exists
 	if _, err := os.Stat(r.asoundPath); os.IsNotExist(err) {
-		return stats, nil // No ALSA support, return empty stats
+		return nil // No ALSA support, leave stats empty
 	}
 
 	// Read card information from /proc/asound/cards
 	cards, err := r.readCards()
 	if err != nil {
-		return stats, fmt.Errorf("reading cards: %w", err)
+		return fmt.Errorf("reading cards: %w", err)
 	}
 
 	for idx, card := range cards {
@@ -111,6 +156,7 @@ func (r *audioReader) ReadStats() (AudioStats, error) {
 
 	// Set default card (first available)
 	if stats.HasAudio {
+		stats.Backend = AudioBackendALSA
 		// Try to find default from /proc/asound/default, otherwise use first card
 		stats.DefaultCard = r.findDefaultCard(stats.Cards)
 
@@ -120,7 +166,7 @@ func (r *audioReader) ReadStats() (AudioStats, error) {
 		}
 	}
 
-	return stats, nil
+	return nil
 }
 
 // readCards reads card information from /proc/asound/cards.
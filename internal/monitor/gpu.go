@@ -1,4 +1,5 @@
-// Package monitor provides NVIDIA GPU monitoring via nvidia-smi.
+// Package monitor provides GPU monitoring via nvidia-smi for NVIDIA cards and
+// the DRM sysfs interface for AMD and Intel cards.
 package monitor
 
 import (
@@ -9,10 +10,21 @@ import (
 	"time"
 )
 
-// GPUStats contains NVIDIA GPU statistics.
+// GPU vendors reported in GPUStats.Vendor.
+const (
+	GPUVendorNVIDIA = "nvidia"
+	GPUVendorAMD    = "amd"
+	GPUVendorIntel  = "intel"
+)
+
+// GPUStats contains the statistics of one GPU. Fields a driver does not
+// expose are left zero.
 type GPUStats struct {
 	Name        string
 	DriverVer   string
+	Vendor      string  // GPUVendorNVIDIA, GPUVendorAMD or GPUVendorIntel
+	Driver      string  // Kernel driver (nvidia, amdgpu, i915, ...)
+	Card        string  // DRM card name (card0, ...), empty for nvidia-smi
 	Temperature int     // Celsius
 	UtilGPU     int     // GPU utilization percentage
 	UtilMem     int     // Memory utilization percentage
@@ -20,18 +32,22 @@ type GPUStats struct {
 	MemTotal    uint64  // Bytes
 	MemFree     uint64  // Bytes
 	FanSpeed    int     // Percentage
+	FanRPM      int     // Revolutions per minute
 	PowerDraw   float64 // Watts
 	PowerLimit  float64 // Watts
+	FreqMHz     int     // Current core clock
+	FreqMaxMHz  int     // Maximum core clock
 	Available   bool
 }
 
-// gpuReader reads NVIDIA GPU stats using nvidia-smi.
+// gpuReader reads GPU stats from nvidia-smi and from the DRM sysfs tree.
 type gpuReader struct {
 	mu            sync.RWMutex
-	cache         GPUStats
+	cache         []GPUStats
 	lastUpdate    time.Time
 	cacheDuration time.Duration
 	nvidiaSmiPath string
+	drmPath       string
 }
 
 // newGPUReader creates a new gpuReader.
@@ -44,37 +60,47 @@ func newGPUReader() *gpuReader {
 	return &gpuReader{
 		cacheDuration: 2 * time.Second,
 		nvidiaSmiPath: path,
+		drmPath:       "/sys/class/drm",
 	}
 }
 
-// ReadStats reads current GPU statistics.
+// ReadStats reads the statistics of the first NVIDIA GPU, as used by the
+// ${nvidia} variables. Available is false when there is none.
 func (r *gpuReader) ReadStats() (GPUStats, error) {
+	for _, gpu := range r.ReadAll() {
+		if gpu.Vendor == GPUVendorNVIDIA && gpu.Driver == "nvidia" {
+			return gpu, nil
+		}
+	}
+	return GPUStats{Available: false}, nil
+}
+
+// ReadAll reads the statistics of every GPU: NVIDIA cards reported by
+// nvidia-smi first, followed by the other DRM cards in card order.
+func (r *gpuReader) ReadAll() []GPUStats {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Return cached if fresh
-	if time.Since(r.lastUpdate) < r.cacheDuration {
-		return r.cache, nil
-	}
-
-	if r.nvidiaSmiPath == "" {
-		r.cache = GPUStats{Available: false}
-		return r.cache, nil
+	if !r.lastUpdate.IsZero() && time.Since(r.lastUpdate) < r.cacheDuration {
+		return append([]GPUStats(nil), r.cache...)
 	}
 
-	stats, err := r.queryNvidiaSmi()
-	if err != nil {
-		r.cache = GPUStats{Available: false}
-		return r.cache, nil
+	var gpus []GPUStats
+	if r.nvidiaSmiPath != "" {
+		if output, err := r.queryNvidiaSmi(); err == nil {
+			gpus = append(gpus, parseNvidiaSmiGPUs(output)...)
+		}
 	}
+	gpus = append(gpus, readDRMGPUs(r.drmPath)...)
 
-	r.cache = stats
+	r.cache = gpus
 	r.lastUpdate = time.Now()
-	return r.cache, nil
+	return append([]GPUStats(nil), r.cache...)
 }
 
-// queryNvidiaSmi runs nvidia-smi and parses output.
-func (r *gpuReader) queryNvidiaSmi() (GPUStats, error) {
+// queryNvidiaSmi runs nvidia-smi and returns its CSV output.
+func (r *gpuReader) queryNvidiaSmi() (string, error) {
 	// Query specific fields in CSV format
 	cmd := exec.Command(r.nvidiaSmiPath,
 		"--query-gpu=name,driver_version,temperature.gpu,utilization.gpu,utilization.memory,memory.used,memory.total,memory.free,fan.speed,power.draw,power.limit",
@@ -82,26 +108,40 @@ func (r *gpuReader) queryNvidiaSmi() (GPUStats, error) {
 
 	output, err := cmd.Output()
 	if err != nil {
-		return GPUStats{}, err
+		return "", err
 	}
-
-	return r.parseNvidiaSmiOutput(string(output))
+	return string(output), nil
 }
 
-// parseNvidiaSmiOutput parses CSV output from nvidia-smi.
+// parseNvidiaSmiOutput parses the first GPU from nvidia-smi CSV output.
 func (r *gpuReader) parseNvidiaSmiOutput(output string) (GPUStats, error) {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	if len(lines) == 0 {
+	gpus := parseNvidiaSmiGPUs(output)
+	if len(gpus) == 0 {
 		return GPUStats{}, nil
 	}
+	return gpus[0], nil
+}
+
+// parseNvidiaSmiGPUs parses every GPU from nvidia-smi CSV output, one per
+// line. Malformed lines are skipped.
+func parseNvidiaSmiGPUs(output string) []GPUStats {
+	var gpus []GPUStats
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if stats, ok := parseNvidiaSmiLine(line); ok {
+			gpus = append(gpus, stats)
+		}
+	}
+	return gpus
+}
 
-	// Parse first GPU
-	fields := strings.Split(lines[0], ", ")
+// parseNvidiaSmiLine parses one line of nvidia-smi CSV output.
+func parseNvidiaSmiLine(line string) (GPUStats, bool) {
+	fields := strings.Split(line, ", ")
 	if len(fields) < 11 {
-		return GPUStats{}, nil
+		return GPUStats{}, false
 	}
 
-	stats := GPUStats{Available: true}
+	stats := GPUStats{Available: true, Vendor: GPUVendorNVIDIA, Driver: "nvidia"}
 
 	stats.Name = strings.TrimSpace(fields[0])
 	stats.DriverVer = strings.TrimSpace(fields[1])
@@ -121,7 +161,7 @@ func (r *gpuReader) parseNvidiaSmiOutput(output string) (GPUStats, error) {
 	stats.PowerDraw, _ = strconv.ParseFloat(strings.TrimSpace(fields[9]), 64)
 	stats.PowerLimit, _ = strconv.ParseFloat(strings.TrimSpace(fields[10]), 64)
 
-	return stats, nil
+	return stats, true
 }
 
 // GetField returns a specific field value as string.
@@ -141,8 +181,16 @@ func (stats GPUStats) GetField(field string) string {
 		return stats.DriverVer
 	case "name", "model":
 		return stats.Name
+	case "vendor":
+		return stats.Vendor
 	case "fan", "fanspeed":
 		return strconv.Itoa(stats.FanSpeed) + "%"
+	case "fanrpm":
+		return strconv.Itoa(stats.FanRPM) + " RPM"
+	case "freq", "clock":
+		return strconv.Itoa(stats.FreqMHz) + "MHz"
+	case "freqmax":
+		return strconv.Itoa(stats.FreqMaxMHz) + "MHz"
 	case "power", "powerdraw":
 		return strconv.FormatFloat(stats.PowerDraw, 'f', 1, 64) + "W"
 	case "powerlimit":
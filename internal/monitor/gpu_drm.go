@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// drmVendors maps DRM kernel drivers to GPU vendors. Cards driven by the
+// proprietary nvidia driver are absent: nvidia-smi reports them.
+var drmVendors = map[string]string{
+	"amdgpu":  GPUVendorAMD,
+	"radeon":  GPUVendorAMD,
+	"i915":    GPUVendorIntel,
+	"xe":      GPUVendorIntel,
+	"nouveau": GPUVendorNVIDIA,
+}
+
+// drmVendorNames are the names reported for cards without a product name.
+var drmVendorNames = map[string]string{
+	GPUVendorAMD:    "AMD Radeon",
+	GPUVendorIntel:  "Intel Graphics",
+	GPUVendorNVIDIA: "NVIDIA",
+}
+
+// readDRMGPUs reads the GPUs under drmPath (normally /sys/class/drm) in
+// card order, skipping connectors such as card0-DP-1 and cards with
+// unsupported drivers.
+func readDRMGPUs(drmPath string) []GPUStats {
+	entries, err := os.ReadDir(drmPath)
+	if err != nil {
+		return nil
+	}
+
+	var cards []int
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), "card")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(suffix); err == nil {
+			cards = append(cards, n)
+		}
+	}
+	slices.Sort(cards)
+
+	var gpus []GPUStats
+	for _, n := range cards {
+		card := "card" + strconv.Itoa(n)
+		if stats, ok := readDRMCard(filepath.Join(drmPath, card), card); ok {
+			gpus = append(gpus, stats)
+		}
+	}
+	return gpus
+}
+
+// readDRMCard reads one DRM card. ok is false for unsupported drivers.
+func readDRMCard(cardPath, card string) (GPUStats, bool) {
+	devicePath := filepath.Join(cardPath, "device")
+	link, err := os.Readlink(filepath.Join(devicePath, "driver"))
+	if err != nil {
+		return GPUStats{}, false
+	}
+	driver := filepath.Base(link)
+	vendor, ok := drmVendors[driver]
+	if !ok {
+		return GPUStats{}, false
+	}
+
+	stats := GPUStats{
+		Name:      drmVendorNames[vendor],
+		Vendor:    vendor,
+		Driver:    driver,
+		Card:      card,
+		Available: true,
+	}
+	if name, err := readSysfsString(filepath.Join(devicePath, "product_name")); err == nil && name != "" {
+		stats.Name = name
+	}
+
+	switch driver {
+	case "amdgpu":
+		readAMDGPU(devicePath, &stats)
+	case "i915":
+		readIntelGPU(cardPath, &stats)
+	}
+	readGPUHwmon(devicePath, &stats)
+	return stats, true
+}
+
+// readAMDGPU reads the amdgpu utilization, VRAM and clock attributes.
+func readAMDGPU(devicePath string, stats *GPUStats) {
+	if v, err := readSysfsInt(filepath.Join(devicePath, "gpu_busy_percent")); err == nil {
+		stats.UtilGPU = int(v)
+	}
+	if v, err := readSysfsInt(filepath.Join(devicePath, "mem_busy_percent")); err == nil {
+		stats.UtilMem = int(v)
+	}
+	used, usedErr := readSysfsInt(filepath.Join(devicePath, "mem_info_vram_used"))
+	total, totalErr := readSysfsInt(filepath.Join(devicePath, "mem_info_vram_total"))
+	if usedErr == nil && totalErr == nil && total >= used {
+		stats.MemUsed = uint64(used)
+		stats.MemTotal = uint64(total)
+		stats.MemFree = uint64(total - used)
+	}
+	if data, err := os.ReadFile(filepath.Join(devicePath, "pp_dpm_sclk")); err == nil {
+		stats.FreqMHz, stats.FreqMaxMHz = parseDPMClock(string(data))
+	}
+}
+
+// parseDPMClock parses an amdgpu pp_dpm_* clock table such as
+//
+//	0: 500Mhz
+//	1: 1800Mhz *
+//
+// returning the active level (marked with *) and the highest level in MHz.
+func parseDPMClock(table string) (current, maxFreq int) {
+	for _, line := range strings.Split(table, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		mhz, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(fields[1]), "mhz"))
+		if err != nil {
+			continue
+		}
+		maxFreq = max(maxFreq, mhz)
+		if len(fields) > 2 && fields[2] == "*" {
+			current = mhz
+		}
+	}
+	return current, maxFreq
+}
+
+// readIntelGPU reads the i915 frequency counters, which live on the card
+// rather than its device. i915 exposes no utilization counter in sysfs.
+func readIntelGPU(cardPath string, stats *GPUStats) {
+	for _, name := range []string{"gt_act_freq_mhz", "gt_cur_freq_mhz"} {
+		if v, err := readSysfsInt(filepath.Join(cardPath, name)); err == nil {
+			stats.FreqMHz = int(v)
+			break
+		}
+	}
+	if v, err := readSysfsInt(filepath.Join(cardPath, "gt_max_freq_mhz")); err == nil {
+		stats.FreqMaxMHz = int(v)
+	}
+}
+
+// readGPUHwmon reads the temperature, power and fan of a GPU from the first
+// hwmon device under its PCI device.
+func readGPUHwmon(devicePath string, stats *GPUStats) {
+	matches, _ := filepath.Glob(filepath.Join(devicePath, "hwmon", "hwmon*"))
+	if len(matches) == 0 {
+		return
+	}
+	slices.Sort(matches)
+	hwmon := matches[0]
+
+	// Temperatures in millidegrees Celsius
+	if v, err := readSysfsInt(filepath.Join(hwmon, "temp1_input")); err == nil {
+		stats.Temperature = int(v / 1000)
+	}
+	// Power in microwatts
+	for _, name := range []string{"power1_average", "power1_input"} {
+		if v, err := readSysfsInt(filepath.Join(hwmon, name)); err == nil {
+			stats.PowerDraw = float64(v) / 1e6
+			break
+		}
+	}
+	for _, name := range []string{"power1_cap", "power1_max"} {
+		if v, err := readSysfsInt(filepath.Join(hwmon, name)); err == nil {
+			stats.PowerLimit = float64(v) / 1e6
+			break
+		}
+	}
+	if v, err := readSysfsInt(filepath.Join(hwmon, "fan1_input")); err == nil {
+		stats.FanRPM = int(v)
+	}
+	// PWM duty cycle from 0 to 255
+	if v, err := readSysfsInt(filepath.Join(hwmon, "pwm1")); err == nil {
+		stats.FanSpeed = int(v * 100 / 255)
+	}
+}
+
+// readSysfsString reads a trimmed string from a sysfs attribute.
+func readSysfsString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSysfsInt reads an integer from a sysfs attribute.
+func readSysfsInt(path string) (int64, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -1,7 +1,10 @@
 package monitor
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestGPUReader(t *testing.T) {
@@ -91,6 +94,7 @@ func TestGPUStatsGetField(t *testing.T) {
 		{"fan", "50%"},
 		{"power", "200.5W"},
 		{"memperc", "20.0%"},
+		{"freq", "0MHz"},
 	}
 
 	for _, tt := range tests {
@@ -106,3 +110,133 @@ func TestGPUStatsGetField(t *testing.T) {
 		t.Errorf("expected N/A for unavailable GPU")
 	}
 }
+
+func TestParseNvidiaSmiGPUs(t *testing.T) {
+	output := "NVIDIA GeForce RTX 3080, 535.154.05, 45, 30, 25, 2048, 10240, 8192, 55, 150.5, 320.0\n" +
+		"NVIDIA GeForce RTX 3060, 535.154.05, 50, 10, 5, 1024, 12288, 11264, 40, 60.0, 170.0\n" +
+		"garbage\n"
+
+	gpus := parseNvidiaSmiGPUs(output)
+	if len(gpus) != 2 {
+		t.Fatalf("got %d GPUs, want 2", len(gpus))
+	}
+	if gpus[1].Name != "NVIDIA GeForce RTX 3060" || gpus[1].Temperature != 50 {
+		t.Errorf("second GPU = %+v", gpus[1])
+	}
+	if gpus[0].Vendor != GPUVendorNVIDIA || gpus[0].Driver != "nvidia" {
+		t.Errorf("Vendor, Driver = %q, %q, want nvidia, nvidia", gpus[0].Vendor, gpus[0].Driver)
+	}
+}
+
+// createDRMCard creates a fixture /sys/class/drm/<card> tree bound to driver
+// and returns the card and PCI device directories.
+func createDRMCard(t *testing.T, drmPath, card, driver string) (string, string) {
+	t.Helper()
+	cardPath := filepath.Join(drmPath, card)
+	devicePath := filepath.Join(cardPath, "device")
+	if err := os.MkdirAll(filepath.Join(devicePath, "hwmon", "hwmon3"), 0o755); err != nil {
+		t.Fatalf("failed to create %s: %v", card, err)
+	}
+	if err := os.Symlink("../../../bus/pci/drivers/"+driver, filepath.Join(devicePath, "driver")); err != nil {
+		t.Fatalf("failed to link driver: %v", err)
+	}
+	return cardPath, devicePath
+}
+
+func TestReadDRMGPUs(t *testing.T) {
+	drmPath := t.TempDir()
+
+	// AMD discrete card
+	_, amd := createDRMCard(t, drmPath, "card0", "amdgpu")
+	writeFile(t, amd, "product_name", "Radeon RX 6800")
+	writeFile(t, amd, "gpu_busy_percent", "37")
+	writeFile(t, amd, "mem_busy_percent", "12")
+	writeFile(t, amd, "mem_info_vram_used", "4294967296")
+	writeFile(t, amd, "mem_info_vram_total", "17179869184")
+	writeFile(t, amd, "pp_dpm_sclk", "0: 500Mhz\n1: 1815Mhz *\n2: 2475Mhz")
+	amdHwmon := filepath.Join(amd, "hwmon", "hwmon3")
+	writeFile(t, amdHwmon, "temp1_input", "61000")
+	writeFile(t, amdHwmon, "power1_average", "152000000")
+	writeFile(t, amdHwmon, "power1_cap", "203000000")
+	writeFile(t, amdHwmon, "fan1_input", "1450")
+	writeFile(t, amdHwmon, "pwm1", "102")
+
+	// Intel integrated card
+	intelCard, intel := createDRMCard(t, drmPath, "card1", "i915")
+	writeFile(t, intelCard, "gt_act_freq_mhz", "950")
+	writeFile(t, intelCard, "gt_cur_freq_mhz", "1000")
+	writeFile(t, intelCard, "gt_max_freq_mhz", "1300")
+	writeFile(t, filepath.Join(intel, "hwmon", "hwmon3"), "temp1_input", "48500")
+
+	// Connectors, proprietary NVIDIA and unsupported drivers are skipped
+	if err := os.MkdirAll(filepath.Join(drmPath, "card0-DP-1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	createDRMCard(t, drmPath, "card2", "nvidia")
+	createDRMCard(t, drmPath, "card10", "simpledrm")
+
+	gpus := readDRMGPUs(drmPath)
+	if len(gpus) != 2 {
+		t.Fatalf("got %d GPUs, want 2: %+v", len(gpus), gpus)
+	}
+
+	want := GPUStats{
+		Name:        "Radeon RX 6800",
+		Vendor:      GPUVendorAMD,
+		Driver:      "amdgpu",
+		Card:        "card0",
+		Temperature: 61,
+		UtilGPU:     37,
+		UtilMem:     12,
+		MemUsed:     4 << 30,
+		MemTotal:    16 << 30,
+		MemFree:     12 << 30,
+		FanSpeed:    40,
+		FanRPM:      1450,
+		PowerDraw:   152,
+		PowerLimit:  203,
+		FreqMHz:     1815,
+		FreqMaxMHz:  2475,
+		Available:   true,
+	}
+	if gpus[0] != want {
+		t.Errorf("AMD GPU = %+v, want %+v", gpus[0], want)
+	}
+
+	want = GPUStats{
+		Name:        "Intel Graphics",
+		Vendor:      GPUVendorIntel,
+		Driver:      "i915",
+		Card:        "card1",
+		Temperature: 48,
+		FreqMHz:     950,
+		FreqMaxMHz:  1300,
+		Available:   true,
+	}
+	if gpus[1] != want {
+		t.Errorf("Intel GPU = %+v, want %+v", gpus[1], want)
+	}
+}
+
+func TestGPUReaderReadAll(t *testing.T) {
+	drmPath := t.TempDir()
+	_, amd := createDRMCard(t, drmPath, "card0", "amdgpu")
+	writeFile(t, amd, "gpu_busy_percent", "5")
+
+	r := &gpuReader{cacheDuration: time.Hour, drmPath: drmPath}
+	gpus := r.ReadAll()
+	if len(gpus) != 1 || gpus[0].UtilGPU != 5 {
+		t.Fatalf("ReadAll() = %+v, want one AMD GPU at 5%%", gpus)
+	}
+
+	// Cached until the cache duration expires
+	writeFile(t, amd, "gpu_busy_percent", "90")
+	if gpus := r.ReadAll(); gpus[0].UtilGPU != 5 {
+		t.Errorf("UtilGPU = %d, want cached 5", gpus[0].UtilGPU)
+	}
+
+	// Without nvidia-smi there is no NVIDIA GPU for ${nvidia}
+	if stats, _ := r.ReadStats(); stats.Available {
+		t.Errorf("ReadStats() = %+v, want unavailable", stats)
+	}
+}
@@ -0,0 +1,274 @@
+package monitor
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistoryLength is the number of samples kept per metric when no
+// length is configured. At the default update interval of one second this
+// covers two minutes.
+const DefaultHistoryLength = 120
+
+// Sample is a single recorded value of a metric.
+type Sample struct {
+	// Time is when the value was recorded.
+	Time time.Time
+	// Value is the recorded value.
+	Value float64
+}
+
+// History keeps the most recent samples of each metric in a fixed-length
+// ring buffer per metric ID. SystemMonitor records every monitored metric
+// after each update, so that graphs, statistics variables, Lua scripts and
+// exporters all read the same samples.
+//
+// Metric IDs are dot-separated paths:
+//
+//	cpu.total, cpu.core<N>          CPU usage in percent (cores from 0)
+//	mem.used, mem.used_perc         memory usage in bytes and percent
+//	swap.used, swap.used_perc       swap usage in bytes and percent
+//	load.1, load.5, load.15         load averages
+//	net.<iface>.down, .up           network rates in bytes per second
+//	fs.<mount>.used, .used_perc     filesystem usage in bytes and percent
+//	diskio.<dev>.read, .write       disk I/O rates in bytes per second
+//	hwmon.<dev>.<sensor>            temperatures in degrees Celsius, fan
+//	                                speeds in RPM, voltages, power and
+//	                                currents in V, W and A
+//	battery.<name>.percent          battery charge in percent
+//	battery.<name>.discharging      1 while the battery discharges, else 0
+//	ac.online                       1 while on AC power, else 0
+//
+// History is safe for concurrent use.
+type History struct {
+	mu     sync.RWMutex
+	length int
+	series map[string]*ring
+}
+
+// ring is a fixed-capacity circular buffer of samples.
+type ring struct {
+	samples []Sample // Backing array, len(samples) == capacity
+	start   int      // Index of the oldest sample
+	count   int      // Number of samples stored
+}
+
+// NewHistory creates a History keeping length samples per metric.
+// A length of zero or less uses DefaultHistoryLength.
+func NewHistory(length int) *History {
+	if length <= 0 {
+		length = DefaultHistoryLength
+	}
+	return &History{
+		length: length,
+		series: make(map[string]*ring),
+	}
+}
+
+// Length returns the number of samples kept per metric.
+func (h *History) Length() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.length
+}
+
+// SetLength changes the number of samples kept per metric, keeping the most
+// recent samples of each metric. A length of zero or less uses
+// DefaultHistoryLength.
+func (h *History) SetLength(length int) {
+	if length <= 0 {
+		length = DefaultHistoryLength
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if length == h.length {
+		return
+	}
+	h.length = length
+	for id, r := range h.series {
+		resized := newRing(length)
+		for _, s := range r.last(length) {
+			resized.push(s)
+		}
+		h.series[id] = resized
+	}
+}
+
+// Record appends a sample to the metric's ring buffer, replacing its oldest
+// sample once the buffer is full.
+func (h *History) Record(id string, t time.Time, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.series[id]
+	if !ok {
+		r = newRing(h.length)
+		h.series[id] = r
+	}
+	r.push(Sample{Time: t, Value: value})
+}
+
+// Query returns up to n of the most recent samples of a metric, oldest
+// first. n of zero or less returns all retained samples. The result is nil
+// for unknown metrics.
+func (h *History) Query(id string, n int) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r, ok := h.series[id]
+	if !ok {
+		return nil
+	}
+	if n <= 0 {
+		n = r.count
+	}
+	return r.last(n)
+}
+
+// IDs returns the IDs of all recorded metrics in sorted order.
+func (h *History) IDs() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.series))
+	for id := range h.series {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Prune removes metrics whose most recent sample is older than before, such
+// as interfaces or mounts that have disappeared.
+func (h *History) Prune(before time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, r := range h.series {
+		if r.count == 0 || r.newest().Time.Before(before) {
+			delete(h.series, id)
+		}
+	}
+}
+
+// HistoryScale returns the value a metric reaches at full scale: 100 for
+// percentages, or 0 for unbounded metrics such as rates and byte counts.
+func HistoryScale(id string) float64 {
+	if strings.HasPrefix(id, "cpu.") || strings.HasSuffix(id, ".used_perc") || strings.HasSuffix(id, ".percent") {
+		return 100
+	}
+	return 0
+}
+
+// boolValue records a boolean as 1 or 0.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// newRing creates an empty ring holding up to capacity samples.
+func newRing(capacity int) *ring {
+	return &ring{samples: make([]Sample, capacity)}
+}
+
+// push appends s, overwriting the oldest sample when full.
+func (r *ring) push(s Sample) {
+	capacity := len(r.samples)
+	if r.count < capacity {
+		r.samples[(r.start+r.count)%capacity] = s
+		r.count++
+		return
+	}
+	r.samples[r.start] = s
+	r.start = (r.start + 1) % capacity
+}
+
+// newest returns the most recent sample. The ring must not be empty.
+func (r *ring) newest() Sample {
+	return r.samples[(r.start+r.count-1)%len(r.samples)]
+}
+
+// last returns a copy of up to n of the most recent samples, oldest first.
+func (r *ring) last(n int) []Sample {
+	n = min(n, r.count)
+	out := make([]Sample, n)
+	first := r.start + r.count - n
+	for i := range out {
+		out[i] = r.samples[(first+i)%len(r.samples)]
+	}
+	return out
+}
+
+// recordHistory records the current value of every monitored metric. It
+// records at most once per half update interval, since the render loop
+// calls Update in addition to the monitoring loop.
+func (sm *SystemMonitor) recordHistory(now time.Time) {
+	sm.mu.Lock()
+	due := sm.historyAt.IsZero() || now.Sub(sm.historyAt) >= sm.interval/2
+	if due {
+		sm.historyAt = now
+	}
+	sm.mu.Unlock()
+	if !due {
+		return
+	}
+
+	h := sm.history
+
+	cpu := sm.data.GetCPU()
+	h.Record("cpu.total", now, cpu.UsagePercent)
+	for i, usage := range cpu.Cores {
+		h.Record("cpu.core"+strconv.Itoa(i), now, usage)
+	}
+
+	mem := sm.data.GetMemory()
+	h.Record("mem.used", now, float64(mem.Used))
+	h.Record("mem.used_perc", now, mem.UsagePercent)
+	h.Record("swap.used", now, float64(mem.SwapUsed))
+	h.Record("swap.used_perc", now, mem.SwapPercent)
+
+	sysInfo := sm.data.GetSysInfo()
+	h.Record("load.1", now, sysInfo.LoadAvg1)
+	h.Record("load.5", now, sysInfo.LoadAvg5)
+	h.Record("load.15", now, sysInfo.LoadAvg15)
+
+	for name, iface := range sm.data.GetNetwork().Interfaces {
+		h.Record("net."+name+".down", now, iface.RxBytesPerSec)
+		h.Record("net."+name+".up", now, iface.TxBytesPerSec)
+	}
+
+	for mountPoint, mount := range sm.data.GetFilesystem().Mounts {
+		h.Record("fs."+mountPoint+".used", now, float64(mount.Used))
+		h.Record("fs."+mountPoint+".used_perc", now, mount.UsagePercent)
+	}
+
+	for name, disk := range sm.data.GetDiskIO().Disks {
+		h.Record("diskio."+name+".read", now, disk.ReadBytesPerSec)
+		h.Record("diskio."+name+".write", now, disk.WriteBytesPerSec)
+	}
+
+	for devName, dev := range sm.data.GetHwmon().Devices {
+		for sensor, temp := range dev.Temps {
+			h.Record("hwmon."+devName+"."+sensor, now, temp.InputCelsius)
+		}
+		for _, sensors := range []map[string]HwmonSensor{dev.Fans, dev.Voltages, dev.Power, dev.Currents} {
+			for sensor, reading := range sensors {
+				h.Record("hwmon."+devName+"."+sensor, now, reading.Input)
+			}
+		}
+	}
+
+	battery := sm.data.GetBattery()
+	for name, bat := range battery.Batteries {
+		h.Record("battery."+name+".percent", now, float64(bat.Capacity))
+		h.Record("battery."+name+".discharging", now, boolValue(bat.Status == "Discharging"))
+	}
+	if len(battery.ACAdapters) > 0 {
+		h.Record("ac.online", now, boolValue(battery.ACOnline))
+	}
+
+	// Drop metrics that have not been recorded for a full history length
+	h.Prune(now.Add(-time.Duration(h.Length()) * sm.interval))
+}
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func sampleValues(samples []Sample) []float64 {
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Value
+	}
+	return values
+}
+
+func equalValues(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHistoryRingBuffer(t *testing.T) {
+	h := NewHistory(3)
+	start := time.Unix(1000, 0)
+	for i := 1; i <= 5; i++ {
+		h.Record("cpu.total", start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []float64
+	}{
+		{"all", 0, []float64{3, 4, 5}},
+		{"last two", 2, []float64{4, 5}},
+		{"more than retained", 10, []float64{3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sampleValues(h.Query("cpu.total", tt.n))
+			if !equalValues(got, tt.want) {
+				t.Errorf("Query(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+
+	samples := h.Query("cpu.total", 1)
+	if !samples[0].Time.Equal(start.Add(5 * time.Second)) {
+		t.Errorf("newest sample time = %v, want %v", samples[0].Time, start.Add(5*time.Second))
+	}
+	if got := h.Query("missing", 0); got != nil {
+		t.Errorf("Query(missing) = %v, want nil", got)
+	}
+}
+
+func TestHistorySetLength(t *testing.T) {
+	h := NewHistory(4)
+	now := time.Now()
+	for i := 1; i <= 4; i++ {
+		h.Record("mem.used_perc", now, float64(i))
+	}
+
+	h.SetLength(2)
+	if got := sampleValues(h.Query("mem.used_perc", 0)); !equalValues(got, []float64{3, 4}) {
+		t.Errorf("after shrinking, samples = %v, want [3 4]", got)
+	}
+
+	h.SetLength(3)
+	h.Record("mem.used_perc", now, 5)
+	h.Record("mem.used_perc", now, 6)
+	if got := sampleValues(h.Query("mem.used_perc", 0)); !equalValues(got, []float64{4, 5, 6}) {
+		t.Errorf("after growing, samples = %v, want [4 5 6]", got)
+	}
+
+	h.SetLength(0)
+	if h.Length() != DefaultHistoryLength {
+		t.Errorf("Length() = %d, want %d", h.Length(), DefaultHistoryLength)
+	}
+}
+
+func TestHistoryPrune(t *testing.T) {
+	h := NewHistory(10)
+	now := time.Now()
+	h.Record("net.eth0.down", now.Add(-time.Minute), 1)
+	h.Record("net.eth1.down", now, 2)
+
+	h.Prune(now.Add(-time.Second))
+
+	ids := h.IDs()
+	if len(ids) != 1 || ids[0] != "net.eth1.down" {
+		t.Errorf("IDs() after Prune = %v, want [net.eth1.down]", ids)
+	}
+}
+
+func TestHistoryScale(t *testing.T) {
+	tests := []struct {
+		id   string
+		want float64
+	}{
+		{"cpu.total", 100},
+		{"cpu.core3", 100},
+		{"mem.used_perc", 100},
+		{"fs./home.used_perc", 100},
+		{"battery.BAT0.percent", 100},
+		{"mem.used", 0},
+		{"net.eth0.down", 0},
+		{"load.1", 0},
+	}
+	for _, tt := range tests {
+		if got := HistoryScale(tt.id); got != tt.want {
+			t.Errorf("HistoryScale(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestSystemMonitorHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupMockProcFiles(t, tmpDir)
+
+	sm := createTestMonitor(tmpDir, time.Nanosecond)
+	sm.SetHistoryLength(2)
+	for i := 0; i < 3; i++ {
+		_ = sm.Update()
+	}
+
+	for _, id := range []string{"cpu.total", "cpu.core0", "cpu.core1", "mem.used_perc", "net.eth0.down", "diskio.sda.read"} {
+		if got := len(sm.History(id, 0)); got != 2 {
+			t.Errorf("len(History(%q)) = %d, want 2", id, got)
+		}
+	}
+
+	want := sm.Memory().UsagePercent
+	samples := sm.History("mem.used_perc", 1)
+	if len(samples) != 1 || samples[0].Value != want {
+		t.Errorf("History(mem.used_perc, 1) = %v, want value %v", samples, want)
+	}
+}
+
+func TestSystemMonitorHistoryOncePerInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupMockProcFiles(t, tmpDir)
+
+	sm := createTestMonitor(tmpDir, time.Hour)
+	for i := 0; i < 3; i++ {
+		_ = sm.Update()
+	}
+
+	if got := len(sm.History("cpu.total", 0)); got != 1 {
+		t.Errorf("len(History(cpu.total)) = %d, want 1", got)
+	}
+}
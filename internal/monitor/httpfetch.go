@@ -0,0 +1,179 @@
+// Package monitor provides system monitoring functionality.
+// This file implements the cached HTTP fetch layer shared by the web
+// content variables.
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerInterface mirrors conky.CircuitBreaker. It is defined
+// locally to avoid an import cycle (pkg/conky -> monitor).
+type CircuitBreakerInterface interface {
+	// Execute runs fn unless the circuit is open, in which case it returns
+	// an error without calling fn.
+	Execute(fn func() error) error
+}
+
+// errFetchPending is returned for a URL until its first fetch completes.
+var errFetchPending = errors.New("fetch in progress")
+
+// httpFetcher fetches web resources in the background, caching each URL's
+// response for the interval requested by its caller. Requests to a host go
+// through that host's circuit breaker, if a breaker factory is set.
+type httpFetcher struct {
+	mu          sync.Mutex
+	client      *http.Client
+	maxBodySize int64
+	minInterval time.Duration
+	userAgent   string
+	cache       map[string]*httpCacheEntry
+	breakers    map[string]CircuitBreakerInterface
+	newBreaker  func() CircuitBreakerInterface
+}
+
+// httpCacheEntry holds the outcome of the last fetch of a URL.
+type httpCacheEntry struct {
+	body      []byte    // Body of the last successful fetch
+	updated   time.Time // When body was fetched
+	err       error     // Error of the last fetch, nil if it succeeded
+	fetchTime time.Time // When the last fetch was attempted
+	fetching  bool      // Whether a fetch is in progress
+}
+
+// newHTTPFetcher creates an httpFetcher with default settings.
+func newHTTPFetcher() *httpFetcher {
+	return &httpFetcher{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxBodySize: 1 << 20,
+		minInterval: 30 * time.Second,
+		userAgent:   "go-conky",
+		cache:       make(map[string]*httpCacheEntry),
+		breakers:    make(map[string]CircuitBreakerInterface),
+	}
+}
+
+// SetCircuitBreaker sets the factory creating the circuit breaker that
+// guards each host. Nil disables circuit breaking.
+func (f *httpFetcher) SetCircuitBreaker(newBreaker func() CircuitBreakerInterface) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.newBreaker = newBreaker
+	f.breakers = make(map[string]CircuitBreakerInterface)
+}
+
+// Fetch returns the last body fetched from rawURL and the time it was
+// fetched, without waiting on the network. Once interval has passed a
+// fetch is started in the background, at most one per URL at a time, and
+// the previous body is returned until it completes; before the first fetch
+// completes the body is nil and the error is errFetchPending. When a fetch
+// fails, the body of the last successful fetch, if any, is returned along
+// with the error.
+func (f *httpFetcher) Fetch(rawURL string, interval time.Duration) ([]byte, time.Time, error) {
+	interval = max(interval, f.minInterval)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.cache[rawURL]
+	if !ok {
+		entry = &httpCacheEntry{err: errFetchPending}
+		f.cache[rawURL] = entry
+	}
+	if !entry.fetching && time.Since(entry.fetchTime) >= interval {
+		entry.fetching = true
+		go f.refresh(rawURL, entry)
+	}
+	return entry.body, entry.updated, entry.err
+}
+
+// refresh fetches rawURL and records the outcome in entry.
+func (f *httpFetcher) refresh(rawURL string, entry *httpCacheEntry) {
+	body, err := f.fetch(rawURL)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry.err = err
+	entry.fetchTime = time.Now()
+	entry.fetching = false
+	if err == nil {
+		entry.body = body
+		entry.updated = entry.fetchTime
+	}
+}
+
+// fetch requests rawURL through its host's circuit breaker.
+func (f *httpFetcher) fetch(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	breaker := f.breaker(u.Host)
+	if breaker == nil {
+		return f.get(rawURL)
+	}
+	var body []byte
+	err = breaker.Execute(func() error {
+		var err error
+		body, err = f.get(rawURL)
+		return err
+	})
+	return body, err
+}
+
+// breaker returns the circuit breaker guarding host, or nil if circuit
+// breaking is disabled.
+func (f *httpFetcher) breaker(host string) CircuitBreakerInterface {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.newBreaker == nil {
+		return nil
+	}
+	breaker, ok := f.breakers[host]
+	if !ok {
+		breaker = f.newBreaker()
+		f.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// get performs the GET request, rejecting bodies over maxBodySize.
+func (f *httpFetcher) get(rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if int64(len(body)) > f.maxBodySize {
+		return nil, fmt.Errorf("response exceeds %d bytes", f.maxBodySize)
+	}
+	return body, nil
+}
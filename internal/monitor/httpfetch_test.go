@@ -0,0 +1,223 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBreaker is a circuit breaker that opens after threshold
+// consecutive failures and never recovers.
+type countingBreaker struct {
+	threshold int
+	failures  int
+}
+
+var errTestCircuitOpen = errors.New("circuit open")
+
+func (b *countingBreaker) Execute(fn func() error) error {
+	if b.failures >= b.threshold {
+		return errTestCircuitOpen
+	}
+	err := fn()
+	if err != nil {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+	return err
+}
+
+func TestHTTPFetcherFetch(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if r.Header.Get("User-Agent") != "go-conky" {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		fmt.Fprintf(w, "status %d", n)
+	}))
+	defer server.Close()
+
+	f := newHTTPFetcher()
+	f.minInterval = 0
+
+	if body, _, err := f.Fetch(server.URL, time.Hour); body != nil || err != errFetchPending {
+		t.Errorf("first Fetch() = %q, %v, want nil and errFetchPending", body, err)
+	}
+	body, updated, err := fetchNow(t, f, server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(body) != "status 1" || updated.IsZero() {
+		t.Errorf("Fetch() = %q, %v", body, updated)
+	}
+
+	// Cached within the interval
+	body, cachedUpdated, _ := f.Fetch(server.URL, time.Hour)
+	if string(body) != "status 1" || !cachedUpdated.Equal(updated) {
+		t.Errorf("cached Fetch() = %q, %v", body, cachedUpdated)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+
+	// Refetched once the interval has passed
+	body, _, _ = fetchNow(t, f, server.URL, 0)
+	if string(body) != "status 2" {
+		t.Errorf("Fetch() after interval = %q, want %q", body, "status 2")
+	}
+}
+
+func TestHTTPFetcherMinInterval(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	f := newHTTPFetcher()
+	f.minInterval = time.Hour
+	if _, _, err := fetchNow(t, f, server.URL, 0); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := f.Fetch(server.URL, 0); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 within the minimum interval", n)
+	}
+}
+
+func TestHTTPFetcherErrors(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/large":
+			w.Write([]byte(strings.Repeat("x", 64)))
+		case fail.Load():
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	f := newHTTPFetcher()
+	f.minInterval = 0
+	f.maxBodySize = 32
+
+	if _, _, err := fetchNow(t, f, server.URL+"/large", 0); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Fetch() of a large body error = %v, want size error", err)
+	}
+	if _, _, err := fetchNow(t, f, "file:///etc/passwd", 0); err == nil {
+		t.Error("Fetch() of a file URL succeeded, want error")
+	}
+
+	// A failed refetch keeps the last good body
+	if _, _, err := fetchNow(t, f, server.URL, 0); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	fail.Store(true)
+	body, _, err := fetchNow(t, f, server.URL, 0)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+		t.Errorf("Fetch() error = %v, want HTTP 503", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("Fetch() body = %q, want the last good body", body)
+	}
+}
+
+func TestHTTPFetcherCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	f := newHTTPFetcher()
+	f.minInterval = 0
+	var breakers int
+	f.SetCircuitBreaker(func() CircuitBreakerInterface {
+		breakers++
+		return &countingBreaker{threshold: 2}
+	})
+
+	// The breaker is shared by every URL on the host
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		_, _, err := fetchNow(t, f, server.URL+path, 0)
+		if err == nil {
+			t.Fatalf("Fetch(%s) succeeded, want error", path)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2 before the circuit opened", n)
+	}
+	if breakers != 1 {
+		t.Errorf("breakers created = %d, want 1 per host", breakers)
+	}
+	if _, _, err := fetchNow(t, f, server.URL+"/e", 0); !errors.Is(err, errTestCircuitOpen) {
+		t.Errorf("Fetch() error = %v, want the breaker's error", err)
+	}
+}
+
+func TestHTTPFetcherSingleFlight(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	f := newHTTPFetcher()
+	f.minInterval = 0
+
+	// Callers are not held up by the slow response and share one request
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if body, _, err := f.Fetch(server.URL, 0); body != nil || err != errFetchPending {
+			t.Errorf("Fetch() = %q, %v, want nil and errFetchPending", body, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Fetch() took %v waiting on the server", elapsed)
+		}
+	}
+	waitFor(t, func() bool { return requests.Load() == 1 })
+	release <- struct{}{}
+
+	body, _, err := fetchNow(t, f, server.URL, time.Hour)
+	if err != nil || string(body) != "done" {
+		t.Errorf("Fetch() = %q, %v, want %q", body, err, "done")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 for concurrent callers", n)
+	}
+}
+
+// fetchNow calls Fetch, which starts a fetch of rawURL if interval has
+// passed, and returns the cached result once no fetch is in progress.
+func fetchNow(t *testing.T, f *httpFetcher, rawURL string, interval time.Duration) ([]byte, time.Time, error) {
+	t.Helper()
+	f.Fetch(rawURL, interval)
+	waitFor(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return !f.cache[rawURL].fetching
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry := f.cache[rawURL]
+	return entry.body, entry.updated, entry.err
+}
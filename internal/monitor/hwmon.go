@@ -2,8 +2,10 @@ package monitor
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +33,26 @@ type TempSensor struct {
 	Type string
 }
 
+// HwmonSensor represents a single fan, voltage, power or current sensor
+// reading. Values are converted from the sysfs units to RPM, volts, watts
+// and amperes; thresholds the driver does not report are zero.
+type HwmonSensor struct {
+	// Label is the sensor label (e.g., "Vcore", "CPU Fan"), or Type when
+	// the driver provides none.
+	Label string
+	// Type is the sensor type identifier (e.g., "fan1", "in0", "power1").
+	Type string
+	// Input is the current reading.
+	Input float64
+	// Min is the minimum threshold.
+	Min float64
+	// Max is the maximum threshold. For power sensors without a maximum
+	// it is the power cap.
+	Max float64
+	// Crit is the critical threshold.
+	Crit float64
+}
+
 // HwmonDevice represents a single hwmon device with its sensors.
 type HwmonDevice struct {
 	// Name is the device name (e.g., "coretemp", "acpitz").
@@ -39,6 +61,56 @@ type HwmonDevice struct {
 	Path string
 	// Temps contains temperature sensor readings keyed by sensor type.
 	Temps map[string]TempSensor
+	// Fans contains fan speeds in RPM keyed by sensor type ("fan1").
+	Fans map[string]HwmonSensor
+	// Voltages contains voltages in volts keyed by sensor type ("in0").
+	Voltages map[string]HwmonSensor
+	// Power contains power readings in watts keyed by sensor type ("power1").
+	Power map[string]HwmonSensor
+	// Currents contains currents in amperes keyed by sensor type ("curr1").
+	Currents map[string]HwmonSensor
+}
+
+// Sensor returns the sensor of the given kind ("temp", "fan", "in" or
+// "vol", "power", "curr") identified by its number or, case-insensitively,
+// by its label. Temperatures are returned in degrees Celsius.
+func (d HwmonDevice) Sensor(kind, id string) (HwmonSensor, bool) {
+	var sensors map[string]HwmonSensor
+	switch kind {
+	case "temp":
+		sensors = make(map[string]HwmonSensor, len(d.Temps))
+		for sensorType, temp := range d.Temps {
+			sensors[sensorType] = HwmonSensor{
+				Label: temp.Label,
+				Type:  temp.Type,
+				Input: temp.InputCelsius,
+				Max:   temp.MaxCelsius,
+				Crit:  temp.CritCelsius,
+			}
+		}
+	case "fan":
+		sensors = d.Fans
+	case "in", "vol":
+		kind, sensors = "in", d.Voltages
+	case "power":
+		sensors = d.Power
+	case "curr":
+		sensors = d.Currents
+	default:
+		return HwmonSensor{}, false
+	}
+
+	if _, err := strconv.Atoi(id); err == nil {
+		sensor, ok := sensors[kind+id]
+		return sensor, ok
+	}
+	// Sort so that a label shared by several sensors always finds the same one
+	for _, sensorType := range slices.Sorted(maps.Keys(sensors)) {
+		if strings.EqualFold(sensors[sensorType].Label, id) {
+			return sensors[sensorType], true
+		}
+	}
+	return HwmonSensor{}, false
 }
 
 // HwmonStats contains hardware monitoring statistics.
@@ -49,6 +121,42 @@ type HwmonStats struct {
 	TempSensors []TempSensor
 }
 
+// Device returns the device named id. The device may also be given by its
+// sysfs directory name ("hwmon2") or the number of that directory ("2").
+func (s HwmonStats) Device(id string) (HwmonDevice, bool) {
+	if device, ok := s.Devices[id]; ok {
+		return device, true
+	}
+	if _, err := strconv.Atoi(id); err == nil {
+		id = "hwmon" + id
+	}
+	for _, device := range s.Devices {
+		if filepath.Base(device.Path) == id {
+			return device, true
+		}
+	}
+	return HwmonDevice{}, false
+}
+
+// hwmonSensorKind describes how to read one kind of non-temperature sensor.
+type hwmonSensorKind struct {
+	// prefix is the sensor type prefix, e.g. "fan" for fan1_input.
+	prefix string
+	// inputs lists the reading attributes in order of preference.
+	inputs []string
+	// scale converts sysfs values to the HwmonSensor unit.
+	scale float64
+}
+
+// hwmonSensorKinds lists the sensors read besides temperatures. sysfs
+// reports voltages and currents in milli-units and power in microwatts.
+var hwmonSensorKinds = []hwmonSensorKind{
+	{prefix: "fan", inputs: []string{"input"}, scale: 1},
+	{prefix: "in", inputs: []string{"input"}, scale: 1000},
+	{prefix: "power", inputs: []string{"average", "input"}, scale: 1000000},
+	{prefix: "curr", inputs: []string{"input"}, scale: 1000},
+}
+
 // hwmonReader reads hardware monitoring data from /sys/class/hwmon.
 type hwmonReader struct {
 	mu        sync.Mutex
@@ -109,8 +217,18 @@ func (r *hwmonReader) ReadStats() (HwmonStats, error) {
 // readDevice reads information for a single hwmon device.
 func (r *hwmonReader) readDevice(devicePath string) (HwmonDevice, error) {
 	device := HwmonDevice{
-		Path:  devicePath,
-		Temps: make(map[string]TempSensor),
+		Path:     devicePath,
+		Temps:    make(map[string]TempSensor),
+		Fans:     make(map[string]HwmonSensor),
+		Voltages: make(map[string]HwmonSensor),
+		Power:    make(map[string]HwmonSensor),
+		Currents: make(map[string]HwmonSensor),
+	}
+	sensorMaps := map[string]map[string]HwmonSensor{
+		"fan":   device.Fans,
+		"in":    device.Voltages,
+		"power": device.Power,
+		"curr":  device.Currents,
 	}
 
 	// Read device name from the 'name' file
@@ -128,7 +246,7 @@ func (r *hwmonReader) readDevice(devicePath string) (HwmonDevice, error) {
 		device.Name = strings.TrimSpace(string(nameBytes))
 	}
 
-	// Find all temperature sensors (temp1_input, temp2_input, etc.)
+	// Find all sensors (temp1_input, fan1_input, in0_input, etc.)
 	entries, err := os.ReadDir(devicePath)
 	if err != nil {
 		return device, fmt.Errorf("reading device directory: %w", err)
@@ -136,6 +254,17 @@ func (r *hwmonReader) readDevice(devicePath string) (HwmonDevice, error) {
 
 	for _, entry := range entries {
 		name := entry.Name()
+		if kind, sensorType, ok := matchSensorKind(name); ok {
+			sensors := sensorMaps[kind.prefix]
+			if _, seen := sensors[sensorType]; seen {
+				// power1_average and power1_input describe the same sensor
+				continue
+			}
+			if sensor, err := readSensor(devicePath, sensorType, kind); err == nil {
+				sensors[sensorType] = sensor
+			}
+			continue
+		}
 		if !strings.HasPrefix(name, "temp") || !strings.HasSuffix(name, "_input") {
 			continue
 		}
@@ -200,3 +329,74 @@ func (r *hwmonReader) readTempSensor(devicePath, sensorType string) (TempSensor,
 
 	return sensor, nil
 }
+
+// matchSensorKind reports whether the sysfs attribute name is the reading of
+// a non-temperature sensor and returns its kind and sensor type.
+func matchSensorKind(name string) (hwmonSensorKind, string, bool) {
+	sensorType, attr, ok := strings.Cut(name, "_")
+	if !ok {
+		return hwmonSensorKind{}, "", false
+	}
+	for _, kind := range hwmonSensorKinds {
+		number, ok := strings.CutPrefix(sensorType, kind.prefix)
+		if !ok || !slices.Contains(kind.inputs, attr) {
+			continue
+		}
+		// Reject attributes such as intrusion0_alarm that share a prefix
+		if _, err := strconv.Atoi(number); err != nil {
+			continue
+		}
+		return kind, sensorType, true
+	}
+	return hwmonSensorKind{}, "", false
+}
+
+// readSensor reads a single fan, voltage, power or current sensor.
+func readSensor(devicePath, sensorType string, kind hwmonSensorKind) (HwmonSensor, error) {
+	sensor := HwmonSensor{Type: sensorType, Label: sensorType}
+
+	// Read the first available input (required)
+	var err error
+	for _, input := range kind.inputs {
+		var value float64
+		if value, err = readSensorValue(devicePath, sensorType+"_"+input); err == nil {
+			sensor.Input = value / kind.scale
+			break
+		}
+	}
+	if err != nil {
+		return sensor, err
+	}
+
+	if labelBytes, err := os.ReadFile(filepath.Join(devicePath, sensorType+"_label")); err == nil {
+		sensor.Label = strings.TrimSpace(string(labelBytes))
+	}
+
+	// Read thresholds (optional)
+	if value, err := readSensorValue(devicePath, sensorType+"_min"); err == nil {
+		sensor.Min = value / kind.scale
+	}
+	if value, err := readSensorValue(devicePath, sensorType+"_max"); err == nil {
+		sensor.Max = value / kind.scale
+	} else if value, err := readSensorValue(devicePath, sensorType+"_cap"); err == nil {
+		sensor.Max = value / kind.scale
+	}
+	if value, err := readSensorValue(devicePath, sensorType+"_crit"); err == nil {
+		sensor.Crit = value / kind.scale
+	}
+
+	return sensor, nil
+}
+
+// readSensorValue reads an integer sysfs attribute of a hwmon device.
+func readSensorValue(devicePath, attr string) (float64, error) {
+	data, err := os.ReadFile(filepath.Join(devicePath, attr))
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", attr, err)
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", attr, err)
+	}
+	return float64(value), nil
+}
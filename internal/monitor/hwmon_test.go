@@ -445,3 +445,133 @@ func TestHwmonStatsStruct(t *testing.T) {
 		t.Errorf("TempSensors count = %d, want 1", len(stats.TempSensors))
 	}
 }
+
+// writeNCT6775 creates a hwmon device with fan, voltage, power and current
+// sensors under dir.
+func writeNCT6775(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"name":               "nct6775",
+		"fan1_input":         "1200",
+		"fan1_label":         "CPU Fan",
+		"fan1_min":           "300",
+		"fan2_input":         "0",
+		"fan2_alarm":         "0",
+		"in0_input":          "1104",
+		"in0_label":          "Vcore",
+		"in0_min":            "800",
+		"in0_max":            "1500",
+		"in1_input":          "12096",
+		"intrusion0_alarm":   "1",
+		"power1_average":     "35500000",
+		"power1_input":       "99000000",
+		"power1_cap":         "65000000",
+		"power1_average_min": "0",
+		"curr1_input":        "2500",
+		"curr1_crit":         "10000",
+		"temp1_input":        "42000",
+		"temp1_label":        "SYSTIN",
+	}
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+}
+
+func TestHwmonReaderFanVoltagePowerCurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeNCT6775(t, filepath.Join(tmpDir, "hwmon2"))
+
+	reader := &hwmonReader{hwmonPath: tmpDir}
+	stats, err := reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	device, ok := stats.Devices["nct6775"]
+	if !ok {
+		t.Fatal("nct6775 device not found")
+	}
+
+	tests := []struct {
+		name    string
+		sensors map[string]HwmonSensor
+		key     string
+		want    HwmonSensor
+	}{
+		{"fan1", device.Fans, "fan1", HwmonSensor{Label: "CPU Fan", Type: "fan1", Input: 1200, Min: 300}},
+		{"fan2", device.Fans, "fan2", HwmonSensor{Label: "fan2", Type: "fan2"}},
+		{"in0", device.Voltages, "in0", HwmonSensor{Label: "Vcore", Type: "in0", Input: 1.104, Min: 0.8, Max: 1.5}},
+		{"in1", device.Voltages, "in1", HwmonSensor{Label: "in1", Type: "in1", Input: 12.096}},
+		{"power1 prefers average and falls back to cap", device.Power, "power1", HwmonSensor{Label: "power1", Type: "power1", Input: 35.5, Max: 65}},
+		{"curr1", device.Currents, "curr1", HwmonSensor{Label: "curr1", Type: "curr1", Input: 2.5, Crit: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sensors[tt.key]; got != tt.want {
+				t.Errorf("%s = %+v, want %+v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if len(device.Voltages) != 2 {
+		t.Errorf("Voltages = %v, want in0 and in1 only", device.Voltages)
+	}
+	if len(device.Power) != 1 {
+		t.Errorf("Power = %v, want power1 only", device.Power)
+	}
+	if len(device.Temps) != 1 {
+		t.Errorf("Temps count = %d, want 1", len(device.Temps))
+	}
+}
+
+func TestHwmonDeviceSensor(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeNCT6775(t, filepath.Join(tmpDir, "hwmon2"))
+	writeFile(t, filepath.Join(tmpDir, "hwmon2"), "temp1_crit", "95000")
+
+	reader := &hwmonReader{hwmonPath: tmpDir}
+	stats, err := reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+
+	for _, id := range []string{"nct6775", "hwmon2", "2"} {
+		if _, ok := stats.Device(id); !ok {
+			t.Errorf("Device(%q) not found", id)
+		}
+	}
+	if _, ok := stats.Device("3"); ok {
+		t.Error("Device(3) found a missing device")
+	}
+	device, _ := stats.Device("2")
+
+	tests := []struct {
+		kind, id  string
+		wantType  string
+		wantInput float64
+	}{
+		{"temp", "1", "temp1", 42},
+		{"temp", "systin", "temp1", 42},
+		{"fan", "CPU Fan", "fan1", 1200},
+		{"in", "0", "in0", 1.104},
+		{"vol", "Vcore", "in0", 1.104},
+		{"power", "1", "power1", 35.5},
+		{"curr", "curr1", "curr1", 2.5},
+	}
+	for _, tt := range tests {
+		sensor, ok := device.Sensor(tt.kind, tt.id)
+		if !ok || sensor.Type != tt.wantType || sensor.Input != tt.wantInput {
+			t.Errorf("Sensor(%q, %q) = %+v, %v, want %s = %v", tt.kind, tt.id, sensor, ok, tt.wantType, tt.wantInput)
+		}
+	}
+	if sensor, _ := device.Sensor("temp", "1"); sensor.Crit != 95 {
+		t.Errorf("temp1 Crit = %v, want 95", sensor.Crit)
+	}
+	for _, missing := range [][2]string{{"fan", "3"}, {"in", "VBAT"}, {"pwm", "1"}} {
+		if _, ok := device.Sensor(missing[0], missing[1]); ok {
+			t.Errorf("Sensor(%q, %q) found a missing sensor", missing[0], missing[1])
+		}
+	}
+}
@@ -51,10 +51,23 @@ type MailConfig struct {
 	UseTLS bool
 	// Folder is the mailbox folder (IMAP only, default "INBOX").
 	Folder string
-	// Interval is how often to check (minimum 60 seconds).
+	// Interval is how often to check (minimum 60 seconds, default
+	// DefaultMailInterval).
 	Interval time.Duration
 }
 
+// DefaultMailInterval is the time between mail checks when an account does
+// not set one, as in Conky.
+const DefaultMailInterval = 5 * time.Minute
+
+// Default mail server ports.
+const (
+	defaultIMAPPort  = 143
+	defaultIMAPSPort = 993
+	defaultPOP3Port  = 110
+	defaultPOP3SPort = 995
+)
+
 // mailReader reads mail statistics via IMAP/POP3.
 type mailReader struct {
 	mu       sync.RWMutex
@@ -78,16 +91,66 @@ func newMailReader() *mailReader {
 	}
 }
 
-// AddAccount adds a mail account configuration.
+// AddAccount adds a mail account configuration, replacing any account of
+// the same name.
 func (r *mailReader) AddAccount(config MailConfig) error {
+	config, err := normalizeMailConfig(config)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.accounts[config.Name] = newMailAccountReader(config)
+	return nil
+}
+
+// Account returns the statistics of the account described by config,
+// adding the account first if it is not monitored yet or its settings have
+// changed.
+func (r *mailReader) Account(config MailConfig) (MailAccountStats, error) {
+	config, err := normalizeMailConfig(config)
+	if err != nil {
+		return MailAccountStats{}, err
+	}
+
+	r.mu.Lock()
+	account, ok := r.accounts[config.Name]
+	if !ok || account.config != config {
+		account = newMailAccountReader(config)
+		r.accounts[config.Name] = account
+	}
+	r.mu.Unlock()
+
+	return account.getStats(), nil
+}
+
+// normalizeMailConfig validates config and fills in its defaults.
+func normalizeMailConfig(config MailConfig) (MailConfig, error) {
 	if config.Name == "" {
-		return fmt.Errorf("mail account name is required")
+		return config, fmt.Errorf("mail account name is required")
 	}
 	if config.Type != "imap" && config.Type != "pop3" {
-		return fmt.Errorf("mail type must be 'imap' or 'pop3'")
+		return config, fmt.Errorf("mail type must be 'imap' or 'pop3'")
 	}
 	if config.Host == "" {
-		return fmt.Errorf("mail host is required")
+		return config, fmt.Errorf("mail host is required")
+	}
+	if config.Port == 0 {
+		switch {
+		case config.Type == "imap" && config.UseTLS:
+			config.Port = defaultIMAPSPort
+		case config.Type == "imap":
+			config.Port = defaultIMAPPort
+		case config.UseTLS:
+			config.Port = defaultPOP3SPort
+		default:
+			config.Port = defaultPOP3Port
+		}
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultMailInterval
 	}
 	if config.Interval < 60*time.Second {
 		config.Interval = 60 * time.Second
@@ -95,17 +158,16 @@ func (r *mailReader) AddAccount(config MailConfig) error {
 	if config.Folder == "" {
 		config.Folder = "INBOX"
 	}
+	return config, nil
+}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	r.accounts[config.Name] = &mailAccountReader{
+// newMailAccountReader creates a reader for a normalized account.
+func newMailAccountReader(config MailConfig) *mailAccountReader {
+	return &mailAccountReader{
 		config:      config,
 		dialTimeout: 10 * time.Second,
 		readTimeout: 30 * time.Second,
 	}
-
-	return nil
 }
 
 // RemoveAccount removes a mail account.
@@ -1,6 +1,10 @@
 package monitor
 
 import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -351,3 +355,120 @@ func TestMailReaderAccountOverwrite(t *testing.T) {
 		t.Errorf("Expected host to be 'pop.example.com', got %s", account.config.Host)
 	}
 }
+
+func TestNormalizeMailConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       MailConfig
+		wantPort     int
+		wantInterval time.Duration
+	}{
+		{"imap", MailConfig{Name: "a", Type: "imap", Host: "h"}, 143, DefaultMailInterval},
+		{"imaps", MailConfig{Name: "a", Type: "imap", Host: "h", UseTLS: true}, 993, DefaultMailInterval},
+		{"pop3", MailConfig{Name: "a", Type: "pop3", Host: "h"}, 110, DefaultMailInterval},
+		{"pop3s", MailConfig{Name: "a", Type: "pop3", Host: "h", UseTLS: true}, 995, DefaultMailInterval},
+		{"explicit", MailConfig{Name: "a", Type: "imap", Host: "h", Port: 1143, Interval: 2 * time.Minute}, 1143, 2 * time.Minute},
+		{"short interval", MailConfig{Name: "a", Type: "imap", Host: "h", Interval: time.Second}, 143, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeMailConfig(tt.config)
+			if err != nil {
+				t.Fatalf("normalizeMailConfig() error = %v", err)
+			}
+			if got.Port != tt.wantPort {
+				t.Errorf("Port = %d, want %d", got.Port, tt.wantPort)
+			}
+			if got.Interval != tt.wantInterval {
+				t.Errorf("Interval = %v, want %v", got.Interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+// servePOP3 runs a minimal POP3 server reporting count messages and returns
+// its port.
+func servePOP3(t *testing.T, count int) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = conn.Write([]byte("+OK ready\r\n"))
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					switch strings.Fields(scanner.Text())[0] {
+					case "STAT":
+						_, _ = conn.Write([]byte("+OK " + strconv.Itoa(count) + " 4096\r\n"))
+					case "QUIT":
+						_, _ = conn.Write([]byte("+OK bye\r\n"))
+						return
+					default:
+						_, _ = conn.Write([]byte("+OK\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestMailReaderAccount(t *testing.T) {
+	reader := newMailReader()
+	config := MailConfig{
+		Name:     "pop3:user@127.0.0.1",
+		Type:     "pop3",
+		Host:     "127.0.0.1",
+		Port:     servePOP3(t, 3),
+		Username: "user",
+		Password: "secret",
+	}
+
+	stats, err := reader.Account(config)
+	if err != nil {
+		t.Fatalf("Account() error = %v", err)
+	}
+	if stats.Error != "" || stats.Total != 3 || stats.Unseen != 3 {
+		t.Errorf("Account() = %+v, want 3 messages", stats)
+	}
+
+	// The same settings reuse the cached account
+	reader.mu.RLock()
+	first := reader.accounts[config.Name]
+	reader.mu.RUnlock()
+	if _, err := reader.Account(config); err != nil {
+		t.Fatalf("Account() error = %v", err)
+	}
+	reader.mu.RLock()
+	again := reader.accounts[config.Name]
+	reader.mu.RUnlock()
+	if again != first {
+		t.Error("Account() replaced an unchanged account")
+	}
+
+	// Changed settings replace it
+	config.Port = servePOP3(t, 7)
+	stats, err = reader.Account(config)
+	if err != nil {
+		t.Fatalf("Account() error = %v", err)
+	}
+	if stats.Total != 7 {
+		t.Errorf("Total after change = %d, want 7", stats.Total)
+	}
+
+	if _, err := reader.Account(MailConfig{Name: "x", Type: "smtp", Host: "h"}); err == nil {
+		t.Error("Account() with invalid type succeeded, want error")
+	}
+}
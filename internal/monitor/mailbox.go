@@ -0,0 +1,320 @@
+// Package monitor provides local Maildir and mbox mailbox monitoring.
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailbox formats.
+const (
+	MailboxMaildir = "maildir"
+	MailboxMbox    = "mbox"
+)
+
+// MailboxStats contains the message counts of a local mailbox.
+type MailboxStats struct {
+	// Path is the mailbox path after expansion.
+	Path string
+	// Format is MailboxMaildir or MailboxMbox.
+	Format string
+	// Total is the number of messages.
+	Total int
+	// New is the number of messages not yet seen by a mail client: those in
+	// a Maildir's new directory, or mbox messages without an O or R status.
+	New int
+	// Unseen is the number of unread messages.
+	Unseen int
+	// Flagged is the number of flagged messages.
+	Flagged int
+	// Replied is the number of messages that were replied to.
+	Replied int
+	// Forwarded is the number of messages that were forwarded (Maildir only).
+	Forwarded int
+	// Trashed is the number of messages marked as deleted.
+	Trashed int
+	// Draft is the number of draft messages.
+	Draft int
+}
+
+// Seen returns the number of read messages.
+func (s MailboxStats) Seen() int {
+	return s.Total - s.Unseen
+}
+
+// mailboxReader counts the messages of local mailboxes, caching each
+// mailbox until its modification time or size changes.
+type mailboxReader struct {
+	mu    sync.Mutex
+	spool string
+	cache map[string]*cachedMailbox
+}
+
+// cachedMailbox holds the counts of a mailbox and the state they were read
+// at: the mtimes of a Maildir's new and cur directories, or the mtime and
+// size of an mbox file.
+type cachedMailbox struct {
+	stats   MailboxStats
+	newTime time.Time
+	curTime time.Time
+	size    int64
+}
+
+// newMailboxReader creates a mailbox reader using $MAIL as the spool.
+func newMailboxReader() *mailboxReader {
+	return &mailboxReader{
+		cache: make(map[string]*cachedMailbox),
+	}
+}
+
+// SetSpool sets the mailbox read when no path is given. Empty means $MAIL.
+func (r *mailboxReader) SetSpool(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spool = path
+}
+
+// Path returns the file or directory Read reads for path: the spool if
+// path is empty, with a leading ~/ and environment variables expanded. It
+// returns "" if path is empty and no spool is configured.
+func (r *mailboxReader) Path(path string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.path(path)
+}
+
+// path implements Path. The caller must hold r.mu.
+func (r *mailboxReader) path(path string) string {
+	if path == "" {
+		path = r.spool
+	}
+	if path == "" {
+		path = os.Getenv("MAIL")
+	}
+	if path == "" {
+		return ""
+	}
+	return expandMailboxPath(path)
+}
+
+// Read returns the counts of the Maildir or mbox at path, or of the spool
+// if path is empty. The path may start with ~/ and contain environment
+// variables.
+func (r *mailboxReader) Read(path string) (MailboxStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = r.path(path)
+	if path == "" {
+		return MailboxStats{}, fmt.Errorf("no mail spool configured and $MAIL is not set")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return MailboxStats{}, err
+	}
+
+	cached := r.cache[path]
+	if info.IsDir() {
+		newInfo, newErr := os.Stat(filepath.Join(path, "new"))
+		curInfo, curErr := os.Stat(filepath.Join(path, "cur"))
+		if newErr != nil && curErr != nil {
+			return MailboxStats{}, fmt.Errorf("%s is not a Maildir", path)
+		}
+		var newTime, curTime time.Time
+		if newErr == nil {
+			newTime = newInfo.ModTime()
+		}
+		if curErr == nil {
+			curTime = curInfo.ModTime()
+		}
+		if cached != nil && cached.newTime.Equal(newTime) && cached.curTime.Equal(curTime) {
+			return cached.stats, nil
+		}
+
+		stats, err := readMaildir(path)
+		if err != nil {
+			return MailboxStats{}, err
+		}
+		r.cache[path] = &cachedMailbox{stats: stats, newTime: newTime, curTime: curTime}
+		return stats, nil
+	}
+
+	if cached != nil && cached.newTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.stats, nil
+	}
+	stats, err := readMbox(path)
+	if err != nil {
+		return MailboxStats{}, err
+	}
+	r.cache[path] = &cachedMailbox{stats: stats, newTime: info.ModTime(), size: info.Size()}
+	return stats, nil
+}
+
+// expandMailboxPath expands a leading ~/ and environment variables.
+func expandMailboxPath(path string) string {
+	path = os.ExpandEnv(path)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// readMaildir counts the messages of a Maildir. Messages in new are unseen;
+// those in cur carry their flags after ":2," in the file name: S (seen),
+// F (flagged), R (replied), P (passed, i.e. forwarded), T (trashed) and
+// D (draft).
+func readMaildir(path string) (MailboxStats, error) {
+	stats := MailboxStats{Path: path, Format: MailboxMaildir}
+
+	newEntries, err := os.ReadDir(filepath.Join(path, "new"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return MailboxStats{}, err
+	}
+	for _, entry := range newEntries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		stats.Total++
+		stats.New++
+		stats.Unseen++
+	}
+
+	curEntries, err := os.ReadDir(filepath.Join(path, "cur"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return MailboxStats{}, err
+	}
+	for _, entry := range curEntries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		stats.Total++
+		_, flags, _ := strings.Cut(entry.Name(), ":2,")
+		if !strings.Contains(flags, "S") {
+			stats.Unseen++
+		}
+		if strings.Contains(flags, "F") {
+			stats.Flagged++
+		}
+		if strings.Contains(flags, "R") {
+			stats.Replied++
+		}
+		if strings.Contains(flags, "P") {
+			stats.Forwarded++
+		}
+		if strings.Contains(flags, "T") {
+			stats.Trashed++
+		}
+		if strings.Contains(flags, "D") {
+			stats.Draft++
+		}
+	}
+
+	return stats, nil
+}
+
+// readMbox counts the messages of an mbox file.
+func readMbox(path string) (MailboxStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MailboxStats{}, err
+	}
+	defer f.Close()
+
+	stats, err := parseMbox(f)
+	if err != nil {
+		return MailboxStats{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	stats.Path = path
+	return stats, nil
+}
+
+// parseMbox counts the messages of an mbox. Each message starts with a
+// "From " line after a blank line and is classified by its Status header
+// (R read, O old) and X-Status header (F flagged, A answered, D deleted,
+// T draft).
+func parseMbox(r io.Reader) (MailboxStats, error) {
+	stats := MailboxStats{Format: MailboxMbox}
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	var status, xstatus string
+	inMessage, inHeaders := false, false
+	lineStart, prevBlank := true, true
+
+	finish := func() {
+		if !inMessage {
+			return
+		}
+		stats.Total++
+		if !strings.ContainsAny(status, "RO") {
+			stats.New++
+		}
+		if !strings.Contains(status, "R") {
+			stats.Unseen++
+		}
+		if strings.Contains(xstatus, "F") {
+			stats.Flagged++
+		}
+		if strings.Contains(xstatus, "A") {
+			stats.Replied++
+		}
+		if strings.Contains(xstatus, "D") {
+			stats.Trashed++
+		}
+		if strings.Contains(xstatus, "T") {
+			stats.Draft++
+		}
+	}
+
+	for {
+		line, isPrefix, err := br.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return MailboxStats{}, err
+		}
+
+		// Only the first chunk of an overlong line is inspected
+		if lineStart {
+			switch {
+			case prevBlank && bytes.HasPrefix(line, []byte("From ")):
+				finish()
+				inMessage, inHeaders = true, true
+				status, xstatus = "", ""
+			case inHeaders && len(line) == 0:
+				inHeaders = false
+			case inHeaders:
+				if value, ok := mboxHeader(line, "Status:"); ok {
+					status = value
+				} else if value, ok := mboxHeader(line, "X-Status:"); ok {
+					xstatus = value
+				}
+			}
+			prevBlank = len(line) == 0 && !isPrefix
+		}
+		lineStart = !isPrefix
+	}
+	finish()
+
+	return stats, nil
+}
+
+// mboxHeader returns the value of line if it is the header name, which is
+// matched case-insensitively.
+func mboxHeader(line []byte, name string) (string, bool) {
+	if len(line) < len(name) || !strings.EqualFold(string(line[:len(name)]), name) {
+		return "", false
+	}
+	return strings.TrimSpace(string(line[len(name):])), true
+}
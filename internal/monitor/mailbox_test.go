@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{
+		"new/1700000000.1.host",
+		"new/1700000000.2.host",
+		"cur/1700000000.3.host:2,S",
+		"cur/1700000000.4.host:2,FS",
+		"cur/1700000000.5.host:2,RS",
+		"cur/1700000000.6.host:2,F",
+		"cur/1700000000.7.host:2,PST",
+		"cur/1700000000.8.host:2,DS",
+		"cur/.hidden",
+		"tmp/1700000000.9.host",
+	} {
+		writeFile(t, dir, name, "Subject: test\n\nbody\n")
+	}
+
+	stats, err := readMaildir(dir)
+	if err != nil {
+		t.Fatalf("readMaildir() error = %v", err)
+	}
+	want := MailboxStats{
+		Path:      dir,
+		Format:    MailboxMaildir,
+		Total:     8,
+		New:       2,
+		Unseen:    3,
+		Flagged:   2,
+		Replied:   1,
+		Forwarded: 1,
+		Trashed:   1,
+		Draft:     1,
+	}
+	if stats != want {
+		t.Errorf("readMaildir() = %+v, want %+v", stats, want)
+	}
+	if stats.Seen() != 5 {
+		t.Errorf("Seen() = %d, want 5", stats.Seen())
+	}
+}
+
+func TestParseMbox(t *testing.T) {
+	mbox := `From alice@example.com Mon Jan  1 00:00:00 2024
+Subject: new
+
+body
+
+From bob@example.com Mon Jan  1 00:01:00 2024
+Subject: old unread
+Status: O
+
+body
+From inside a paragraph is not a separator
+
+From carol@example.com Mon Jan  1 00:02:00 2024
+status: RO
+X-Status: AF
+
+body
+
+From dave@example.com Mon Jan  1 00:03:00 2024
+Status: RO
+X-Status: DT
+
+Status: R
+`
+	stats, err := parseMbox(strings.NewReader(mbox))
+	if err != nil {
+		t.Fatalf("parseMbox() error = %v", err)
+	}
+	want := MailboxStats{
+		Format:  MailboxMbox,
+		Total:   4,
+		New:     1,
+		Unseen:  2,
+		Flagged: 1,
+		Replied: 1,
+		Trashed: 1,
+		Draft:   1,
+	}
+	if stats != want {
+		t.Errorf("parseMbox() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestParseMboxLongLines(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	mbox := "From a@example.com Mon Jan  1 00:00:00 2024\nStatus: RO\n\n" + long + "\n\n" +
+		"From b@example.com Mon Jan  1 00:00:00 2024\n\n" + long + "From c@example.com\n"
+
+	stats, err := parseMbox(strings.NewReader(mbox))
+	if err != nil {
+		t.Fatalf("parseMbox() error = %v", err)
+	}
+	if stats.Total != 2 || stats.Unseen != 1 {
+		t.Errorf("parseMbox() = %+v, want 2 messages with 1 unseen", stats)
+	}
+}
+
+func TestMailboxReaderCache(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, dir, "new/1", "")
+	writeFile(t, dir, "cur/2:2,S", "")
+
+	reader := newMailboxReader()
+	reader.SetSpool(dir)
+
+	stats, err := reader.Read("")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if stats.Total != 2 || stats.New != 1 {
+		t.Errorf("Read() = %+v, want 2 messages with 1 new", stats)
+	}
+
+	// Unchanged directories are served from the cache
+	reader.cache[dir].stats.Total = 99
+	if stats, _ := reader.Read(dir); stats.Total != 99 {
+		t.Errorf("Read() total = %d, want cached 99", stats.Total)
+	}
+
+	// A new message changes the mtime of new
+	writeFile(t, dir, "new/3", "")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "new"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = reader.Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if stats.Total != 3 || stats.New != 2 {
+		t.Errorf("Read() after change = %+v, want 3 messages with 2 new", stats)
+	}
+}
+
+func TestMailboxReaderMbox(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "spool", "From a@example.com\nSubject: hi\n\nbody\n")
+	t.Setenv("MAIL", filepath.Join(dir, "spool"))
+
+	reader := newMailboxReader()
+	stats, err := reader.Read("")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if stats.Format != MailboxMbox || stats.Total != 1 || stats.New != 1 {
+		t.Errorf("Read() = %+v, want one new mbox message", stats)
+	}
+
+	writeFile(t, dir, "spool", "From a@example.com\nStatus: RO\n\nbody\n\nFrom b@example.com\n\nbody\n")
+	stats, err = reader.Read("$MAIL")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if stats.Total != 2 || stats.New != 1 {
+		t.Errorf("Read() after append = %+v, want 2 messages with 1 new", stats)
+	}
+}
+
+func TestMailboxReaderErrors(t *testing.T) {
+	t.Setenv("MAIL", "")
+	reader := newMailboxReader()
+	if _, err := reader.Read(""); err == nil {
+		t.Error("Read() without a spool succeeded, want error")
+	}
+	if _, err := reader.Read(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Read() of a missing mailbox succeeded, want error")
+	}
+	if _, err := reader.Read(t.TempDir()); err == nil {
+		t.Error("Read() of a plain directory succeeded, want error")
+	}
+}
+
+func TestMailboxReaderPath(t *testing.T) {
+	t.Setenv("MAIL", "/var/mail/alice")
+	t.Setenv("MAILDIR", "/srv/mail")
+	reader := newMailboxReader()
+
+	if got := reader.Path(""); got != "/var/mail/alice" {
+		t.Errorf("Path(\"\") = %q, want $MAIL", got)
+	}
+	if got := reader.Path("$MAILDIR/inbox"); got != "/srv/mail/inbox" {
+		t.Errorf("Path(\"$MAILDIR/inbox\") = %q, want /srv/mail/inbox", got)
+	}
+	reader.SetSpool("/var/spool/mail/bob")
+	if got := reader.Path(""); got != "/var/spool/mail/bob" {
+		t.Errorf("Path(\"\") = %q, want the spool", got)
+	}
+
+	t.Setenv("MAIL", "")
+	if got := newMailboxReader().Path(""); got != "" {
+		t.Errorf("Path(\"\") without a spool = %q, want empty", got)
+	}
+}
@@ -24,14 +24,22 @@ type SystemMonitor struct {
 	diskIOReader      *diskIOReader
 	hwmonReader       *hwmonReader
 	processReader     *processReader
+	pidInfoReader     *pidInfoReader
 	batteryReader     *batteryReader
 	audioReader       *audioReader
 	sysInfoReader     *sysInfoReader
 	tcpReader         *tcpReader
+	socketReader      *socketReader
 	gpuReader         *gpuReader
 	mailReader        *mailReader
+	mailboxReader     *mailboxReader
 	weatherReader     *weatherReader
 	mpdReader         *mpdReader
+	apcupsdReader     *apcupsdReader
+	httpFetcher       *httpFetcher
+	rssReader         *rssReader
+	history           *History
+	historyAt         time.Time // When history was last recorded
 	ctx               context.Context
 	cancel            context.CancelFunc
 	wg                sync.WaitGroup
@@ -44,7 +52,7 @@ type SystemMonitor struct {
 func NewSystemMonitor(interval time.Duration) *SystemMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &SystemMonitor{
+	sm := &SystemMonitor{
 		data:              NewSystemData(),
 		interval:          interval,
 		cpuReader:         newCPUReader(),
@@ -57,17 +65,25 @@ func NewSystemMonitor(interval time.Duration) *SystemMonitor {
 		diskIOReader:      newDiskIOReader(),
 		hwmonReader:       newHwmonReader(),
 		processReader:     newProcessReader(),
+		pidInfoReader:     newPIDInfoReader(),
 		batteryReader:     newBatteryReader(),
 		audioReader:       newAudioReader(),
 		sysInfoReader:     newSysInfoReader(),
 		tcpReader:         newTCPReader(),
+		socketReader:      newSocketReader(),
 		gpuReader:         newGPUReader(),
 		mailReader:        newMailReader(),
-		weatherReader:     newWeatherReader(),
+		mailboxReader:     newMailboxReader(),
 		mpdReader:         newMPDReader(),
+		apcupsdReader:     newAPCUPSDReader(),
+		httpFetcher:       newHTTPFetcher(),
+		history:           NewHistory(DefaultHistoryLength),
 		ctx:               ctx,
 		cancel:            cancel,
 	}
+	sm.rssReader = newRSSReader(sm.httpFetcher)
+	sm.weatherReader = newWeatherReader(sm.httpFetcher)
+	return sm
 }
 
 // NewSystemMonitorWithPlatform creates a new SystemMonitor that uses the platform
@@ -91,17 +107,25 @@ func NewSystemMonitorWithPlatform(interval time.Duration, plat PlatformInterface
 		networkAddrReader: newNetworkAddressReader(),
 		wirelessReader:    newWirelessReader(),
 		processReader:     newProcessReader(),
+		pidInfoReader:     newPIDInfoReader(),
 		audioReader:       newAudioReader(),
 		sysInfoReader:     newSysInfoReader(),
 		tcpReader:         newTCPReader(),
+		socketReader:      newSocketReader(),
 		gpuReader:         newGPUReader(),
 		mailReader:        newMailReader(),
-		weatherReader:     newWeatherReader(),
+		mailboxReader:     newMailboxReader(),
 		mpdReader:         newMPDReader(),
+		apcupsdReader:     newAPCUPSDReader(),
+		httpFetcher:       newHTTPFetcher(),
+		history:           NewHistory(DefaultHistoryLength),
 		ctx:               ctx,
 		cancel:            cancel,
 	}
 
+	sm.rssReader = newRSSReader(sm.httpFetcher)
+	sm.weatherReader = newWeatherReader(sm.httpFetcher)
+
 	// Keep Linux fallback readers for cases where platform adapter fails or is nil
 	sm.cpuReader = newCPUReader()
 	sm.memReader = newMemoryReader()
@@ -152,6 +176,11 @@ func (sm *SystemMonitor) Stop() {
 	sm.cancel()
 	sm.wg.Wait()
 
+	// Release the sound server connection; a restart reopens it
+	if sm.audioReader != nil {
+		sm.audioReader.Close()
+	}
+
 	sm.mu.Lock()
 	sm.running = false
 	sm.mu.Unlock()
@@ -375,12 +404,32 @@ func (sm *SystemMonitor) Update() error {
 		}
 	}
 
+	sm.recordHistory(time.Now())
+
 	if len(errs) > 0 {
 		return &UpdateError{Errors: errs}
 	}
 	return nil
 }
 
+// History returns up to n of the most recent samples of a metric, oldest
+// first; n of zero or less returns all retained samples. See History for
+// the metric IDs.
+func (sm *SystemMonitor) History(id string, n int) []Sample {
+	return sm.history.Query(id, n)
+}
+
+// HistoryIDs returns the IDs of all metrics with recorded history.
+func (sm *SystemMonitor) HistoryIDs() []string {
+	return sm.history.IDs()
+}
+
+// SetHistoryLength sets the number of samples kept per metric.
+// Zero or less uses DefaultHistoryLength.
+func (sm *SystemMonitor) SetHistoryLength(n int) {
+	sm.history.SetLength(n)
+}
+
 // Data returns a snapshot of the current system data.
 func (sm *SystemMonitor) Data() SystemData {
 	sm.data.mu.RLock()
@@ -471,12 +520,33 @@ func (sm *SystemMonitor) TCPConnectionByIndex(minPort, maxPort, index int) *TCPC
 	return sm.tcpReader.GetConnectionByIndex(minPort, maxPort, index)
 }
 
+// SocketOwner returns the lowest PID of the processes holding the socket
+// with the given inode and that process's name, or 0 and "" if the owner
+// is unknown.
+func (sm *SystemMonitor) SocketOwner(inode uint64) (int, string) {
+	owner := sm.socketReader.owners.Owners()[inode]
+	return owner.PID, owner.Name
+}
+
+// Sockets returns the TCP, UDP and UNIX sockets with their owning processes.
+func (sm *SystemMonitor) Sockets() SocketStats {
+	stats, _ := sm.socketReader.ReadStats()
+	return stats
+}
+
 // GPU returns the current NVIDIA GPU statistics.
 func (sm *SystemMonitor) GPU() GPUStats {
 	stats, _ := sm.gpuReader.ReadStats()
 	return stats
 }
 
+// GPUs returns the statistics of every GPU: NVIDIA cards reported by
+// nvidia-smi first, followed by AMD, Intel and nouveau cards read from
+// /sys/class/drm in card order.
+func (sm *SystemMonitor) GPUs() []GPUStats {
+	return sm.gpuReader.ReadAll()
+}
+
 // Mail returns the current mail statistics.
 func (sm *SystemMonitor) Mail() MailStats {
 	stats, _ := sm.mailReader.ReadStats()
@@ -488,6 +558,13 @@ func (sm *SystemMonitor) AddMailAccount(config MailConfig) error {
 	return sm.mailReader.AddAccount(config)
 }
 
+// MailAccount returns the statistics of the account described by config,
+// adding it to the monitored accounts if needed. This serves variables that
+// name their own server, such as ${imap_unseen host user pass}.
+func (sm *SystemMonitor) MailAccount(config MailConfig) (MailAccountStats, error) {
+	return sm.mailReader.Account(config)
+}
+
 // RemoveMailAccount removes a mail account from monitoring.
 func (sm *SystemMonitor) RemoveMailAccount(name string) {
 	sm.mailReader.RemoveAccount(name)
@@ -513,12 +590,54 @@ func (sm *SystemMonitor) MailTotalMessages() int {
 	return sm.mailReader.GetTotalMessages()
 }
 
+// PIDInfo returns the details of process pid, such as its command line,
+// open files and memory use.
+func (sm *SystemMonitor) PIDInfo(pid int) (PIDInfo, error) {
+	return sm.pidInfoReader.Read(pid)
+}
+
+// Pidof returns the lowest PID of the processes with the given command name
+// as of the last update.
+func (sm *SystemMonitor) Pidof(name string) (int, bool) {
+	return sm.processReader.Pidof(name)
+}
+
+// Mailbox returns the message counts of the local Maildir or mbox at path,
+// or of the mail spool if path is empty.
+func (sm *SystemMonitor) Mailbox(path string) (MailboxStats, error) {
+	return sm.mailboxReader.Read(path)
+}
+
+// MailboxPath returns the file or directory Mailbox reads for path, or ""
+// if path is empty and there is no mail spool.
+func (sm *SystemMonitor) MailboxPath(path string) string {
+	return sm.mailboxReader.Path(path)
+}
+
+// SetMailSpool sets the mailbox read by Mailbox when no path is given.
+// Empty means $MAIL.
+func (sm *SystemMonitor) SetMailSpool(path string) {
+	sm.mailboxReader.SetSpool(path)
+}
+
 // Weather returns weather data for the given station ID (ICAO code).
 func (sm *SystemMonitor) Weather(stationID string) WeatherStats {
 	stats, _ := sm.weatherReader.ReadWeather(stationID)
 	return stats
 }
 
+// SetWeatherProvider sets the source of Weather data and clears the
+// weather cache.
+func (sm *SystemMonitor) SetWeatherProvider(provider WeatherProvider) {
+	sm.weatherReader.SetProvider(provider)
+}
+
+// SetWeatherUnits sets the unit system of Weather data: WeatherMetric,
+// WeatherImperial, or empty for the units of METAR reports.
+func (sm *SystemMonitor) SetWeatherUnits(units string) {
+	sm.weatherReader.SetUnits(units)
+}
+
 // MPD returns the current MPD playback status.
 func (sm *SystemMonitor) MPD() MPDStats {
 	stats, _ := sm.mpdReader.ReadStats()
@@ -540,6 +659,39 @@ func (sm *SystemMonitor) SetMPDPassword(password string) {
 	sm.mpdReader.SetPassword(password)
 }
 
+// APCUPSD returns the UPS status reported by the apcupsd Network
+// Information Server at host:port. An empty host or zero port selects
+// localhost:3551. The server is queried in the background, so the status
+// returned is the last one received and an error is returned until the
+// first query completes.
+func (sm *SystemMonitor) APCUPSD(host string, port int) (APCUPSDStats, error) {
+	return sm.apcupsdReader.Read(host, port)
+}
+
+// Curl returns the body of the web resource at url, fetched in the
+// background at most once per interval. The body is empty until the first
+// fetch completes; if a fetch fails, the last body fetched is returned
+// along with the error.
+func (sm *SystemMonitor) Curl(url string, interval time.Duration) (string, error) {
+	body, _, err := sm.httpFetcher.Fetch(url, interval)
+	return string(body), err
+}
+
+// RSS returns the RSS or Atom feed at url, fetched in the background at
+// most once per interval. The feed is empty until the first fetch
+// completes; if a fetch fails, the last feed fetched is returned along with
+// the error.
+func (sm *SystemMonitor) RSS(url string, interval time.Duration) (RSSFeed, error) {
+	return sm.rssReader.Read(url, interval)
+}
+
+// SetHTTPCircuitBreaker sets the factory creating the circuit breaker that
+// guards each host contacted by Curl, RSS and Weather. Nil disables circuit
+// breaking.
+func (sm *SystemMonitor) SetHTTPCircuitBreaker(newBreaker func() CircuitBreakerInterface) {
+	sm.httpFetcher.SetCircuitBreaker(newBreaker)
+}
+
 // augmentNetworkStats adds IP address, gateway, nameserver, and wireless information to network stats.
 func (sm *SystemMonitor) augmentNetworkStats(stats *NetworkStats) {
 	// Read interface addresses
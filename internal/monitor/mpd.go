@@ -145,7 +145,7 @@ func (r *mpdReader) fetchStats() (MPDStats, error) {
 	}
 
 	// Connect with timeout using interface types
-	addr := fmt.Sprintf("%s:%d", host, port)
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	var conn net.Conn
 	var err error
 	conn, err = net.DialTimeout("tcp", addr, timeout)
@@ -0,0 +1,290 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field indices in /proc/[pid]/stat after the command name, as for
+// parseProcessStat.
+const (
+	statFieldPPid     = 1
+	statFieldPriority = 15
+	statFieldNice     = 16
+)
+
+// PIDInfo contains details of a single process read from /proc/[pid].
+// Fields the process's owner does not allow reading, such as the working
+// directory of another user's process, are left empty.
+type PIDInfo struct {
+	// PID is the process identifier.
+	PID int
+	// Name is the command name.
+	Name string
+	// Cmdline is the command line with arguments separated by spaces.
+	Cmdline string
+	// Cwd is the current working directory.
+	Cwd string
+	// Exe is the path of the executable.
+	Exe string
+	// Root is the root directory, which differs from / in a chroot.
+	Root string
+	// Environ holds the environment variables in their original order as
+	// NAME=value strings.
+	Environ []string
+	// OpenFiles lists the distinct files open by the process, in file
+	// descriptor order.
+	OpenFiles []string
+	// Stdin, Stdout and Stderr are the targets of file descriptors 0-2.
+	Stdin, Stdout, Stderr string
+	// Parent is the parent process ID.
+	Parent int
+	// State is the state letter (R, S, D, Z, T, ...).
+	State string
+	// StateName is the state description, such as "sleeping".
+	StateName string
+	// Nice is the nice value (-20 to 19).
+	Nice int
+	// Priority is the kernel scheduling priority.
+	Priority int
+	// Threads lists the thread IDs of the process.
+	Threads []int
+	// UserTime and SystemTime are the CPU time spent in user and kernel
+	// mode in seconds.
+	UserTime, SystemTime float64
+	// UIDs and GIDs are the real, effective, saved and filesystem user and
+	// group IDs.
+	UIDs, GIDs [4]int
+	// Memory holds the Vm* lines of /proc/[pid]/status in bytes, keyed by
+	// their lower-case name without the prefix ("peak", "rss", "pte", ...).
+	Memory map[string]uint64
+	// ReadBytes and WriteBytes are the bytes read from and written to
+	// storage over the process's life time.
+	ReadBytes, WriteBytes uint64
+}
+
+// Getenv returns the value of the environment variable name and whether
+// it is set.
+func (p PIDInfo) Getenv(name string) (string, bool) {
+	for _, kv := range p.Environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// pidInfoReader reads process details, caching each process briefly so
+// that several ${pid_*} variables in one update share a single read.
+type pidInfoReader struct {
+	mu       sync.Mutex
+	procPath string
+	clkTck   float64
+	cacheTTL time.Duration
+	cache    map[int]cachedPIDInfo
+}
+
+// cachedPIDInfo holds the details of a process and when they were read.
+type cachedPIDInfo struct {
+	info PIDInfo
+	at   time.Time
+}
+
+// newPIDInfoReader creates a pidInfoReader with default paths.
+func newPIDInfoReader() *pidInfoReader {
+	return &pidInfoReader{
+		procPath: "/proc",
+		clkTck:   100.0, // Standard Linux value for USER_HZ
+		cacheTTL: 1 * time.Second,
+		cache:    make(map[int]cachedPIDInfo),
+	}
+}
+
+// Read returns the details of process pid.
+func (r *pidInfoReader) Read(pid int) (PIDInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if cached, ok := r.cache[pid]; ok && now.Sub(cached.at) < r.cacheTTL {
+		return cached.info, nil
+	}
+	// Drop expired entries so that exited processes do not accumulate
+	for p, cached := range r.cache {
+		if now.Sub(cached.at) >= r.cacheTTL {
+			delete(r.cache, p)
+		}
+	}
+
+	info, err := r.readPID(pid)
+	if err != nil {
+		return PIDInfo{}, err
+	}
+	r.cache[pid] = cachedPIDInfo{info: info, at: now}
+	return info, nil
+}
+
+// readPID reads the details of a process. Only a missing or malformed stat
+// file is an error; other files are read on a best-effort basis.
+func (r *pidInfoReader) readPID(pid int) (PIDInfo, error) {
+	dir := filepath.Join(r.procPath, strconv.Itoa(pid))
+	info := PIDInfo{PID: pid}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return info, fmt.Errorf("reading stat: %w", err)
+	}
+	if err := r.parseStat(&info, string(stat)); err != nil {
+		return info, fmt.Errorf("parsing stat: %w", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		parsePIDStatus(&info, string(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		info.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "environ")); err == nil {
+		for _, kv := range strings.Split(string(data), "\x00") {
+			if kv != "" {
+				info.Environ = append(info.Environ, kv)
+			}
+		}
+	}
+	info.Cwd, _ = os.Readlink(filepath.Join(dir, "cwd"))
+	info.Exe, _ = os.Readlink(filepath.Join(dir, "exe"))
+	info.Root, _ = os.Readlink(filepath.Join(dir, "root"))
+	info.Stdin, _ = os.Readlink(filepath.Join(dir, "fd", "0"))
+	info.Stdout, _ = os.Readlink(filepath.Join(dir, "fd", "1"))
+	info.Stderr, _ = os.Readlink(filepath.Join(dir, "fd", "2"))
+	info.OpenFiles = readOpenFiles(filepath.Join(dir, "fd"))
+	info.Threads = readThreadIDs(filepath.Join(dir, "task"))
+	if io, err := readProcIO(filepath.Join(dir, "io")); err == nil {
+		info.ReadBytes, info.WriteBytes = io.readBytes, io.writeBytes
+	}
+
+	return info, nil
+}
+
+// parseStat fills in the fields of info read from /proc/[pid]/stat.
+func (r *pidInfoReader) parseStat(info *PIDInfo, content string) error {
+	openParen := strings.IndexByte(content, '(')
+	closeParen := strings.LastIndexByte(content, ')')
+	if openParen == -1 || closeParen == -1 || closeParen <= openParen || closeParen+2 > len(content) {
+		return fmt.Errorf("invalid stat format: missing parentheses")
+	}
+	info.Name = content[openParen+1 : closeParen]
+
+	fields := strings.Fields(content[closeParen+2:])
+	if len(fields) < statMinFields {
+		return fmt.Errorf("invalid stat format: not enough fields (got %d, need %d)", len(fields), statMinFields)
+	}
+
+	info.State = fields[statFieldState]
+	info.Parent, _ = strconv.Atoi(fields[statFieldPPid])
+	info.Priority, _ = strconv.Atoi(fields[statFieldPriority])
+	info.Nice, _ = strconv.Atoi(fields[statFieldNice])
+	if utime, err := strconv.ParseUint(fields[statFieldUtime], 10, 64); err == nil {
+		info.UserTime = float64(utime) / r.clkTck
+	}
+	if stime, err := strconv.ParseUint(fields[statFieldStime], 10, 64); err == nil {
+		info.SystemTime = float64(stime) / r.clkTck
+	}
+	return nil
+}
+
+// parsePIDStatus fills in the state name, IDs and memory sizes of info from
+// /proc/[pid]/status.
+func parsePIDStatus(info *PIDInfo, content string) {
+	info.Memory = make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "State":
+			// "S (sleeping)"
+			if _, name, ok := strings.Cut(value, "("); ok {
+				info.StateName = strings.TrimSuffix(name, ")")
+			}
+		case key == "Uid" || key == "Gid":
+			ids := &info.UIDs
+			if key == "Gid" {
+				ids = &info.GIDs
+			}
+			for i, field := range strings.Fields(value) {
+				if i < len(ids) {
+					ids[i], _ = strconv.Atoi(field)
+				}
+			}
+		case strings.HasPrefix(key, "Vm"):
+			// "VmRSS:	  12345 kB"
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				continue
+			}
+			n, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			if len(fields) > 1 && fields[1] == "kB" {
+				n *= 1024
+			}
+			info.Memory[strings.ToLower(strings.TrimPrefix(key, "Vm"))] = n
+		}
+	}
+}
+
+// readOpenFiles returns the distinct targets of the file descriptors in
+// fdDir in descriptor order.
+func readOpenFiles(fdDir string) []string {
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+
+	fds := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if fd, err := strconv.Atoi(entry.Name()); err == nil {
+			fds = append(fds, fd)
+		}
+	}
+	slices.Sort(fds)
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, strconv.Itoa(fd)))
+		if err != nil || seen[target] {
+			continue
+		}
+		seen[target] = true
+		files = append(files, target)
+	}
+	return files
+}
+
+// readThreadIDs returns the sorted thread IDs listed in taskDir.
+func readThreadIDs(taskDir string) []int {
+	entries, err := os.ReadDir(taskDir)
+	if err != nil {
+		return nil
+	}
+	tids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if tid, err := strconv.Atoi(entry.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+	slices.Sort(tids)
+	return tids
+}
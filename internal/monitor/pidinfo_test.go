@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeFakePID creates /proc/[pid] files for a postgres process under
+// procPath.
+func writeFakePID(t *testing.T, procPath string) string {
+	t.Helper()
+	dir := filepath.Join(procPath, "4242")
+	for _, sub := range []string{"fd", "task/4242", "task/4250"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		"stat": "4242 (postgres) S 1 4242 4242 0 -1 4194560 100 0 0 0 250 50 0 0 20 -5 2 0 1000 200000000 5000 18446744073709551615",
+		"status": "Name:\tpostgres\nState:\tS (sleeping)\nUid:\t999\t999\t999\t999\nGid:\t998\t998\t998\t998\n" +
+			"VmPeak:\t  220000 kB\nVmSize:\t  200000 kB\nVmRSS:\t   20000 kB\nVmPTE:\t     120 kB\nThreads:\t2\n",
+		"cmdline": "postgres\x00-D\x00/var/lib/postgres/data\x00",
+		"environ": "PGDATA=/var/lib/postgres/data\x00LANG=C\x00",
+		"io":      "rchar: 1\nwchar: 1\nread_bytes: 4096\nwrite_bytes: 8192\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"cwd":  "/var/lib/postgres/data",
+		"exe":  "/usr/bin/postgres",
+		"root": "/",
+		"fd/0": "/dev/null",
+		"fd/1": "/var/log/postgres.log",
+		"fd/2": "/var/log/postgres.log",
+		"fd/3": "socket:[12345]",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPIDInfoReaderRead(t *testing.T) {
+	procPath := t.TempDir()
+	writeFakePID(t, procPath)
+
+	reader := newPIDInfoReader()
+	reader.procPath = procPath
+
+	info, err := reader.Read(4242)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if info.Name != "postgres" || info.Parent != 1 || info.State != "S" || info.StateName != "sleeping" {
+		t.Errorf("Name/Parent/State = %s/%d/%s (%s)", info.Name, info.Parent, info.State, info.StateName)
+	}
+	if info.Priority != 20 || info.Nice != -5 {
+		t.Errorf("Priority/Nice = %d/%d, want 20/-5", info.Priority, info.Nice)
+	}
+	if info.UserTime != 2.5 || info.SystemTime != 0.5 {
+		t.Errorf("UserTime/SystemTime = %v/%v, want 2.5/0.5", info.UserTime, info.SystemTime)
+	}
+	if info.Cmdline != "postgres -D /var/lib/postgres/data" {
+		t.Errorf("Cmdline = %q", info.Cmdline)
+	}
+	if info.Cwd != "/var/lib/postgres/data" || info.Exe != "/usr/bin/postgres" || info.Root != "/" {
+		t.Errorf("Cwd/Exe/Root = %s/%s/%s", info.Cwd, info.Exe, info.Root)
+	}
+	if v, ok := info.Getenv("PGDATA"); !ok || v != "/var/lib/postgres/data" {
+		t.Errorf("Getenv(PGDATA) = %q, %v", v, ok)
+	}
+	if _, ok := info.Getenv("HOME"); ok {
+		t.Error("Getenv(HOME) found an unset variable")
+	}
+	wantFiles := []string{"/dev/null", "/var/log/postgres.log", "socket:[12345]"}
+	if !slices.Equal(info.OpenFiles, wantFiles) {
+		t.Errorf("OpenFiles = %v, want %v", info.OpenFiles, wantFiles)
+	}
+	if info.Stdin != "/dev/null" || info.Stderr != "/var/log/postgres.log" {
+		t.Errorf("Stdin/Stderr = %s/%s", info.Stdin, info.Stderr)
+	}
+	if !slices.Equal(info.Threads, []int{4242, 4250}) {
+		t.Errorf("Threads = %v", info.Threads)
+	}
+	if info.UIDs != [4]int{999, 999, 999, 999} || info.GIDs[1] != 998 {
+		t.Errorf("UIDs/GIDs = %v/%v", info.UIDs, info.GIDs)
+	}
+	if info.Memory["rss"] != 20000*1024 || info.Memory["peak"] != 220000*1024 || info.Memory["pte"] != 120*1024 {
+		t.Errorf("Memory = %v", info.Memory)
+	}
+	if info.ReadBytes != 4096 || info.WriteBytes != 8192 {
+		t.Errorf("ReadBytes/WriteBytes = %d/%d", info.ReadBytes, info.WriteBytes)
+	}
+}
+
+func TestPIDInfoReaderCache(t *testing.T) {
+	procPath := t.TempDir()
+	dir := writeFakePID(t, procPath)
+
+	reader := newPIDInfoReader()
+	reader.procPath = procPath
+	reader.cacheTTL = time.Hour
+
+	if _, err := reader.Read(4242); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte("postgres: checkpointer\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := reader.Read(4242); info.Cmdline != "postgres -D /var/lib/postgres/data" {
+		t.Errorf("Cmdline = %q, want cached value", info.Cmdline)
+	}
+
+	reader.cacheTTL = 0
+	if info, _ := reader.Read(4242); info.Cmdline != "postgres: checkpointer" {
+		t.Errorf("Cmdline = %q, want reread value", info.Cmdline)
+	}
+}
+
+func TestPIDInfoReaderMissing(t *testing.T) {
+	reader := newPIDInfoReader()
+	reader.procPath = t.TempDir()
+
+	if _, err := reader.Read(1); err == nil {
+		t.Error("Read() of a missing process succeeded, want error")
+	}
+}
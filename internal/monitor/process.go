@@ -40,14 +40,20 @@ const (
 	pageSize = 4096
 )
 
+// commMaxLen is the maximum length of a process command name (TASK_COMM_LEN
+// minus the terminating NUL).
+const commMaxLen = 15
+
 // processReader reads process statistics from /proc filesystem.
 type processReader struct {
 	mu               sync.Mutex
 	procPath         string
-	lastCPUTimes     map[int]cpuTime // PID -> last CPU time for rate calculation
-	lastTotalCPU     uint64          // Total CPU time at last measurement
-	totalMemoryBytes uint64          // Total system memory in bytes
-	clkTck           float64         // Clock ticks per second (typically 100)
+	lastCPUTimes     map[int]cpuTime    // PID -> last CPU time for rate calculation
+	lastIO           map[int]ioCounters // PID -> last I/O counters for rate calculation
+	pidsByName       map[string]int     // Process name -> lowest PID at the last scan
+	lastTotalCPU     uint64             // Total CPU time at last measurement
+	totalMemoryBytes uint64             // Total system memory in bytes
+	clkTck           float64            // Clock ticks per second (typically 100)
 }
 
 // cpuTime stores CPU time information for rate calculation.
@@ -57,11 +63,20 @@ type cpuTime struct {
 	total uint64 // Combined time
 }
 
+// ioCounters stores the cumulative storage I/O of a process from
+// /proc/[pid]/io. The start time identifies the process across PID reuse.
+type ioCounters struct {
+	readBytes  uint64
+	writeBytes uint64
+	startTime  uint64
+}
+
 // newProcessReader creates a new processReader with default paths.
 func newProcessReader() *processReader {
 	return &processReader{
 		procPath:     "/proc",
 		lastCPUTimes: make(map[int]cpuTime),
+		lastIO:       make(map[int]ioCounters),
 		clkTck:       100.0, // Standard Linux value for USER_HZ
 	}
 }
@@ -74,6 +89,7 @@ func (r *processReader) ReadStats() (ProcessStats, error) {
 	stats := ProcessStats{
 		TopCPU: make([]ProcessInfo, 0, TopProcessCount),
 		TopMem: make([]ProcessInfo, 0, TopProcessCount),
+		TopIO:  make([]ProcessInfo, 0, TopProcessCount),
 	}
 
 	// Read total memory for percentage calculation
@@ -97,6 +113,10 @@ func (r *processReader) ReadStats() (ProcessStats, error) {
 
 	processes := make([]ProcessInfo, 0, len(entries))
 	currentCPUTimes := make(map[int]cpuTime)
+	currentIO := make(map[int]ioCounters)
+	pidsByName := make(map[string]int)
+	var ioProcesses []int // Indices of processes with readable I/O counters
+	var ioTotal uint64
 	cpuDelta := totalCPU - r.lastTotalCPU
 
 	for _, entry := range entries {
@@ -117,6 +137,23 @@ func (r *processReader) ReadStats() (ProcessStats, error) {
 		}
 
 		currentCPUTimes[pid] = ct
+		if lowest, ok := pidsByName[proc.Name]; !ok || pid < lowest {
+			pidsByName[proc.Name] = pid
+		}
+
+		// I/O counters need the same user or CAP_SYS_PTRACE; processes
+		// without them are left out of TopIO
+		if io, err := r.readProcessIO(pid); err == nil {
+			io.startTime = proc.StartTime
+			if last, ok := r.lastIO[pid]; ok && last.startTime == io.startTime {
+				proc.IOReadBytes = counterDelta(io.readBytes, last.readBytes)
+				proc.IOWriteBytes = counterDelta(io.writeBytes, last.writeBytes)
+				ioTotal += proc.IOReadBytes + proc.IOWriteBytes
+			}
+			currentIO[pid] = io
+			ioProcesses = append(ioProcesses, len(processes))
+		}
+
 		processes = append(processes, proc)
 
 		// Count by state
@@ -136,8 +173,20 @@ func (r *processReader) ReadStats() (ProcessStats, error) {
 
 	// Update cached values for next calculation
 	r.lastCPUTimes = currentCPUTimes
+	r.lastIO = currentIO
+	r.pidsByName = pidsByName
 	r.lastTotalCPU = totalCPU
 
+	// Collect processes with I/O counters before the slice is reordered
+	ioTop := make([]ProcessInfo, 0, len(ioProcesses))
+	for _, i := range ioProcesses {
+		proc := processes[i]
+		if ioTotal > 0 {
+			proc.IOPercent = float64(proc.IOReadBytes+proc.IOWriteBytes) / float64(ioTotal) * 100.0
+		}
+		ioTop = append(ioTop, proc)
+	}
+
 	// Sort by CPU usage and get top N
 	sort.Slice(processes, func(i, j int) bool {
 		return processes[i].CPUPercent > processes[j].CPUPercent
@@ -154,9 +203,31 @@ func (r *processReader) ReadStats() (ProcessStats, error) {
 		stats.TopMem = append(stats.TopMem, processes[i])
 	}
 
+	// Sort by I/O in the last interval and get top N
+	sort.SliceStable(ioTop, func(i, j int) bool {
+		return ioTop[i].IOReadBytes+ioTop[i].IOWriteBytes > ioTop[j].IOReadBytes+ioTop[j].IOWriteBytes
+	})
+	for i := 0; i < len(ioTop) && i < TopProcessCount; i++ {
+		stats.TopIO = append(stats.TopIO, ioTop[i])
+	}
+
 	return stats, nil
 }
 
+// Pidof returns the lowest PID of the processes named name at the last
+// scan. Names are matched against the kernel's command names, which are
+// truncated to 15 characters.
+func (r *processReader) Pidof(name string) (int, bool) {
+	if len(name) > commMaxLen {
+		name = name[:commMaxLen]
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pid, ok := r.pidsByName[name]
+	return pid, ok
+}
+
 // readProcess reads information for a single process.
 func (r *processReader) readProcess(pid int, cpuDelta uint64) (ProcessInfo, cpuTime, error) {
 	proc := ProcessInfo{PID: pid}
@@ -176,6 +247,54 @@ func (r *processReader) readProcess(pid int, cpuDelta uint64) (ProcessInfo, cpuT
 	return proc, ct, nil
 }
 
+// readProcessIO reads the storage I/O counters of a process.
+func (r *processReader) readProcessIO(pid int) (ioCounters, error) {
+	return readProcIO(filepath.Join(r.procPath, strconv.Itoa(pid), "io"))
+}
+
+// readProcIO reads the storage I/O counters from a /proc/[pid]/io file. The
+// read_bytes and write_bytes fields count bytes fetched from and sent to
+// the storage layer, unlike rchar and wchar which include cached reads and
+// pipes.
+func readProcIO(path string) (ioCounters, error) {
+	var io ioCounters
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return io, fmt.Errorf("reading io: %w", err)
+	}
+
+	var haveRead, haveWrite bool
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			io.readBytes, haveRead = n, true
+		case "write_bytes":
+			io.writeBytes, haveWrite = n, true
+		}
+	}
+	if !haveRead || !haveWrite {
+		return io, fmt.Errorf("parsing io: missing read_bytes or write_bytes")
+	}
+	return io, nil
+}
+
+// counterDelta returns the increase of a cumulative counter, or 0 if it
+// went backwards.
+func counterDelta(current, last uint64) uint64 {
+	if current < last {
+		return 0
+	}
+	return current - last
+}
+
 // parseProcessStat parses /proc/[pid]/stat content.
 // The format is: pid (comm) state ppid pgrp session tty_nr tpgid flags
 // minflt cminflt majflt cmajflt utime stime cutime cstime priority nice
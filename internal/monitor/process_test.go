@@ -612,3 +612,121 @@ func TestProcessStatsGetterSetter(t *testing.T) {
 		t.Error("deep copy failed - modification affected original")
 	}
 }
+
+func TestProcessReaderTopIO(t *testing.T) {
+	tmpDir := t.TempDir()
+	reader := newProcessReader()
+	reader.procPath = tmpDir
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "stat"), []byte("cpu  1000 200 300 4000 100 50 25 0 0 0\n"), 0o644); err != nil {
+		t.Fatalf("failed to create stat file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "meminfo"), []byte("MemTotal: 16000000 kB\n"), 0o644); err != nil {
+		t.Fatalf("failed to create meminfo file: %v", err)
+	}
+
+	writeProc := func(pid int, name, io string) {
+		t.Helper()
+		dir := filepath.Join(tmpDir, fmt.Sprint(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create process directory: %v", err)
+		}
+		stat := fmt.Sprintf("%d (%s) S 0 1 1 0 -1 0 0 0 0 0 10 5 0 0 20 0 1 0 100 1000 10 0", pid, name)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatalf("failed to create process stat file: %v", err)
+		}
+		if io != "" {
+			if err := os.WriteFile(filepath.Join(dir, "io"), []byte(io), 0o644); err != nil {
+				t.Fatalf("failed to create process io file: %v", err)
+			}
+		}
+	}
+	ioFile := func(read, write int) string {
+		return fmt.Sprintf("rchar: 999999\nwchar: 999999\nsyscr: 10\nsyscw: 10\nread_bytes: %d\nwrite_bytes: %d\ncancelled_write_bytes: 0\n", read, write)
+	}
+
+	writeProc(1, "reader", ioFile(5000, 0))
+	writeProc(2, "writer", ioFile(0, 8000))
+	writeProc(3, "private", "") // io unreadable, e.g. another user's process
+
+	stats, err := reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats failed: %v", err)
+	}
+	if len(stats.TopIO) != 2 {
+		t.Fatalf("expected 2 processes in TopIO, got %d", len(stats.TopIO))
+	}
+	for _, proc := range stats.TopIO {
+		if proc.IOReadBytes != 0 || proc.IOWriteBytes != 0 {
+			t.Errorf("first sample of %s: expected no I/O delta, got %d/%d", proc.Name, proc.IOReadBytes, proc.IOWriteBytes)
+		}
+	}
+
+	writeProc(1, "reader", ioFile(6000, 0))
+	writeProc(2, "writer", ioFile(0, 11000))
+
+	stats, err = reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats failed: %v", err)
+	}
+	if stats.TotalProcesses != 3 || len(stats.TopIO) != 2 {
+		t.Fatalf("expected 3 processes with 2 in TopIO, got %d and %d", stats.TotalProcesses, len(stats.TopIO))
+	}
+
+	top := stats.TopIO[0]
+	if top.Name != "writer" || top.IOWriteBytes != 3000 || top.IOReadBytes != 0 || top.IOPercent != 75 {
+		t.Errorf("TopIO[0] = %s read %d write %d (%.1f%%), want writer write 3000 (75%%)",
+			top.Name, top.IOReadBytes, top.IOWriteBytes, top.IOPercent)
+	}
+	second := stats.TopIO[1]
+	if second.Name != "reader" || second.IOReadBytes != 1000 || second.IOPercent != 25 {
+		t.Errorf("TopIO[1] = %s read %d (%.1f%%), want reader read 1000 (25%%)",
+			second.Name, second.IOReadBytes, second.IOPercent)
+	}
+}
+
+func TestProcessReaderPidof(t *testing.T) {
+	tmpDir := t.TempDir()
+	reader := newProcessReader()
+	reader.procPath = tmpDir
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "stat"), []byte("cpu  1000 200 300 4000 100 50 25 0 0 0\n"), 0o644); err != nil {
+		t.Fatalf("failed to create stat file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "meminfo"), []byte("MemTotal: 16000000 kB\n"), 0o644); err != nil {
+		t.Fatalf("failed to create meminfo file: %v", err)
+	}
+	for pid, name := range map[int]string{812: "postgres", 97: "postgres", 1: "systemd", 300: "systemd-journal"} {
+		dir := filepath.Join(tmpDir, fmt.Sprint(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create process directory: %v", err)
+		}
+		stat := fmt.Sprintf("%d (%s) S 0 1 1 0 -1 0 0 0 0 0 10 5 0 0 20 0 1 0 100 1000 10 0", pid, name)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatalf("failed to create process stat file: %v", err)
+		}
+	}
+
+	if _, ok := reader.Pidof("postgres"); ok {
+		t.Error("Pidof before the first scan found a process")
+	}
+	if _, err := reader.ReadStats(); err != nil {
+		t.Fatalf("ReadStats failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pid  int
+		ok   bool
+	}{
+		{"postgres", 97, true},
+		{"systemd", 1, true},
+		{"systemd-journald", 300, true}, // truncated to the command name
+		{"dockerd", 0, false},
+	}
+	for _, tt := range tests {
+		if pid, ok := reader.Pidof(tt.name); pid != tt.pid || ok != tt.ok {
+			t.Errorf("Pidof(%q) = %d, %v, want %d, %v", tt.name, pid, ok, tt.pid, tt.ok)
+		}
+	}
+}
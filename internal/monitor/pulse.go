@@ -0,0 +1,796 @@
+// Package monitor provides system monitoring functionality.
+// This file implements a client for the PulseAudio native protocol, which
+// PipeWire also serves through pipewire-pulse.
+package monitor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PulseAudio native protocol constants, from pulsecore/native-common.h and
+// pulsecore/tagstruct.h.
+const (
+	// pulseProtocolVersion is the protocol version requested from the
+	// server. The reply parsers handle versions 13 to 32.
+	pulseProtocolVersion = 32
+	pulseMinVersion      = 13
+
+	pulseCommandError                = 0
+	pulseCommandReply                = 2
+	pulseCommandAuth                 = 8
+	pulseCommandSetClientName        = 9
+	pulseCommandGetServerInfo        = 20
+	pulseCommandGetSinkInfoList      = 22
+	pulseCommandGetSourceInfoList    = 24
+	pulseCommandGetSinkInputInfoList = 30
+
+	// pulseControlChannel marks packets that carry commands rather than
+	// audio data.
+	pulseControlChannel = 0xFFFFFFFF
+	// pulseHeaderSize is the size of the packet descriptor: length,
+	// channel, offset (two words) and flags.
+	pulseHeaderSize = 20
+	// pulseMaxPacket bounds the packets accepted from the server.
+	pulseMaxPacket = 16 * 1024 * 1024
+	// pulseCookieLength is the size of the authentication cookie.
+	pulseCookieLength = 256
+	// pulseVolumeNorm is the volume value for 100%.
+	pulseVolumeNorm = 0x10000
+)
+
+// Tagstruct value types.
+const (
+	pulseTagString     = 't'
+	pulseTagStringNull = 'N'
+	pulseTagU32        = 'L'
+	pulseTagU8         = 'B'
+	pulseTagSampleSpec = 'a'
+	pulseTagArbitrary  = 'x'
+	pulseTagBoolTrue   = '1'
+	pulseTagBoolFalse  = '0'
+	pulseTagUsec       = 'U'
+	pulseTagChannelMap = 'm'
+	pulseTagCVolume    = 'v'
+	pulseTagProplist   = 'P'
+	pulseTagVolume     = 'V'
+	pulseTagFormatInfo = 'f'
+)
+
+// Sink and source states.
+const (
+	pulseStateRunning   = 0
+	pulseStateIdle      = 1
+	pulseStateSuspended = 2
+)
+
+// PulseDevice represents a PulseAudio sink (output) or source (input).
+type PulseDevice struct {
+	// Index is the server's index of the device.
+	Index uint32
+	// Name is the device name (e.g., "alsa_output.pci-0000_00_1f.3.analog-stereo").
+	Name string
+	// Description is the human-readable name (e.g., "Built-in Audio Analog Stereo").
+	Description string
+	// Volume is the average channel volume in percent, where 100 is the
+	// nominal maximum. Software amplification can exceed 100.
+	Volume float64
+	// Muted indicates if the device is muted.
+	Muted bool
+	// State is "running", "idle" or "suspended".
+	State string
+	// ActivePort is the name of the active port (e.g., "analog-output-headphones").
+	ActivePort string
+	// ActivePortDescription is the description of the active port (e.g., "Headphones").
+	ActivePortDescription string
+	// Properties holds the device's property list, such as "alsa.card_name".
+	Properties map[string]string
+}
+
+// CardName returns the name of the sound card the device belongs to, or its
+// description for devices without an ALSA card such as Bluetooth headsets.
+func (d PulseDevice) CardName() string {
+	if name := d.Properties["alsa.card_name"]; name != "" {
+		return name
+	}
+	return d.Description
+}
+
+// PulseStream represents an application's playback stream (sink input).
+type PulseStream struct {
+	// Index is the server's index of the stream.
+	Index uint32
+	// Name is the stream name (e.g., "Playback").
+	Name string
+	// Application is the application name (e.g., "Firefox").
+	Application string
+	// Sink is the index of the sink the stream plays to.
+	Sink uint32
+	// Volume is the average channel volume in percent.
+	Volume float64
+	// Muted indicates if the stream is muted.
+	Muted bool
+	// Corked indicates if the stream is paused.
+	Corked bool
+}
+
+// PulseStats contains the state of a PulseAudio or PipeWire server.
+type PulseStats struct {
+	// Server is the server name and version (e.g., "pulseaudio 16.1").
+	Server string
+	// DefaultSink is the default output device.
+	DefaultSink PulseDevice
+	// DefaultSource is the default input device.
+	DefaultSource PulseDevice
+	// Sinks lists all output devices.
+	Sinks []PulseDevice
+	// Sources lists all input devices, including sink monitors.
+	Sources []PulseDevice
+	// Streams lists the applications' playback streams.
+	Streams []PulseStream
+}
+
+// Stream returns the first playback stream whose application name matches
+// app case-insensitively.
+func (p PulseStats) Stream(app string) (PulseStream, bool) {
+	for _, s := range p.Streams {
+		if strings.EqualFold(s.Application, app) {
+			return s, true
+		}
+	}
+	return PulseStream{}, false
+}
+
+// clone returns a deep copy of p.
+func (p PulseStats) clone() PulseStats {
+	cloneDevice := func(d PulseDevice) PulseDevice {
+		d.Properties = maps.Clone(d.Properties)
+		return d
+	}
+	result := p
+	result.DefaultSink = cloneDevice(p.DefaultSink)
+	result.DefaultSource = cloneDevice(p.DefaultSource)
+	result.Sinks = make([]PulseDevice, len(p.Sinks))
+	for i, d := range p.Sinks {
+		result.Sinks[i] = cloneDevice(d)
+	}
+	result.Sources = make([]PulseDevice, len(p.Sources))
+	for i, d := range p.Sources {
+		result.Sources[i] = cloneDevice(d)
+	}
+	result.Streams = slices.Clone(p.Streams)
+	return result
+}
+
+// pulseReader reads the server state over a connection that is kept open
+// between reads and reopened after errors.
+type pulseReader struct {
+	mu sync.Mutex
+	// socketPath overrides the socket found from the environment.
+	socketPath string
+	// cookiePath overrides the authentication cookie location.
+	cookiePath    string
+	timeout       time.Duration
+	retryInterval time.Duration
+	conn          *pulseConn
+	dialErr       error
+	dialTime      time.Time
+}
+
+// newPulseReader creates a pulseReader that locates the server from the
+// environment.
+func newPulseReader() *pulseReader {
+	return &pulseReader{
+		timeout:       2 * time.Second,
+		retryInterval: 10 * time.Second,
+	}
+}
+
+// ReadStats queries the server for its sinks, sources and streams. After a
+// failed connection attempt the error is returned without retrying for the
+// retry interval, so systems without a sound server are not probed on
+// every update.
+func (r *pulseReader) ReadStats() (PulseStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if r.dialErr != nil && time.Since(r.dialTime) < r.retryInterval {
+			return PulseStats{}, r.dialErr
+		}
+		r.dialTime = time.Now()
+		r.conn, r.dialErr = r.dial()
+		if r.dialErr != nil {
+			return PulseStats{}, r.dialErr
+		}
+	}
+
+	stats, err := r.conn.readStats()
+	if err != nil {
+		// The server may have restarted; reconnect on the next read
+		r.conn.close()
+		r.conn = nil
+		return PulseStats{}, err
+	}
+	return stats, nil
+}
+
+// Close closes the connection to the server.
+func (r *pulseReader) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		r.conn.close()
+		r.conn = nil
+	}
+}
+
+// dial connects and authenticates to the server.
+func (r *pulseReader) dial() (*pulseConn, error) {
+	path := r.socketPath
+	if path == "" {
+		var err error
+		if path, err = pulseSocketPath(); err != nil {
+			return nil, err
+		}
+	}
+	cookie := readPulseCookie(r.cookiePath)
+	return dialPulse(path, cookie, r.timeout)
+}
+
+// pulseSocketPath returns the server socket named by $PULSE_SERVER, or the
+// per-user socket in $XDG_RUNTIME_DIR.
+func pulseSocketPath() (string, error) {
+	if server := os.Getenv("PULSE_SERVER"); server != "" {
+		// The variable may list several servers; use the first local one
+		for _, s := range strings.Fields(server) {
+			if path, ok := strings.CutPrefix(s, "unix:"); ok {
+				return path, nil
+			}
+			if strings.HasPrefix(s, "/") {
+				return s, nil
+			}
+		}
+		return "", fmt.Errorf("PULSE_SERVER %q names no local socket", server)
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return filepath.Join(runtimeDir, "pulse", "native"), nil
+}
+
+// readPulseCookie returns the authentication cookie, or zeros if none is
+// found. PipeWire does not check the cookie.
+func readPulseCookie(path string) []byte {
+	candidates := []string{path, os.Getenv("PULSE_COOKIE")}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".config", "pulse", "cookie"),
+			filepath.Join(home, ".pulse-cookie"))
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if data, err := os.ReadFile(c); err == nil && len(data) >= pulseCookieLength {
+			return data[:pulseCookieLength]
+		}
+	}
+	return make([]byte, pulseCookieLength)
+}
+
+// pulseConn is an authenticated connection to a PulseAudio server.
+type pulseConn struct {
+	conn    net.Conn
+	timeout time.Duration
+	// version is the negotiated protocol version.
+	version uint32
+	tag     uint32
+}
+
+// dialPulse connects to the server socket at path, authenticates with
+// cookie and names the client.
+func dialPulse(path string, cookie []byte, timeout time.Duration) (*pulseConn, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("connect to pulseaudio: %w", err)
+	}
+	c := &pulseConn{conn: conn, timeout: timeout}
+
+	reply, err := c.request(pulseCommandAuth, func(w *pulseTagWriter) {
+		w.putU32(pulseProtocolVersion)
+		w.putArbitrary(cookie)
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+	// The upper bits of the server version flag shared memory support
+	serverVersion := reply.u32() & 0xFFFF
+	if reply.err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("authenticate: %w", reply.err)
+	}
+	c.version = min(serverVersion, pulseProtocolVersion)
+	if c.version < pulseMinVersion {
+		conn.Close()
+		return nil, fmt.Errorf("pulseaudio protocol version %d is too old", c.version)
+	}
+
+	_, err = c.request(pulseCommandSetClientName, func(w *pulseTagWriter) {
+		w.putProplist(map[string]string{"application.name": "go-conky"})
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("set client name: %w", err)
+	}
+	return c, nil
+}
+
+// close closes the connection.
+func (c *pulseConn) close() {
+	c.conn.Close()
+}
+
+// request sends a command whose arguments are written by args and returns a
+// reader positioned at the start of the reply's arguments.
+func (c *pulseConn) request(command uint32, args func(w *pulseTagWriter)) (*pulseTagReader, error) {
+	c.tag++
+	w := &pulseTagWriter{}
+	w.putU32(command)
+	w.putU32(c.tag)
+	if args != nil {
+		args(w)
+	}
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	if err := writePulsePacket(c.conn, w.buf); err != nil {
+		return nil, fmt.Errorf("send command %d: %w", command, err)
+	}
+
+	for {
+		channel, payload, err := readPulsePacket(c.conn)
+		if err != nil {
+			return nil, fmt.Errorf("read reply to command %d: %w", command, err)
+		}
+		if channel != pulseControlChannel {
+			continue
+		}
+		r := &pulseTagReader{buf: payload}
+		replyCommand, tag := r.u32(), r.u32()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if tag != c.tag {
+			continue
+		}
+		switch replyCommand {
+		case pulseCommandReply:
+			return r, nil
+		case pulseCommandError:
+			return nil, fmt.Errorf("command %d failed with pulseaudio error %d", command, r.u32())
+		default:
+			return nil, fmt.Errorf("unexpected reply %d to command %d", replyCommand, command)
+		}
+	}
+}
+
+// readStats queries the server information, devices and streams.
+func (c *pulseConn) readStats() (PulseStats, error) {
+	var stats PulseStats
+
+	r, err := c.request(pulseCommandGetServerInfo, nil)
+	if err != nil {
+		return stats, err
+	}
+	name, version := r.string(), r.string()
+	r.string() // user name
+	r.string() // host name
+	r.sampleSpec()
+	defaultSink, defaultSource := r.string(), r.string()
+	if r.err != nil {
+		return stats, fmt.Errorf("parsing server info: %w", r.err)
+	}
+	stats.Server = name + " " + version
+
+	if stats.Sinks, err = c.readDevices(pulseCommandGetSinkInfoList, true); err != nil {
+		return stats, err
+	}
+	if stats.Sources, err = c.readDevices(pulseCommandGetSourceInfoList, false); err != nil {
+		return stats, err
+	}
+	if stats.Streams, err = c.readStreams(); err != nil {
+		return stats, err
+	}
+
+	stats.DefaultSink = findPulseDevice(stats.Sinks, defaultSink)
+	stats.DefaultSource = findPulseDevice(stats.Sources, defaultSource)
+	return stats, nil
+}
+
+// readDevices reads the sink or source list.
+func (c *pulseConn) readDevices(command uint32, sink bool) ([]PulseDevice, error) {
+	r, err := c.request(command, nil)
+	if err != nil {
+		return nil, err
+	}
+	var devices []PulseDevice
+	for !r.done() {
+		device := parsePulseDevice(r, c.version, sink)
+		if r.err != nil {
+			return nil, fmt.Errorf("parsing device list: %w", r.err)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// readStreams reads the sink input list.
+func (c *pulseConn) readStreams() ([]PulseStream, error) {
+	r, err := c.request(pulseCommandGetSinkInputInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var streams []PulseStream
+	for !r.done() {
+		stream := parsePulseStream(r, c.version)
+		if r.err != nil {
+			return nil, fmt.Errorf("parsing stream list: %w", r.err)
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// findPulseDevice returns the device called name, or the first device if
+// there is none.
+func findPulseDevice(devices []PulseDevice, name string) PulseDevice {
+	for _, d := range devices {
+		if d.Name == name {
+			return d
+		}
+	}
+	if len(devices) > 0 {
+		return devices[0]
+	}
+	return PulseDevice{}
+}
+
+// parsePulseDevice reads one entry of a sink or source info list, laid out
+// as in sink_fill_tagstruct and source_fill_tagstruct of the server.
+func parsePulseDevice(r *pulseTagReader, version uint32, sink bool) PulseDevice {
+	var d PulseDevice
+	d.Index = r.u32()
+	d.Name = r.string()
+	d.Description = r.string()
+	r.sampleSpec()
+	r.channelMap()
+	r.u32() // owner module
+	d.Volume = pulseVolumePercent(r.cvolume())
+	d.Muted = r.boolean()
+	r.u32()    // monitor source, or monitored sink
+	r.string() // its name
+	r.usec()   // latency
+	r.string() // driver
+	r.u32()    // flags
+
+	d.Properties = r.proplist()
+	r.usec() // configured latency
+
+	if version >= 15 {
+		r.volume() // base volume
+		switch r.u32() {
+		case pulseStateRunning:
+			d.State = "running"
+		case pulseStateIdle:
+			d.State = "idle"
+		case pulseStateSuspended:
+			d.State = "suspended"
+		}
+		r.u32() // volume steps
+		r.u32() // card
+	}
+
+	if version >= 16 {
+		ports := make(map[string]string)
+		for n := r.u32(); n > 0 && r.err == nil; n-- {
+			name, description := r.string(), r.string()
+			r.u32() // priority
+			if version >= 24 {
+				r.u32() // availability
+			}
+			ports[name] = description
+		}
+		d.ActivePort = r.string()
+		d.ActivePortDescription = ports[d.ActivePort]
+	}
+
+	if (sink && version >= 21) || (!sink && version >= 22) {
+		for n := r.u8(); n > 0 && r.err == nil; n-- {
+			r.formatInfo()
+		}
+	}
+	return d
+}
+
+// parsePulseStream reads one entry of a sink input info list, laid out as
+// in sink_input_fill_tagstruct of the server.
+func parsePulseStream(r *pulseTagReader, version uint32) PulseStream {
+	var s PulseStream
+	s.Index = r.u32()
+	s.Name = r.string()
+	r.u32() // owner module
+	r.u32() // client
+	s.Sink = r.u32()
+	r.sampleSpec()
+	r.channelMap()
+	s.Volume = pulseVolumePercent(r.cvolume())
+	r.usec()   // buffer latency
+	r.usec()   // sink latency
+	r.string() // resample method
+	r.string() // driver
+	s.Muted = r.boolean()
+	props := r.proplist()
+	s.Application = props["application.name"]
+	if version >= 19 {
+		s.Corked = r.boolean()
+	}
+	if version >= 20 {
+		r.boolean() // has volume
+		r.boolean() // volume writable
+	}
+	if version >= 21 {
+		r.formatInfo()
+	}
+	return s
+}
+
+// pulseVolumePercent returns the average of channel volumes in percent.
+func pulseVolumePercent(volumes []uint32) float64 {
+	if len(volumes) == 0 {
+		return 0
+	}
+	var sum uint64
+	for _, v := range volumes {
+		sum += uint64(v)
+	}
+	return float64(sum) / float64(len(volumes)) * 100 / pulseVolumeNorm
+}
+
+// writePulsePacket writes a control packet with payload.
+func writePulsePacket(w io.Writer, payload []byte) error {
+	packet := make([]byte, pulseHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(packet[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(packet[4:], pulseControlChannel)
+	copy(packet[pulseHeaderSize:], payload)
+	_, err := w.Write(packet)
+	return err
+}
+
+// readPulsePacket reads a packet and returns its channel and payload.
+func readPulsePacket(r io.Reader) (uint32, []byte, error) {
+	var header [pulseHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[0:])
+	channel := binary.BigEndian.Uint32(header[4:])
+	if length > pulseMaxPacket {
+		return 0, nil, fmt.Errorf("packet of %d bytes exceeds limit", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return channel, payload, nil
+}
+
+// errPulseShortRead reports a tagstruct that ends in the middle of a value.
+var errPulseShortRead = errors.New("truncated tagstruct")
+
+// pulseTagWriter encodes a tagstruct, the self-describing serialization of
+// command arguments.
+type pulseTagWriter struct {
+	buf []byte
+}
+
+func (w *pulseTagWriter) putU32(v uint32) {
+	w.buf = append(w.buf, pulseTagU32)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *pulseTagWriter) putString(s string) {
+	w.buf = append(w.buf, pulseTagString)
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+}
+
+func (w *pulseTagWriter) putStringNull() {
+	w.buf = append(w.buf, pulseTagStringNull)
+}
+
+func (w *pulseTagWriter) putArbitrary(data []byte) {
+	w.buf = append(w.buf, pulseTagArbitrary)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(len(data)))
+	w.buf = append(w.buf, data...)
+}
+
+// putProplist writes a property list of NUL-terminated string values.
+func (w *pulseTagWriter) putProplist(props map[string]string) {
+	w.buf = append(w.buf, pulseTagProplist)
+	for _, key := range slices.Sorted(maps.Keys(props)) {
+		value := append([]byte(props[key]), 0)
+		w.putString(key)
+		w.putU32(uint32(len(value)))
+		w.putArbitrary(value)
+	}
+	w.putStringNull()
+}
+
+// pulseTagReader decodes a tagstruct. The first error is kept in err and
+// makes all later reads return zero values, so that a reply can be parsed
+// field by field and checked once.
+type pulseTagReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+// done reports whether all values have been read or an error occurred.
+func (r *pulseTagReader) done() bool {
+	return r.err != nil || r.pos >= len(r.buf)
+}
+
+// next returns the next n bytes.
+func (r *pulseTagReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.buf) {
+		r.err = errPulseShortRead
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// tag reads a type tag and checks that it is one of want.
+func (r *pulseTagReader) tag(want ...byte) byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	if !slices.Contains(want, b[0]) {
+		r.err = fmt.Errorf("tagstruct: got type %q, want %q", b[0], want)
+		return 0
+	}
+	return b[0]
+}
+
+func (r *pulseTagReader) rawU32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *pulseTagReader) rawU8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *pulseTagReader) u32() uint32 {
+	r.tag(pulseTagU32)
+	return r.rawU32()
+}
+
+func (r *pulseTagReader) u8() uint8 {
+	r.tag(pulseTagU8)
+	return r.rawU8()
+}
+
+func (r *pulseTagReader) usec() uint64 {
+	r.tag(pulseTagUsec)
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *pulseTagReader) volume() uint32 {
+	r.tag(pulseTagVolume)
+	return r.rawU32()
+}
+
+func (r *pulseTagReader) boolean() bool {
+	return r.tag(pulseTagBoolTrue, pulseTagBoolFalse) == pulseTagBoolTrue
+}
+
+// string reads a string; a null string is returned as "".
+func (r *pulseTagReader) string() string {
+	if r.tag(pulseTagString, pulseTagStringNull) != pulseTagString {
+		return ""
+	}
+	end := r.pos
+	for end < len(r.buf) && r.buf[end] != 0 {
+		end++
+	}
+	if end == len(r.buf) {
+		r.err = errPulseShortRead
+		return ""
+	}
+	s := string(r.buf[r.pos:end])
+	r.pos = end + 1
+	return s
+}
+
+func (r *pulseTagReader) arbitrary() []byte {
+	r.tag(pulseTagArbitrary)
+	return r.next(int(r.rawU32()))
+}
+
+// sampleSpec skips a sample spec: format, channel count and rate.
+func (r *pulseTagReader) sampleSpec() {
+	r.tag(pulseTagSampleSpec)
+	r.next(6)
+}
+
+// channelMap skips a channel map.
+func (r *pulseTagReader) channelMap() {
+	r.tag(pulseTagChannelMap)
+	r.next(int(r.rawU8()))
+}
+
+// cvolume reads the per-channel volumes.
+func (r *pulseTagReader) cvolume() []uint32 {
+	r.tag(pulseTagCVolume)
+	n := int(r.rawU8())
+	volumes := make([]uint32, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		volumes = append(volumes, r.rawU32())
+	}
+	return volumes
+}
+
+// proplist reads a property list, dropping the NUL that terminates string
+// values.
+func (r *pulseTagReader) proplist() map[string]string {
+	r.tag(pulseTagProplist)
+	props := make(map[string]string)
+	for r.err == nil {
+		key := r.string()
+		if key == "" {
+			break
+		}
+		length := r.u32()
+		value := r.arbitrary()
+		if r.err == nil && uint32(len(value)) != length {
+			r.err = fmt.Errorf("tagstruct: property %s has %d bytes, want %d", key, len(value), length)
+		}
+		props[key] = strings.TrimSuffix(string(value), "\x00")
+	}
+	return props
+}
+
+// formatInfo skips a format info: encoding and property list.
+func (r *pulseTagReader) formatInfo() {
+	r.tag(pulseTagFormatInfo)
+	r.u8()
+	r.proplist()
+}
@@ -0,0 +1,435 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func (w *pulseTagWriter) putU8(v uint8) {
+	w.buf = append(w.buf, pulseTagU8, v)
+}
+
+func (w *pulseTagWriter) putBool(v bool) {
+	if v {
+		w.buf = append(w.buf, pulseTagBoolTrue)
+	} else {
+		w.buf = append(w.buf, pulseTagBoolFalse)
+	}
+}
+
+func (w *pulseTagWriter) putUsec(v uint64) {
+	w.buf = append(w.buf, pulseTagUsec)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *pulseTagWriter) putVolume(v uint32) {
+	w.buf = append(w.buf, pulseTagVolume)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+// putSampleSpec writes a 44.1 kHz stereo sample spec.
+func (w *pulseTagWriter) putSampleSpec() {
+	w.buf = append(w.buf, pulseTagSampleSpec, 3, 2)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, 44100)
+}
+
+// putStereo writes a stereo sample spec and channel map.
+func (w *pulseTagWriter) putStereo() {
+	w.putSampleSpec()
+	w.buf = append(w.buf, pulseTagChannelMap, 2, 1, 2)
+}
+
+func (w *pulseTagWriter) putCVolume(volumes ...uint32) {
+	w.buf = append(w.buf, pulseTagCVolume, uint8(len(volumes)))
+	for _, v := range volumes {
+		w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	}
+}
+
+func (w *pulseTagWriter) putFormatInfo() {
+	w.buf = append(w.buf, pulseTagFormatInfo)
+	w.putU8(1) // PCM
+	w.putProplist(nil)
+}
+
+// fakePulseDevice is a sink or source served by the fake server.
+type fakePulseDevice struct {
+	name, description string
+	volume            []uint32
+	muted             bool
+	state             uint32
+	ports             [][2]string
+	activePort        string
+	props             map[string]string
+}
+
+// putDevice writes a device as the server's sink_fill_tagstruct does.
+func (w *pulseTagWriter) putDevice(index uint32, d fakePulseDevice, version uint32, sink bool) {
+	w.putU32(index)
+	w.putString(d.name)
+	w.putString(d.description)
+	w.putStereo()
+	w.putU32(1)
+	w.putCVolume(d.volume...)
+	w.putBool(d.muted)
+	w.putU32(0xFFFFFFFF)
+	w.putStringNull()
+	w.putUsec(20000)
+	w.putString("module-alsa-card.c")
+	w.putU32(0)
+	w.putProplist(d.props)
+	w.putUsec(0)
+	if version >= 15 {
+		w.putVolume(pulseVolumeNorm)
+		w.putU32(d.state)
+		w.putU32(65537)
+		w.putU32(0)
+	}
+	if version >= 16 {
+		w.putU32(uint32(len(d.ports)))
+		for i, port := range d.ports {
+			w.putString(port[0])
+			w.putString(port[1])
+			w.putU32(uint32(100 - i))
+			if version >= 24 {
+				w.putU32(2)
+			}
+		}
+		w.putString(d.activePort)
+	}
+	if (sink && version >= 21) || (!sink && version >= 22) {
+		w.putU8(1)
+		w.putFormatInfo()
+	}
+}
+
+// putStream writes a sink input as the server's sink_input_fill_tagstruct
+// does.
+func (w *pulseTagWriter) putStream(index uint32, app string, volume uint32, muted bool, version uint32) {
+	w.putU32(index)
+	w.putString("Playback")
+	w.putU32(0xFFFFFFFF)
+	w.putU32(5)
+	w.putU32(1)
+	w.putStereo()
+	w.putCVolume(volume, volume)
+	w.putUsec(0)
+	w.putUsec(0)
+	w.putString("speex-float-1")
+	w.putString("protocol-native.c")
+	w.putBool(muted)
+	w.putProplist(map[string]string{"application.name": app, "media.name": "Playback"})
+	if version >= 19 {
+		w.putBool(false)
+	}
+	if version >= 20 {
+		w.putBool(true)
+		w.putBool(true)
+	}
+	if version >= 21 {
+		w.putFormatInfo()
+	}
+}
+
+// servePulse starts a fake PulseAudio server on a socket under a temporary
+// directory that speaks protocol version serverVersion, and returns the
+// socket path.
+func servePulse(t *testing.T, serverVersion uint32) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "native")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	sinks := []fakePulseDevice{
+		{
+			name:        "alsa_output.hdmi",
+			description: "HDMI Output",
+			volume:      []uint32{pulseVolumeNorm, pulseVolumeNorm},
+			state:       pulseStateSuspended,
+			props:       map[string]string{"alsa.card_name": "HDA ATI HDMI"},
+		},
+		{
+			name:        "alsa_output.analog-stereo",
+			description: "Built-in Audio Analog Stereo",
+			volume:      []uint32{0xC000, 0xC000},
+			state:       pulseStateRunning,
+			ports:       [][2]string{{"analog-output-speaker", "Speakers"}, {"analog-output-headphones", "Headphones"}},
+			activePort:  "analog-output-headphones",
+			props:       map[string]string{"alsa.card_name": "HDA Intel PCH", "device.class": "sound"},
+		},
+	}
+	sources := []fakePulseDevice{
+		{
+			name:        "alsa_input.analog-stereo",
+			description: "Built-in Audio Analog Stereo",
+			volume:      []uint32{pulseVolumeNorm / 2, pulseVolumeNorm / 2},
+			muted:       true,
+			state:       pulseStateIdle,
+			props:       map[string]string{"alsa.card_name": "HDA Intel PCH"},
+		},
+	}
+
+	handle := func(conn net.Conn) {
+		defer conn.Close()
+		version := uint32(0)
+		for {
+			_, payload, err := readPulsePacket(conn)
+			if err != nil {
+				return
+			}
+			r := &pulseTagReader{buf: payload}
+			command, tag := r.u32(), r.u32()
+
+			w := &pulseTagWriter{}
+			w.putU32(pulseCommandReply)
+			w.putU32(tag)
+			switch command {
+			case pulseCommandAuth:
+				version = min(r.u32()&0xFFFF, serverVersion)
+				// Advertise shared memory support as real servers do
+				w.putU32(serverVersion | 0x80000000)
+			case pulseCommandSetClientName:
+				if r.proplist()["application.name"] != "go-conky" {
+					return
+				}
+				w.putU32(7)
+			case pulseCommandGetServerInfo:
+				w.putString("pulseaudio")
+				w.putString("16.1")
+				w.putString("user")
+				w.putString("desktop")
+				w.putSampleSpec()
+				w.putString("alsa_output.analog-stereo")
+				w.putString("alsa_input.analog-stereo")
+			case pulseCommandGetSinkInfoList:
+				for i, d := range sinks {
+					w.putDevice(uint32(i), d, version, true)
+				}
+			case pulseCommandGetSourceInfoList:
+				for i, d := range sources {
+					w.putDevice(uint32(i), d, version, false)
+				}
+			case pulseCommandGetSinkInputInfoList:
+				w.putStream(12, "Firefox", pulseVolumeNorm/2, false, version)
+				w.putStream(13, "mpv", pulseVolumeNorm, true, version)
+			default:
+				w = &pulseTagWriter{}
+				w.putU32(pulseCommandError)
+				w.putU32(tag)
+				w.putU32(2)
+			}
+			if r.err != nil {
+				return
+			}
+			if err := writePulsePacket(conn, w.buf); err != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return path
+}
+
+func TestPulseReaderReadStats(t *testing.T) {
+	for _, version := range []uint32{35, 16} {
+		t.Run(fmt.Sprintf("server version %d", version), func(t *testing.T) {
+			reader := newPulseReader()
+			reader.socketPath = servePulse(t, version)
+			reader.cookiePath = filepath.Join(t.TempDir(), "missing")
+			defer reader.Close()
+
+			stats, err := reader.ReadStats()
+			if err != nil {
+				t.Fatalf("ReadStats() error = %v", err)
+			}
+			if want := min(version, pulseProtocolVersion); reader.conn.version != want {
+				t.Errorf("negotiated version = %d, want %d", reader.conn.version, want)
+			}
+
+			if stats.Server != "pulseaudio 16.1" {
+				t.Errorf("Server = %q", stats.Server)
+			}
+			if len(stats.Sinks) != 2 || len(stats.Sources) != 1 || len(stats.Streams) != 2 {
+				t.Fatalf("got %d sinks, %d sources, %d streams, want 2, 1, 2", len(stats.Sinks), len(stats.Sources), len(stats.Streams))
+			}
+
+			sink := stats.DefaultSink
+			if sink.Name != "alsa_output.analog-stereo" || sink.Description != "Built-in Audio Analog Stereo" {
+				t.Errorf("DefaultSink = %s (%s)", sink.Name, sink.Description)
+			}
+			if sink.Volume != 75 || sink.Muted || sink.State != "running" {
+				t.Errorf("DefaultSink volume/muted/state = %v/%v/%s, want 75/false/running", sink.Volume, sink.Muted, sink.State)
+			}
+			if sink.ActivePort != "analog-output-headphones" || sink.ActivePortDescription != "Headphones" {
+				t.Errorf("DefaultSink port = %s (%s)", sink.ActivePort, sink.ActivePortDescription)
+			}
+			if sink.CardName() != "HDA Intel PCH" {
+				t.Errorf("CardName() = %q", sink.CardName())
+			}
+
+			source := stats.DefaultSource
+			if source.Volume != 50 || !source.Muted || source.State != "idle" {
+				t.Errorf("DefaultSource volume/muted/state = %v/%v/%s, want 50/true/idle", source.Volume, source.Muted, source.State)
+			}
+
+			stream, ok := stats.Stream("firefox")
+			if !ok || stream.Index != 12 || stream.Volume != 50 || stream.Muted {
+				t.Errorf("Stream(firefox) = %+v, %v", stream, ok)
+			}
+			if stream, _ := stats.Stream("mpv"); !stream.Muted || stream.Volume != 100 {
+				t.Errorf("Stream(mpv) = %+v", stream)
+			}
+
+			// The connection stays open for the next read
+			conn := reader.conn
+			if _, err := reader.ReadStats(); err != nil || reader.conn != conn {
+				t.Errorf("second ReadStats() error = %v, reused connection = %v", err, reader.conn == conn)
+			}
+		})
+	}
+}
+
+func TestPulseReaderReconnect(t *testing.T) {
+	reader := newPulseReader()
+	reader.socketPath = servePulse(t, 32)
+	defer reader.Close()
+
+	if _, err := reader.ReadStats(); err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	reader.conn.conn.Close()
+
+	if _, err := reader.ReadStats(); err == nil {
+		t.Error("ReadStats() over a closed connection succeeded, want error")
+	}
+	if _, err := reader.ReadStats(); err != nil {
+		t.Errorf("ReadStats() after reconnecting error = %v", err)
+	}
+}
+
+func TestPulseReaderRetryInterval(t *testing.T) {
+	dir := t.TempDir()
+	reader := newPulseReader()
+	reader.socketPath = filepath.Join(dir, "native")
+	reader.retryInterval = time.Hour
+
+	if _, err := reader.ReadStats(); err == nil {
+		t.Fatal("ReadStats() without a server succeeded, want error")
+	}
+
+	// A server that starts later is not contacted within the retry interval
+	server := servePulse(t, 32)
+	if err := os.Symlink(server, reader.socketPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadStats(); err == nil {
+		t.Error("ReadStats() within the retry interval succeeded, want the cached error")
+	}
+
+	reader.retryInterval = 0
+	if _, err := reader.ReadStats(); err != nil {
+		t.Errorf("ReadStats() after the retry interval error = %v", err)
+	}
+	reader.Close()
+}
+
+func TestAudioReaderPulse(t *testing.T) {
+	reader := &audioReader{
+		asoundPath: t.TempDir(),
+		pulse:      newPulseReader(),
+	}
+	reader.pulse.socketPath = servePulse(t, 32)
+	defer reader.Close()
+
+	stats, err := reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if stats.Backend != AudioBackendPulse || !stats.HasAudio {
+		t.Errorf("Backend = %q, HasAudio = %v, want pulseaudio", stats.Backend, stats.HasAudio)
+	}
+	if stats.MasterVolume != 75 || stats.MasterMuted {
+		t.Errorf("MasterVolume/MasterMuted = %v/%v, want 75/false", stats.MasterVolume, stats.MasterMuted)
+	}
+}
+
+func TestAudioReaderPulseFallback(t *testing.T) {
+	asound := t.TempDir()
+	writeFile(t, asound, "cards", " 0 [PCH            ]: HDA-Intel - HDA Intel PCH")
+
+	reader := &audioReader{
+		asoundPath: asound,
+		pulse:      newPulseReader(),
+	}
+	reader.pulse.socketPath = filepath.Join(t.TempDir(), "native")
+
+	stats, err := reader.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if stats.Backend != AudioBackendALSA || len(stats.Cards) != 1 {
+		t.Errorf("Backend = %q with %d cards, want alsa with 1", stats.Backend, len(stats.Cards))
+	}
+}
+
+func TestPulseTagReaderErrors(t *testing.T) {
+	w := &pulseTagWriter{}
+	w.putU32(7)
+	w.putString("sink")
+
+	r := &pulseTagReader{buf: w.buf}
+	if r.string(); r.err == nil {
+		t.Error("reading a u32 as a string succeeded, want type error")
+	}
+	if r.u32() != 0 {
+		t.Error("read after an error returned a value")
+	}
+
+	r = &pulseTagReader{buf: w.buf[:3]}
+	if r.u32(); !errors.Is(r.err, errPulseShortRead) {
+		t.Errorf("truncated u32 error = %v, want %v", r.err, errPulseShortRead)
+	}
+
+	r = &pulseTagReader{buf: w.buf[:len(w.buf)-1]}
+	r.u32()
+	if r.string(); !errors.Is(r.err, errPulseShortRead) {
+		t.Errorf("unterminated string error = %v, want %v", r.err, errPulseShortRead)
+	}
+}
+
+func TestPulseSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	t.Setenv("PULSE_SERVER", "")
+	if path, err := pulseSocketPath(); err != nil || path != "/run/user/1000/pulse/native" {
+		t.Errorf("pulseSocketPath() = %q, %v", path, err)
+	}
+
+	t.Setenv("PULSE_SERVER", "tcp:music.local unix:/tmp/pulse.sock")
+	if path, err := pulseSocketPath(); err != nil || path != "/tmp/pulse.sock" {
+		t.Errorf("pulseSocketPath() with PULSE_SERVER = %q, %v", path, err)
+	}
+
+	t.Setenv("PULSE_SERVER", "tcp:music.local")
+	if _, err := pulseSocketPath(); err == nil {
+		t.Error("pulseSocketPath() with a remote server succeeded, want error")
+	}
+}
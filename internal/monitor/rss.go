@@ -0,0 +1,248 @@
+// Package monitor provides system monitoring functionality.
+// This file implements RSS and Atom feed parsing for the ${rss} variable.
+package monitor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RSSFeed contains a parsed RSS or Atom feed.
+type RSSFeed struct {
+	// Title is the feed title.
+	Title string
+	// Link is the URL of the site the feed belongs to.
+	Link string
+	// Description is the feed description or Atom subtitle.
+	Description string
+	// Items are the feed items in document order.
+	Items []RSSItem
+}
+
+// RSSItem is an RSS item or Atom entry.
+type RSSItem struct {
+	// Title is the item title.
+	Title string
+	// Link is the URL of the item.
+	Link string
+	// Description is the item description or Atom summary.
+	Description string
+	// Published is the publication date as written in the feed.
+	Published string
+}
+
+// rssReader fetches feeds and caches their parsed form until the fetcher
+// returns a newer body.
+type rssReader struct {
+	mu      sync.Mutex
+	fetcher *httpFetcher
+	cache   map[string]rssCacheEntry
+}
+
+// rssCacheEntry holds a parsed feed and the fetch time of its body.
+type rssCacheEntry struct {
+	feed    RSSFeed
+	err     error
+	updated time.Time
+}
+
+// newRSSReader creates an rssReader that fetches through fetcher.
+func newRSSReader(fetcher *httpFetcher) *rssReader {
+	return &rssReader{
+		fetcher: fetcher,
+		cache:   make(map[string]rssCacheEntry),
+	}
+}
+
+// Read returns the feed at rawURL, fetching it in the background at most
+// once per interval. The last successfully fetched feed is returned along
+// with any fetch error.
+func (r *rssReader) Read(rawURL string, interval time.Duration) (RSSFeed, error) {
+	body, updated, fetchErr := r.fetcher.Fetch(rawURL, interval)
+	if body == nil {
+		return RSSFeed{}, fetchErr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[rawURL]
+	if !ok || !entry.updated.Equal(updated) {
+		feed, err := parseFeed(body)
+		entry = rssCacheEntry{feed: feed, err: err, updated: updated}
+		r.cache[rawURL] = entry
+	}
+	if entry.err != nil {
+		return RSSFeed{}, entry.err
+	}
+	return entry.feed, fetchErr
+}
+
+// rssDocument is an RSS 0.9x/2.0 <rss> or RSS 1.0 <rdf:RDF> document.
+// RSS 1.0 places items beside the channel rather than inside it.
+type rssDocument struct {
+	Channel struct {
+		Title       string       `xml:"title"`
+		Link        string       `xml:"link"`
+		Description string       `xml:"description"`
+		Items       []rssElement `xml:"item"`
+	} `xml:"channel"`
+	Items []rssElement `xml:"item"`
+}
+
+// rssElement is an RSS <item>.
+type rssElement struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+// atomDocument is an Atom <feed> document.
+type atomDocument struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// atomEntry is an Atom <entry>.
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// atomLink is an Atom <link>.
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// parseFeed parses an RSS 0.9x, 1.0 or 2.0 or an Atom document.
+func parseFeed(data []byte) (RSSFeed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = feedCharsetReader
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return RSSFeed{}, fmt.Errorf("no feed element found")
+			}
+			return RSSFeed{}, fmt.Errorf("parse feed: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "rss", "RDF":
+			var doc rssDocument
+			if err := decoder.DecodeElement(&doc, &start); err != nil {
+				return RSSFeed{}, fmt.Errorf("parse RSS: %w", err)
+			}
+			return doc.feed(), nil
+		case "feed":
+			var doc atomDocument
+			if err := decoder.DecodeElement(&doc, &start); err != nil {
+				return RSSFeed{}, fmt.Errorf("parse Atom: %w", err)
+			}
+			return doc.feed(), nil
+		default:
+			return RSSFeed{}, fmt.Errorf("unknown feed element <%s>", start.Name.Local)
+		}
+	}
+}
+
+// feed converts the document to an RSSFeed.
+func (d *rssDocument) feed() RSSFeed {
+	feed := RSSFeed{
+		Title:       strings.TrimSpace(d.Channel.Title),
+		Link:        strings.TrimSpace(d.Channel.Link),
+		Description: strings.TrimSpace(d.Channel.Description),
+	}
+	for _, item := range append(d.Channel.Items, d.Items...) {
+		published := item.PubDate
+		if published == "" {
+			published = item.Date
+		}
+		feed.Items = append(feed.Items, RSSItem{
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.Link),
+			Description: strings.TrimSpace(item.Description),
+			Published:   strings.TrimSpace(published),
+		})
+	}
+	return feed
+}
+
+// feed converts the document to an RSSFeed.
+func (d *atomDocument) feed() RSSFeed {
+	feed := RSSFeed{
+		Title:       strings.TrimSpace(d.Title),
+		Link:        atomAlternateLink(d.Links),
+		Description: strings.TrimSpace(d.Subtitle),
+	}
+	for _, entry := range d.Entries {
+		description := entry.Summary
+		if strings.TrimSpace(description) == "" {
+			description = entry.Content
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		feed.Items = append(feed.Items, RSSItem{
+			Title:       strings.TrimSpace(entry.Title),
+			Link:        atomAlternateLink(entry.Links),
+			Description: strings.TrimSpace(description),
+			Published:   strings.TrimSpace(published),
+		})
+	}
+	return feed
+}
+
+// atomAlternateLink returns the href of the alternate link, which is the
+// link without a rel attribute or with rel="alternate".
+func atomAlternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// feedCharsetReader converts the single-byte encodings commonly declared
+// by feeds to UTF-8.
+func feedCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 0, len(data))
+		for _, b := range data {
+			buf = utf8.AppendRune(buf, rune(b))
+		}
+		return bytes.NewReader(buf), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
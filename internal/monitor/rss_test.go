@@ -0,0 +1,171 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRSS2 = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Status Updates</title>
+    <link>https://status.example.com/</link>
+    <description>Service status</description>
+    <item>
+      <title>API latency elevated</title>
+      <link>https://status.example.com/incidents/2</link>
+      <description><![CDATA[<p>Investigating</p>]]></description>
+      <pubDate>Tue, 02 Jan 2024 10:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title> Scheduled maintenance </title>
+      <link>https://status.example.com/incidents/1</link>
+      <description>Database upgrade</description>
+      <dc:date>2024-01-01T08:00:00Z</dc:date>
+    </item>
+  </channel>
+</rss>`
+
+const testRSS1 = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://news.example.org/">
+    <title>Example News</title>
+    <link>https://news.example.org/</link>
+  </channel>
+  <item rdf:about="https://news.example.org/1">
+    <title>First story</title>
+    <link>https://news.example.org/1</link>
+  </item>
+</rdf:RDF>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Release Notes</title>
+  <subtitle>New versions</subtitle>
+  <link rel="self" href="https://example.com/releases.atom"/>
+  <link href="https://example.com/releases"/>
+  <entry>
+    <title>v2.0.0</title>
+    <link rel="alternate" href="https://example.com/releases/v2.0.0"/>
+    <content type="html">Major release</content>
+    <updated>2024-02-01T12:00:00Z</updated>
+  </entry>
+  <entry>
+    <title>v1.9.1</title>
+    <link href="https://example.com/releases/v1.9.1"/>
+    <summary>Bug fixes</summary>
+    <published>2024-01-15T12:00:00Z</published>
+  </entry>
+</feed>`
+
+const testLatin1RSS = "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>" +
+	"<rss version=\"2.0\"><channel><title>Caf\xe9</title></channel></rss>"
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		want  RSSFeed
+		items []RSSItem
+	}{
+		{
+			name: "RSS 2.0",
+			data: testRSS2,
+			want: RSSFeed{Title: "Status Updates", Link: "https://status.example.com/", Description: "Service status"},
+			items: []RSSItem{
+				{"API latency elevated", "https://status.example.com/incidents/2", "<p>Investigating</p>", "Tue, 02 Jan 2024 10:00:00 GMT"},
+				{"Scheduled maintenance", "https://status.example.com/incidents/1", "Database upgrade", "2024-01-01T08:00:00Z"},
+			},
+		},
+		{
+			name:  "RSS 1.0",
+			data:  testRSS1,
+			want:  RSSFeed{Title: "Example News", Link: "https://news.example.org/"},
+			items: []RSSItem{{Title: "First story", Link: "https://news.example.org/1"}},
+		},
+		{
+			name: "Atom",
+			data: testAtom,
+			want: RSSFeed{Title: "Release Notes", Link: "https://example.com/releases", Description: "New versions"},
+			items: []RSSItem{
+				{"v2.0.0", "https://example.com/releases/v2.0.0", "Major release", "2024-02-01T12:00:00Z"},
+				{"v1.9.1", "https://example.com/releases/v1.9.1", "Bug fixes", "2024-01-15T12:00:00Z"},
+			},
+		},
+		{
+			name: "ISO-8859-1",
+			data: testLatin1RSS,
+			want: RSSFeed{Title: "Café"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+			if feed.Title != tt.want.Title || feed.Link != tt.want.Link || feed.Description != tt.want.Description {
+				t.Errorf("feed = %q/%q/%q, want %q/%q/%q", feed.Title, feed.Link, feed.Description,
+					tt.want.Title, tt.want.Link, tt.want.Description)
+			}
+			if len(feed.Items) != len(tt.items) {
+				t.Fatalf("len(Items) = %d, want %d", len(feed.Items), len(tt.items))
+			}
+			for i, item := range feed.Items {
+				if item != tt.items[i] {
+					t.Errorf("Items[%d] = %+v, want %+v", i, item, tt.items[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeedErrors(t *testing.T) {
+	for _, data := range []string{"", "<html><body>Not a feed</body></html>", "<rss><channel><title>x"} {
+		if _, err := parseFeed([]byte(data)); err == nil {
+			t.Errorf("parseFeed(%q) succeeded, want error", data)
+		}
+	}
+}
+
+func TestRSSReaderRead(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(testRSS2))
+	}))
+	defer server.Close()
+
+	fetcher := newHTTPFetcher()
+	fetcher.minInterval = 0
+	reader := newRSSReader(fetcher)
+
+	fetchNow(t, fetcher, server.URL, time.Hour)
+	for i := 0; i < 2; i++ {
+		feed, err := reader.Read(server.URL, time.Hour)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if feed.Title != "Status Updates" || len(feed.Items) != 2 {
+			t.Errorf("Read() = %q with %d items", feed.Title, len(feed.Items))
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 within the interval", n)
+	}
+
+	server.Close()
+	fetchNow(t, fetcher, server.URL, 0)
+	feed, err := reader.Read(server.URL, time.Hour)
+	if err == nil {
+		t.Error("Read() from a closed server succeeded, want error")
+	}
+	if feed.Title != "Status Updates" {
+		t.Errorf("Read() after failure = %q, want the last feed", feed.Title)
+	}
+}
@@ -0,0 +1,333 @@
+// Package monitor provides system monitoring functionality.
+// This file implements a socket inventory read from /proc/net/{tcp,udp,unix}
+// with the processes owning each socket.
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Socket protocols reported in Socket.Protocol.
+const (
+	SocketTCP  = "tcp"
+	SocketUDP  = "udp"
+	SocketUnix = "unix"
+)
+
+// Socket is an open TCP, UDP or UNIX domain socket.
+type Socket struct {
+	// Protocol is SocketTCP, SocketUDP or SocketUnix.
+	Protocol string
+	// IPv6 reports whether a TCP or UDP socket is read from the IPv6 table.
+	IPv6 bool
+	// LocalIP and LocalPort are the local address of a TCP or UDP socket.
+	LocalIP   string
+	LocalPort int
+	// RemoteIP and RemotePort are the peer address of a TCP or UDP socket.
+	RemoteIP   string
+	RemotePort int
+	// State is the TCP state name, such as "LISTEN" or "ESTABLISHED".
+	// Unconnected UDP and UNIX sockets are "UNCONN", and connected ones
+	// "ESTABLISHED"; listening UNIX sockets are "LISTEN".
+	State string
+	// Path is the path of a UNIX socket, "@name" for abstract sockets,
+	// or empty for unnamed ones.
+	Path string
+	// Inode is the socket inode.
+	Inode uint64
+	// PID is the lowest PID of the processes holding the socket, or 0 if
+	// it is unknown.
+	PID int
+	// Process is the name of the process PID.
+	Process string
+}
+
+// SocketStats is the socket inventory of the system.
+type SocketStats struct {
+	Sockets []Socket
+}
+
+// Listening returns the listening sockets of protocol, or of all protocols
+// if protocol is empty. Bound, unconnected UDP sockets count as listening.
+func (s SocketStats) Listening(protocol string) []Socket {
+	var listening []Socket
+	for _, sock := range s.Sockets {
+		if protocol != "" && sock.Protocol != protocol {
+			continue
+		}
+		switch {
+		case sock.State == "LISTEN",
+			sock.Protocol == SocketUDP && sock.State == "UNCONN" && sock.LocalPort != 0:
+			listening = append(listening, sock)
+		}
+	}
+	return listening
+}
+
+// Count returns the number of sockets of protocol in state. An empty
+// protocol or state matches all. States are case-insensitive and accept
+// the aliases "listening", "estab" and "unconnected".
+func (s SocketStats) Count(protocol, state string) int {
+	state = normalizeSocketState(state)
+	count := 0
+	for _, sock := range s.Sockets {
+		if (protocol == "" || sock.Protocol == protocol) && (state == "" || sock.State == state) {
+			count++
+		}
+	}
+	return count
+}
+
+// normalizeSocketState converts a state name to the form used in
+// Socket.State.
+func normalizeSocketState(state string) string {
+	state = strings.ToUpper(state)
+	switch state {
+	case "LISTENING":
+		return "LISTEN"
+	case "ESTAB":
+		return "ESTABLISHED"
+	case "UNCONNECTED":
+		return "UNCONN"
+	}
+	return state
+}
+
+// UNIX socket flags and states in /proc/net/unix.
+const (
+	unixFlagAcceptConn = 0x10000 // __SO_ACCEPTCON, set on listening sockets
+	unixStateConnected = 3       // SS_CONNECTED
+)
+
+// socketReader reads the socket inventory from /proc.
+type socketReader struct {
+	mu       sync.Mutex
+	procPath string
+	tcp      *tcpReader
+	owners   *socketOwnerReader
+	cacheTTL time.Duration
+	stats    SocketStats
+	readTime time.Time
+}
+
+// newSocketReader creates a socketReader with default paths.
+func newSocketReader() *socketReader {
+	return &socketReader{
+		procPath: "/proc",
+		tcp:      &tcpReader{},
+		owners:   newSocketOwnerReader(),
+		cacheTTL: 1 * time.Second,
+	}
+}
+
+// ReadStats returns the socket inventory. Tables that cannot be read are
+// left out.
+func (r *socketReader) ReadStats() (SocketStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.readTime.IsZero() && time.Since(r.readTime) < r.cacheTTL {
+		return SocketStats{Sockets: slices.Clone(r.stats.Sockets)}, nil
+	}
+
+	var sockets []Socket
+	tables := []struct {
+		name     string
+		protocol string
+		ipv6     bool
+	}{
+		{"tcp", SocketTCP, false},
+		{"tcp6", SocketTCP, true},
+		{"udp", SocketUDP, false},
+		{"udp6", SocketUDP, true},
+	}
+	for _, table := range tables {
+		conns, err := r.tcp.readProcTCP(filepath.Join(r.procPath, "net", table.name), table.ipv6)
+		if err != nil {
+			continue
+		}
+		for _, conn := range conns {
+			sock := Socket{
+				Protocol:   table.protocol,
+				IPv6:       table.ipv6,
+				LocalIP:    conn.LocalIP,
+				LocalPort:  conn.LocalPort,
+				RemoteIP:   conn.RemoteIP,
+				RemotePort: conn.RemotePort,
+				State:      conn.State,
+				Inode:      conn.Inode,
+			}
+			// UDP reuses the TCP states: TCP_ESTABLISHED for connected
+			// sockets and TCP_CLOSE for the others
+			if table.protocol == SocketUDP && sock.State == "CLOSE" {
+				sock.State = "UNCONN"
+			}
+			sockets = append(sockets, sock)
+		}
+	}
+	if unix, err := r.readProcUnix(filepath.Join(r.procPath, "net", "unix")); err == nil {
+		sockets = append(sockets, unix...)
+	}
+
+	owners := r.owners.Owners()
+	for i := range sockets {
+		if owner, ok := owners[sockets[i].Inode]; ok {
+			sockets[i].PID = owner.PID
+			sockets[i].Process = owner.Name
+		}
+	}
+
+	r.stats = SocketStats{Sockets: sockets}
+	r.readTime = time.Now()
+	return SocketStats{Sockets: slices.Clone(sockets)}, nil
+}
+
+// readProcUnix parses /proc/net/unix.
+// Format: Num RefCount Protocol Flags Type St Inode [Path]
+func (r *socketReader) readProcUnix(path string) ([]Socket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(file)
+
+	// Skip header line
+	if !scanner.Scan() {
+		return sockets, nil
+	}
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			continue
+		}
+		st, err := strconv.ParseUint(fields[5], 16, 8)
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		sock := Socket{Protocol: SocketUnix, Inode: inode, State: "UNCONN"}
+		switch {
+		case flags&unixFlagAcceptConn != 0:
+			sock.State = "LISTEN"
+		case st == unixStateConnected:
+			sock.State = "ESTABLISHED"
+		}
+		if len(fields) > 7 {
+			sock.Path = fields[7]
+		}
+		sockets = append(sockets, sock)
+	}
+
+	return sockets, scanner.Err()
+}
+
+// socketOwner identifies the process holding a socket.
+type socketOwner struct {
+	PID  int
+	Name string
+}
+
+// socketOwnerReader maps socket inodes to the processes holding them by
+// scanning /proc/[pid]/fd. Sockets held only by processes whose
+// descriptors cannot be read, such as those of other users when not
+// running as root, have no owner.
+type socketOwnerReader struct {
+	mu       sync.Mutex
+	procPath string
+	cacheTTL time.Duration
+	owners   map[uint64]socketOwner
+	readTime time.Time
+}
+
+// newSocketOwnerReader creates a socketOwnerReader with default paths.
+func newSocketOwnerReader() *socketOwnerReader {
+	return &socketOwnerReader{
+		procPath: "/proc",
+		cacheTTL: 2 * time.Second,
+	}
+}
+
+// Owners returns the owner of each socket inode. The map must not be
+// modified.
+func (r *socketOwnerReader) Owners() map[uint64]socketOwner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.owners != nil && time.Since(r.readTime) < r.cacheTTL {
+		return r.owners
+	}
+
+	owners := make(map[uint64]socketOwner)
+	entries, err := os.ReadDir(r.procPath)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(r.procPath, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		name := ""
+		for _, fd := range fds {
+			inode, ok := parseSocketLink(filepath.Join(fdDir, fd.Name()))
+			if !ok {
+				continue
+			}
+			if owner, ok := owners[inode]; ok && owner.PID < pid {
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(r.procPath, entry.Name(), "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			owners[inode] = socketOwner{PID: pid, Name: name}
+		}
+	}
+
+	r.owners = owners
+	r.readTime = time.Now()
+	return owners
+}
+
+// parseSocketLink returns the inode of a file descriptor link of the form
+// "socket:[12345]".
+func parseSocketLink(path string) (uint64, bool) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return 0, false
+	}
+	rest, ok := strings.CutPrefix(target, "socket:[")
+	if !ok {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(strings.TrimSuffix(rest, "]"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return inode, true
+}
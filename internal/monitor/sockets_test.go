@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// writeFakeProcess creates /proc/[pid]/comm and fd links to the socket
+// inodes under procDir.
+func writeFakeProcess(t *testing.T, procDir string, pid int, name string, inodes ...uint64) {
+	t.Helper()
+	pidDir := filepath.Join(procDir, strconv.Itoa(pid))
+	fdDir := filepath.Join(pidDir, "fd")
+	if err := os.MkdirAll(fdDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pidDir, "comm"), []byte(name+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A non-socket descriptor is skipped
+	if err := os.Symlink("/dev/null", filepath.Join(fdDir, "0")); err != nil {
+		t.Fatal(err)
+	}
+	for i, inode := range inodes {
+		link := "socket:[" + strconv.FormatUint(inode, 10) + "]"
+		if err := os.Symlink(link, filepath.Join(fdDir, strconv.Itoa(i+3))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeFakeProcNet writes the /proc/net tables under procDir.
+func writeFakeProcNet(t *testing.T, procDir string, tables map[string]string) {
+	t.Helper()
+	netDir := filepath.Join(procDir, "net")
+	if err := os.MkdirAll(netDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range tables {
+		if err := os.WriteFile(filepath.Join(netDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const (
+	testProcTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0016 0100007F:C000 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0000000000000000 100 0 0 10 0
+`
+	testProcTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0000000000000000 100 0 0 10 0
+`
+	testProcUDP = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0000000000000000 0
+  101: 0100007F:D431 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 2002 2 0000000000000000 0
+`
+	testProcUnix = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 3001 /run/dbus/system_bus_socket
+0000000000000000: 00000003 00000000 00000000 0001 03 3002
+0000000000000000: 00000002 00000000 00000000 0002 01 3003 @/org/example/abstract
+`
+)
+
+func TestSocketReaderReadStats(t *testing.T) {
+	procDir := t.TempDir()
+	writeFakeProcNet(t, procDir, map[string]string{
+		"tcp":  testProcTCP,
+		"tcp6": testProcTCP6,
+		"udp":  testProcUDP,
+		"unix": testProcUnix,
+	})
+	// sshd's child inherits the listening sockets; the lowest PID wins
+	writeFakeProcess(t, procDir, 612, "sshd", 1001, 1004)
+	writeFakeProcess(t, procDir, 4242, "sshd", 1001, 1002)
+	writeFakeProcess(t, procDir, 977, "dhclient", 2001)
+	writeFakeProcess(t, procDir, 501, "dbus-daemon", 3001)
+
+	r := newSocketReader()
+	r.procPath = procDir
+	r.owners.procPath = procDir
+
+	stats, err := r.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if len(stats.Sockets) != 9 {
+		t.Fatalf("len(Sockets) = %d, want 9", len(stats.Sockets))
+	}
+
+	byInode := make(map[uint64]Socket)
+	for _, sock := range stats.Sockets {
+		byInode[sock.Inode] = sock
+	}
+	tests := []struct {
+		inode uint64
+		want  Socket
+	}{
+		{1001, Socket{Protocol: SocketTCP, LocalIP: "0.0.0.0", LocalPort: 22, RemoteIP: "0.0.0.0", State: "LISTEN", Inode: 1001, PID: 612, Process: "sshd"}},
+		{1002, Socket{Protocol: SocketTCP, LocalIP: "127.0.0.1", LocalPort: 22, RemoteIP: "127.0.0.1", RemotePort: 49152, State: "ESTABLISHED", Inode: 1002, PID: 4242, Process: "sshd"}},
+		{1003, Socket{Protocol: SocketTCP, LocalIP: "127.0.0.1", LocalPort: 8080, RemoteIP: "0.0.0.0", State: "LISTEN", Inode: 1003}},
+		{1004, Socket{Protocol: SocketTCP, IPv6: true, LocalIP: "::", LocalPort: 22, RemoteIP: "::", State: "LISTEN", Inode: 1004, PID: 612, Process: "sshd"}},
+		{2001, Socket{Protocol: SocketUDP, LocalIP: "0.0.0.0", LocalPort: 68, RemoteIP: "0.0.0.0", State: "UNCONN", Inode: 2001, PID: 977, Process: "dhclient"}},
+		{2002, Socket{Protocol: SocketUDP, LocalIP: "127.0.0.1", LocalPort: 54321, RemoteIP: "127.0.0.1", RemotePort: 53, State: "ESTABLISHED", Inode: 2002}},
+		{3001, Socket{Protocol: SocketUnix, State: "LISTEN", Path: "/run/dbus/system_bus_socket", Inode: 3001, PID: 501, Process: "dbus-daemon"}},
+		{3002, Socket{Protocol: SocketUnix, State: "ESTABLISHED", Inode: 3002}},
+		{3003, Socket{Protocol: SocketUnix, State: "UNCONN", Path: "@/org/example/abstract", Inode: 3003}},
+	}
+	for _, tt := range tests {
+		if got := byInode[tt.inode]; got != tt.want {
+			t.Errorf("socket %d = %+v, want %+v", tt.inode, got, tt.want)
+		}
+	}
+}
+
+func TestSystemMonitorSocketOwner(t *testing.T) {
+	procDir := t.TempDir()
+	writeFakeProcess(t, procDir, 812, "sshd", 54321)
+
+	sm := &SystemMonitor{socketReader: newSocketReader()}
+	sm.socketReader.owners.procPath = procDir
+
+	if pid, name := sm.SocketOwner(54321); pid != 812 || name != "sshd" {
+		t.Errorf("SocketOwner(54321) = %d, %q; want 812, %q", pid, name, "sshd")
+	}
+	if pid, name := sm.SocketOwner(12345); pid != 0 || name != "" {
+		t.Errorf("SocketOwner(12345) = %d, %q; want no owner", pid, name)
+	}
+}
+
+func TestSocketReaderMissingTables(t *testing.T) {
+	procDir := t.TempDir()
+	writeFakeProcNet(t, procDir, map[string]string{"udp": testProcUDP})
+
+	r := newSocketReader()
+	r.procPath = procDir
+	r.owners.procPath = procDir
+
+	stats, err := r.ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if len(stats.Sockets) != 2 {
+		t.Errorf("len(Sockets) = %d, want the 2 UDP sockets", len(stats.Sockets))
+	}
+}
+
+func TestSocketStatsQueries(t *testing.T) {
+	stats := SocketStats{Sockets: []Socket{
+		{Protocol: SocketTCP, LocalPort: 22, State: "LISTEN"},
+		{Protocol: SocketTCP, LocalPort: 22, State: "ESTABLISHED"},
+		{Protocol: SocketTCP, LocalPort: 40000, State: "TIME_WAIT"},
+		{Protocol: SocketUDP, LocalPort: 68, State: "UNCONN"},
+		{Protocol: SocketUDP, LocalPort: 51000, State: "ESTABLISHED"},
+		{Protocol: SocketUnix, State: "LISTEN"},
+		{Protocol: SocketUnix, State: "ESTABLISHED"},
+	}}
+
+	countTests := []struct {
+		protocol, state string
+		want            int
+	}{
+		{"", "", 7},
+		{SocketTCP, "", 3},
+		{SocketTCP, "established", 1},
+		{SocketTCP, "time_wait", 1},
+		{"", "ESTAB", 3},
+		{"", "listening", 2},
+		{SocketUDP, "unconnected", 1},
+		{SocketUnix, "listen", 1},
+	}
+	for _, tt := range countTests {
+		if got := stats.Count(tt.protocol, tt.state); got != tt.want {
+			t.Errorf("Count(%q, %q) = %d, want %d", tt.protocol, tt.state, got, tt.want)
+		}
+	}
+
+	listeningTests := []struct {
+		protocol string
+		want     int
+	}{
+		{"", 3},
+		{SocketTCP, 1},
+		{SocketUDP, 1},
+		{SocketUnix, 1},
+	}
+	for _, tt := range listeningTests {
+		if got := len(stats.Listening(tt.protocol)); got != tt.want {
+			t.Errorf("len(Listening(%q)) = %d, want %d", tt.protocol, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 // Package monitor provides TCP connection monitoring for Linux systems.
 // It reads TCP connection info from /proc/net/tcp and /proc/net/tcp6.
+// The UDP tables share the format and are parsed by socketReader with the
+// same code.
 package monitor
 
 import (
@@ -21,6 +23,9 @@ type TCPConnection struct {
 	RemotePort int
 	State      string
 	UID        int
+	// Inode is the socket inode, which SystemMonitor.SocketOwner maps to
+	// the process holding the socket.
+	Inode uint64
 }
 
 // TCPState constants matching /proc/net/tcp state values.
@@ -171,6 +176,7 @@ func (r *tcpReader) parseTCPLine(line string, isIPv6 bool) (TCPConnection, error
 	}
 
 	uid, _ := strconv.Atoi(fields[7])
+	inode, _ := strconv.ParseUint(fields[9], 10, 64)
 
 	return TCPConnection{
 		LocalIP:    localIP,
@@ -179,6 +185,7 @@ func (r *tcpReader) parseTCPLine(line string, isIPv6 bool) (TCPConnection, error
 		RemotePort: remotePort,
 		State:      r.stateToString(fields[3]),
 		UID:        uid,
+		Inode:      inode,
 	}, nil
 }
 
@@ -117,6 +117,10 @@ func TestTCPReaderWithMockFile(t *testing.T) {
 	if stats.ListenCount != 1 {
 		t.Errorf("ListenCount = %d, want 1", stats.ListenCount)
 	}
+
+	if inode := stats.Connections[1].Inode; inode != 54321 {
+		t.Errorf("Inode = %d, want 54321", inode)
+	}
 }
 
 func TestCountInRange(t *testing.T) {
@@ -4,6 +4,7 @@
 package monitor
 
 import (
+	"maps"
 	"sync"
 	"time"
 )
@@ -210,6 +211,15 @@ type ProcessInfo struct {
 	Threads int
 	// StartTime is the process start time in jiffies since system boot.
 	StartTime uint64
+	// IOReadBytes is the number of bytes the process read from storage
+	// since the previous update.
+	IOReadBytes uint64
+	// IOWriteBytes is the number of bytes the process wrote to storage
+	// since the previous update.
+	IOWriteBytes uint64
+	// IOPercent is the process's share (0-100) of the storage I/O of all
+	// processes since the previous update.
+	IOPercent float64
 }
 
 // ProcessStats contains process-related statistics.
@@ -230,6 +240,10 @@ type ProcessStats struct {
 	TopCPU []ProcessInfo
 	// TopMem contains the top processes by memory usage.
 	TopMem []ProcessInfo
+	// TopIO contains the top processes by storage I/O. Processes whose
+	// I/O counters cannot be read, such as those of other users, are
+	// left out.
+	TopIO []ProcessInfo
 }
 
 // SystemData aggregates all system monitoring data.
@@ -393,11 +407,15 @@ func (sd *SystemData) copyHwmon() HwmonStats {
 		TempSensors: make([]TempSensor, len(sd.Hwmon.TempSensors)),
 	}
 	for k, v := range sd.Hwmon.Devices {
-		// Deep copy the device including its temps map
+		// Deep copy the device including its sensor maps
 		deviceCopy := HwmonDevice{
-			Name:  v.Name,
-			Path:  v.Path,
-			Temps: make(map[string]TempSensor, len(v.Temps)),
+			Name:     v.Name,
+			Path:     v.Path,
+			Temps:    make(map[string]TempSensor, len(v.Temps)),
+			Fans:     maps.Clone(v.Fans),
+			Voltages: maps.Clone(v.Voltages),
+			Power:    maps.Clone(v.Power),
+			Currents: maps.Clone(v.Currents),
 		}
 		for tk, tv := range v.Temps {
 			deviceCopy.Temps[tk] = tv
@@ -434,9 +452,11 @@ func (sd *SystemData) copyProcess() ProcessStats {
 		TotalThreads:      sd.Process.TotalThreads,
 		TopCPU:            make([]ProcessInfo, len(sd.Process.TopCPU)),
 		TopMem:            make([]ProcessInfo, len(sd.Process.TopMem)),
+		TopIO:             make([]ProcessInfo, len(sd.Process.TopIO)),
 	}
 	copy(result.TopCPU, sd.Process.TopCPU)
 	copy(result.TopMem, sd.Process.TopMem)
+	copy(result.TopIO, sd.Process.TopIO)
 	return result
 }
 
@@ -499,6 +519,8 @@ func (sd *SystemData) copyAudio() AudioStats {
 		MasterVolume: sd.Audio.MasterVolume,
 		MasterMuted:  sd.Audio.MasterMuted,
 		HasAudio:     sd.Audio.HasAudio,
+		Backend:      sd.Audio.Backend,
+		Pulse:        sd.Audio.Pulse.clone(),
 	}
 	for k, v := range sd.Audio.Cards {
 		// Deep copy the card including its mixers map
@@ -1,10 +1,11 @@
-// Package monitor provides weather monitoring via METAR data.
+// Package monitor provides weather monitoring via METAR data and forecast
+// services.
 package monitor
 
 import (
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,9 +13,10 @@ import (
 	"time"
 )
 
-// WeatherStats contains weather data from METAR reports.
+// WeatherStats contains the current weather at a station.
 type WeatherStats struct {
-	// StationID is the ICAO airport code (e.g., "KJFK").
+	// StationID is the ICAO airport code (e.g., "KJFK") or, for forecast
+	// providers, the "latitude,longitude" of the location.
 	StationID string
 	// Temperature is the temperature in Celsius.
 	Temperature float64
@@ -42,92 +44,193 @@ type WeatherStats struct {
 	LastUpdate time.Time
 	// Error contains any error message.
 	Error string
+	// Units is the unit system GetField converts values to: WeatherMetric,
+	// WeatherImperial, or empty for the units of METAR reports (°C, hPa,
+	// knots and statute miles).
+	Units string
+	// Forecast holds the daily forecast starting today, if the provider
+	// has one.
+	Forecast []WeatherForecast
 }
 
-// weatherReader reads weather data from METAR sources.
+// WeatherForecast is the forecast for one day.
+type WeatherForecast struct {
+	// Date is the day the forecast is for.
+	Date time.Time
+	// TempMin is the minimum temperature in Celsius.
+	TempMin float64
+	// TempMax is the maximum temperature in Celsius.
+	TempMax float64
+	// Condition is the weather condition description.
+	Condition string
+	// Precipitation is the precipitation sum in millimetres.
+	Precipitation float64
+	// PrecipitationChance is the probability of precipitation in percent.
+	PrecipitationChance float64
+	// WindSpeedMax is the maximum wind speed in knots.
+	WindSpeedMax float64
+}
+
+// Unit systems for WeatherStats.Units.
+const (
+	WeatherMetric   = "metric"
+	WeatherImperial = "imperial"
+)
+
+// WeatherProvider is a source of weather data.
+type WeatherProvider interface {
+	// Name identifies the provider, such as "metar".
+	Name() string
+	// Fetch returns the weather at station, requesting URLs with get.
+	Fetch(get func(url string) ([]byte, error), station string) (WeatherStats, error)
+}
+
+// NewWeatherProvider returns the provider with the given name, "metar" or
+// "open-meteo", served from baseURL. An empty name selects METAR and an
+// empty baseURL the provider's public service.
+func NewWeatherProvider(name, baseURL string) (WeatherProvider, error) {
+	switch strings.ToLower(name) {
+	case "", "metar":
+		return newMETARProvider(baseURL), nil
+	case "open-meteo", "openmeteo":
+		return newOpenMeteoProvider(baseURL), nil
+	}
+	return nil, fmt.Errorf("unknown weather provider %q", name)
+}
+
+// errWeatherPending is returned for a station until its first fetch
+// completes.
+var errWeatherPending = errors.New("weather not received yet")
+
+// weatherReader reads weather data from a WeatherProvider in the
+// background, caching each station's weather for minInterval. Requests go
+// through the shared httpFetcher, so its per-host circuit breakers apply.
 type weatherReader struct {
 	mu          sync.RWMutex
 	cache       map[string]*weatherCacheEntry
-	httpClient  *http.Client
-	metarURL    string
+	fetcher     *httpFetcher
+	provider    WeatherProvider
+	units       string
 	minInterval time.Duration
 }
 
-// weatherCacheEntry stores cached weather data for a station.
+// weatherCacheEntry holds the outcome of the last fetch for a station.
 type weatherCacheEntry struct {
-	stats     WeatherStats
-	fetchTime time.Time
+	stats     WeatherStats // Stats of the last successful fetch
+	ok        bool         // Whether a fetch has succeeded
+	err       error        // Error of the last fetch, nil if it succeeded
+	fetchTime time.Time    // When the last fetch completed
+	fetching  bool         // Whether a fetch is in progress
 }
 
-// newWeatherReader creates a new weather reader.
-func newWeatherReader() *weatherReader {
+// newWeatherReader creates a weather reader using METAR reports, fetched
+// through fetcher.
+func newWeatherReader(fetcher *httpFetcher) *weatherReader {
 	return &weatherReader{
-		cache: make(map[string]*weatherCacheEntry),
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		// Uses aviationweather.gov which provides free METAR data
-		metarURL:    "https://aviationweather.gov/api/data/metar?ids=%s&format=raw",
+		cache:       make(map[string]*weatherCacheEntry),
+		fetcher:     fetcher,
+		provider:    newMETARProvider(""),
 		minInterval: 10 * time.Minute, // METAR data updates roughly every hour
 	}
 }
 
-// ReadWeather returns weather data for the given station ID.
+// SetProvider replaces the weather provider and clears the cache.
+func (r *weatherReader) SetProvider(provider WeatherProvider) {
+	r.mu.Lock()
+	r.provider = provider
+	r.cache = make(map[string]*weatherCacheEntry)
+	r.mu.Unlock()
+}
+
+// SetUnits sets the unit system of the returned stats.
+func (r *weatherReader) SetUnits(units string) {
+	r.mu.Lock()
+	r.units = units
+	r.mu.Unlock()
+}
+
+// ReadWeather returns the last weather fetched for the given station ID
+// without waiting on the network. Once minInterval has passed since the
+// last fetch, a fetch is started in the background, at most one per
+// station at a time, and the previous weather is returned until it
+// completes. Failed fetches are cached like successful ones, so an
+// unreachable provider is not contacted on every update. When a fetch
+// fails, the weather of the last successful fetch is returned with the
+// failure in its Error field; without one, the error is returned, and it
+// is errWeatherPending until the first fetch completes.
 func (r *weatherReader) ReadWeather(stationID string) (WeatherStats, error) {
 	stationID = strings.ToUpper(strings.TrimSpace(stationID))
 	if stationID == "" {
 		return WeatherStats{}, fmt.Errorf("station ID is required")
 	}
 
-	r.mu.RLock()
-	entry, ok := r.cache[stationID]
-	r.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Return cached data if fresh
-	if ok && time.Since(entry.fetchTime) < r.minInterval {
-		return entry.stats, nil
+	entry, ok := r.cache[stationID]
+	if !ok {
+		entry = &weatherCacheEntry{err: errWeatherPending}
+		r.cache[stationID] = entry
 	}
-
-	// Fetch new data
-	stats, err := r.fetchMETAR(stationID)
-	if err != nil {
-		// Return cached data with error if available
-		if ok {
-			stats = entry.stats
-			stats.Error = err.Error()
-			return stats, nil
-		}
-		return WeatherStats{StationID: stationID, Error: err.Error()}, err
+	if !entry.fetching && time.Since(entry.fetchTime) >= r.minInterval {
+		entry.fetching = true
+		go r.refresh(r.provider, stationID, entry)
 	}
 
-	// Cache the result
-	r.mu.Lock()
-	r.cache[stationID] = &weatherCacheEntry{
-		stats:     stats,
-		fetchTime: time.Now(),
+	if !entry.ok {
+		return WeatherStats{StationID: stationID, Error: entry.err.Error(), Units: r.units}, entry.err
 	}
-	r.mu.Unlock()
-
+	stats := entry.stats
+	if entry.err != nil {
+		stats.Error = entry.err.Error()
+	}
+	stats.Units = r.units
 	return stats, nil
 }
 
-// fetchMETAR fetches and parses METAR data from aviationweather.gov.
-func (r *weatherReader) fetchMETAR(stationID string) (WeatherStats, error) {
-	url := fmt.Sprintf(r.metarURL, stationID)
+// refresh fetches the weather at stationID from provider and records the
+// outcome in entry.
+func (r *weatherReader) refresh(provider WeatherProvider, stationID string, entry *weatherCacheEntry) {
+	stats, err := provider.Fetch(r.fetcher.fetch, stationID)
 
-	resp, err := r.httpClient.Get(url)
-	if err != nil {
-		return WeatherStats{}, fmt.Errorf("fetch failed: %w", err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.err = err
+	entry.fetchTime = time.Now()
+	entry.fetching = false
+	if err == nil {
+		entry.stats = stats
+		entry.ok = true
 	}
-	defer resp.Body.Close()
+}
+
+// metarProvider reads METAR reports from an aviationweather.gov compatible
+// data API. METAR reports have no forecast.
+type metarProvider struct {
+	metarURL string
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return WeatherStats{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+// newMETARProvider creates a metarProvider served from baseURL, by default
+// aviationweather.gov, which provides free METAR data.
+func newMETARProvider(baseURL string) *metarProvider {
+	if baseURL == "" {
+		baseURL = "https://aviationweather.gov"
+	}
+	return &metarProvider{
+		metarURL: strings.TrimSuffix(baseURL, "/") + "/api/data/metar?ids=%s&format=raw",
 	}
+}
+
+// Name returns "metar".
+func (p *metarProvider) Name() string {
+	return "metar"
+}
 
-	body, err := io.ReadAll(resp.Body)
+// Fetch fetches and parses the latest METAR report of an ICAO station.
+func (p *metarProvider) Fetch(get func(url string) ([]byte, error), stationID string) (WeatherStats, error) {
+	body, err := get(fmt.Sprintf(p.metarURL, url.QueryEscape(stationID)))
 	if err != nil {
-		return WeatherStats{}, fmt.Errorf("read failed: %w", err)
+		return WeatherStats{}, err
 	}
 
 	raw := strings.TrimSpace(string(body))
@@ -135,11 +238,11 @@ func (r *weatherReader) fetchMETAR(stationID string) (WeatherStats, error) {
 		return WeatherStats{}, fmt.Errorf("no data for station %s", stationID)
 	}
 
-	return r.parseMETAR(stationID, raw), nil
+	return parseMETAR(stationID, raw), nil
 }
 
 // parseMETAR parses a raw METAR string into WeatherStats.
-func (r *weatherReader) parseMETAR(stationID, raw string) WeatherStats {
+func parseMETAR(stationID, raw string) WeatherStats {
 	stats := WeatherStats{
 		StationID:  stationID,
 		RawMETAR:   raw,
@@ -341,24 +444,28 @@ func parseWeatherCondition(part, existing string) string {
 	return existing
 }
 
-// GetField returns a specific weather field as a string.
+// GetField returns a specific weather field as a string, converted to
+// the unit system in Units.
 func (w *WeatherStats) GetField(field string) string {
 	field = strings.ToLower(strings.TrimSpace(field))
 	switch field {
 	case "temp", "temperature":
-		return fmt.Sprintf("%.0f", w.Temperature)
+		return w.formatTemp(w.Temperature)
 	case "temp_f", "temperature_f":
 		return fmt.Sprintf("%.0f", w.Temperature*9/5+32)
 	case "dewpoint", "dew_point":
-		return fmt.Sprintf("%.0f", w.DewPoint)
+		return w.formatTemp(w.DewPoint)
 	case "humidity":
 		return fmt.Sprintf("%.0f", w.Humidity)
 	case "pressure", "pressure_mb":
+		if w.Units == WeatherImperial {
+			return fmt.Sprintf("%.2f", w.Pressure/33.8639)
+		}
 		return fmt.Sprintf("%.0f", w.Pressure)
 	case "pressure_inhg":
 		return fmt.Sprintf("%.2f", w.Pressure/33.8639)
 	case "wind", "wind_speed":
-		return fmt.Sprintf("%.0f", w.WindSpeed)
+		return w.formatSpeed(w.WindSpeed)
 	case "wind_dir", "wind_direction":
 		if w.WindDirection == -1 {
 			return "VRB"
@@ -367,8 +474,11 @@ func (w *WeatherStats) GetField(field string) string {
 	case "wind_dir_compass":
 		return degreesToCompass(w.WindDirection)
 	case "wind_gust":
-		return fmt.Sprintf("%.0f", w.WindGust)
+		return w.formatSpeed(w.WindGust)
 	case "visibility":
+		if w.Units == WeatherMetric {
+			return fmt.Sprintf("%.1f", w.Visibility*1.609344)
+		}
 		return fmt.Sprintf("%.1f", w.Visibility)
 	case "condition", "weather":
 		return w.Condition
@@ -381,6 +491,61 @@ func (w *WeatherStats) GetField(field string) string {
 	}
 }
 
+// GetForecastField returns a field of the forecast for day, where 0 is
+// today, converted to the unit system in Units. It returns "" if there is
+// no forecast for day.
+func (w *WeatherStats) GetForecastField(day int, field string) string {
+	if day < 0 || day >= len(w.Forecast) {
+		return ""
+	}
+	f := w.Forecast[day]
+
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "min", "temp_min":
+		return w.formatTemp(f.TempMin)
+	case "max", "temp_max":
+		return w.formatTemp(f.TempMax)
+	case "condition", "weather":
+		return f.Condition
+	case "precip", "precipitation":
+		if w.Units == WeatherImperial {
+			return fmt.Sprintf("%.2f", f.Precipitation/25.4)
+		}
+		return fmt.Sprintf("%.1f", f.Precipitation)
+	case "precip_chance", "precipitation_probability":
+		return fmt.Sprintf("%.0f", f.PrecipitationChance)
+	case "wind", "wind_max":
+		return w.formatSpeed(f.WindSpeedMax)
+	case "date":
+		return f.Date.Format("2006-01-02")
+	case "day":
+		return f.Date.Format("Mon")
+	default:
+		return ""
+	}
+}
+
+// formatTemp formats a temperature in Celsius, in Fahrenheit for imperial
+// units.
+func (w *WeatherStats) formatTemp(celsius float64) string {
+	if w.Units == WeatherImperial {
+		return fmt.Sprintf("%.0f", celsius*9/5+32)
+	}
+	return fmt.Sprintf("%.0f", celsius)
+}
+
+// formatSpeed formats a speed in knots, in km/h for metric and mph for
+// imperial units.
+func (w *WeatherStats) formatSpeed(knots float64) string {
+	switch w.Units {
+	case WeatherMetric:
+		return fmt.Sprintf("%.0f", knots*1.852)
+	case WeatherImperial:
+		return fmt.Sprintf("%.0f", knots*1.150779)
+	}
+	return fmt.Sprintf("%.0f", knots)
+}
+
 // degreesToCompass converts wind direction in degrees to compass direction.
 func degreesToCompass(degrees int) string {
 	if degrees < 0 {
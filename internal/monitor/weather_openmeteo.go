@@ -0,0 +1,217 @@
+// Package monitor provides system monitoring functionality.
+// This file implements a weather provider for the Open-Meteo forecast API.
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openMeteoDays is the number of forecast days requested.
+const openMeteoDays = 7
+
+// openMeteoProvider reads current conditions and a daily forecast from an
+// Open-Meteo compatible API. Stations are "latitude,longitude" pairs.
+type openMeteoProvider struct {
+	baseURL string
+}
+
+// newOpenMeteoProvider creates an openMeteoProvider served from baseURL,
+// by default api.open-meteo.com.
+func newOpenMeteoProvider(baseURL string) *openMeteoProvider {
+	if baseURL == "" {
+		baseURL = "https://api.open-meteo.com"
+	}
+	return &openMeteoProvider{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Name returns "open-meteo".
+func (p *openMeteoProvider) Name() string {
+	return "open-meteo"
+}
+
+// openMeteoResponse is the part of a /v1/forecast response that is read.
+// Wind speeds are requested in knots; other values are in the API's
+// metric defaults.
+type openMeteoResponse struct {
+	Current struct {
+		Temperature   float64 `json:"temperature_2m"`
+		Humidity      float64 `json:"relative_humidity_2m"`
+		DewPoint      float64 `json:"dew_point_2m"`
+		Pressure      float64 `json:"pressure_msl"`
+		WindSpeed     float64 `json:"wind_speed_10m"`
+		WindDirection float64 `json:"wind_direction_10m"`
+		WindGust      float64 `json:"wind_gusts_10m"`
+		WeatherCode   int     `json:"weather_code"`
+		CloudCover    float64 `json:"cloud_cover"`
+		Visibility    float64 `json:"visibility"`
+	} `json:"current"`
+	Daily struct {
+		Time                []string  `json:"time"`
+		WeatherCode         []int     `json:"weather_code"`
+		TempMax             []float64 `json:"temperature_2m_max"`
+		TempMin             []float64 `json:"temperature_2m_min"`
+		Precipitation       []float64 `json:"precipitation_sum"`
+		PrecipitationChance []float64 `json:"precipitation_probability_max"`
+		WindSpeedMax        []float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// Fetch fetches the current conditions and forecast at a
+// "latitude,longitude" station.
+func (p *openMeteoProvider) Fetch(get func(url string) ([]byte, error), station string) (WeatherStats, error) {
+	lat, lon, ok := strings.Cut(station, ",")
+	if !ok {
+		return WeatherStats{}, fmt.Errorf("station %q is not latitude,longitude", station)
+	}
+	for _, coord := range []string{lat, lon} {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(coord), 64); err != nil {
+			return WeatherStats{}, fmt.Errorf("station %q is not latitude,longitude", station)
+		}
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strings.TrimSpace(lat))
+	query.Set("longitude", strings.TrimSpace(lon))
+	query.Set("current", "temperature_2m,relative_humidity_2m,dew_point_2m,pressure_msl,"+
+		"wind_speed_10m,wind_direction_10m,wind_gusts_10m,weather_code,cloud_cover,visibility")
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,"+
+		"precipitation_probability_max,wind_speed_10m_max")
+	query.Set("wind_speed_unit", "kn")
+	query.Set("timezone", "auto")
+	query.Set("forecast_days", strconv.Itoa(openMeteoDays))
+
+	body, err := get(p.baseURL + "/v1/forecast?" + query.Encode())
+	if err != nil {
+		return WeatherStats{}, err
+	}
+
+	var resp openMeteoResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return WeatherStats{}, fmt.Errorf("parse forecast: %w", err)
+	}
+	if resp.Error {
+		return WeatherStats{}, fmt.Errorf("forecast error: %s", resp.Reason)
+	}
+
+	return parseOpenMeteo(station, &resp), nil
+}
+
+// parseOpenMeteo converts a response to WeatherStats in the units of METAR
+// reports.
+func parseOpenMeteo(station string, resp *openMeteoResponse) WeatherStats {
+	cur := resp.Current
+	stats := WeatherStats{
+		StationID:     station,
+		Temperature:   cur.Temperature,
+		DewPoint:      cur.DewPoint,
+		Humidity:      cur.Humidity,
+		Pressure:      cur.Pressure,
+		WindSpeed:     cur.WindSpeed,
+		WindDirection: int(cur.WindDirection),
+		WindGust:      cur.WindGust,
+		Visibility:    cur.Visibility / 1609.344,
+		Condition:     wmoWeatherCondition(cur.WeatherCode),
+		Cloud:         cloudCoverDescription(cur.CloudCover),
+		LastUpdate:    time.Now(),
+	}
+
+	daily := resp.Daily
+	for i, day := range daily.Time {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		stats.Forecast = append(stats.Forecast, WeatherForecast{
+			Date:                date,
+			TempMin:             valueAt(daily.TempMin, i),
+			TempMax:             valueAt(daily.TempMax, i),
+			Condition:           wmoWeatherCondition(valueAt(daily.WeatherCode, i)),
+			Precipitation:       valueAt(daily.Precipitation, i),
+			PrecipitationChance: valueAt(daily.PrecipitationChance, i),
+			WindSpeedMax:        valueAt(daily.WindSpeedMax, i),
+		})
+	}
+	return stats
+}
+
+// valueAt returns values[i], or zero if values is too short.
+func valueAt[T int | float64](values []T, i int) T {
+	if i < len(values) {
+		return values[i]
+	}
+	return 0
+}
+
+// cloudCoverDescription describes a cloud cover percentage with the METAR
+// coverage terms used by parseMETAR.
+func cloudCoverDescription(cover float64) string {
+	switch {
+	case cover < 6:
+		return "clear"
+	case cover <= 25:
+		return "few clouds"
+	case cover <= 50:
+		return "scattered clouds"
+	case cover < 95:
+		return "broken clouds"
+	default:
+		return "overcast"
+	}
+}
+
+// wmoWeatherCondition describes a WMO weather interpretation code in the
+// terms used by parseWeatherCondition.
+func wmoWeatherCondition(code int) string {
+	switch code {
+	case 0:
+		return "clear"
+	case 1:
+		return "mainly clear"
+	case 2:
+		return "partly cloudy"
+	case 3:
+		return "overcast"
+	case 45, 48:
+		return "fog"
+	case 51, 53:
+		return "drizzle"
+	case 55:
+		return "heavy drizzle"
+	case 56, 57:
+		return "freezing drizzle"
+	case 61:
+		return "light rain"
+	case 63:
+		return "rain"
+	case 65:
+		return "heavy rain"
+	case 66, 67:
+		return "freezing rain"
+	case 71:
+		return "light snow"
+	case 73:
+		return "snow"
+	case 75:
+		return "heavy snow"
+	case 77:
+		return "snow grains"
+	case 80, 81:
+		return "showers"
+	case 82:
+		return "heavy showers"
+	case 85, 86:
+		return "snow showers"
+	case 95:
+		return "thunderstorm"
+	case 96, 99:
+		return "thunderstorm with hail"
+	}
+	return "unknown"
+}
@@ -0,0 +1,221 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// openMeteoForecast is a /v1/forecast response in Open-Meteo's format.
+const openMeteoForecast = `{
+  "latitude": 52.52, "longitude": 13.42, "timezone": "Europe/Berlin",
+  "current_units": {"temperature_2m": "°C", "wind_speed_10m": "kn", "visibility": "m"},
+  "current": {
+    "time": "2024-03-01T12:00", "interval": 900,
+    "temperature_2m": 8.4, "relative_humidity_2m": 71, "dew_point_2m": 3.4,
+    "pressure_msl": 1012.8, "wind_speed_10m": 10.0, "wind_direction_10m": 225,
+    "wind_gusts_10m": 20.0, "weather_code": 61, "cloud_cover": 80, "visibility": 16093.44
+  },
+  "daily": {
+    "time": ["2024-03-01", "2024-03-02", "2024-03-03"],
+    "weather_code": [61, 3, 0],
+    "temperature_2m_max": [10.2, 12.0, 15.5],
+    "temperature_2m_min": [2.1, -1.0, 4.0],
+    "precipitation_sum": [5.2, 0.0, 0.0],
+    "precipitation_probability_max": [90, 20, 0],
+    "wind_speed_10m_max": [18.0, 9.5, 5.0]
+  }
+}`
+
+// serveOpenMeteo starts an Open-Meteo stand-in and returns its URL and the
+// query of the last request.
+func serveOpenMeteo(t *testing.T) (string, *url.Values) {
+	t.Helper()
+	last := &url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = r.URL.Query()
+		if r.URL.Path != "/v1/forecast" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("latitude") == "91" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": true, "reason": "Latitude must be in range of -90 to 90°."}`))
+			return
+		}
+		_, _ = w.Write([]byte(openMeteoForecast))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, last
+}
+
+func TestOpenMeteoProviderFetch(t *testing.T) {
+	baseURL, last := serveOpenMeteo(t)
+
+	r := newWeatherReader(newHTTPFetcher())
+	r.provider = newOpenMeteoProvider(baseURL + "/")
+	stats, err := readWeatherNow(t, r, "52.52,13.42")
+	if err != nil {
+		t.Fatalf("ReadWeather() error = %v", err)
+	}
+
+	query := *last
+	if query.Get("latitude") != "52.52" || query.Get("longitude") != "13.42" || query.Get("wind_speed_unit") != "kn" {
+		t.Errorf("request query = %v", query)
+	}
+
+	if stats.Temperature != 8.4 || stats.DewPoint != 3.4 || stats.Humidity != 71 || stats.Pressure != 1012.8 {
+		t.Errorf("Temperature/DewPoint/Humidity/Pressure = %v/%v/%v/%v",
+			stats.Temperature, stats.DewPoint, stats.Humidity, stats.Pressure)
+	}
+	if stats.WindSpeed != 10 || stats.WindDirection != 225 || stats.WindGust != 20 {
+		t.Errorf("Wind = %v kn from %d gusting %v", stats.WindSpeed, stats.WindDirection, stats.WindGust)
+	}
+	if stats.Visibility != 10 {
+		t.Errorf("Visibility = %v, want 10 miles", stats.Visibility)
+	}
+	if stats.Condition != "light rain" || stats.Cloud != "broken clouds" {
+		t.Errorf("Condition/Cloud = %q/%q", stats.Condition, stats.Cloud)
+	}
+
+	if len(stats.Forecast) != 3 {
+		t.Fatalf("len(Forecast) = %d, want 3", len(stats.Forecast))
+	}
+	tomorrow := stats.Forecast[1]
+	if tomorrow.TempMin != -1 || tomorrow.TempMax != 12 || tomorrow.Condition != "overcast" ||
+		tomorrow.PrecipitationChance != 20 || tomorrow.WindSpeedMax != 9.5 {
+		t.Errorf("Forecast[1] = %+v", tomorrow)
+	}
+	if got := tomorrow.Date.Format("2006-01-02"); got != "2024-03-02" {
+		t.Errorf("Forecast[1].Date = %s", got)
+	}
+}
+
+func TestOpenMeteoProviderErrors(t *testing.T) {
+	baseURL, _ := serveOpenMeteo(t)
+	provider := newOpenMeteoProvider(baseURL)
+	get := newHTTPFetcher().fetch
+
+	for _, station := range []string{"KJFK", "52.52", "north,south", "91,13.42"} {
+		if _, err := provider.Fetch(get, station); err == nil {
+			t.Errorf("Fetch(%q) succeeded, want error", station)
+		}
+	}
+}
+
+func TestNewWeatherProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "metar", false},
+		{"METAR", "metar", false},
+		{"open-meteo", "open-meteo", false},
+		{"openweathermap", "", true},
+	}
+
+	for _, tt := range tests {
+		provider, err := NewWeatherProvider(tt.name, "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewWeatherProvider(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && provider.Name() != tt.want {
+			t.Errorf("NewWeatherProvider(%q).Name() = %q, want %q", tt.name, provider.Name(), tt.want)
+		}
+	}
+}
+
+func TestWeatherReaderUnitsAndProvider(t *testing.T) {
+	baseURL, _ := serveOpenMeteo(t)
+
+	r := newWeatherReader(newHTTPFetcher())
+	r.SetProvider(newOpenMeteoProvider(baseURL))
+	r.SetUnits(WeatherImperial)
+	stats, err := readWeatherNow(t, r, "52.52,13.42")
+	if err != nil {
+		t.Fatalf("ReadWeather() error = %v", err)
+	}
+	if stats.Units != WeatherImperial {
+		t.Errorf("Units = %q, want imperial", stats.Units)
+	}
+
+	// Units apply to cached stats too
+	r.SetUnits(WeatherMetric)
+	if stats, _ := r.ReadWeather("52.52,13.42"); stats.Units != WeatherMetric {
+		t.Errorf("cached Units = %q, want metric", stats.Units)
+	}
+
+	// Changing provider drops the cached stats
+	r.SetProvider(newMETARProvider(baseURL))
+	if _, err := readWeatherNow(t, r, "52.52,13.42"); err == nil {
+		t.Error("ReadWeather() after switching to METAR succeeded, want error")
+	}
+}
+
+func TestWeatherStats_Units(t *testing.T) {
+	stats := WeatherStats{
+		Temperature: 20,
+		DewPoint:    10,
+		Pressure:    1013.25,
+		WindSpeed:   10,
+		WindGust:    20,
+		Visibility:  10,
+		Forecast: []WeatherForecast{{
+			TempMin:             -5,
+			TempMax:             25,
+			Condition:           "rain",
+			Precipitation:       12.7,
+			PrecipitationChance: 80,
+			WindSpeedMax:        30,
+		}},
+	}
+
+	tests := []struct {
+		units string
+		field string
+		day   int
+		want  string
+	}{
+		{"", "temp", -1, "20"},
+		{"", "wind", -1, "10"},
+		{"", "visibility", -1, "10.0"},
+		{WeatherMetric, "temp", -1, "20"},
+		{WeatherMetric, "pressure", -1, "1013"},
+		{WeatherMetric, "wind", -1, "19"},
+		{WeatherMetric, "wind_gust", -1, "37"},
+		{WeatherMetric, "visibility", -1, "16.1"},
+		{WeatherImperial, "temp", -1, "68"},
+		{WeatherImperial, "dewpoint", -1, "50"},
+		{WeatherImperial, "pressure", -1, "29.92"},
+		{WeatherImperial, "wind", -1, "12"},
+		{WeatherImperial, "visibility", -1, "10.0"},
+		{"", "max", 0, "25"},
+		{"", "precip", 0, "12.7"},
+		{"", "wind_max", 0, "30"},
+		{WeatherMetric, "min", 0, "-5"},
+		{WeatherMetric, "wind_max", 0, "56"},
+		{WeatherImperial, "min", 0, "23"},
+		{WeatherImperial, "max", 0, "77"},
+		{WeatherImperial, "precip", 0, "0.50"},
+		{WeatherImperial, "condition", 0, "rain"},
+		{WeatherImperial, "precip_chance", 0, "80"},
+		{"", "max", 1, ""},
+		{"", "unknown", 0, ""},
+	}
+
+	for _, tt := range tests {
+		stats.Units = tt.units
+		var got string
+		if tt.day < 0 {
+			got = stats.GetField(tt.field)
+		} else {
+			got = stats.GetForecastField(tt.day, tt.field)
+		}
+		if got != tt.want {
+			t.Errorf("units %q day %d field %q = %q, want %q", tt.units, tt.day, tt.field, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,16 @@
 package monitor
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestWeatherReader_ParseMETAR(t *testing.T) {
-	r := newWeatherReader()
-
 	tests := []struct {
 		name           string
 		stationID      string
@@ -103,7 +103,7 @@ func TestWeatherReader_ParseMETAR(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			stats := r.parseMETAR(tt.stationID, tt.rawMETAR)
+			stats := parseMETAR(tt.stationID, tt.rawMETAR)
 
 			if stats.StationID != tt.stationID {
 				t.Errorf("StationID = %q, want %q", stats.StationID, tt.stationID)
@@ -222,72 +222,131 @@ func TestDegreesToCompass(t *testing.T) {
 	}
 }
 
+// readWeatherNow starts a weather fetch for station if one is due, waits
+// for it to complete and returns the result.
+func readWeatherNow(t *testing.T, r *weatherReader, station string) (WeatherStats, error) {
+	t.Helper()
+	if _, err := r.ReadWeather(station); err != nil && !errors.Is(err, errWeatherPending) {
+		return WeatherStats{}, err
+	}
+	waitFor(t, func() bool {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		entry := r.cache[strings.ToUpper(strings.TrimSpace(station))]
+		return entry == nil || !entry.fetching
+	})
+	return r.ReadWeather(station)
+}
+
 func TestWeatherReader_ReadWeather_Caching(t *testing.T) {
-	callCount := 0
+	var callCount atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		callCount++
+		callCount.Add(1)
 		_, _ = w.Write([]byte("KJFK 151756Z 31012KT 10SM FEW045 22/06 A3012"))
 	}))
 	defer server.Close()
 
-	r := newWeatherReader()
-	r.metarURL = server.URL + "?ids=%s"
+	r := newWeatherReader(newHTTPFetcher())
+	r.provider = newMETARProvider(server.URL)
 	r.minInterval = 100 * time.Millisecond
 
-	// First call should fetch
-	stats1, err := r.ReadWeather("KJFK")
+	// The first call starts a fetch and does not wait for it
+	if _, err := r.ReadWeather("KJFK"); !errors.Is(err, errWeatherPending) {
+		t.Fatalf("first ReadWeather error = %v, want errWeatherPending", err)
+	}
+
+	stats1, err := readWeatherNow(t, r, "KJFK")
 	if err != nil {
-		t.Fatalf("First ReadWeather failed: %v", err)
+		t.Fatalf("ReadWeather failed: %v", err)
 	}
 	if stats1.Temperature != 22 {
 		t.Errorf("Temperature = %v, want 22", stats1.Temperature)
 	}
-	if callCount != 1 {
-		t.Errorf("callCount = %d, want 1", callCount)
+	if n := callCount.Load(); n != 1 {
+		t.Errorf("callCount = %d, want 1", n)
 	}
 
-	// Second call should use cache
+	// Further calls use the cache
 	stats2, err := r.ReadWeather("KJFK")
 	if err != nil {
-		t.Fatalf("Second ReadWeather failed: %v", err)
+		t.Fatalf("cached ReadWeather failed: %v", err)
 	}
 	if stats2.Temperature != 22 {
 		t.Errorf("Temperature = %v, want 22", stats2.Temperature)
 	}
-	if callCount != 1 {
-		t.Errorf("callCount = %d after cache hit, want 1", callCount)
+	if n := callCount.Load(); n != 1 {
+		t.Errorf("callCount = %d after cache hit, want 1", n)
 	}
 
-	// Wait for cache to expire
+	// Once the cache expires, the next call fetches again
 	time.Sleep(150 * time.Millisecond)
-
-	// Third call should fetch again
-	_, err = r.ReadWeather("KJFK")
-	if err != nil {
-		t.Fatalf("Third ReadWeather failed: %v", err)
+	if _, err := readWeatherNow(t, r, "KJFK"); err != nil {
+		t.Fatalf("ReadWeather after expiry failed: %v", err)
 	}
-	if callCount != 2 {
-		t.Errorf("callCount = %d after cache expiry, want 2", callCount)
+	if n := callCount.Load(); n != 2 {
+		t.Errorf("callCount = %d after cache expiry, want 2", n)
 	}
 }
 
 func TestWeatherReader_ReadWeather_Error(t *testing.T) {
+	var callCount atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount.Add(1)
 		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer server.Close()
 
-	r := newWeatherReader()
-	r.metarURL = server.URL + "?ids=%s"
+	r := newWeatherReader(newHTTPFetcher())
+	r.provider = newMETARProvider(server.URL)
 
-	_, err := r.ReadWeather("INVALID")
-	if err == nil {
-		t.Error("Expected error for HTTP 404")
+	stats, err := readWeatherNow(t, r, "INVALID")
+	if err == nil || errors.Is(err, errWeatherPending) {
+		t.Fatalf("ReadWeather error = %v, want HTTP 404 error", err)
+	}
+	if stats.Error == "" {
+		t.Error("Error field is empty")
+	}
+
+	// The failure is cached rather than retried on every call
+	if _, err := r.ReadWeather("INVALID"); err == nil {
+		t.Error("cached ReadWeather succeeded, want error")
+	}
+	if n := callCount.Load(); n != 1 {
+		t.Errorf("callCount = %d, want 1", n)
+	}
+}
+
+func TestWeatherReader_ReadWeather_KeepsStatsOnError(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("KJFK 151756Z 31012KT 10SM FEW045 22/06 A3012"))
+	}))
+	defer server.Close()
+
+	r := newWeatherReader(newHTTPFetcher())
+	r.provider = newMETARProvider(server.URL)
+	r.minInterval = 0
+
+	if _, err := readWeatherNow(t, r, "KJFK"); err != nil {
+		t.Fatalf("ReadWeather failed: %v", err)
+	}
+
+	fail.Store(true)
+	stats, err := readWeatherNow(t, r, "KJFK")
+	if err != nil {
+		t.Fatalf("ReadWeather after failure error = %v, want nil", err)
+	}
+	if stats.Temperature != 22 || stats.Error == "" {
+		t.Errorf("stats = %+v, want last weather with Error set", stats)
 	}
 }
 
 func TestWeatherReader_ReadWeather_EmptyStation(t *testing.T) {
-	r := newWeatherReader()
+	r := newWeatherReader(newHTTPFetcher())
 	_, err := r.ReadWeather("")
 	if err == nil {
 		t.Error("Expected error for empty station ID")
@@ -295,7 +354,7 @@ func TestWeatherReader_ReadWeather_EmptyStation(t *testing.T) {
 }
 
 func TestWeatherReader_ClearCache(t *testing.T) {
-	r := newWeatherReader()
+	r := newWeatherReader(newHTTPFetcher())
 	r.cache["KJFK"] = &weatherCacheEntry{
 		stats:     WeatherStats{StationID: "KJFK"},
 		fetchTime: time.Now(),
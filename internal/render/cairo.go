@@ -4,6 +4,7 @@
 package render
 
 import (
+	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
@@ -288,9 +289,12 @@ func (p *CairoPattern) radialColorAt(x, y float64) color.RGBA {
 	dy := y - p.cy1
 	dist := math.Sqrt(dx*dx + dy*dy)
 
-	// Map distance to gradient position
+	// Map distance to gradient position. Circles of equal radius make a
+	// disc with the last stop inside and the first outside. Points on the
+	// circle are outside, so a disc centred on a pixel has no one-pixel
+	// spurs where the circle meets the pixel's row and column.
 	if p.r1 == p.r0 {
-		if dist <= p.r1 {
+		if dist < p.r1 {
 			return p.ColorAt(1)
 		}
 		return p.ColorAt(0)
@@ -770,10 +774,12 @@ func (cr *CairoRenderer) Screen() *ebiten.Image {
 }
 
 // SetSourceRGB sets the current drawing color using RGB values (0.0-1.0).
+// It replaces any source pattern or surface.
 // This is equivalent to cairo_set_source_rgb.
 func (cr *CairoRenderer) SetSourceRGB(r, g, b float64) {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
+	cr.clearSourceUnlocked()
 	cr.currentColor = color.RGBA{
 		R: clampToByte(r),
 		G: clampToByte(g),
@@ -783,10 +789,12 @@ func (cr *CairoRenderer) SetSourceRGB(r, g, b float64) {
 }
 
 // SetSourceRGBA sets the current drawing color using RGBA values (0.0-1.0).
+// It replaces any source pattern or surface.
 // This is equivalent to cairo_set_source_rgba.
 func (cr *CairoRenderer) SetSourceRGBA(r, g, b, a float64) {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
+	cr.clearSourceUnlocked()
 	cr.currentColor = color.RGBA{
 		R: clampToByte(r),
 		G: clampToByte(g),
@@ -795,6 +803,14 @@ func (cr *CairoRenderer) SetSourceRGBA(r, g, b, a float64) {
 	}
 }
 
+// clearSourceUnlocked removes the source pattern and surface, so the current
+// color is used again. This must be called while holding the mutex.
+func (cr *CairoRenderer) clearSourceUnlocked() {
+	cr.sourcePattern = nil
+	cr.sourceSurface = nil
+	cr.hasSourceSurface = false
+}
+
 // GetCurrentColor returns the current drawing color.
 func (cr *CairoRenderer) GetCurrentColor() color.RGBA {
 	cr.mu.Lock()
@@ -1464,8 +1480,8 @@ func (cr *CairoRenderer) PaintWithAlpha(alpha float64) {
 			}
 			// Apply pattern offset, adjusted for clip region
 			opts.GeoM.Translate(cr.sourcePattern.x0-float64(clipX), cr.sourcePattern.y0-float64(clipY))
-			// Apply alpha via color matrix
-			opts.ColorScale.Scale(1, 1, 1, float32(alpha))
+			// Images hold premultiplied alpha, so every channel is scaled
+			opts.ColorScale.ScaleAlpha(float32(alpha))
 			screen.DrawImage(cr.sourcePattern.surface, opts)
 			return
 		}
@@ -2486,6 +2502,7 @@ type CairoSurface struct {
 	width     int
 	height    int
 	destroyed bool
+	borrowed  bool // image is owned by the caller and not deallocated on Destroy
 	mu        sync.Mutex
 }
 
@@ -2518,6 +2535,22 @@ func NewCairoXlibSurface(display, drawable, visual uintptr, width, height int) *
 	return NewCairoSurface(width, height)
 }
 
+// NewCairoSurfaceForImage creates a surface that draws directly onto an
+// existing image, such as the window frame handed to Lua draw hooks.
+// The surface does not take ownership: Destroy leaves the image allocated.
+func NewCairoSurfaceForImage(image *ebiten.Image) *CairoSurface {
+	if image == nil {
+		return nil
+	}
+	bounds := image.Bounds()
+	return &CairoSurface{
+		image:    image,
+		width:    bounds.Dx(),
+		height:   bounds.Dy(),
+		borrowed: true,
+	}
+}
+
 // Image returns the underlying Ebiten image.
 // This allows integration with the rendering loop.
 func (s *CairoSurface) Image() *ebiten.Image {
@@ -2560,11 +2593,12 @@ func (s *CairoSurface) Destroy() {
 		return
 	}
 	s.destroyed = true
-	// Dispose of the Ebiten image to free GPU resources
-	if s.image != nil {
+	// Dispose of the Ebiten image to free GPU resources, unless it belongs
+	// to the caller
+	if s.image != nil && !s.borrowed {
 		s.image.Deallocate()
-		s.image = nil
 	}
+	s.image = nil
 }
 
 // Flush completes any pending drawing operations.
@@ -2679,6 +2713,40 @@ func (s *CairoSurface) WriteToPNG(filename string) error {
 	return nil
 }
 
+// Data returns the surface pixels in Cairo's CAIRO_FORMAT_ARGB32 layout:
+// premultiplied 32-bit ARGB values in native byte order, Width()*4 bytes
+// per row. This is equivalent to cairo_image_surface_get_data, except that
+// the returned bytes are a copy.
+//
+// Like WriteToPNG, Data reads the pixels back from the GPU, which Ebiten
+// only allows while the game loop is running; otherwise it returns an error.
+func (s *CairoSurface) Data() (data []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.destroyed {
+		return nil, fmt.Errorf("cairo_image_surface_get_data: surface has been destroyed")
+	}
+	if s.image == nil {
+		return nil, fmt.Errorf("cairo_image_surface_get_data: surface image is nil")
+	}
+
+	pixels := make([]byte, s.width*s.height*4)
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("cairo_image_surface_get_data: %v", r)
+		}
+	}()
+	s.image.ReadPixels(pixels)
+
+	// Ebiten returns premultiplied RGBA bytes
+	for i := 0; i < len(pixels); i += 4 {
+		argb := uint32(pixels[i+3])<<24 | uint32(pixels[i])<<16 | uint32(pixels[i+1])<<8 | uint32(pixels[i+2])
+		binary.NativeEndian.PutUint32(pixels[i:], argb)
+	}
+	return pixels, nil
+}
+
 // CairoContext wraps a CairoRenderer with its associated surface.
 // This provides the cairo_create/cairo_destroy pattern expected by Lua scripts.
 type CairoContext struct {
@@ -3029,7 +3097,9 @@ func (cr *CairoRenderer) GetFontWeight() FontWeight {
 // The mask pattern's alpha channel modulates the current source: where the
 // mask is opaque (alpha = 1), the source is fully applied; where transparent
 // (alpha = 0), no source is applied. Intermediate alpha values produce
-// partial transparency.
+// partial transparency. The source is the source surface when one is set,
+// for example with SetSourceSurface or PopGroupToSource, and the current
+// color otherwise.
 //
 // The current path is not affected by this operation.
 func (cr *CairoRenderer) Mask(pattern *CairoPattern) {
@@ -3049,55 +3119,35 @@ func (cr *CairoRenderer) Mask(pattern *CairoPattern) {
 		return
 	}
 
-	// Create a temporary image to hold the masked result
-	tempImg := ebiten.NewImage(w, h)
-	defer tempImg.Deallocate()
-
-	// For each pixel, compute the mask alpha and blend accordingly
-	// We use a pixel-by-pixel approach for accuracy
+	// Build the mask's alpha channel pixel by pixel for accuracy. Ebiten
+	// images hold premultiplied alpha, so each pixel is white scaled by it.
 	pixels := make([]byte, w*h*4)
-
 	for y := 0; y < h; y++ {
 		for x := 0; x < w; x++ {
-			// Get mask alpha at this point
-			maskColor := pattern.ColorAtPoint(float64(x), float64(y))
-			maskAlpha := float64(maskColor.A) / 255.0
-
-			// Compute final color: source color with alpha modulated by mask
-			finalR := uint8(float64(cr.currentColor.R) * maskAlpha)
-			finalG := uint8(float64(cr.currentColor.G) * maskAlpha)
-			finalB := uint8(float64(cr.currentColor.B) * maskAlpha)
-			finalA := uint8(float64(cr.currentColor.A) * maskAlpha)
-
-			// Write to pixel buffer (RGBA format)
+			alpha := pattern.ColorAtPoint(float64(x), float64(y)).A
 			offset := (y*w + x) * 4
-			pixels[offset] = finalR
-			pixels[offset+1] = finalG
-			pixels[offset+2] = finalB
-			pixels[offset+3] = finalA
+			pixels[offset] = alpha
+			pixels[offset+1] = alpha
+			pixels[offset+2] = alpha
+			pixels[offset+3] = alpha
 		}
 	}
+	maskImg := ebiten.NewImage(w, h)
+	defer maskImg.Deallocate()
+	maskImg.WritePixels(pixels)
 
-	tempImg.WritePixels(pixels)
-
-	// Get clipped screen for compositing
-	screen, _, _ := cr.getClippedScreen()
-
-	// Composite the masked image onto the screen
-	screen.DrawImage(tempImg, &ebiten.DrawImageOptions{
-		Blend: cr.getEbitenBlend(),
-	})
+	cr.drawMaskedSourceUnlocked(maskImg, 0, 0)
 }
 
 // MaskSurface paints the current source using the alpha channel of the given
 // surface as a mask. This is equivalent to cairo_mask_surface.
 //
 // The surface is placed at (surfaceX, surfaceY) in user-space coordinates.
-// The alpha channel of the surface modulates the current source color.
+// The alpha channel of the surface modulates the current source, as in Mask.
 //
-// Implementation note: This uses Ebiten's DrawImage with color matrix to
-// achieve the masking effect without requiring ReadPixels, which has
-// limitations in Ebiten's execution model.
+// Implementation note: This uses Ebiten's blend modes to achieve the masking
+// effect without requiring ReadPixels, which has limitations in Ebiten's
+// execution model.
 func (cr *CairoRenderer) MaskSurface(surface *CairoSurface, surfaceX, surfaceY float64) {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
@@ -3113,56 +3163,61 @@ func (cr *CairoRenderer) MaskSurface(surface *CairoSurface, surfaceX, surfaceY f
 
 	// Get mask bounds
 	maskBounds := maskImg.Bounds()
-	maskW := maskBounds.Dx()
-	maskH := maskBounds.Dy()
-
-	if maskW <= 0 || maskH <= 0 {
+	if maskBounds.Dx() <= 0 || maskBounds.Dy() <= 0 {
 		return
 	}
 
 	// Apply transformation to surface position
 	tx, ty := cr.transformPointUnlocked(surfaceX, surfaceY)
 
-	// Create a temporary image the size of the mask to hold the colored result
-	tempImg := ebiten.NewImage(maskW, maskH)
-	defer tempImg.Deallocate()
-
-	// Fill temp image with the source color
-	tempImg.Fill(cr.currentColor)
-
-	// Get clipped screen for compositing
-	screen, _, _ := cr.getClippedScreen()
-
-	// Use Ebiten's blend modes to apply the mask.
-	// We draw the colored image using the mask's alpha channel.
-	// The BlendSourceIn blend mode uses: result = source * dest_alpha
-	// So we first draw the mask to establish alpha, then draw color with SourceIn.
+	cr.drawMaskedSourceUnlocked(maskImg, tx, ty)
+}
 
-	// Alternative approach: Use a ColorMatrix to modulate the colored image
-	// by the mask's alpha. We draw the mask first, then draw the color on top.
+// drawMaskedSourceUnlocked draws the current source onto the screen through
+// the alpha channel of maskImg placed at (x, y) in device coordinates.
+// This must be called while holding the mutex.
+func (cr *CairoRenderer) drawMaskedSourceUnlocked(maskImg *ebiten.Image, x, y float64) {
+	bounds := maskImg.Bounds()
 
-	// Create a destination image for compositing
-	compositeImg := ebiten.NewImage(maskW, maskH)
+	// Render the part of the source under the mask, then keep it only
+	// where the mask is opaque. DestinationIn: result = dest * source_alpha
+	compositeImg := cr.sourceImageUnlocked(bounds.Dx(), bounds.Dy(), x, y)
 	defer compositeImg.Deallocate()
 
-	// Draw the mask to establish the alpha channel
-	compositeImg.DrawImage(maskImg, nil)
-
-	// Now draw the colored image with SourceIn blending
-	// SourceIn: result = source * dest_alpha (uses destination alpha as mask)
-	opts := &ebiten.DrawImageOptions{
-		Blend: ebiten.BlendSourceIn,
+	maskOpts := &ebiten.DrawImageOptions{
+		Blend: ebiten.BlendDestinationIn,
 	}
-	compositeImg.DrawImage(tempImg, opts)
+	maskOpts.GeoM.Translate(-float64(bounds.Min.X), -float64(bounds.Min.Y))
+	compositeImg.DrawImage(maskImg, maskOpts)
+
+	// Get clipped screen for compositing. Sub-images share the screen's
+	// coordinates, so the position needs no adjustment.
+	screen, _, _ := cr.getClippedScreen()
 
-	// Finally, draw the composite result to the screen at the specified position
 	screenOpts := &ebiten.DrawImageOptions{
 		Blend: cr.getEbitenBlend(),
 	}
-	screenOpts.GeoM.Translate(tx, ty)
+	screenOpts.GeoM.Translate(x, y)
 	screen.DrawImage(compositeImg, screenOpts)
 }
 
+// sourceImageUnlocked returns a new width x height image holding the current
+// source as seen from (x, y) in device coordinates: the source surface when
+// one is set, transparent outside it, and the current color otherwise.
+// The caller deallocates the image. This must be called while holding the mutex.
+func (cr *CairoRenderer) sourceImageUnlocked(width, height int, x, y float64) *ebiten.Image {
+	img := ebiten.NewImage(width, height)
+	if p := cr.sourcePattern; p != nil && p.patternType == PatternTypeSurface && p.surface != nil {
+		opts := &ebiten.DrawImageOptions{}
+		opts.GeoM.Translate(p.x0-x, p.y0-y)
+		img.DrawImage(p.surface, opts)
+		return img
+	}
+	// The current color is not premultiplied
+	img.Fill(color.NRGBA(cr.currentColor))
+	return img
+}
+
 // --- Group Rendering Functions ---
 //
 // Group rendering allows drawing to a temporary surface that can later be
@@ -1100,6 +1100,40 @@ func TestNewCairoXlibSurface(t *testing.T) {
 	}
 }
 
+func TestNewCairoSurfaceForImage(t *testing.T) {
+	if NewCairoSurfaceForImage(nil) != nil {
+		t.Error("NewCairoSurfaceForImage(nil) should return nil")
+	}
+
+	image := ebiten.NewImage(320, 200)
+	defer image.Deallocate()
+
+	surface := NewCairoSurfaceForImage(image)
+	if surface.Image() != image {
+		t.Error("surface should draw onto the given image")
+	}
+	if surface.Width() != 320 || surface.Height() != 200 {
+		t.Errorf("Expected dimensions (320,200), got (%d,%d)", surface.Width(), surface.Height())
+	}
+
+	ctx := NewCairoContext(surface)
+	if ctx == nil {
+		t.Fatal("NewCairoContext returned nil for image surface")
+	}
+	if ctx.Renderer().Screen() != image {
+		t.Error("context renderer should target the given image")
+	}
+
+	// Destroying the surface releases it without deallocating the image
+	surface.Destroy()
+	if !surface.IsDestroyed() || surface.Image() != nil {
+		t.Error("surface should be destroyed")
+	}
+	if image.Bounds().Dx() != 320 {
+		t.Error("image should be left intact")
+	}
+}
+
 func TestCairoSurface_Destroy(t *testing.T) {
 	surface := NewCairoSurface(100, 100)
 
@@ -3169,6 +3203,29 @@ func TestCairoRenderer_Mask_RadialGradientPattern(t *testing.T) {
 	cr.Mask(mask)
 }
 
+func TestCairoPattern_RadialEqualRadii(t *testing.T) {
+	disc := NewRadialPattern(16, 16, 12, 16, 16, 12)
+	disc.AddColorStopRGBA(0, 1, 1, 1, 0)
+	disc.AddColorStopRGBA(1, 1, 1, 1, 1)
+
+	tests := []struct {
+		x, y  float64
+		alpha uint8
+	}{
+		{16, 16, 255}, // Centre
+		{27, 16, 255}, // Inside
+		{28, 16, 0},   // On the circle
+		{16, 4, 0},    // On the circle
+		{4, 16, 0},    // On the circle
+		{29, 16, 0},   // Outside
+	}
+	for _, tt := range tests {
+		if got := disc.ColorAtPoint(tt.x, tt.y).A; got != tt.alpha {
+			t.Errorf("ColorAtPoint(%v, %v).A = %d, want %d", tt.x, tt.y, got, tt.alpha)
+		}
+	}
+}
+
 func TestCairoRenderer_Mask_WithClipping(t *testing.T) {
 	cr := NewCairoRenderer()
 	screen := createTestScreen(100, 100)
@@ -3239,6 +3296,31 @@ func TestCairoRenderer_MaskSurface_BasicUsage(t *testing.T) {
 	cr.MaskSurface(surface, 25, 25)
 }
 
+func TestCairoRenderer_Mask_SurfaceSource(t *testing.T) {
+	cr := NewCairoRenderer()
+	screen := createTestScreen(100, 100)
+	cr.SetScreen(screen)
+
+	// Masked album art: an image source shown through a circular mask
+	art := NewCairoSurface(50, 50)
+	defer art.Destroy()
+	cr.SetSourceSurface(art, 25, 25)
+
+	mask := NewRadialPattern(50, 50, 20, 50, 50, 20)
+	mask.AddColorStopRGBA(0, 1, 1, 1, 0)
+	mask.AddColorStopRGBA(1, 1, 1, 1, 1)
+	cr.Mask(mask)
+
+	maskSurface := NewCairoSurface(20, 20)
+	defer maskSurface.Destroy()
+	cr.MaskSurface(maskSurface, 40, 40)
+
+	// The source is unchanged by masking
+	if source := cr.GetSource(); source == nil || source.Type() != PatternTypeSurface {
+		t.Errorf("Expected the surface source to remain set, got %v", source)
+	}
+}
+
 func TestCairoRenderer_MaskSurface_WithTransformation(t *testing.T) {
 	cr := NewCairoRenderer()
 	screen := createTestScreen(100, 100)
@@ -3662,6 +3744,27 @@ func TestCairoRenderer_SetSourceSurface(t *testing.T) {
 	}
 }
 
+func TestCairoRenderer_SetSourceRGBReplacesSurface(t *testing.T) {
+	cr := NewCairoRenderer()
+	surface := NewCairoSurface(10, 10)
+	defer surface.Destroy()
+
+	cr.SetSourceSurface(surface, 0, 0)
+	cr.SetSourceRGB(1, 0, 0)
+	if source := cr.GetSource(); source != nil {
+		t.Errorf("Expected SetSourceRGB to replace the surface source, got %v", source)
+	}
+
+	cr.SetSourceSurface(surface, 0, 0)
+	cr.SetSourceRGBA(0, 1, 0, 1)
+	if source := cr.GetSource(); source != nil {
+		t.Errorf("Expected SetSourceRGBA to replace the surface source, got %v", source)
+	}
+	if got := cr.GetCurrentColor(); got != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Expected the current color to be set, got %v", got)
+	}
+}
+
 func TestCairoRenderer_SetSourceSurfaceNil(t *testing.T) {
 	cr := NewCairoRenderer()
 	screen := createTestScreen(100, 100)
@@ -3926,3 +4029,18 @@ func TestCairoRenderer_GroupConcurrency(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestCairoSurface_Data(t *testing.T) {
+	surface := NewCairoSurface(4, 3)
+
+	// Outside the game loop pixels cannot be read back, which is an error
+	// rather than a panic
+	if data, err := surface.Data(); err == nil || data != nil {
+		t.Errorf("Expected an error outside the game loop, got %d bytes and %v", len(data), err)
+	}
+
+	surface.Destroy()
+	if _, err := surface.Data(); err == nil {
+		t.Error("Expected an error for a destroyed surface")
+	}
+}
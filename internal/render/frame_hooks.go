@@ -0,0 +1,159 @@
+// Package render provides Ebiten-based rendering capabilities for conky-go.
+// This file implements frame hooks, which let scripts draw below and above
+// the text on every update.
+package render
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// FrameInfo describes the window geometry passed to frame hooks, matching
+// the fields of Conky's conky_window table.
+type FrameInfo struct {
+	// Width and Height are the window size in pixels.
+	Width, Height int
+	// BorderInnerMargin, BorderOuterMargin and BorderWidth are the border
+	// settings in pixels.
+	BorderInnerMargin, BorderOuterMargin, BorderWidth int
+	// TextStartX and TextStartY are the top-left corner of the text.
+	TextStartX, TextStartY int
+	// TextWidth and TextHeight are the extents of the text.
+	TextWidth, TextHeight int
+}
+
+// FrameHooks receives callbacks at fixed points of the update cycle.
+// It lets the Lua lifecycle hooks run inside the render loop without this
+// package depending on the Lua runtime.
+//
+// The draw hooks do not draw onto the screen image passed to Draw. They run
+// from Update once per update interval, as Conky runs them once per update,
+// onto offscreen layers that Draw composites beneath and above the text on
+// every frame. Drawing in Draw would run the scripts at the frame rate and
+// lose their output whenever Ebiten clears the screen between updates. As a
+// consequence a hook cannot erase the background or the text: operators
+// such as CAIRO_OPERATOR_CLEAR affect only its own layer.
+type FrameHooks interface {
+	// Update is called once per update interval, after the data provider
+	// and text lines have been refreshed, with the current window geometry.
+	Update(frame FrameInfo) error
+	// DrawPre draws onto the layer shown beneath the text.
+	// The layer is cleared before each call.
+	DrawPre(layer *ebiten.Image) error
+	// DrawPost draws onto the layer shown above the text.
+	// The layer is cleared before each call.
+	DrawPost(layer *ebiten.Image) error
+}
+
+// SetFrameHooks sets the hooks run on every update interval.
+// Passing nil disables them and discards any previously drawn layers.
+func (g *Game) SetFrameHooks(hooks FrameHooks) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.frameHooks = hooks
+	g.hooksRan = false
+	if hooks == nil {
+		g.deallocateHookLayers()
+	}
+}
+
+// runFrameHooks invokes the frame hooks and redraws the hook layers.
+// The caller must hold the write lock.
+func (g *Game) runFrameHooks() {
+	g.ensureHookLayers()
+
+	if err := g.frameHooks.Update(g.frameInfo()); err != nil {
+		g.handleError(err)
+	}
+
+	g.preLayer.Clear()
+	if err := g.frameHooks.DrawPre(g.preLayer); err != nil {
+		g.handleError(err)
+	}
+
+	g.postLayer.Clear()
+	if err := g.frameHooks.DrawPost(g.postLayer); err != nil {
+		g.handleError(err)
+	}
+}
+
+// frameInfo returns the window geometry and the extents of the current text
+// lines. Without lines, the text area is the window inside the borders.
+func (g *Game) frameInfo() FrameInfo {
+	info := FrameInfo{
+		Width:             g.config.Width,
+		Height:            g.config.Height,
+		BorderInnerMargin: g.config.BorderInnerMargin,
+		BorderOuterMargin: g.config.BorderOuterMargin,
+		BorderWidth:       g.config.BorderWidth,
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	extend := func(x, y, width float64) {
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x+width)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y+g.textRenderer.LineHeight())
+	}
+	var style lineStyle
+	for _, line := range g.lines {
+		if style.isDefault() && !ContainsWidgetMarker(line.Text) && !ContainsImageMarker(line.Text) && !ContainsLayoutMarker(line.Text) {
+			width, _ := g.textRenderer.MeasureText(line.Text)
+			extend(line.X, line.Y, width)
+			continue
+		}
+		for _, run := range g.layoutLine(line, &style) {
+			extend(run.x, run.y, run.width)
+		}
+	}
+
+	if math.IsInf(minX, 1) {
+		margin := g.config.BorderOuterMargin + g.config.BorderWidth + g.config.BorderInnerMargin
+		info.TextStartX, info.TextStartY = margin, margin
+		info.TextWidth = max(g.config.Width-2*margin, 0)
+		info.TextHeight = max(g.config.Height-2*margin, 0)
+		return info
+	}
+
+	info.TextStartX = int(math.Floor(minX))
+	info.TextStartY = int(math.Floor(minY))
+	info.TextWidth = int(math.Ceil(maxX)) - info.TextStartX
+	info.TextHeight = int(math.Ceil(maxY)) - info.TextStartY
+	return info
+}
+
+// ensureHookLayers allocates the hook layers to match the window size.
+func (g *Game) ensureHookLayers() {
+	width, height := g.config.Width, g.config.Height
+	if width <= 0 || height <= 0 {
+		width, height = 1, 1
+	}
+	if g.preLayer != nil {
+		bounds := g.preLayer.Bounds()
+		if bounds.Dx() == width && bounds.Dy() == height {
+			return
+		}
+		g.deallocateHookLayers()
+	}
+	g.preLayer = ebiten.NewImage(width, height)
+	g.postLayer = ebiten.NewImage(width, height)
+}
+
+// deallocateHookLayers releases the hook layers.
+func (g *Game) deallocateHookLayers() {
+	if g.preLayer != nil {
+		g.preLayer.Deallocate()
+		g.preLayer = nil
+	}
+	if g.postLayer != nil {
+		g.postLayer.Deallocate()
+		g.postLayer = nil
+	}
+}
+
+// handleError passes err to the configured error handler, if any.
+func (g *Game) handleError(err error) {
+	if g.errorHandler != nil {
+		g.errorHandler(err)
+	}
+}
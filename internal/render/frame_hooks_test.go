@@ -0,0 +1,200 @@
+//go:build !noebiten
+
+package render
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// mockFrameHooks records frame hook invocations.
+type mockFrameHooks struct {
+	order    []string
+	layers   []*ebiten.Image
+	frames   []FrameInfo
+	updateFn func() error
+}
+
+func (m *mockFrameHooks) Update(frame FrameInfo) error {
+	m.order = append(m.order, "update")
+	m.frames = append(m.frames, frame)
+	if m.updateFn != nil {
+		return m.updateFn()
+	}
+	return nil
+}
+
+func (m *mockFrameHooks) DrawPre(layer *ebiten.Image) error {
+	m.order = append(m.order, "draw_pre")
+	m.layers = append(m.layers, layer)
+	return nil
+}
+
+func (m *mockFrameHooks) DrawPost(layer *ebiten.Image) error {
+	m.order = append(m.order, "draw_post")
+	m.layers = append(m.layers, layer)
+	return nil
+}
+
+func TestGameFrameHooksRunOnFirstUpdate(t *testing.T) {
+	config := DefaultConfig()
+	config.Width = 120
+	config.Height = 80
+	config.UpdateInterval = time.Hour
+	game := NewGameWithRenderer(config, newMockTextRenderer())
+
+	hooks := &mockFrameHooks{}
+	game.SetFrameHooks(hooks)
+
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []string{"update", "draw_pre", "draw_post"}
+	if len(hooks.order) != len(want) {
+		t.Fatalf("hook calls = %v, want %v", hooks.order, want)
+	}
+	for i, name := range want {
+		if hooks.order[i] != name {
+			t.Errorf("hook call %d = %q, want %q", i, hooks.order[i], name)
+		}
+	}
+
+	for i, layer := range hooks.layers {
+		if layer == nil {
+			t.Fatalf("layer %d is nil", i)
+		}
+		if b := layer.Bounds(); b.Dx() != 120 || b.Dy() != 80 {
+			t.Errorf("layer %d size = %dx%d, want 120x80", i, b.Dx(), b.Dy())
+		}
+	}
+	if hooks.layers[0] == hooks.layers[1] {
+		t.Error("pre and post hooks share a layer")
+	}
+
+	// The interval has not elapsed, so the hooks must not run again
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(hooks.order) != len(want) {
+		t.Errorf("hooks ran again before interval elapsed: %v", hooks.order)
+	}
+}
+
+func TestGameFrameHooksRespectInterval(t *testing.T) {
+	config := DefaultConfig()
+	config.UpdateInterval = 0
+	game := NewGameWithRenderer(config, newMockTextRenderer())
+
+	hooks := &mockFrameHooks{}
+	game.SetFrameHooks(hooks)
+
+	for i := 0; i < 3; i++ {
+		if err := game.Update(); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	if len(hooks.order) != 9 {
+		t.Errorf("got %d hook calls, want 9", len(hooks.order))
+	}
+}
+
+func TestGameFrameHooksErrors(t *testing.T) {
+	config := DefaultConfig()
+	game := NewGameWithRenderer(config, newMockTextRenderer())
+
+	hookErr := errors.New("hook failed")
+	var handled []error
+	game.SetErrorHandler(func(err error) { handled = append(handled, err) })
+	game.SetFrameHooks(&mockFrameHooks{updateFn: func() error { return hookErr }})
+
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(handled) != 1 || !errors.Is(handled[0], hookErr) {
+		t.Errorf("handled errors = %v, want [%v]", handled, hookErr)
+	}
+}
+
+func TestGameDrawWithFrameHooks(t *testing.T) {
+	config := DefaultConfig()
+	config.Width = 100
+	config.Height = 50
+	renderer := newMockTextRenderer()
+	game := NewGameWithRenderer(config, renderer)
+	game.SetLines([]TextLine{{Text: "hello", X: 5, Y: 10}})
+	game.SetFrameHooks(&mockFrameHooks{})
+
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	screen := ebiten.NewImage(100, 50)
+	defer screen.Deallocate()
+	game.Draw(screen)
+
+	if renderer.drawTextCalls != 1 {
+		t.Errorf("got %d text draw calls, want 1", renderer.drawTextCalls)
+	}
+}
+
+func TestGameSetFrameHooksNil(t *testing.T) {
+	game := NewGameWithRenderer(DefaultConfig(), newMockTextRenderer())
+	game.SetFrameHooks(&mockFrameHooks{})
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	game.SetFrameHooks(nil)
+
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+	if game.preLayer != nil || game.postLayer != nil {
+		t.Error("hook layers not released after SetFrameHooks(nil)")
+	}
+}
+
+func TestGameFrameInfo(t *testing.T) {
+	game, _ := newLayoutTestGame(200)
+	game.config.Height = 100
+	game.config.BorderWidth = 2
+	game.config.BorderInnerMargin = 3
+	game.config.BorderOuterMargin = 4
+	game.config.UpdateInterval = time.Hour
+
+	hooks := &mockFrameHooks{}
+	game.SetFrameHooks(hooks)
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Without lines the text area is the window inside the borders
+	want := FrameInfo{
+		Width: 200, Height: 100,
+		BorderInnerMargin: 3, BorderOuterMargin: 4, BorderWidth: 2,
+		TextStartX: 9, TextStartY: 9, TextWidth: 182, TextHeight: 82,
+	}
+	if got := hooks.frames[0]; got != want {
+		t.Errorf("frame without lines = %+v, want %+v", got, want)
+	}
+
+	game.SetLines([]TextLine{
+		{Text: "abc", X: 10, Y: 20},
+		{Text: "CPU:" + EncodeAlignRMarker() + "42%", X: 10, Y: 32},
+	})
+	game.SetFrameHooks(hooks)
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Lines span x 10..190 (right-aligned run ends at 200 - 10) and
+	// y 20..44 (last line starts at 32 and is 12 pixels tall)
+	want.TextStartX, want.TextStartY = 10, 20
+	want.TextWidth, want.TextHeight = 180, 24
+	if got := hooks.frames[1]; got != want {
+		t.Errorf("frame with lines = %+v, want %+v", got, want)
+	}
+}
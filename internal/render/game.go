@@ -48,6 +48,7 @@ type Game struct {
 	config             Config
 	textRenderer       TextRendererInterface
 	dataProvider       DataProvider
+	lineProvider       LineProvider
 	errorHandler       ErrorHandler
 	lastUpdate         time.Time
 	lines              []TextLine
@@ -57,7 +58,12 @@ type Game struct {
 	imageCache         *ImageCache           // Cache for loaded images
 	backgroundRenderer BackgroundRenderer    // Handles background drawing
 	graphHistories     map[string]*LineGraph // Historical data for graph widgets
+	historySource      HistorySource         // Shared metric history for graph widgets
 	hintsApplied       bool                  // Track if X11 window hints have been applied
+	frameHooks         FrameHooks            // Script hooks run on every update interval
+	hooksRan           bool                  // Track if frame hooks have run since being set
+	preLayer           *ebiten.Image         // Drawn by frame hooks beneath the text
+	postLayer          *ebiten.Image         // Drawn by frame hooks above the text
 }
 
 // NewGame creates a new Game instance with the provided configuration.
@@ -106,6 +112,22 @@ func (g *Game) SetDataProvider(dp DataProvider) {
 	g.dataProvider = dp
 }
 
+// SetLineProvider sets the provider used to refresh text lines after each
+// data update. Passing nil keeps the lines set via SetLines unchanged.
+func (g *Game) SetLineProvider(lp LineProvider) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lineProvider = lp
+}
+
+// SetHistorySource sets the source of recorded metric history for graph
+// widgets. Passing nil makes graphs record one point per frame themselves.
+func (g *Game) SetHistorySource(hs HistorySource) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.historySource = hs
+}
+
 // SetContext sets a context for the game loop. When the context is cancelled,
 // the game loop will terminate gracefully.
 func (g *Game) SetContext(ctx context.Context) {
@@ -159,16 +181,25 @@ func (g *Game) Update() error {
 		}
 	}
 
-	// Update system data at configured intervals
-	if g.dataProvider != nil && time.Since(g.lastUpdate) >= g.config.UpdateInterval {
-		if err := g.dataProvider.Update(); err != nil {
-			// Use error handler if configured
-			if g.errorHandler != nil {
-				g.errorHandler(err)
+	// Update system data, re-evaluate text lines and run frame hooks at
+	// configured intervals. Frame hooks also run on the first tick so their
+	// layers are not blank until the first interval elapses.
+	due := time.Since(g.lastUpdate) >= g.config.UpdateInterval
+	if due && (g.dataProvider != nil || g.lineProvider != nil || g.frameHooks != nil) {
+		if g.dataProvider != nil {
+			if err := g.dataProvider.Update(); err != nil {
+				g.handleError(err)
 			}
 		}
+		if g.lineProvider != nil {
+			g.lines = g.lineProvider.Lines()
+		}
 		g.lastUpdate = time.Now()
 	}
+	if g.frameHooks != nil && (due || !g.hooksRan) {
+		g.runFrameHooks()
+		g.hooksRan = true
+	}
 
 	return nil
 }
@@ -182,47 +213,53 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	// Draw background using the configured background renderer
 	g.backgroundRenderer.Draw(screen)
 
+	// Draw the pre-draw hook layer beneath everything else
+	if g.preLayer != nil {
+		screen.DrawImage(g.preLayer, nil)
+	}
+
 	// Draw borders if enabled
 	if g.config.DrawBorders {
 		g.drawBorders(screen)
 	}
 
-	// Render all text lines with inline widget support
+	// Render all text lines with inline widget support, carrying color,
+	// font and vertical offset changes from each line to the next
+	var style lineStyle
 	for _, line := range g.lines {
-		g.drawLineWithWidgets(screen, line)
+		g.drawLineWithWidgets(screen, line, &style)
+	}
+
+	// Draw the post-draw hook layer on top
+	if g.postLayer != nil {
+		screen.DrawImage(g.postLayer, nil)
 	}
 }
 
-// drawLineWithWidgets renders a text line, handling inline widget markers.
-func (g *Game) drawLineWithWidgets(screen *ebiten.Image, line TextLine) {
-	// Fast path: if no widget markers, just draw text with effects
-	if !ContainsWidgetMarker(line.Text) {
+// drawLineWithWidgets renders a text line, handling inline widget, image and
+// layout markers. Lines with markers, or following a line that changed the
+// style, are laid out as styled runs.
+func (g *Game) drawLineWithWidgets(screen *ebiten.Image, line TextLine, style *lineStyle) {
+	// Fast path: if no markers or carried style, just draw text with effects
+	if style.isDefault() && !ContainsWidgetMarker(line.Text) && !ContainsImageMarker(line.Text) && !ContainsLayoutMarker(line.Text) {
 		g.drawTextWithEffects(screen, line.Text, line.X, line.Y, line.Color)
 		return
 	}
 
-	// Parse segments and render each one
-	segments := ParseWidgetSegments(line.Text)
-	x := line.X
+	runs := g.layoutLine(line, style)
+
+	base := g.currentFont()
+	defer g.applyFont(base)
 
-	for _, seg := range segments {
+	for _, run := range runs {
 		switch {
-		case seg.IsWidget && seg.Widget != nil:
-			// Render the widget
-			g.drawInlineWidget(screen, seg.Widget, x, line.Y, line.Color)
-			x += seg.Widget.Width
-		case seg.IsImage && seg.Image != nil:
-			// Render the image
-			imgWidth := g.drawImageMarker(screen, seg.Image, x, line.Y)
-			// Only advance x position for inline images (x == -1)
-			if seg.Image.X < 0 {
-				x += imgWidth
-			}
-		default:
-			// Render text segment with effects
-			g.drawTextWithEffects(screen, seg.Text, x, line.Y, line.Color)
-			textWidth, _ := g.textRenderer.MeasureText(seg.Text)
-			x += textWidth
+		case run.seg.IsWidget && run.seg.Widget != nil:
+			g.drawInlineWidget(screen, run.seg.Widget, run.x, run.y, run.color)
+		case run.seg.IsImage && run.seg.Image != nil:
+			g.drawImageMarker(screen, run.seg.Image, run.x, run.y)
+		case run.seg.Text != "":
+			g.applyFont(run.font)
+			g.drawTextWithEffects(screen, run.seg.Text, run.x, run.y, run.color)
 		}
 	}
 }
@@ -418,7 +455,8 @@ func (g *Game) drawGraphWidget(screen *ebiten.Image, x, y, width, height, value
 }
 
 // drawGraphWidgetWithHistory renders a graph widget using LineGraph for historical data.
-// If the marker has an ID, it maintains a historical time-series. Otherwise falls back
+// If the marker has an ID, it draws the samples recorded for it by the history source,
+// or otherwise maintains a historical time-series itself. Without an ID it falls back
 // to simple single-value rendering.
 func (g *Game) drawGraphWidgetWithHistory(screen *ebiten.Image, x, y float64, marker *WidgetMarker, clr color.RGBA) {
 	// If no ID, fall back to simple graph rendering
@@ -450,8 +488,17 @@ func (g *Game) drawGraphWidgetWithHistory(screen *ebiten.Image, x, y float64, ma
 	lg.SetPosition(x, y)
 	lg.SetSize(marker.Width, marker.Height)
 
-	// Add the new data point
-	lg.AddPoint(marker.Value)
+	// Draw the shared history if recorded, otherwise add the new data point
+	if values, scale, ok := g.graphHistory(marker.ID, lg.MaxPoints()); ok {
+		lg.SetData(values)
+		if scale > 0 {
+			lg.SetRange(0, scale)
+		} else {
+			lg.SetAutoScale(true)
+		}
+	} else {
+		lg.AddPoint(marker.Value)
+	}
 
 	// Apply style from the color
 	style := GraphStyle{
@@ -471,6 +518,15 @@ func (g *Game) drawGraphWidgetWithHistory(screen *ebiten.Image, x, y float64, ma
 	vector.StrokeRect(screen, float32(x), float32(y), float32(marker.Width), float32(marker.Height), 1, borderColor, false)
 }
 
+// graphHistory returns the recorded history for a graph ID, if the game has
+// a history source that records it.
+func (g *Game) graphHistory(id string, n int) ([]float64, float64, bool) {
+	if g.historySource == nil {
+		return nil, 0, false
+	}
+	return g.historySource.GraphHistory(id, n)
+}
+
 // drawGaugeWidget renders a circular gauge widget.
 // The gauge is drawn as a 270-degree arc centered within the bounding box.
 func (g *Game) drawGaugeWidget(screen *ebiten.Image, x, y, width, height, value float64, clr color.RGBA) {
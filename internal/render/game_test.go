@@ -259,6 +259,60 @@ func TestGameUpdate(t *testing.T) {
 	}
 }
 
+// mockLineProvider implements LineProvider for testing
+type mockLineProvider struct {
+	calls int
+	lines []TextLine
+}
+
+func (m *mockLineProvider) Lines() []TextLine {
+	m.calls++
+	return m.lines
+}
+
+func TestGameUpdateRefreshesLines(t *testing.T) {
+	config := DefaultConfig()
+	config.UpdateInterval = 0
+	renderer := newMockTextRenderer()
+	game := NewGameWithRenderer(config, renderer)
+	game.SetLines([]TextLine{{Text: "${cpu}"}})
+
+	provider := &mockLineProvider{lines: []TextLine{{Text: "42"}}}
+	game.SetLineProvider(provider)
+
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if provider.calls != 1 {
+		t.Errorf("Lines() called %d times, want 1", provider.calls)
+	}
+
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+	if len(game.lines) != 1 || game.lines[0].Text != "42" {
+		t.Errorf("lines = %+v, want evaluated line", game.lines)
+	}
+}
+
+func TestGameUpdateLineProviderRespectsInterval(t *testing.T) {
+	config := DefaultConfig()
+	config.UpdateInterval = time.Hour
+	renderer := newMockTextRenderer()
+	game := NewGameWithRenderer(config, renderer)
+
+	provider := &mockLineProvider{}
+	game.SetLineProvider(provider)
+
+	if err := game.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if provider.calls != 0 {
+		t.Errorf("Lines() called %d times before interval elapsed, want 0", provider.calls)
+	}
+}
+
 func TestGameUpdateWithNoProvider(t *testing.T) {
 	renderer := newMockTextRenderer()
 	game := NewGameWithRenderer(DefaultConfig(), renderer)
@@ -441,7 +495,7 @@ func TestDrawLineWithWidgets(t *testing.T) {
 		}
 		// This should use the text renderer once
 		mockRenderer.drawTextCalls = 0
-		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line)
+		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line, &lineStyle{})
 		if mockRenderer.drawTextCalls != 1 {
 			t.Errorf("expected 1 DrawText call, got %d", mockRenderer.drawTextCalls)
 		}
@@ -459,7 +513,7 @@ func TestDrawLineWithWidgets(t *testing.T) {
 		}
 		mockRenderer.drawTextCalls = 0
 		mockRenderer.measureTextCalls = 0
-		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line)
+		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line, &lineStyle{})
 		// Should draw "CPU: " and " done" as text (2 calls)
 		if mockRenderer.drawTextCalls != 2 {
 			t.Errorf("expected 2 DrawText calls, got %d", mockRenderer.drawTextCalls)
@@ -476,7 +530,7 @@ func TestDrawLineWithWidgets(t *testing.T) {
 			Color: color.RGBA{R: 100, G: 200, B: 100, A: 255},
 		}
 		mockRenderer.drawTextCalls = 0
-		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line)
+		game.drawLineWithWidgets(ebiten.NewImage(400, 300), line, &lineStyle{})
 		// No text to draw
 		if mockRenderer.drawTextCalls != 0 {
 			t.Errorf("expected 0 DrawText calls for widget-only line, got %d", mockRenderer.drawTextCalls)
@@ -818,7 +872,7 @@ func TestDrawLineWithWidgetsUsesEffects(t *testing.T) {
 	defer screen.Deallocate()
 
 	line := TextLine{Text: "Hello", X: 10, Y: 20, Color: color.RGBA{R: 255, G: 255, B: 255, A: 255}}
-	game.drawLineWithWidgets(screen, line)
+	game.drawLineWithWidgets(screen, line, &lineStyle{})
 
 	// Should have called DrawText twice (shade + main)
 	renderer.mu.RLock()
@@ -1063,3 +1117,46 @@ func TestGameDrawWithARGBAlpha(t *testing.T) {
 		})
 	}
 }
+
+// fakeHistorySource returns fixed samples for the "cpu.total" ID.
+type fakeHistorySource struct {
+	requested int
+}
+
+func (f *fakeHistorySource) GraphHistory(id string, n int) ([]float64, float64, bool) {
+	if id != "cpu.total" {
+		return nil, 0, false
+	}
+	f.requested = n
+	return []float64{10, 20, 30}, 100, true
+}
+
+func TestDrawGraphWidgetWithHistorySource(t *testing.T) {
+	game := NewGameWithRenderer(DefaultConfig(), newMockTextRenderer())
+	source := &fakeHistorySource{}
+	game.SetHistorySource(source)
+	screen := ebiten.NewImage(400, 300)
+	clr := color.RGBA{R: 200, G: 100, B: 100, A: 255}
+
+	// Recorded IDs draw the shared samples on every frame
+	for i := 0; i < 3; i++ {
+		marker := &WidgetMarker{Type: WidgetTypeGraph, Value: 75, Width: 100, Height: 20, ID: "cpu.total"}
+		game.drawGraphWidgetWithHistory(screen, 10, 10, marker, clr)
+	}
+	lg := game.graphHistories["cpu.total"]
+	if got := len(lg.data); got != 3 {
+		t.Errorf("graph has %d points, want the 3 recorded samples", got)
+	}
+	if source.requested != lg.MaxPoints() {
+		t.Errorf("requested %d samples, want %d", source.requested, lg.MaxPoints())
+	}
+
+	// Unrecorded IDs keep a history of their own
+	for i := 0; i < 2; i++ {
+		marker := &WidgetMarker{Type: WidgetTypeGraph, Value: 50, Width: 100, Height: 20, ID: "net.unknown.down"}
+		game.drawGraphWidgetWithHistory(screen, 10, 40, marker, clr)
+	}
+	if got := len(game.graphHistories["net.unknown.down"].data); got != 2 {
+		t.Errorf("fallback graph has %d points, want 2", got)
+	}
+}
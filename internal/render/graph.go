@@ -110,6 +110,13 @@ func (lg *LineGraph) SetMaxPoints(n int) {
 	}
 }
 
+// MaxPoints returns the maximum number of data points displayed.
+func (lg *LineGraph) MaxPoints() int {
+	lg.mu.RLock()
+	defer lg.mu.RUnlock()
+	return lg.maxPoints
+}
+
 // SetRange sets the minimum and maximum values for the Y axis.
 // This disables auto-scaling. If maxVal <= minVal, the values are swapped
 // to ensure a valid range.
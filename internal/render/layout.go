@@ -0,0 +1,256 @@
+// Package render provides Ebiten-based rendering capabilities for conky-go.
+// This file implements the inline layout engine that turns a line containing
+// layout markers into positioned, styled runs.
+package render
+
+import (
+	"image/color"
+	"math"
+)
+
+// fontSelector is implemented by text renderers that can switch font family
+// and style, such as TextRenderer. Renderers without it only honour size changes.
+type fontSelector interface {
+	SetFont(family string, style FontStyle)
+	FontFamily() string
+	FontStyle() FontStyle
+}
+
+// runFont is the font used to draw a single run.
+type runFont struct {
+	family string
+	style  FontStyle
+	size   float64
+}
+
+// layoutRun is a positioned piece of line content drawn with a single style.
+type layoutRun struct {
+	// seg is the text, widget or image content of the run.
+	seg WidgetSegment
+	// color is the color the run is drawn with.
+	color color.RGBA
+	// font is the font the run is drawn with.
+	font runFont
+	// x and y are the absolute position of the run.
+	x, y float64
+	// width is the horizontal space the run occupies.
+	width float64
+}
+
+// lineAlign is the alignment of a section of a line.
+type lineAlign int
+
+const (
+	alignLeft lineAlign = iota
+	alignRight
+	alignCenter
+)
+
+// currentFont returns the text renderer's active font.
+func (g *Game) currentFont() runFont {
+	f := runFont{size: g.textRenderer.FontSize()}
+	if fs, ok := g.textRenderer.(fontSelector); ok {
+		f.family = fs.FontFamily()
+		f.style = fs.FontStyle()
+	}
+	return f
+}
+
+// applyFont switches the text renderer to f.
+func (g *Game) applyFont(f runFont) {
+	if fs, ok := g.textRenderer.(fontSelector); ok {
+		fs.SetFont(f.family, f.style)
+	}
+	g.textRenderer.SetFontSize(f.size)
+}
+
+// lineStyle is the color, font and vertical offset that ${color}, ${font}
+// and ${voffset} carry over from one line to the lines that follow it.
+// The zero value is the line's own color with the renderer's font.
+type lineStyle struct {
+	color    color.RGBA
+	hasColor bool // color replaces the line color
+	font     runFont
+	hasFont  bool // font replaces the renderer's font
+	yOffset  float64
+}
+
+// isDefault reports whether the style leaves lines unchanged.
+func (s *lineStyle) isDefault() bool {
+	return !s.hasColor && !s.hasFont && s.yOffset == 0
+}
+
+// layoutLine splits a line into styled runs and positions them.
+// Text before an ${alignr} or ${alignc} marker flows left to right from the
+// line origin; content after it is right-aligned or centered as a block
+// until the next alignment or ${goto} marker. The line starts with style,
+// which is updated with the line's color, font and vertical offset changes
+// for the next line.
+func (g *Game) layoutLine(line TextLine, style *lineStyle) []layoutRun {
+	base := g.currentFont()
+	defer g.applyFont(base)
+
+	clr := line.Color
+	if style.hasColor {
+		clr = style.color
+	}
+	font := base
+	if style.hasFont {
+		font = style.font
+		g.applyFont(font)
+	}
+	yOffset := style.yOffset
+
+	var runs []layoutRun
+	sectionStart := 0
+	align := alignLeft
+	originX := line.X
+	cursor := 0.0 // position relative to the start of the current section
+
+	// flush converts the current section's relative positions to absolute ones.
+	flush := func() {
+		var start float64
+		switch align {
+		case alignRight:
+			start = float64(g.config.Width) - line.X - cursor
+		case alignCenter:
+			start = (float64(g.config.Width) - cursor) / 2
+		default:
+			start = originX
+		}
+		for i := sectionStart; i < len(runs); i++ {
+			runs[i].x += start
+		}
+		sectionStart = len(runs)
+		cursor = 0
+	}
+
+	for _, seg := range ParseWidgetSegments(line.Text) {
+		if !seg.IsLayout {
+			width := g.segmentWidth(seg)
+			runs = append(runs, layoutRun{
+				seg:   seg,
+				color: clr,
+				font:  font,
+				x:     cursor,
+				y:     line.Y + yOffset,
+				width: width,
+			})
+			cursor += width
+			continue
+		}
+
+		marker := seg.Layout
+		switch marker.Type {
+		case LayoutTypeColor:
+			clr = line.Color
+			style.hasColor = false
+			if marker.Arg != "" {
+				if parsed, err := ParseColor(marker.Arg); err == nil {
+					clr = parsed
+					style.hasColor = true
+				}
+			}
+		case LayoutTypeColorIndex:
+			clr = g.paletteColor(int(marker.Value), line.Color)
+			style.hasColor = true
+		case LayoutTypeFont:
+			font = base
+			style.hasFont = marker.Arg != ""
+			if marker.Arg != "" {
+				spec := ParseFontSpec(marker.Arg)
+				if spec.Family != "" {
+					font.family = spec.Family
+				}
+				if spec.Size > 0 {
+					font.size = spec.Size
+				}
+				font.style = spec.Style
+			}
+			g.applyFont(font)
+		case LayoutTypeAlignR:
+			flush()
+			align = alignRight
+		case LayoutTypeAlignC:
+			flush()
+			align = alignCenter
+		case LayoutTypeGoto:
+			flush()
+			align = alignLeft
+			originX = line.X + marker.Value
+		case LayoutTypeOffset:
+			cursor += marker.Value
+		case LayoutTypeVOffset:
+			yOffset += marker.Value
+		case LayoutTypeTab:
+			step := marker.Value
+			if step <= 0 {
+				step = defaultTabWidth
+			}
+			if align == alignLeft {
+				pos := originX + cursor - line.X
+				cursor += (math.Floor(pos/step)+1)*step - pos
+			} else {
+				cursor += step
+			}
+		}
+	}
+	flush()
+
+	style.color = clr
+	style.font = font
+	style.yOffset = yOffset
+	return runs
+}
+
+// paletteColor returns the colorN palette entry, or fallback if it is unset.
+func (g *Game) paletteColor(index int, fallback color.RGBA) color.RGBA {
+	if index < 0 || index >= len(g.config.Colors) {
+		return fallback
+	}
+	if clr := g.config.Colors[index]; clr != (color.RGBA{}) {
+		return clr
+	}
+	return fallback
+}
+
+// segmentWidth returns the horizontal space a content segment occupies
+// with the text renderer's current font.
+func (g *Game) segmentWidth(seg WidgetSegment) float64 {
+	switch {
+	case seg.IsWidget && seg.Widget != nil:
+		return seg.Widget.Width
+	case seg.IsImage && seg.Image != nil:
+		return g.inlineImageWidth(seg.Image)
+	default:
+		width, _ := g.textRenderer.MeasureText(seg.Text)
+		return width
+	}
+}
+
+// inlineImageWidth returns the width an image marker advances the line by.
+// Absolutely positioned images do not advance the line.
+func (g *Game) inlineImageWidth(marker *ImageMarker) float64 {
+	if marker.X >= 0 {
+		return 0
+	}
+	if marker.Width > 0 {
+		return marker.Width
+	}
+	if marker.Path == "" {
+		return 0
+	}
+	if marker.NoCache {
+		img, _, _, err := NewImageLoader().LoadFile(marker.Path)
+		if err != nil {
+			return 0
+		}
+		defer img.Deallocate()
+		return float64(img.Bounds().Dx())
+	}
+	img, err := g.imageCache.Load(marker.Path)
+	if err != nil {
+		return 0
+	}
+	return float64(img.Bounds().Dx())
+}
@@ -0,0 +1,229 @@
+// Package render provides Ebiten-based rendering capabilities for conky-go.
+// This file implements layout markers for inline text formatting directives
+// such as ${color}, ${font}, ${alignr} and ${goto}.
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LayoutType represents the kind of inline layout directive.
+type LayoutType int
+
+const (
+	// LayoutTypeColor changes the color of the following text.
+	// An empty Arg resets to the line's default color.
+	LayoutTypeColor LayoutType = iota
+	// LayoutTypeColorIndex switches to one of the color0-color9 palette entries.
+	LayoutTypeColorIndex
+	// LayoutTypeFont changes the font of the following text.
+	// An empty Arg resets to the default font.
+	LayoutTypeFont
+	// LayoutTypeAlignR right-aligns the rest of the line.
+	LayoutTypeAlignR
+	// LayoutTypeAlignC centers the rest of the line.
+	LayoutTypeAlignC
+	// LayoutTypeOffset moves the following content horizontally by Value pixels.
+	LayoutTypeOffset
+	// LayoutTypeVOffset moves the following content vertically by Value pixels.
+	LayoutTypeVOffset
+	// LayoutTypeGoto moves the following content to the absolute X position Value.
+	LayoutTypeGoto
+	// LayoutTypeTab advances to the next tab stop, Value pixels apart.
+	LayoutTypeTab
+)
+
+// layoutTypeNames maps layout types to their encoded names.
+var layoutTypeNames = map[LayoutType]string{
+	LayoutTypeColor:      "color",
+	LayoutTypeColorIndex: "colorn",
+	LayoutTypeFont:       "font",
+	LayoutTypeAlignR:     "alignr",
+	LayoutTypeAlignC:     "alignc",
+	LayoutTypeOffset:     "offset",
+	LayoutTypeVOffset:    "voffset",
+	LayoutTypeGoto:       "goto",
+	LayoutTypeTab:        "tab",
+}
+
+// String returns the string representation of the layout type.
+func (lt LayoutType) String() string {
+	if name, ok := layoutTypeNames[lt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// defaultTabWidth is the tab stop spacing in pixels used when ${tab} has no width.
+const defaultTabWidth = 10.0
+
+// LayoutMarker encodes an inline formatting directive.
+// It is embedded in text content and applied by the rendering layer
+// when laying out a line as styled runs.
+type LayoutMarker struct {
+	// Type is the kind of layout directive.
+	Type LayoutType
+	// Value is the numeric argument: pixels for offset, voffset, goto and tab,
+	// or the palette index for LayoutTypeColorIndex.
+	Value float64
+	// Arg is the text argument: a color specification for LayoutTypeColor or
+	// a font specification (e.g. "DejaVu Sans:size=10") for LayoutTypeFont.
+	Arg string
+}
+
+// layoutMarkerPrefix delimits layout markers in text.
+const layoutMarkerPrefix = "\x00LAY:"
+
+// Encode returns the string representation of the layout marker.
+// Format: \x00LAY:type:value:arg\x00
+func (lm LayoutMarker) Encode() string {
+	return fmt.Sprintf("%s%s:%g:%s%s",
+		layoutMarkerPrefix,
+		lm.Type.String(),
+		lm.Value,
+		lm.Arg,
+		markerSuffix,
+	)
+}
+
+// DecodeLayoutMarker parses a layout marker string.
+// Returns nil if the string is not a valid layout marker.
+func DecodeLayoutMarker(s string) *LayoutMarker {
+	if !strings.HasPrefix(s, layoutMarkerPrefix) || !strings.HasSuffix(s, markerSuffix) {
+		return nil
+	}
+
+	// Arg is last so it may contain colons (font specifications do)
+	content := s[len(layoutMarkerPrefix) : len(s)-len(markerSuffix)]
+	parts := strings.SplitN(content, ":", 3)
+	if len(parts) != 3 {
+		return nil
+	}
+
+	lType := LayoutType(-1)
+	for t, name := range layoutTypeNames {
+		if name == parts[0] {
+			lType = t
+			break
+		}
+	}
+	if lType < 0 {
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil
+	}
+
+	return &LayoutMarker{
+		Type:  lType,
+		Value: value,
+		Arg:   parts[2],
+	}
+}
+
+// ContainsLayoutMarker checks if a string contains any layout markers.
+func ContainsLayoutMarker(s string) bool {
+	return strings.Contains(s, layoutMarkerPrefix)
+}
+
+// EncodeColorMarker creates a layout marker that switches to the given color.
+// An empty spec resets to the line's default color.
+func EncodeColorMarker(spec string) string {
+	return LayoutMarker{Type: LayoutTypeColor, Arg: spec}.Encode()
+}
+
+// EncodeColorIndexMarker creates a layout marker for the colorN palette entry.
+func EncodeColorIndexMarker(index int) string {
+	return LayoutMarker{Type: LayoutTypeColorIndex, Value: float64(index)}.Encode()
+}
+
+// EncodeFontMarker creates a layout marker that switches to the given font.
+// An empty spec resets to the default font.
+func EncodeFontMarker(spec string) string {
+	return LayoutMarker{Type: LayoutTypeFont, Arg: spec}.Encode()
+}
+
+// EncodeAlignRMarker creates a layout marker that right-aligns the rest of the line.
+func EncodeAlignRMarker() string {
+	return LayoutMarker{Type: LayoutTypeAlignR}.Encode()
+}
+
+// EncodeAlignCMarker creates a layout marker that centers the rest of the line.
+func EncodeAlignCMarker() string {
+	return LayoutMarker{Type: LayoutTypeAlignC}.Encode()
+}
+
+// EncodeOffsetMarker creates a layout marker for a horizontal offset in pixels.
+func EncodeOffsetMarker(pixels float64) string {
+	return LayoutMarker{Type: LayoutTypeOffset, Value: pixels}.Encode()
+}
+
+// EncodeVOffsetMarker creates a layout marker for a vertical offset in pixels.
+func EncodeVOffsetMarker(pixels float64) string {
+	return LayoutMarker{Type: LayoutTypeVOffset, Value: pixels}.Encode()
+}
+
+// EncodeGotoMarker creates a layout marker that moves to absolute X position x.
+func EncodeGotoMarker(x float64) string {
+	return LayoutMarker{Type: LayoutTypeGoto, Value: x}.Encode()
+}
+
+// EncodeTabMarker creates a layout marker that advances to the next tab stop.
+// A non-positive width uses the default tab width.
+func EncodeTabMarker(width float64) string {
+	if width <= 0 {
+		width = defaultTabWidth
+	}
+	return LayoutMarker{Type: LayoutTypeTab, Value: width}.Encode()
+}
+
+// FontSpec describes a font selected with ${font}.
+type FontSpec struct {
+	// Family is the font family name. Empty keeps the current family.
+	Family string
+	// Style is the font style variation.
+	Style FontStyle
+	// Size is the font size in points. Zero keeps the current size.
+	Size float64
+}
+
+// ParseFontSpec parses a Conky/fontconfig-style font specification such as
+// "DejaVu Sans Mono:size=10", "Ubuntu:bold:size=12" or ":pixelsize=14".
+// Unrecognized attributes are ignored.
+func ParseFontSpec(spec string) FontSpec {
+	parts := strings.Split(spec, ":")
+	fs := FontSpec{Family: strings.TrimSpace(parts[0])}
+
+	bold, italic := false, false
+	for _, part := range parts[1:] {
+		part = strings.ToLower(strings.TrimSpace(part))
+		key, value, hasValue := strings.Cut(part, "=")
+		switch {
+		case hasValue && (key == "size" || key == "pixelsize"):
+			if size, err := strconv.ParseFloat(value, 64); err == nil && size > 0 {
+				fs.Size = size
+			}
+		case hasValue && key == "style":
+			bold = bold || strings.Contains(value, "bold")
+			italic = italic || strings.Contains(value, "italic") || strings.Contains(value, "oblique")
+		case part == "bold":
+			bold = true
+		case part == "italic", part == "oblique":
+			italic = true
+		}
+	}
+
+	switch {
+	case bold && italic:
+		fs.Style = FontStyleBoldItalic
+	case bold:
+		fs.Style = FontStyleBold
+	case italic:
+		fs.Style = FontStyleItalic
+	}
+	return fs
+}
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestLayoutTypeString(t *testing.T) {
+	tests := []struct {
+		lType    LayoutType
+		expected string
+	}{
+		{LayoutTypeColor, "color"},
+		{LayoutTypeColorIndex, "colorn"},
+		{LayoutTypeFont, "font"},
+		{LayoutTypeAlignR, "alignr"},
+		{LayoutTypeAlignC, "alignc"},
+		{LayoutTypeOffset, "offset"},
+		{LayoutTypeVOffset, "voffset"},
+		{LayoutTypeGoto, "goto"},
+		{LayoutTypeTab, "tab"},
+		{LayoutType(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := tt.lType.String(); got != tt.expected {
+				t.Errorf("LayoutType.String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLayoutMarkerRoundTrip(t *testing.T) {
+	markers := []LayoutMarker{
+		{Type: LayoutTypeColor},
+		{Type: LayoutTypeColor, Arg: "#ff8800"},
+		{Type: LayoutTypeColorIndex, Value: 3},
+		{Type: LayoutTypeFont, Arg: "DejaVu Sans Mono:bold:size=10"},
+		{Type: LayoutTypeAlignR},
+		{Type: LayoutTypeAlignC},
+		{Type: LayoutTypeOffset, Value: -12.5},
+		{Type: LayoutTypeVOffset, Value: 4},
+		{Type: LayoutTypeGoto, Value: 150},
+		{Type: LayoutTypeTab, Value: 40},
+	}
+
+	for _, m := range markers {
+		t.Run(m.Type.String(), func(t *testing.T) {
+			decoded := DecodeLayoutMarker(m.Encode())
+			if decoded == nil {
+				t.Fatalf("DecodeLayoutMarker(%q) returned nil", m.Encode())
+			}
+			if *decoded != m {
+				t.Errorf("round trip = %+v, want %+v", *decoded, m)
+			}
+		})
+	}
+}
+
+func TestDecodeLayoutMarkerInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"plain text", "hello"},
+		{"widget marker", EncodeBarMarker(50, 100, 8)},
+		{"unknown type", "\x00LAY:blink:0:\x00"},
+		{"bad value", "\x00LAY:goto:abc:\x00"},
+		{"missing parts", "\x00LAY:goto\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeLayoutMarker(tt.input); got != nil {
+				t.Errorf("DecodeLayoutMarker(%q) = %+v, want nil", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestEncodeTabMarkerDefaultWidth(t *testing.T) {
+	decoded := DecodeLayoutMarker(EncodeTabMarker(0))
+	if decoded == nil || decoded.Value != defaultTabWidth {
+		t.Errorf("EncodeTabMarker(0) decoded = %+v, want width %v", decoded, defaultTabWidth)
+	}
+}
+
+func TestParseWidgetSegmentsWithLayout(t *testing.T) {
+	input := "CPU:" + EncodeAlignRMarker() + EncodeColorMarker("red") + "42%" + EncodeBarMarker(42, 50, 6)
+	segments := ParseWidgetSegments(input)
+
+	if len(segments) != 5 {
+		t.Fatalf("got %d segments, want 5: %+v", len(segments), segments)
+	}
+	if segments[0].Text != "CPU:" {
+		t.Errorf("segments[0].Text = %q, want %q", segments[0].Text, "CPU:")
+	}
+	if !segments[1].IsLayout || segments[1].Layout.Type != LayoutTypeAlignR {
+		t.Errorf("segments[1] = %+v, want alignr layout marker", segments[1])
+	}
+	if !segments[2].IsLayout || segments[2].Layout.Arg != "red" {
+		t.Errorf("segments[2] = %+v, want color layout marker", segments[2])
+	}
+	if segments[3].Text != "42%" {
+		t.Errorf("segments[3].Text = %q, want %q", segments[3].Text, "42%")
+	}
+	if !segments[4].IsWidget {
+		t.Errorf("segments[4] = %+v, want widget marker", segments[4])
+	}
+}
+
+func TestParseFontSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected FontSpec
+	}{
+		{"", FontSpec{}},
+		{"GoMono", FontSpec{Family: "GoMono"}},
+		{"DejaVu Sans Mono:size=10", FontSpec{Family: "DejaVu Sans Mono", Size: 10}},
+		{":pixelsize=14", FontSpec{Size: 14}},
+		{"Ubuntu:bold:size=12", FontSpec{Family: "Ubuntu", Style: FontStyleBold, Size: 12}},
+		{"Ubuntu:italic", FontSpec{Family: "Ubuntu", Style: FontStyleItalic}},
+		{"Ubuntu:style=Bold Italic:size=9", FontSpec{Family: "Ubuntu", Style: FontStyleBoldItalic, Size: 9}},
+		{"Ubuntu:size=abc", FontSpec{Family: "Ubuntu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if got := ParseFontSpec(tt.spec); got != tt.expected {
+				t.Errorf("ParseFontSpec(%q) = %+v, want %+v", tt.spec, got, tt.expected)
+			}
+		})
+	}
+}
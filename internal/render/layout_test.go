@@ -0,0 +1,256 @@
+//go:build !noebiten
+
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// drawCall records a single DrawText invocation.
+type drawCall struct {
+	text   string
+	x, y   float64
+	color  color.RGBA
+	family string
+	size   float64
+}
+
+// recordingTextRenderer is a fixed-width text renderer that records draw calls
+// and supports font switching.
+type recordingTextRenderer struct {
+	calls  []drawCall
+	family string
+	style  FontStyle
+	size   float64
+}
+
+func newRecordingTextRenderer() *recordingTextRenderer {
+	return &recordingTextRenderer{family: "GoMono", size: 10}
+}
+
+func (r *recordingTextRenderer) DrawText(_ *ebiten.Image, textStr string, x, y float64, clr color.RGBA) {
+	r.calls = append(r.calls, drawCall{text: textStr, x: x, y: y, color: clr, family: r.family, size: r.size})
+}
+
+// MeasureText returns one font-size unit of width per byte.
+func (r *recordingTextRenderer) MeasureText(textStr string) (width, height float64) {
+	return float64(len(textStr)) * r.size, r.size
+}
+
+func (r *recordingTextRenderer) LineHeight() float64      { return r.size * 1.2 }
+func (r *recordingTextRenderer) SetFontSize(size float64) { r.size = size }
+func (r *recordingTextRenderer) FontSize() float64        { return r.size }
+func (r *recordingTextRenderer) FontFamily() string       { return r.family }
+func (r *recordingTextRenderer) FontStyle() FontStyle     { return r.style }
+
+func (r *recordingTextRenderer) SetFont(family string, style FontStyle) {
+	r.family = family
+	r.style = style
+}
+
+func newLayoutTestGame(width int) (*Game, *recordingTextRenderer) {
+	config := DefaultConfig()
+	config.Width = width
+	renderer := newRecordingTextRenderer()
+	return NewGameWithRenderer(config, renderer), renderer
+}
+
+func TestLayoutLineAlignR(t *testing.T) {
+	game, _ := newLayoutTestGame(200)
+	line := TextLine{Text: "CPU:" + EncodeAlignRMarker() + "42%", X: 10, Y: 20}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[0].x != 10 {
+		t.Errorf("left run x = %v, want 10", runs[0].x)
+	}
+	// Right edge mirrors the left margin: 200 - 10 - 3*10
+	if runs[1].x != 160 {
+		t.Errorf("right-aligned run x = %v, want 160", runs[1].x)
+	}
+}
+
+func TestLayoutLineAlignC(t *testing.T) {
+	game, _ := newLayoutTestGame(200)
+	line := TextLine{Text: EncodeAlignCMarker() + "abcd", X: 10, Y: 20}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	if len(runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(runs))
+	}
+	if runs[0].x != 80 {
+		t.Errorf("centered run x = %v, want 80", runs[0].x)
+	}
+}
+
+func TestLayoutLineGotoOffsetVOffset(t *testing.T) {
+	game, _ := newLayoutTestGame(400)
+	line := TextLine{
+		Text: "a" + EncodeGotoMarker(100) + "b" + EncodeOffsetMarker(5) + "c" + EncodeVOffsetMarker(3) + "d",
+		X:    10,
+		Y:    20,
+	}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	if len(runs) != 4 {
+		t.Fatalf("got %d runs, want 4", len(runs))
+	}
+
+	wantX := []float64{10, 110, 125, 135}
+	wantY := []float64{20, 20, 20, 23}
+	for i, run := range runs {
+		if run.x != wantX[i] || run.y != wantY[i] {
+			t.Errorf("run %d (%q) at (%v, %v), want (%v, %v)", i, run.seg.Text, run.x, run.y, wantX[i], wantY[i])
+		}
+	}
+}
+
+func TestLayoutLineTab(t *testing.T) {
+	game, _ := newLayoutTestGame(400)
+	line := TextLine{Text: "abc" + EncodeTabMarker(40) + "d", X: 10, Y: 20}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[1].x != 50 {
+		t.Errorf("tabbed run x = %v, want 50", runs[1].x)
+	}
+}
+
+func TestLayoutLineColors(t *testing.T) {
+	game, _ := newLayoutTestGame(400)
+	palette := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	game.config.Colors[2] = palette
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	line := TextLine{
+		Text: "a" + EncodeColorMarker("red") + "b" + EncodeColorIndexMarker(2) + "c" +
+			EncodeColorIndexMarker(5) + "d" + EncodeColorMarker("") + "e" + EncodeColorMarker("notacolor") + "f",
+		X:     10,
+		Y:     20,
+		Color: white,
+	}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	want := []color.RGBA{white, NamedColors["red"], palette, white, white, white}
+	if len(runs) != len(want) {
+		t.Fatalf("got %d runs, want %d", len(runs), len(want))
+	}
+	for i, run := range runs {
+		if run.color != want[i] {
+			t.Errorf("run %d (%q) color = %v, want %v", i, run.seg.Text, run.color, want[i])
+		}
+	}
+}
+
+func TestLayoutLineFont(t *testing.T) {
+	game, renderer := newLayoutTestGame(400)
+	line := TextLine{
+		Text: "a" + EncodeFontMarker("Ubuntu:bold:size=20") + "bb" + EncodeFontMarker(":size=5") + "c" + EncodeFontMarker("") + "d",
+		X:    0,
+		Y:    20,
+	}
+
+	runs := game.layoutLine(line, &lineStyle{})
+	if len(runs) != 4 {
+		t.Fatalf("got %d runs, want 4", len(runs))
+	}
+
+	if runs[1].font.family != "Ubuntu" || runs[1].font.style != FontStyleBold || runs[1].font.size != 20 {
+		t.Errorf("run 1 font = %+v, want Ubuntu bold 20", runs[1].font)
+	}
+	if runs[2].font.family != "GoMono" || runs[2].font.size != 5 {
+		t.Errorf("run 2 font = %+v, want GoMono 5", runs[2].font)
+	}
+	if runs[3].font != runs[0].font {
+		t.Errorf("run 3 font = %+v, want reset to %+v", runs[3].font, runs[0].font)
+	}
+
+	// Runs are measured with their own font: "a"@10, "bb"@20, "c"@5
+	wantX := []float64{0, 10, 50, 55}
+	for i, run := range runs {
+		if run.x != wantX[i] {
+			t.Errorf("run %d x = %v, want %v", i, run.x, wantX[i])
+		}
+	}
+
+	// Layout must leave the renderer's font untouched
+	if renderer.family != "GoMono" || renderer.size != 10 {
+		t.Errorf("renderer font after layout = %s %v, want GoMono 10", renderer.family, renderer.size)
+	}
+}
+
+func TestLayoutLinesCarryStyle(t *testing.T) {
+	game, renderer := newLayoutTestGame(400)
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	lines := []TextLine{
+		{Text: "a" + EncodeColorMarker("red") + EncodeFontMarker(":size=20") + EncodeVOffsetMarker(5) + "b", Y: 20, Color: white},
+		{Text: "c", Y: 40, Color: white},
+		{Text: EncodeColorMarker("") + EncodeFontMarker("") + "d", Y: 60, Color: white},
+	}
+
+	var style lineStyle
+	var runs []layoutRun
+	for _, line := range lines {
+		runs = append(runs, game.layoutLine(line, &style)...)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("got %d runs, want 4", len(runs))
+	}
+
+	wantColor := []color.RGBA{white, NamedColors["red"], NamedColors["red"], white}
+	wantSize := []float64{10, 20, 20, 10}
+	wantY := []float64{20, 25, 45, 65}
+	for i, run := range runs {
+		if run.color != wantColor[i] || run.font.size != wantSize[i] || run.y != wantY[i] {
+			t.Errorf("run %d (%q) = %v size %v at y %v, want %v size %v at y %v",
+				i, run.seg.Text, run.color, run.font.size, run.y, wantColor[i], wantSize[i], wantY[i])
+		}
+	}
+
+	// A plain line after a style change is drawn with the carried style
+	game.SetLines(lines[:2])
+	game.Draw(ebiten.NewImage(400, 100))
+	last := renderer.calls[len(renderer.calls)-1]
+	if last.text != "c" || last.color != NamedColors["red"] || last.size != 20 || last.y != 45 {
+		t.Errorf("plain line drawn as %+v, want red size 20 at y 45", last)
+	}
+	if renderer.size != 10 {
+		t.Errorf("renderer size after draw = %v, want 10", renderer.size)
+	}
+}
+
+func TestDrawLineWithLayoutMarkers(t *testing.T) {
+	game, renderer := newLayoutTestGame(200)
+	screen := ebiten.NewImage(200, 100)
+	defer screen.Deallocate()
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	line := TextLine{
+		Text:  "CPU:" + EncodeAlignRMarker() + EncodeColorMarker("red") + EncodeFontMarker(":size=20") + "42%",
+		X:     10,
+		Y:     20,
+		Color: white,
+	}
+	game.drawLineWithWidgets(screen, line, &lineStyle{})
+
+	if len(renderer.calls) != 2 {
+		t.Fatalf("got %d DrawText calls, want 2", len(renderer.calls))
+	}
+	if got := renderer.calls[0]; got.text != "CPU:" || got.x != 10 || got.color != white || got.size != 10 {
+		t.Errorf("first call = %+v", got)
+	}
+	// 200 - 10 - 3*20
+	if got := renderer.calls[1]; got.text != "42%" || got.x != 130 || got.color != NamedColors["red"] || got.size != 20 {
+		t.Errorf("second call = %+v", got)
+	}
+	if renderer.size != 10 {
+		t.Errorf("renderer size after draw = %v, want 10", renderer.size)
+	}
+}
@@ -0,0 +1,143 @@
+// Package render provides Ebiten-based rendering capabilities for conky-go.
+// This file degrades widget, image and layout markers to plain text for
+// text-only outputs such as out_to_console.
+package render
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Plain text rendering settings.
+const (
+	// plainCharWidth is the number of pixels one console character stands
+	// for when converting widget widths and offsets to characters.
+	plainCharWidth = 10.0
+	// plainBarFill and plainBarUnfill draw the used and unused parts of bars,
+	// matching Conky's default console_bar_fill and console_bar_unfill.
+	plainBarFill   = "#"
+	plainBarUnfill = "."
+)
+
+// PlainText returns s with its markers degraded for text-only output.
+// Bars, graphs and gauges become ASCII bars such as "####......",
+// horizontal movement (${offset}, ${goto}, ${tab}) becomes spaces, and
+// color, font, alignment, vertical offset and image markers are dropped.
+func PlainText(s string) string {
+	if !ContainsWidgetMarker(s) && !ContainsImageMarker(s) && !ContainsLayoutMarker(s) {
+		return s
+	}
+
+	var sb strings.Builder
+	for _, run := range PlainRuns(s, color.RGBA{}, [10]color.RGBA{}) {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// PlainRun is a run of plain text shown in a single color.
+type PlainRun struct {
+	// Text is the plain text of the run.
+	Text string
+	// Color is the color selected by the last ${color} before the run.
+	Color color.RGBA
+}
+
+// PlainRuns degrades s like PlainText and splits the result into runs at
+// each color change, for text outputs that support color such as HTML.
+// Text starts in, and ${color} without an argument resets to, base.
+// ${colorN} selects palette entries, falling back to base when unset.
+func PlainRuns(s string, base color.RGBA, palette [10]color.RGBA) []PlainRun {
+	var runs []PlainRun
+	var sb strings.Builder
+	col := 0
+	clr := base
+
+	emit := func(text string) {
+		if text == "" {
+			return
+		}
+		sb.WriteString(text)
+		col += utf8.RuneCountInString(text)
+	}
+	flush := func() {
+		if sb.Len() > 0 {
+			runs = append(runs, PlainRun{Text: sb.String(), Color: clr})
+			sb.Reset()
+		}
+	}
+
+	for _, seg := range ParseWidgetSegments(s) {
+		switch {
+		case seg.IsWidget:
+			emit(plainBar(seg.Widget.Value, seg.Widget.Width))
+		case seg.IsImage:
+			// Images have no text representation
+		case seg.IsLayout && seg.Layout.Type == LayoutTypeColor:
+			flush()
+			clr = base
+			if seg.Layout.Arg != "" {
+				if parsed, err := ParseColor(seg.Layout.Arg); err == nil {
+					clr = parsed
+				}
+			}
+		case seg.IsLayout && seg.Layout.Type == LayoutTypeColorIndex:
+			flush()
+			clr = base
+			if index := int(seg.Layout.Value); index >= 0 && index < len(palette) && palette[index] != (color.RGBA{}) {
+				clr = palette[index]
+			}
+		case seg.IsLayout:
+			emit(plainLayout(seg.Layout, col))
+		default:
+			emit(seg.Text)
+		}
+	}
+	flush()
+	return runs
+}
+
+// plainBar renders a percentage as an ASCII bar as wide as width pixels.
+func plainBar(value, width float64) string {
+	cells := plainChars(width)
+	if cells < 1 {
+		cells = 1
+	}
+	filled := int(math.Round(clampPercent(value) / 100 * float64(cells)))
+	return strings.Repeat(plainBarFill, filled) + strings.Repeat(plainBarUnfill, cells-filled)
+}
+
+// plainLayout returns the spacing a layout marker produces at column col.
+func plainLayout(marker *LayoutMarker, col int) string {
+	switch marker.Type {
+	case LayoutTypeOffset:
+		return strings.Repeat(" ", max(plainChars(marker.Value), 0))
+	case LayoutTypeGoto:
+		return strings.Repeat(" ", max(plainChars(marker.Value)-col, 1))
+	case LayoutTypeTab:
+		step := marker.Value
+		if step <= 0 {
+			step = defaultTabWidth
+		}
+		stop := max(plainChars(step), 1)
+		return strings.Repeat(" ", stop-col%stop)
+	case LayoutTypeAlignR, LayoutTypeAlignC:
+		// Without a known line width, keep aligned text apart from the label
+		if col > 0 {
+			return " "
+		}
+	}
+	return ""
+}
+
+// plainChars converts a pixel distance to a whole number of characters.
+func plainChars(pixels float64) int {
+	return int(math.Round(pixels / plainCharWidth))
+}
+
+// clampPercent limits value to the 0-100 range.
+func clampPercent(value float64) float64 {
+	return math.Max(0, math.Min(100, value))
+}
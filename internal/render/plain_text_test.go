@@ -0,0 +1,73 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no markers", "CPU: 42%", "CPU: 42%"},
+		{"bar", "CPU " + EncodeBarMarker(40, 100, 8), "CPU ####......"},
+		{"empty bar", EncodeBarMarker(-5, 50, 8), "....."},
+		{"full bar", EncodeBarMarker(250, 50, 8), "#####"},
+		{"zero width bar", EncodeBarMarker(40, 0, 8), "."},
+		{"graph", EncodeGraphMarkerWithID(50, 40, 20, "cpu"), "##.."},
+		{"gauge", EncodeGaugeMarker(100, 30, 30), "###"},
+		{"image dropped", "a" + EncodeImageMarker("/tmp/x.png", 10, 10, -1, -1, false) + "b", "ab"},
+		{"color dropped", EncodeColorMarker("red") + "hot" + EncodeColorMarker(""), "hot"},
+		{"font dropped", EncodeFontMarker("Mono:size=12") + "big", "big"},
+		{"voffset dropped", EncodeVOffsetMarker(5) + "down", "down"},
+		{"offset", "a" + EncodeOffsetMarker(30) + "b", "a   b"},
+		{"goto", "ab" + EncodeGotoMarker(50) + "c", "ab   c"},
+		{"goto past column", "abcdef" + EncodeGotoMarker(20) + "g", "abcdef g"},
+		{"tab", "ab" + EncodeTabMarker(40) + "c", "ab  c"},
+		{"alignr", "CPU:" + EncodeAlignRMarker() + "42%", "CPU: 42%"},
+		{"alignr at start", EncodeAlignCMarker() + "title", "title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.input); got != tt.expected {
+				t.Errorf("PlainText() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlainRuns(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	palette := [10]color.RGBA{1: green}
+
+	input := "CPU " + EncodeColorMarker("red") + "hot" + EncodeOffsetMarker(20) +
+		EncodeColorIndexMarker(1) + EncodeBarMarker(50, 40, 6) +
+		EncodeColorIndexMarker(5) + "unset" + EncodeColorMarker("") + "reset"
+
+	want := []PlainRun{
+		{Text: "CPU ", Color: white},
+		{Text: "hot  ", Color: red},
+		{Text: "##..", Color: green},
+		{Text: "unset", Color: white},
+		{Text: "reset", Color: white},
+	}
+
+	got := PlainRuns(input, white, palette)
+	if len(got) != len(want) {
+		t.Fatalf("PlainRuns() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("run %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if runs := PlainRuns("", white, palette); len(runs) != 0 {
+		t.Errorf("PlainRuns(\"\") = %+v, want no runs", runs)
+	}
+}
@@ -73,6 +73,9 @@ type Config struct {
 	OutlineColor color.RGBA
 	// ShadeColor is the color for text shadows. If zero value, uses dark gray.
 	ShadeColor color.RGBA
+	// Colors is the color0-color9 palette selected with ${colorN}.
+	// Zero-value entries fall back to the line's default color.
+	Colors [10]color.RGBA
 }
 
 // DefaultConfig returns a Config with sensible default values.
@@ -138,3 +141,21 @@ type DataProvider interface {
 	// Update refreshes the system data.
 	Update() error
 }
+
+// LineProvider is an interface for supplying freshly evaluated text lines.
+// When set on a Game, Lines is called after every data update so that
+// template variables reflect the latest system data.
+type LineProvider interface {
+	// Lines returns the text lines to render for the current update.
+	Lines() []TextLine
+}
+
+// HistorySource supplies the recorded history of a metric. When set on a
+// Game, graph widgets with an ID draw these samples instead of keeping a
+// history of their own.
+type HistorySource interface {
+	// GraphHistory returns up to n of the most recent values recorded for
+	// id, oldest first, and the value drawn at full height (0 scales to
+	// the values). ok is false if nothing is recorded for id.
+	GraphHistory(id string, n int) (values []float64, scale float64, ok bool)
+}
@@ -50,8 +50,9 @@ type WidgetMarker struct {
 	Width float64
 	// Height is the widget height in pixels.
 	Height float64
-	// ID identifies the data source for historical tracking (e.g., "cpu", "mem", "net_eth0_down").
-	// Used by graph widgets to maintain separate time-series histories.
+	// ID identifies the data source for historical tracking (e.g., "cpu.total", "mem.used_perc", "net.eth0.down").
+	// Used by graph widgets to maintain separate time-series histories, or to
+	// read them from the Game's HistorySource.
 	ID string
 }
 
@@ -152,64 +153,56 @@ func ContainsWidgetMarker(s string) bool {
 	return strings.Contains(s, markerPrefix)
 }
 
-// WidgetSegment represents either a text segment, a widget marker, or an image marker.
+// WidgetSegment represents a text segment, a widget marker, an image marker
+// or a layout marker.
 type WidgetSegment struct {
 	// IsWidget is true if this segment is a widget marker.
 	IsWidget bool
 	// IsImage is true if this segment is an image marker.
 	IsImage bool
-	// Text contains the text content (if IsWidget and IsImage are false).
+	// IsLayout is true if this segment is a layout marker.
+	IsLayout bool
+	// Text contains the text content (if IsWidget, IsImage and IsLayout are false).
 	Text string
 	// Widget contains the widget marker (if IsWidget is true).
 	Widget *WidgetMarker
 	// Image contains the image marker (if IsImage is true).
 	Image *ImageMarker
+	// Layout contains the layout marker (if IsLayout is true).
+	Layout *LayoutMarker
 }
 
-// ParseWidgetSegments splits a string into text segments, widget markers, and image markers.
+// segmentPrefixes lists the marker prefixes recognized by ParseWidgetSegments.
+var segmentPrefixes = []string{markerPrefix, imageMarkerPrefix, layoutMarkerPrefix}
+
+// ParseWidgetSegments splits a string into text segments, widget markers,
+// image markers and layout markers.
 func ParseWidgetSegments(s string) []WidgetSegment {
-	if !ContainsWidgetMarker(s) && !ContainsImageMarker(s) {
-		return []WidgetSegment{{IsWidget: false, IsImage: false, Text: s}}
+	if !ContainsWidgetMarker(s) && !ContainsImageMarker(s) && !ContainsLayoutMarker(s) {
+		return []WidgetSegment{{Text: s}}
 	}
 
 	var segments []WidgetSegment
 	remaining := s
 
 	for remaining != "" {
-		// Find the next widget marker
-		widgetIdx := strings.Index(remaining, markerPrefix)
-		// Find the next image marker
-		imageIdx := strings.Index(remaining, imageMarkerPrefix)
-
-		// If no more markers, rest is text
-		if widgetIdx == -1 && imageIdx == -1 {
-			if remaining != "" {
-				segments = append(segments, WidgetSegment{IsWidget: false, IsImage: false, Text: remaining})
+		// Find whichever marker comes first
+		startIdx, prefix := -1, ""
+		for _, p := range segmentPrefixes {
+			if idx := strings.Index(remaining, p); idx != -1 && (startIdx == -1 || idx < startIdx) {
+				startIdx, prefix = idx, p
 			}
-			break
 		}
 
-		// Determine which marker comes first
-		var startIdx int
-		var isImageMarker bool
-		switch {
-		case widgetIdx == -1:
-			startIdx = imageIdx
-			isImageMarker = true
-		case imageIdx == -1:
-			startIdx = widgetIdx
-			isImageMarker = false
-		case imageIdx < widgetIdx:
-			startIdx = imageIdx
-			isImageMarker = true
-		default:
-			startIdx = widgetIdx
-			isImageMarker = false
+		// If no more markers, rest is text
+		if startIdx == -1 {
+			segments = append(segments, WidgetSegment{Text: remaining})
+			break
 		}
 
 		// Add text before the marker
 		if startIdx > 0 {
-			segments = append(segments, WidgetSegment{IsWidget: false, IsImage: false, Text: remaining[:startIdx]})
+			segments = append(segments, WidgetSegment{Text: remaining[:startIdx]})
 		}
 
 		// Find the end of the marker
@@ -217,30 +210,29 @@ func ParseWidgetSegments(s string) []WidgetSegment {
 		endIdx := strings.Index(remaining[1:], markerSuffix) // Skip first char to avoid matching prefix
 		if endIdx == -1 {
 			// Malformed marker, treat rest as text
-			segments = append(segments, WidgetSegment{IsWidget: false, IsImage: false, Text: remaining})
+			segments = append(segments, WidgetSegment{Text: remaining})
 			break
 		}
 		endIdx += 2 // Adjust for the skipped char and include the suffix
 
-		// Parse the marker
+		// Parse the marker, treating malformed markers as text
 		markerStr := remaining[:endIdx]
-		if isImageMarker {
-			imgMarker := DecodeImageMarker(markerStr)
-			if imgMarker != nil {
-				segments = append(segments, WidgetSegment{IsImage: true, Image: imgMarker})
-			} else {
-				// Malformed marker, treat as text
-				segments = append(segments, WidgetSegment{IsWidget: false, IsImage: false, Text: markerStr})
+		seg := WidgetSegment{Text: markerStr}
+		switch prefix {
+		case imageMarkerPrefix:
+			if imgMarker := DecodeImageMarker(markerStr); imgMarker != nil {
+				seg = WidgetSegment{IsImage: true, Image: imgMarker}
 			}
-		} else {
-			marker := DecodeWidgetMarker(markerStr)
-			if marker != nil {
-				segments = append(segments, WidgetSegment{IsWidget: true, Widget: marker})
-			} else {
-				// Malformed marker, treat as text
-				segments = append(segments, WidgetSegment{IsWidget: false, IsImage: false, Text: markerStr})
+		case layoutMarkerPrefix:
+			if layoutMarker := DecodeLayoutMarker(markerStr); layoutMarker != nil {
+				seg = WidgetSegment{IsLayout: true, Layout: layoutMarker}
+			}
+		default:
+			if marker := DecodeWidgetMarker(markerStr); marker != nil {
+				seg = WidgetSegment{IsWidget: true, Widget: marker}
 			}
 		}
+		segments = append(segments, seg)
 
 		remaining = remaining[endIdx:]
 	}
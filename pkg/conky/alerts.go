@@ -0,0 +1,91 @@
+package conky
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/alert"
+	"github.com/opd-ai/go-conky/internal/config"
+)
+
+// compileAlerts builds an alert engine from the configured rules. Every rule
+// reports to the event sink; rules with exec or notify set also start their
+// command through runner or post a desktop notification through notify.
+func compileAlerts(rules []config.AlertConfig, events alert.Sink, notify *alert.NotifySink, runner alert.CommandStarter) (*alert.Engine, error) {
+	compiled := make([]alert.Rule, 0, len(rules))
+	for _, rc := range rules {
+		cond, err := alert.ParseCondition(rc.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("alert %s: %w", rc.Name, err)
+		}
+		sinks := []alert.Sink{events}
+		if rc.Exec != "" {
+			sinks = append(sinks, &alert.ExecSink{Command: rc.Exec, Runner: runner})
+		}
+		if rc.Notify {
+			sinks = append(sinks, notify)
+		}
+		compiled = append(compiled, alert.Rule{
+			Name:       rc.Name,
+			Condition:  cond,
+			Duration:   rc.Duration,
+			Hysteresis: rc.Hysteresis,
+			Cooldown:   rc.Cooldown,
+			Message:    rc.Message,
+			Sinks:      sinks,
+		})
+	}
+	return alert.NewEngine(compiled...), nil
+}
+
+// loadAlerts compiles the alert rules of cfg, sending their alerts to the
+// event handler. Exec commands the policy does not allow are left out; the
+// others run through the Lua API's exec scheduler, so they share its
+// timeout and concurrency limit and are killed when the instance stops.
+// The Lua API must have been initialized.
+func (c *conkyImpl) loadAlerts(cfg *config.Config) (*alert.Engine, error) {
+	return compileAlerts(c.allowedAlerts(cfg), alert.SinkFunc(c.emitAlert), c.alertNotify, c.luaAPI.ExecScheduler())
+}
+
+// emitAlert emits an alert as an EventAlert or EventAlertResolved event.
+func (c *conkyImpl) emitAlert(a alert.Alert) error {
+	if a.Firing {
+		c.emitEvent(EventAlert, a.String())
+	} else {
+		c.emitEvent(EventAlertResolved, a.String())
+	}
+	return nil
+}
+
+// runAlerts evaluates the alert rules against the latest recorded metric
+// values every interval until the instance stops. Sink failures are
+// reported as warnings.
+func (c *conkyImpl) runAlerts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	values := func(id string) (float64, bool) {
+		samples := c.monitor.History(id, 1)
+		if len(samples) == 0 {
+			return 0, false
+		}
+		return samples[0].Value, true
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.mu.RLock()
+			engine := c.alerts
+			c.mu.RUnlock()
+			if engine == nil || engine.Len() == 0 {
+				continue
+			}
+			if err := engine.Check(now, values); err != nil {
+				c.notifyCategorizedError(err, ErrorCategoryIO, SeverityWarning)
+			}
+		}
+	}
+}
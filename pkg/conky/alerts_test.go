@@ -0,0 +1,58 @@
+package conky
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/alert"
+	"github.com/opd-ai/go-conky/internal/config"
+)
+
+func TestCompileAlerts(t *testing.T) {
+	var got []alert.Alert
+	events := alert.SinkFunc(func(a alert.Alert) error {
+		got = append(got, a)
+		return nil
+	})
+
+	engine, err := compileAlerts([]config.AlertConfig{
+		{Name: "home", Condition: "fs./home.used_perc > 90", Message: "home is full"},
+		{Name: "hot", Condition: "hwmon.coretemp.temp1 > 85", Duration: time.Minute},
+	}, events, alert.NewNotifySink(), nil)
+	if err != nil {
+		t.Fatalf("compileAlerts() error = %v", err)
+	}
+	if engine.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", engine.Len())
+	}
+
+	values := func(id string) (float64, bool) {
+		switch id {
+		case "fs./home.used_perc":
+			return 95, true
+		case "hwmon.coretemp.temp1":
+			return 90, true
+		}
+		return 0, false
+	}
+	if err := engine.Check(time.Now(), values); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0].Rule != "home" || !got[0].Firing || got[0].Message != "home is full" {
+		t.Errorf("alerts = %+v, want the home rule firing", got)
+	}
+	if state := engine.State("hot"); state != alert.StatePending {
+		t.Errorf("State(hot) = %v, want pending", state)
+	}
+}
+
+func TestCompileAlertsInvalidCondition(t *testing.T) {
+	_, err := compileAlerts([]config.AlertConfig{
+		{Name: "broken", Condition: "cpu.total >"},
+	}, alert.SinkFunc(func(alert.Alert) error { return nil }), alert.NewNotifySink(), nil)
+	if err == nil || !strings.Contains(err.Error(), "alert broken") {
+		t.Errorf("compileAlerts() error = %v, want error naming the rule", err)
+	}
+}
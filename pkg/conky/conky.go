@@ -70,6 +70,16 @@ type Conky interface {
 	// Use ErrorTracker().SetAlertHandler() to receive alert notifications.
 	// Use ErrorTracker().Stats() for error statistics.
 	ErrorTracker() *ErrorTracker
+
+	// History returns up to n of the most recent samples recorded for a
+	// metric, oldest first; n of zero or less returns all retained samples.
+	// Metric IDs are dot-separated paths such as "cpu.total", "cpu.core3",
+	// "mem.used_perc", "net.eth0.down" or "fs./home.used_perc".
+	// Use Options.HistoryLength to set the number of samples retained.
+	History(id string, n int) []HistorySample
+
+	// HistoryIDs returns the IDs of all metrics with recorded history.
+	HistoryIDs() []string
 }
 
 // New creates a new Conky instance from a configuration file on disk.
@@ -92,7 +102,7 @@ func New(configPath string, opts *Options) (Conky, error) {
 		opts = &defaultOpts
 	}
 
-	parser, err := config.NewParser()
+	parser, err := newParser(opts)
 	if err != nil {
 		return nil, fmt.Errorf("parser init: %w", err)
 	}
@@ -108,7 +118,7 @@ func New(configPath string, opts *Options) (Conky, error) {
 		opts:         *opts,
 		configSource: configPath,
 		configLoader: func() (*config.Config, error) {
-			p, err := config.NewParser()
+			p, err := newParser(opts)
 			if err != nil {
 				return nil, err
 			}
@@ -139,7 +149,7 @@ func NewFromFS(fsys fs.FS, configPath string, opts *Options) (Conky, error) {
 		opts = &defaultOpts
 	}
 
-	parser, err := config.NewParser()
+	parser, err := newParser(opts)
 	if err != nil {
 		return nil, fmt.Errorf("parser init: %w", err)
 	}
@@ -157,7 +167,7 @@ func NewFromFS(fsys fs.FS, configPath string, opts *Options) (Conky, error) {
 		configSource: "embedded:" + configPath,
 		fsys:         fsys,
 		configLoader: func() (*config.Config, error) {
-			p, err := config.NewParser()
+			p, err := newParser(opts)
 			if err != nil {
 				return nil, err
 			}
@@ -170,6 +180,7 @@ func NewFromFS(fsys fs.FS, configPath string, opts *Options) (Conky, error) {
 // NewFromReader creates a new Conky instance from configuration content provided as an io.Reader.
 // The format parameter specifies whether the content is "legacy" or "lua" format.
 // This is useful for dynamically generated configurations or network-loaded configs.
+// Set Options.Policy when the configuration comes from an untrusted source.
 //
 // Example:
 //
@@ -194,7 +205,7 @@ func NewFromReader(r io.Reader, format string, opts *Options) (Conky, error) {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
 
-	parser, err := config.NewParser()
+	parser, err := newParser(opts)
 	if err != nil {
 		return nil, fmt.Errorf("parser init: %w", err)
 	}
@@ -210,7 +221,7 @@ func NewFromReader(r io.Reader, format string, opts *Options) (Conky, error) {
 		opts:         *opts,
 		configSource: "reader",
 		configLoader: func() (*config.Config, error) {
-			p, err := config.NewParser()
+			p, err := newParser(opts)
 			if err != nil {
 				return nil, err
 			}
@@ -22,6 +22,8 @@ func TestEventTypeString(t *testing.T) {
 		{EventRestarted, "restarted"},
 		{EventConfigReloaded, "config_reloaded"},
 		{EventError, "error"},
+		{EventAlert, "alert"},
+		{EventAlertResolved, "alert_resolved"},
 		{EventType(100), "unknown"},
 	}
 
@@ -799,3 +801,44 @@ $uptime
 		t.Errorf("Stop failed: %v", err)
 	}
 }
+
+func TestExecErrorsRecorded(t *testing.T) {
+	config := `# Commands that fail or write to stderr
+TEXT
+${exec echo broken >&2; exit 2}
+${exec echo ok; echo noisy >&2}
+`
+	tracker := NewErrorTracker(DefaultErrorTrackerConfig())
+	c, err := NewFromReader(strings.NewReader(config), "legacy", &Options{
+		Headless:     true,
+		ErrorTracker: tracker,
+	})
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer c.Stop()
+
+	impl := c.(*conkyImpl)
+	impl.textEval.Lines()
+
+	errs := tracker.RecentErrors(10)
+	if len(errs) != 2 {
+		t.Fatalf("recorded %d errors, want 2: %v", len(errs), errs)
+	}
+	severities := map[string]ErrorSeverity{}
+	for _, e := range errs {
+		if e.Category != ErrorCategoryExec {
+			t.Errorf("category = %v, want exec", e.Category)
+		}
+		severities[e.Context["command"]] = e.Severity
+	}
+	if got := severities["echo broken >&2; exit 2"]; got != SeverityError {
+		t.Errorf("failed command severity = %v, want error", got)
+	}
+	if got := severities["echo ok; echo noisy >&2"]; got != SeverityWarning {
+		t.Errorf("stderr-only command severity = %v, want warning", got)
+	}
+}
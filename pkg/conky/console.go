@@ -0,0 +1,125 @@
+package conky
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// consoleSink writes each evaluation of conky.text to a stream as plain
+// text, one template line per output line. It implements out_to_console
+// and out_to_stderr; widgets are degraded to ASCII bars by render.PlainText.
+type consoleSink struct {
+	w       io.Writer
+	onError func(error) // Called when a write fails (may be nil)
+	mu      sync.Mutex
+}
+
+// Verify interface implementation at compile time.
+var _ textSink = (*consoleSink)(nil)
+
+// newConsoleSink creates a console sink writing to w.
+func newConsoleSink(w io.Writer, onError func(error)) *consoleSink {
+	return &consoleSink{w: w, onError: onError}
+}
+
+// WriteLines writes lines as a single block so that concurrent output
+// does not interleave with it.
+func (s *consoleSink) WriteLines(lines []render.TextLine) {
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(render.PlainText(line.Text))
+		sb.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := io.WriteString(s.w, sb.String()); err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// textSinks returns the sinks enabled by the output settings in cfg.
+func (c *conkyImpl) textSinks(cfg *config.Config) []textSink {
+	onError := func(err error) {
+		c.notifyCategorizedError(err, ErrorCategoryRender, SeverityWarning)
+	}
+
+	var sinks []textSink
+	if cfg.Output.ToConsole {
+		sinks = append(sinks, newConsoleSink(writerOr(c.stdout, os.Stdout), onError))
+	}
+	if cfg.Output.ToStderr {
+		sinks = append(sinks, newConsoleSink(writerOr(c.stderr, os.Stderr), onError))
+	}
+	if cfg.Output.ToHTTP && c.httpOutput != nil {
+		sinks = append(sinks, c.httpOutput)
+	}
+	return sinks
+}
+
+// hasTextOutput reports whether any output other than the window is enabled.
+func hasTextOutput(oc config.OutputConfig) bool {
+	return oc.ToConsole || oc.ToStderr || oc.ToHTTP
+}
+
+// writerOr returns w, or fallback if w is nil.
+func writerOr(w, fallback io.Writer) io.Writer {
+	if w == nil {
+		return fallback
+	}
+	return w
+}
+
+// showWindow reports whether the instance draws into a window: not in
+// headless mode and not with out_to_x disabled.
+func (c *conkyImpl) showWindow() bool {
+	return !c.opts.Headless && c.cfg.Output.ToX
+}
+
+// runTextLoop evaluates conky.text and runs the Lua draw hooks on every
+// update interval when no window is shown, feeding the console sinks.
+// It blocks until the context is cancelled.
+func (c *conkyImpl) runTextLoop() {
+	for {
+		c.mu.RLock()
+		interval := c.updateInterval()
+		textEval := c.textEval
+		hooks := c.luaHooks
+		ctx := c.ctx
+		c.mu.RUnlock()
+
+		if textEval != nil {
+			textEval.Lines()
+		}
+		if hooks != nil {
+			if err := hooks.DrawWithoutWindow(); err != nil {
+				c.notifyCategorizedError(err, ErrorCategoryLua, SeverityError)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// updateInterval returns the effective update interval: Options take
+// precedence over the configuration file. The caller must hold c.mu.
+func (c *conkyImpl) updateInterval() time.Duration {
+	interval := c.cfg.Display.UpdateInterval
+	if c.opts.UpdateInterval > 0 {
+		interval = c.opts.UpdateInterval
+	}
+	if interval <= 0 {
+		interval = defaultUpdateInterval
+	}
+	return interval
+}
@@ -0,0 +1,97 @@
+package conky
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestConsoleSinkWriteLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := newConsoleSink(&buf, nil)
+
+	sink.WriteLines([]render.TextLine{
+		{Text: "CPU: 40% " + render.EncodeBarMarker(40, 100, 6)},
+		{Text: render.EncodeColorMarker("red") + "Hot"},
+	})
+
+	want := "CPU: 40% ####......\nHot\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestConsoleSinkWriteError(t *testing.T) {
+	var gotErr error
+	sink := newConsoleSink(failingWriter{}, func(err error) { gotErr = err })
+
+	sink.WriteLines([]render.TextLine{{Text: "x"}})
+	if gotErr == nil {
+		t.Error("expected write error to be reported")
+	}
+}
+
+func TestOutToConsoleWithoutWindow(t *testing.T) {
+	config := `update_interval 0.01
+out_to_x no
+out_to_console yes
+TEXT
+Updates: ${updates}
+`
+	c, err := NewFromReader(strings.NewReader(config), "legacy", nil)
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	impl := c.(*conkyImpl)
+	stdout := &syncBuffer{}
+	impl.stdout = stdout
+
+	if impl.showWindow() {
+		t.Error("out_to_x no should not show a window")
+	}
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = c.Stop() }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(stdout.String(), "Updates: 2\n") {
+		if time.Now().After(deadline) {
+			t.Fatalf("console output after 2s = %q, want repeated updates", stdout.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.HasPrefix(stdout.String(), "Updates: 1\n") {
+		t.Errorf("console output = %q, want it to start with the first update", stdout.String())
+	}
+}
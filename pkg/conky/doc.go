@@ -54,4 +54,57 @@
 //	})
 //	c.Start()
 //	// Use c.Status() or access monitor data
+//
+// # Console Output
+//
+// The out_to_console and out_to_stderr settings print the evaluated text on
+// every update, with widgets drawn as ASCII bars. Combined with out_to_x set
+// to no (or Options.Headless), no window is created, which suits piping
+// into status bars such as tmux, dzen2 or lemonbar.
+//
+// # HTTP Output
+//
+// With out_to_http enabled, the text is served on http_port as an HTML page
+// (reloading every update interval when http_refresh is set), and the
+// system monitor data as JSON at /api/v1/vars.
+// The server listens on 127.0.0.1 unless Options.HTTPHost (the -http-host
+// flag of conky-go) says otherwise, since the data includes process names.
+//
+// # Metric History
+//
+// The system monitor records every metric into a ring buffer of
+// Options.HistoryLength samples, keyed by IDs such as "cpu.total",
+// "net.eth0.down" or "fs./home.used_perc". Conky.History returns them, and
+// graphs, ${history_avg}, conky_history() and /api/v1/history read the same
+// samples.
+//
+// # Prometheus Metrics
+//
+// Setting Options.MetricsAddr (the -metrics-addr flag of conky-go) serves
+// /metrics in the Prometheus text format: the operational metrics and error
+// counts, plus the collected system data as labelled gauges, such as
+// conky_cpu_core_usage_percent{core="0"} or
+// conky_filesystem_used_bytes{mountpoint="/",...}.
+//
+// # Alerts
+//
+// Alert rules declared in the alerts array of conky.config are evaluated
+// against the metric history every update interval. A rule fires once its
+// condition has held for its duration, emitting an EventAlert event and
+// optionally running a command (exec) or posting a desktop notification
+// (notify = true); it emits EventAlertResolved when the condition clears.
+// A hysteresis margin keeps rules from flapping around their thresholds,
+// and a cooldown limits how often a rule fires:
+//
+//	alerts = {
+//	    { name = 'battery', condition = 'battery.BAT0.percent < 10 and battery.BAT0.discharging',
+//	      notify = true, cooldown = 300 },
+//	    { name = 'cpu_temp', condition = 'hwmon.coretemp.temp1 > 85',
+//	      duration = 30, hysteresis = 5, exec = 'notify-send "CPU hot"' },
+//	}
+//
+// Commands run with the same timeout and concurrency limit as ${exec} and
+// are killed when the instance stops. Reloading the configuration keeps the
+// state of rules whose name is unchanged, so a firing rule does not fire
+// again, and resolves firing rules that were removed.
 package conky
@@ -28,6 +28,12 @@ const (
 	ErrorCategoryIO
 	// ErrorCategoryNetwork is for network-related errors.
 	ErrorCategoryNetwork
+	// ErrorCategoryExec is for shell commands run by ${exec} and related
+	// variables that fail, time out or write to stderr.
+	ErrorCategoryExec
+	// ErrorCategoryPolicy is for commands and file accesses blocked by
+	// Options.Policy.
+	ErrorCategoryPolicy
 
 	// errorCategoryCount is a sentinel value representing the total number of categories.
 	// Used for compile-time safety of the categoryCounters array size.
@@ -51,6 +57,10 @@ func (c ErrorCategory) String() string {
 		return "io"
 	case ErrorCategoryNetwork:
 		return "network"
+	case ErrorCategoryExec:
+		return "exec"
+	case ErrorCategoryPolicy:
+		return "policy"
 	default:
 		return "unknown"
 	}
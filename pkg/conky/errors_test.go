@@ -21,6 +21,8 @@ func TestErrorCategory_String(t *testing.T) {
 		{ErrorCategoryRemote, "remote"},
 		{ErrorCategoryIO, "io"},
 		{ErrorCategoryNetwork, "network"},
+		{ErrorCategoryExec, "exec"},
+		{ErrorCategoryPolicy, "policy"},
 		{ErrorCategory(99), "unknown"}, // Invalid category
 	}
 
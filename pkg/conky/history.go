@@ -0,0 +1,71 @@
+package conky
+
+import (
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// HistorySample is a single recorded value of a monitored metric.
+type HistorySample struct {
+	// Time is when the value was recorded.
+	Time time.Time
+	// Value is the recorded value.
+	Value float64
+}
+
+// History returns up to n of the most recent samples recorded for a metric,
+// oldest first; n of zero or less returns all retained samples. It returns
+// nil for unknown metrics or when the instance is not running.
+func (c *conkyImpl) History(id string, n int) []HistorySample {
+	c.mu.RLock()
+	sm := c.monitor
+	c.mu.RUnlock()
+	if sm == nil {
+		return nil
+	}
+
+	recorded := sm.History(id, n)
+	if recorded == nil {
+		return nil
+	}
+	samples := make([]HistorySample, len(recorded))
+	for i, s := range recorded {
+		samples[i] = HistorySample{Time: s.Time, Value: s.Value}
+	}
+	return samples
+}
+
+// HistoryIDs returns the IDs of all metrics with recorded history.
+func (c *conkyImpl) HistoryIDs() []string {
+	c.mu.RLock()
+	sm := c.monitor
+	c.mu.RUnlock()
+	if sm == nil {
+		return nil
+	}
+	return sm.HistoryIDs()
+}
+
+// graphHistory adapts the monitor's metric history to render.HistorySource,
+// so that graph widgets draw the samples recorded by the monitor.
+type graphHistory struct {
+	monitor *monitor.SystemMonitor
+}
+
+// Verify interface implementation at compile time.
+var _ render.HistorySource = graphHistory{}
+
+// GraphHistory implements render.HistorySource.
+func (g graphHistory) GraphHistory(id string, n int) ([]float64, float64, bool) {
+	samples := g.monitor.History(id, n)
+	if len(samples) == 0 {
+		return nil, 0, false
+	}
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Value
+	}
+	return values, monitor.HistoryScale(id), true
+}
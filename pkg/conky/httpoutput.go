@@ -0,0 +1,232 @@
+package conky
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image/color"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/monitor"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// httpOutput serves the evaluated conky.text as an HTML page and the system
+// monitor data as JSON, implementing out_to_http. It is a textSink: each
+// evaluation replaces the lines served by the page.
+//
+// Endpoints:
+//
+//	/                HTML page with ${color} changes translated to spans
+//	/api/v1/vars     JSON snapshot of the system monitor data
+//	/api/v1/history  JSON list of metric IDs, or with ?id=ID[&n=N] the
+//	                 N most recent samples of a metric
+type httpOutput struct {
+	monitor *monitor.SystemMonitor
+
+	mu      sync.RWMutex
+	lines   []render.TextLine
+	palette [10]color.RGBA
+	refresh time.Duration // Page reload interval (0 disables reloading)
+
+	server *backgroundServer
+}
+
+// Verify interface implementation at compile time.
+var _ textSink = (*httpOutput)(nil)
+
+// newHTTPOutput creates an HTTP output for the given monitor. Call Start to
+// begin serving.
+func newHTTPOutput(sm *monitor.SystemMonitor) *httpOutput {
+	return &httpOutput{monitor: sm}
+}
+
+// SetConfig updates the color palette and page refresh interval.
+// interval is the update interval used when http_refresh is enabled.
+func (h *httpOutput) SetConfig(cfg *config.Config, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.palette = configToRenderPalette(cfg.Colors)
+	h.refresh = 0
+	if cfg.Output.HTTPRefresh {
+		h.refresh = interval
+	}
+}
+
+// WriteLines stores the lines served by the HTML page.
+func (h *httpOutput) WriteLines(lines []render.TextLine) {
+	h.mu.Lock()
+	h.lines = lines
+	h.mu.Unlock()
+}
+
+// Handler returns the HTTP handler serving the page and the JSON API.
+func (h *httpOutput) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", h.serveText)
+	mux.HandleFunc("GET /api/v1/vars", h.serveVars)
+	mux.HandleFunc("GET /api/v1/history", h.serveHistory)
+	return mux
+}
+
+// Start listens on addr and serves requests in the background.
+func (h *httpOutput) Start(addr string) error {
+	server, err := startBackgroundServer(addr, h.Handler())
+	if err != nil {
+		return fmt.Errorf("http output: %w", err)
+	}
+	h.server = server
+	return nil
+}
+
+// Addr returns the address the server listens on, or "" before Start.
+func (h *httpOutput) Addr() string {
+	if h.server == nil {
+		return ""
+	}
+	return h.server.Addr()
+}
+
+// Close stops the server.
+func (h *httpOutput) Close() error {
+	if h.server == nil {
+		return nil
+	}
+	return h.server.Close()
+}
+
+// pageTemplate renders the text lines as preformatted, colored spans.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{- if .Refresh}}
+<meta http-equiv="refresh" content="{{.Refresh}}">
+{{- end}}
+<title>conky-go</title>
+<style>body{background:#000;color:#fff;margin:1em}pre{font-family:monospace;margin:0}</style>
+</head>
+<body>
+<pre>
+{{- range .Lines}}
+{{range .}}<span style="color:{{.Color}}">{{.Text}}</span>{{end}}
+{{- end}}
+</pre>
+</body>
+</html>
+`))
+
+// pageRun is a run of text with its CSS color.
+type pageRun struct {
+	Text  string
+	Color string
+}
+
+// serveText writes the HTML page.
+func (h *httpOutput) serveText(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	lines := h.lines
+	palette := h.palette
+	refresh := h.refresh
+	h.mu.RUnlock()
+
+	page := struct {
+		Refresh int
+		Lines   [][]pageRun
+	}{
+		Lines: make([][]pageRun, 0, len(lines)),
+	}
+	if refresh > 0 {
+		page.Refresh = max(int(math.Ceil(refresh.Seconds())), 1)
+	}
+	for _, line := range lines {
+		runs := render.PlainRuns(line.Text, line.Color, palette)
+		pageLine := make([]pageRun, 0, len(runs))
+		for _, run := range runs {
+			pageLine = append(pageLine, pageRun{Text: run.Text, Color: cssColor(run.Color)})
+		}
+		page.Lines = append(page.Lines, pageLine)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// systemVars is the JSON document served by /api/v1/vars.
+type systemVars struct {
+	CPU        monitor.CPUStats        `json:"cpu"`
+	Memory     monitor.MemoryStats     `json:"memory"`
+	Uptime     monitor.UptimeStats     `json:"uptime"`
+	Network    monitor.NetworkStats    `json:"network"`
+	Filesystem monitor.FilesystemStats `json:"filesystem"`
+	DiskIO     monitor.DiskIOStats     `json:"diskio"`
+	Hwmon      monitor.HwmonStats      `json:"hwmon"`
+	Process    monitor.ProcessStats    `json:"process"`
+	Battery    monitor.BatteryStats    `json:"battery"`
+	Audio      monitor.AudioStats      `json:"audio"`
+	SysInfo    monitor.SystemInfo      `json:"sysinfo"`
+	Mail       monitor.MailStats       `json:"mail"`
+}
+
+// serveVars writes the system monitor data as JSON.
+func (h *httpOutput) serveVars(w http.ResponseWriter, _ *http.Request) {
+	vars := systemVars{
+		CPU:        h.monitor.CPU(),
+		Memory:     h.monitor.Memory(),
+		Uptime:     h.monitor.Uptime(),
+		Network:    h.monitor.Network(),
+		Filesystem: h.monitor.Filesystem(),
+		DiskIO:     h.monitor.DiskIO(),
+		Hwmon:      h.monitor.Hwmon(),
+		Process:    h.monitor.Process(),
+		Battery:    h.monitor.Battery(),
+		Audio:      h.monitor.Audio(),
+		SysInfo:    h.monitor.SysInfo(),
+		Mail:       h.monitor.Mail(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vars); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// historySample is a sample in the /api/v1/history JSON document.
+type historySample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// serveHistory writes the recorded metric IDs, or the samples of the metric
+// selected by the id query parameter.
+func (h *httpOutput) serveHistory(w http.ResponseWriter, r *http.Request) {
+	var doc any
+	if id := r.URL.Query().Get("id"); id != "" {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		recorded := h.monitor.History(id, n)
+		samples := make([]historySample, len(recorded))
+		for i, s := range recorded {
+			samples[i] = historySample{Time: s.Time, Value: s.Value}
+		}
+		doc = samples
+	} else {
+		doc = h.monitor.HistoryIDs()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// cssColor formats clr as a CSS hex color, ignoring alpha.
+func cssColor(clr color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", clr.R, clr.G, clr.B)
+}
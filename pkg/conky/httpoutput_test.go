@@ -0,0 +1,215 @@
+package conky
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/monitor"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+func newTestHTTPOutput(t *testing.T, cfg *config.Config) (*httpOutput, *httptest.Server) {
+	t.Helper()
+	out := newHTTPOutput(monitor.NewSystemMonitor(time.Second))
+	out.SetConfig(cfg, 2500*time.Millisecond)
+	server := httptest.NewServer(out.Handler())
+	t.Cleanup(server.Close)
+	return out, server
+}
+
+func getBody(t *testing.T, url string) (string, http.Header) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body failed: %v", err)
+	}
+	return string(body), resp.Header
+}
+
+func TestHTTPOutputPage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Colors.Color1 = color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 255}
+	out, server := newTestHTTPOutput(t, &cfg)
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	out.WriteLines([]render.TextLine{
+		{Text: "CPU " + render.EncodeColorMarker("red") + "<hot>", Color: white},
+		{Text: render.EncodeColorIndexMarker(1) + "bar " + render.EncodeBarMarker(50, 40, 6), Color: white},
+	})
+
+	body, header := getBody(t, server.URL+"/")
+	if ct := header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	for _, want := range []string{
+		`<span style="color:#ffffff">CPU </span><span style="color:#ff0000">&lt;hot&gt;</span>`,
+		`<span style="color:#123456">bar ##..</span>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "http-equiv") {
+		t.Error("page should not refresh without http_refresh")
+	}
+
+	cfg.Output.HTTPRefresh = true
+	out.SetConfig(&cfg, 2500*time.Millisecond)
+	body, _ = getBody(t, server.URL+"/")
+	if !strings.Contains(body, `<meta http-equiv="refresh" content="3">`) {
+		t.Errorf("page does not refresh every 3s:\n%s", body)
+	}
+}
+
+func TestHTTPOutputVars(t *testing.T) {
+	cfg := config.DefaultConfig()
+	_, server := newTestHTTPOutput(t, &cfg)
+
+	body, header := getBody(t, server.URL+"/api/v1/vars")
+	if ct := header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &vars); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	for _, key := range []string{"cpu", "memory", "network", "filesystem", "hwmon", "battery", "sysinfo"} {
+		if _, ok := vars[key]; !ok {
+			t.Errorf("vars missing %q", key)
+		}
+	}
+}
+
+func TestHTTPOutputHistory(t *testing.T) {
+	cfg := config.DefaultConfig()
+	out, server := newTestHTTPOutput(t, &cfg)
+	out.monitor.SetHistoryLength(5)
+	_ = out.monitor.Update()
+
+	body, _ := getBody(t, server.URL+"/api/v1/history")
+	var ids []string
+	if err := json.Unmarshal([]byte(body), &ids); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	if !slices.Contains(ids, "cpu.total") {
+		t.Errorf("history IDs %v do not contain cpu.total", ids)
+	}
+
+	body, _ = getBody(t, server.URL+"/api/v1/history?id=cpu.total&n=10")
+	var samples []struct {
+		Time  time.Time `json:"time"`
+		Value float64   `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(body), &samples); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	if len(samples) != 1 || samples[0].Time.IsZero() {
+		t.Errorf("samples = %+v, want one recorded sample", samples)
+	}
+}
+
+func TestHTTPOutputNotFound(t *testing.T) {
+	cfg := config.DefaultConfig()
+	_, server := newTestHTTPOutput(t, &cfg)
+
+	resp, err := http.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestOutToHTTPWithoutWindow(t *testing.T) {
+	// Reserve a free port for the server
+	probe := newHTTPOutput(nil)
+	if err := probe.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	addr := probe.Addr()
+	_ = probe.Close()
+	port := addr[strings.LastIndex(addr, ":")+1:]
+
+	cfg := fmt.Sprintf(`update_interval 0.01
+out_to_x no
+out_to_http yes
+http_port %s
+TEXT
+Hello ${updates}
+`, port)
+	c, err := NewFromReader(strings.NewReader(cfg), "legacy", nil)
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if addr := c.(*conkyImpl).httpOutput.Addr(); addr != "127.0.0.1:"+port {
+		t.Errorf("server listens on %q, want loopback only", addr)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		body, _ := getBody(t, "http://127.0.0.1:"+port+"/")
+		if strings.Contains(body, "Hello ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("page never showed the text:\n%s", body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := http.Get("http://127.0.0.1:" + port + "/"); err == nil {
+		t.Error("server still running after Stop")
+	}
+}
+
+func TestOutToHTTPPortInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer busy.Close()
+	addr := busy.Addr().String()
+	port := addr[strings.LastIndex(addr, ":")+1:]
+
+	cfg := fmt.Sprintf("out_to_x no\nout_to_http yes\nhttp_port %s\nTEXT\nHello\n", port)
+	c, err := NewFromReader(strings.NewReader(cfg), "legacy", nil)
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	if err := c.Start(); err == nil {
+		_ = c.Stop()
+		t.Fatal("Start succeeded with the HTTP port in use")
+	}
+
+	impl := c.(*conkyImpl)
+	if impl.luaRuntime != nil || impl.luaAPI != nil || impl.luaHooks != nil {
+		t.Error("Lua runtime, API or hooks left open after the failed start")
+	}
+}
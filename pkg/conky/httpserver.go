@@ -0,0 +1,59 @@
+package conky
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpShutdownTimeout bounds how long Close waits for in-flight requests.
+const httpShutdownTimeout = 2 * time.Second
+
+// backgroundServer serves HTTP requests on its own goroutine. It backs the
+// out_to_http page and the Prometheus exporter.
+type backgroundServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// startBackgroundServer listens on addr and serves handler in the background.
+func startBackgroundServer(addr string, handler http.Handler) (*backgroundServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &backgroundServer{
+		server: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		listener: listener,
+	}
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *backgroundServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server, waiting briefly for in-flight requests.
+func (s *backgroundServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = s.server.Close()
+	}
+	// Shutdown only closes listeners that Serve has started on, so close
+	// the listener too in case the serving goroutine has not run yet
+	if closeErr := s.listener.Close(); closeErr != nil && !errors.Is(closeErr, net.ErrClosed) && err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -3,13 +3,20 @@ package conky
 import (
 	"context"
 	"fmt"
-	"image/color"
+	"io"
 	"io/fs"
+	"net"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/opd-ai/go-conky/internal/alert"
 	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
 	"github.com/opd-ai/go-conky/internal/monitor"
 	"github.com/opd-ai/go-conky/internal/render"
 )
@@ -30,10 +37,24 @@ type conkyImpl struct {
 
 	// Components
 	monitor       *monitor.SystemMonitor
-	gameRunner    *gameRunner    // For hot-reload support
-	metrics       *Metrics       // Metrics collector
-	errorTracker  *ErrorTracker  // Error tracking and alerting
-	configWatcher *configWatcher // File watcher for hot-reload
+	luaRuntime    *lua.ConkyRuntime // Lua runtime backing the Conky API
+	luaAPI        *lua.ConkyAPI     // Conky API used to evaluate templates
+	textEval      *textEvaluator    // Evaluates conky.text on each update
+	luaHooks      *luaHooks         // Runs lua_load scripts and lifecycle hooks
+	gameRunner    *gameRunner       // For hot-reload support
+	metrics       *Metrics          // Metrics collector
+	errorTracker  *ErrorTracker     // Error tracking and alerting
+	policyReports policyReporter    // Operations blocked by Options.Policy
+	configWatcher *configWatcher    // File watcher for hot-reload
+	httpOutput    *httpOutput       // Serves the text over HTTP (out_to_http)
+	promExporter  *promExporter     // Serves /metrics (Options.MetricsAddr)
+	alerts        *alert.Engine     // Evaluates the configured alert rules
+	alertNotify   *alert.NotifySink // Desktop notifications for alert rules
+
+	// Console streams for out_to_console and out_to_stderr
+	// (nil means os.Stdout and os.Stderr)
+	stdout io.Writer
+	stderr io.Writer
 
 	// State
 	running     atomic.Bool
@@ -67,8 +88,9 @@ func (c *conkyImpl) Start() error {
 	// Create cancellable context
 	c.ctx, c.cancel = context.WithCancel(context.Background())
 
-	// Initialize components
+	// Initialize components, releasing any that started before the failure
 	if err := c.initComponents(); err != nil {
+		c.cleanup()
 		if c.cancel != nil {
 			c.cancel()
 		}
@@ -83,6 +105,18 @@ func (c *conkyImpl) Start() error {
 		return fmt.Errorf("failed to start monitor: %w", err)
 	}
 
+	// Connect the mail and MPD readers to the configured mailboxes. Errors
+	// leave the affected service unconfigured.
+	servicesErr := applyServices(c.monitor, c.cfg, c.luaRuntime.SecretPolicy())
+
+	// Load Lua scripts and run the startup hook before the first frame.
+	// Script errors are reported but do not prevent startup.
+	luaErr := c.luaHooks.Start(c.cfg.Lua)
+
+	showWindow := c.showWindow()
+	textOutput := hasTextOutput(c.cfg.Output)
+	interval := c.updateInterval()
+
 	// Set running state BEFORE starting goroutine to avoid race
 	c.running.Store(true)
 	c.startTime = time.Now()
@@ -92,6 +126,13 @@ func (c *conkyImpl) Start() error {
 	c.metrics.SetRunning(true)
 	c.metrics.SetActiveMonitors(1)
 
+	// Evaluate alert rules in the background
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.runAlerts(interval)
+	}()
+
 	// Start update loop in goroutine (non-blocking)
 	c.wg.Add(1)
 	go func() {
@@ -101,9 +142,15 @@ func (c *conkyImpl) Start() error {
 		defer c.metrics.SetRunning(false)
 		defer c.metrics.SetActiveMonitors(0)
 
-		if c.opts.Headless {
-			// Headless mode: just wait for context cancellation
-			<-c.ctx.Done()
+		if !showWindow {
+			// Without a window, keep evaluating the text for the console
+			// and HTTP outputs if enabled, otherwise just wait for context
+			// cancellation
+			if textOutput {
+				c.runTextLoop()
+			} else {
+				<-c.ctx.Done()
+			}
 		} else {
 			// GUI mode: run the Ebiten rendering loop
 			c.runRenderLoop()
@@ -122,6 +169,13 @@ func (c *conkyImpl) Start() error {
 	// Release lock before emitting event to avoid deadlock
 	c.mu.Unlock()
 
+	if luaErr != nil {
+		c.notifyCategorizedError(fmt.Errorf("lua scripts: %w", luaErr), ErrorCategoryLua, SeverityError)
+	}
+	if servicesErr != nil {
+		c.notifyCategorizedError(servicesErr, ErrorCategoryConfig, SeverityWarning)
+	}
+
 	c.emitEvent(EventStarted, "Instance started")
 
 	return nil
@@ -219,16 +273,68 @@ func (c *conkyImpl) ReloadConfig() error {
 		return wrappedErr
 	}
 
+	// Compile the new alert rules before applying anything, so that an
+	// invalid condition leaves the running configuration in place
+	alerts, err := c.loadAlerts(newCfg)
+	if err != nil {
+		wrappedErr := fmt.Errorf("config reload failed: %w", err)
+		c.notifyCategorizedError(wrappedErr, ErrorCategoryConfig, SeverityError)
+		return wrappedErr
+	}
+
+	// Continue the new alert rules from the state of the old ones, so that
+	// firing rules keep their cooldown and rules removed while firing
+	// resolve. The old engine is left empty, so the alert loop cannot fire
+	// a rule from it before the swap.
+	c.mu.RLock()
+	oldAlerts := c.alerts
+	c.mu.RUnlock()
+	if err := alerts.Adopt(oldAlerts, time.Now()); err != nil {
+		c.notifyCategorizedError(err, ErrorCategoryIO, SeverityWarning)
+	}
+
 	// Update the configuration atomically
 	c.mu.Lock()
 	oldCfg := c.cfg
 	c.cfg = newCfg
+	c.alerts = alerts
 	gameRunner := c.gameRunner
+	textEval := c.textEval
+	hooks := c.luaHooks
+	httpOutput := c.httpOutput
+	secrets := c.luaRuntime.SecretPolicy()
 	c.mu.Unlock()
 
+	// Reconnect the mail and MPD readers
+	if err := applyServices(c.monitor, newCfg, secrets); err != nil {
+		c.notifyCategorizedError(err, ErrorCategoryConfig, SeverityWarning)
+	}
+
+	// Reload Lua scripts if the scripts or hooks changed
+	if hooks != nil && luaScriptsChanged(oldCfg.Lua, newCfg.Lua) {
+		if err := hooks.Reload(newCfg.Lua); err != nil {
+			c.notifyCategorizedError(fmt.Errorf("lua scripts: %w", err), ErrorCategoryLua, SeverityError)
+		}
+	}
+
+	// Swap in the new text template and console outputs before the next
+	// evaluation
+	if textEval != nil {
+		textEval.SetConfig(newCfg)
+		textEval.SetSinks(c.textSinks(newCfg)...)
+	}
+
+	// Update the HTTP page colors and refresh interval
+	if httpOutput != nil {
+		c.mu.RLock()
+		interval := c.updateInterval()
+		c.mu.RUnlock()
+		httpOutput.SetConfig(newCfg, interval)
+	}
+
 	// Update the render game if running in GUI mode
 	if gameRunner != nil && gameRunner.game != nil {
-		c.applyConfigToGame(gameRunner.game, newCfg, oldCfg)
+		c.applyConfigToGame(gameRunner.game, textEval, newCfg, oldCfg)
 	}
 
 	c.metrics.IncrementConfigReloads()
@@ -237,26 +343,10 @@ func (c *conkyImpl) ReloadConfig() error {
 }
 
 // applyConfigToGame updates the game with new configuration values.
-func (c *conkyImpl) applyConfigToGame(game *render.Game, newCfg, oldCfg *config.Config) {
-	// Update text lines from the new template
-	if len(newCfg.Text.Template) > 0 {
-		textColor := newCfg.Colors.Default
-		if textColor == (color.RGBA{}) {
-			textColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
-		}
-
-		lines := make([]render.TextLine, 0, len(newCfg.Text.Template))
-		y := defaultTextStartY
-		for _, text := range newCfg.Text.Template {
-			lines = append(lines, render.TextLine{
-				Text:  text,
-				X:     defaultTextStartX,
-				Y:     y,
-				Color: textColor,
-			})
-			y += defaultLineHeight
-		}
-		game.SetLines(lines)
+func (c *conkyImpl) applyConfigToGame(game *render.Game, textEval *textEvaluator, newCfg, oldCfg *config.Config) {
+	// Re-evaluate text lines from the new template immediately
+	if textEval != nil && len(newCfg.Text.Template) > 0 {
+		game.SetLines(textEval.Preview())
 	}
 
 	// Update render config if dimensions or colors changed
@@ -275,6 +365,10 @@ func (c *conkyImpl) applyConfigToGame(game *render.Game, newCfg, oldCfg *config.
 		currentConfig.UpdateInterval = newCfg.Display.UpdateInterval
 		needsConfigUpdate = true
 	}
+	if palette := configToRenderPalette(newCfg.Colors); palette != currentConfig.Colors {
+		currentConfig.Colors = palette
+		needsConfigUpdate = true
+	}
 
 	if needsConfigUpdate {
 		game.SetConfig(currentConfig)
@@ -338,13 +432,7 @@ func (c *conkyImpl) initComponents() error {
 	}
 
 	// Determine update interval
-	interval := c.cfg.Display.UpdateInterval
-	if c.opts.UpdateInterval > 0 {
-		interval = c.opts.UpdateInterval
-	}
-	if interval <= 0 {
-		interval = time.Second // Default to 1 second
-	}
+	interval := c.updateInterval()
 
 	// Initialize system monitor with optional cross-platform support
 	// If a platform interface is provided via options, use platform-aware monitoring
@@ -354,6 +442,52 @@ func (c *conkyImpl) initComponents() error {
 		// Fall back to Linux-specific monitor
 		c.monitor = monitor.NewSystemMonitor(interval)
 	}
+	c.monitor.SetHistoryLength(c.opts.HistoryLength)
+
+	// Stop contacting web hosts that keep failing ${curl} and ${rss} fetches
+	c.monitor.SetHTTPCircuitBreaker(func() monitor.CircuitBreakerInterface {
+		return NewCircuitBreaker(DefaultCircuitBreakerConfig())
+	})
+
+	// Initialize the Lua runtime and Conky API used to evaluate conky.text
+	if err := c.initLua(); err != nil {
+		return err
+	}
+
+	// Compile the alert rules, which read the monitor's history and run
+	// their commands through the Lua API's exec scheduler
+	if c.alertNotify == nil {
+		c.alertNotify = alert.NewNotifySink()
+	}
+	alerts, err := c.loadAlerts(c.cfg)
+	if err != nil {
+		return err
+	}
+	c.alerts = alerts
+
+	// Serve the text over HTTP if enabled
+	if c.cfg.Output.ToHTTP {
+		out := newHTTPOutput(c.monitor)
+		out.SetConfig(c.cfg, interval)
+		host := c.opts.HTTPHost
+		if host == "" {
+			host = DefaultHTTPHost
+		}
+		if err := out.Start(net.JoinHostPort(host, strconv.Itoa(c.cfg.Output.HTTPPort))); err != nil {
+			return err
+		}
+		c.httpOutput = out
+		c.textEval.SetSinks(c.textSinks(c.cfg)...)
+	}
+
+	// Serve Prometheus metrics if enabled
+	if c.opts.MetricsAddr != "" {
+		exporter := newPromExporter(c.metrics, c.errorTracker, c.monitor)
+		if err := exporter.Start(c.opts.MetricsAddr); err != nil {
+			return err
+		}
+		c.promExporter = exporter
+	}
 
 	// Initialize config file watcher if enabled
 	if c.opts.WatchConfig && c.configSource != "" {
@@ -396,6 +530,82 @@ func (c *conkyImpl) initComponents() error {
 	return nil
 }
 
+// initLua creates the Lua runtime and the Conky API backed by the system
+// monitor. Resource limits from Options take precedence over the config file.
+func (c *conkyImpl) initLua() error {
+	luaCfg := lua.DefaultConfig()
+	if c.cfg.Lua.CPULimit > 0 {
+		luaCfg.CPULimit = c.cfg.Lua.CPULimit
+	}
+	if c.cfg.Lua.MemoryLimit > 0 {
+		luaCfg.MemoryLimit = c.cfg.Lua.MemoryLimit
+	}
+	if c.opts.LuaCPULimit > 0 {
+		luaCfg.CPULimit = c.opts.LuaCPULimit
+	}
+	if c.opts.LuaMemoryLimit > 0 {
+		luaCfg.MemoryLimit = c.opts.LuaMemoryLimit
+	}
+	luaCfg.Policy = c.opts.Policy.luaPolicy()
+
+	runtime, err := lua.New(luaCfg)
+	if err != nil {
+		return fmt.Errorf("lua runtime: %w", err)
+	}
+	runtime.SetPolicyHandler(c.recordPolicyError)
+	if c.fsys != nil {
+		runtime.SetFS(c.fsys)
+	}
+
+	api, err := lua.NewConkyAPI(runtime, c.monitor)
+	if err != nil {
+		_ = runtime.Close()
+		return fmt.Errorf("conky api: %w", err)
+	}
+
+	api.SetExecErrorHandler(c.recordExecError)
+
+	hooks, err := newLuaHooks(runtime, c.fsys, c.scriptBaseDir(), c.metrics)
+	if err != nil {
+		_ = api.Close()
+		_ = runtime.Close()
+		return fmt.Errorf("lua hooks: %w", err)
+	}
+
+	c.luaRuntime = runtime
+	c.luaAPI = api
+	c.luaHooks = hooks
+	c.textEval = newTextEvaluator(api, c.cfg)
+	c.textEval.SetSinks(c.textSinks(c.cfg)...)
+	return nil
+}
+
+// recordExecError records a failed ${exec} command in the error tracker.
+// Commands that only wrote to stderr are warnings. Unlike other runtime
+// errors they are not passed to the error handler, since a command shown
+// every update would report the same error each time.
+func (c *conkyImpl) recordExecError(err *lua.ExecError) {
+	severity := SeverityError
+	if err.Err == nil {
+		severity = SeverityWarning
+	}
+	c.errorTracker.Record(NewCategorizedError(err, ErrorCategoryExec, severity).WithContext("command", err.Command))
+}
+
+// scriptBaseDir returns the directory relative lua_load paths are resolved
+// against: the directory of the configuration file, or "" for the current
+// directory when the configuration was not read from a file.
+func (c *conkyImpl) scriptBaseDir() string {
+	switch {
+	case c.configSource == "" || c.configSource == "reader":
+		return ""
+	case c.fsys != nil:
+		return path.Dir(strings.TrimPrefix(c.configSource, "embedded:"))
+	default:
+		return filepath.Dir(c.configSource)
+	}
+}
+
 // cleanup releases all resources.
 func (c *conkyImpl) cleanup() {
 	if c.configWatcher != nil {
@@ -404,6 +614,28 @@ func (c *conkyImpl) cleanup() {
 	if c.monitor != nil {
 		c.monitor.Stop()
 	}
+	if c.httpOutput != nil {
+		_ = c.httpOutput.Close()
+		c.httpOutput = nil
+	}
+	if c.promExporter != nil {
+		_ = c.promExporter.Close()
+		c.promExporter = nil
+	}
+	if c.luaHooks != nil {
+		if err := c.luaHooks.Shutdown(); err != nil {
+			c.notifyCategorizedError(fmt.Errorf("lua shutdown hook: %w", err), ErrorCategoryLua, SeverityError)
+		}
+		c.luaHooks = nil
+	}
+	if c.luaAPI != nil {
+		_ = c.luaAPI.Close()
+		c.luaAPI = nil
+	}
+	if c.luaRuntime != nil {
+		_ = c.luaRuntime.Close()
+		c.luaRuntime = nil
+	}
 }
 
 // getError retrieves the last error.
@@ -0,0 +1,249 @@
+package conky
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// luaHooks loads the scripts named by lua_load and runs the lifecycle hooks
+// configured with the lua_*_hook settings. As in Conky, only the functions
+// those settings name are called; defining conky_main alone runs nothing.
+// It implements render.FrameHooks so the render loop can run the draw hooks
+// on every update, with the Cairo functions drawing onto the window while a
+// draw hook runs.
+type luaHooks struct {
+	runtime *lua.ConkyRuntime
+	manager *lua.HookManager
+	cairo   *lua.CairoModule
+	metrics *Metrics
+	fsys    fs.FS  // Filesystem scripts are read from (nil for disk files)
+	baseDir string // Directory relative script paths are resolved against
+	started bool   // Whether the startup hook has run
+	mu      sync.Mutex
+}
+
+// Verify interface implementation at compile time.
+var _ render.FrameHooks = (*luaHooks)(nil)
+
+// newLuaHooks creates a luaHooks for the given runtime and registers the
+// Cairo bindings and conky_window global in it. Relative script paths are
+// resolved against baseDir, within fsys when it is non-nil.
+func newLuaHooks(runtime *lua.ConkyRuntime, fsys fs.FS, baseDir string, metrics *Metrics) (*luaHooks, error) {
+	manager, err := lua.NewHookManager(runtime)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := lua.NewCairoBindings(runtime)
+	if err != nil {
+		return nil, fmt.Errorf("cairo bindings: %w", err)
+	}
+	cairo, err := lua.NewCairoModule(runtime, lua.WithCairoRenderer(bindings.Renderer()))
+	if err != nil {
+		return nil, fmt.Errorf("cairo module: %w", err)
+	}
+	return &luaHooks{
+		runtime: runtime,
+		manager: manager,
+		cairo:   cairo,
+		metrics: metrics,
+		fsys:    fsys,
+		baseDir: baseDir,
+	}, nil
+}
+
+// Start loads the configured scripts, registers the hooks and runs the
+// startup hook. Failures in one script or hook do not prevent the others
+// from loading; all errors are returned joined.
+func (h *luaHooks) Start(cfg config.LuaConfig) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var errs []error
+	for _, script := range cfg.Load {
+		if err := h.loadScript(script); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	h.manager.Clear()
+	hooks := []struct {
+		setting  string
+		hookType lua.HookType
+		spec     string
+	}{
+		{"lua_startup_hook", lua.HookStartup, cfg.StartupHook},
+		{"lua_shutdown_hook", lua.HookShutdown, cfg.ShutdownHook},
+		{"lua_draw_hook_pre", lua.HookDrawPre, cfg.DrawHookPre},
+		{"lua_draw_hook_post", lua.HookDrawPost, cfg.DrawHookPost},
+	}
+	for _, hook := range hooks {
+		if hook.spec == "" {
+			continue
+		}
+		if err := h.manager.RegisterHookSpec(hook.hookType, hook.spec); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.setting, err))
+		}
+	}
+
+	h.started = true
+	if err := h.call(lua.HookStartup); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Shutdown runs the shutdown hook if Start has been called.
+func (h *luaHooks) Shutdown() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.started {
+		return nil
+	}
+	h.started = false
+	return h.call(lua.HookShutdown)
+}
+
+// Reload runs the shutdown hook, then reloads the scripts and runs the
+// startup hook with the new settings.
+func (h *luaHooks) Reload(cfg config.LuaConfig) error {
+	shutdownErr := h.Shutdown()
+	return errors.Join(shutdownErr, h.Start(cfg))
+}
+
+// Update refreshes conky_window from the frame geometry. It implements
+// render.FrameHooks.
+func (h *luaHooks) Update(frame render.FrameInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cairo.UpdateWindowInfoFull(lua.WindowInfo{
+		Width:             frame.Width,
+		Height:            frame.Height,
+		BorderInnerMargin: frame.BorderInnerMargin,
+		BorderOuterMargin: frame.BorderOuterMargin,
+		BorderWidth:       frame.BorderWidth,
+		TextStartX:        frame.TextStartX,
+		TextStartY:        frame.TextStartY,
+		TextWidth:         frame.TextWidth,
+		TextHeight:        frame.TextHeight,
+	})
+	return nil
+}
+
+// DrawWithoutWindow runs the draw hooks with no surface to draw on. It is
+// used when no window is shown, such as with out_to_x disabled; scripts see
+// conky_window as nil.
+func (h *luaHooks) DrawWithoutWindow() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return errors.Join(h.draw(lua.HookDrawPre, nil), h.draw(lua.HookDrawPost, nil))
+}
+
+// DrawPre runs the lua_draw_hook_pre hook onto layer. It implements
+// render.FrameHooks.
+func (h *luaHooks) DrawPre(layer *ebiten.Image) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.draw(lua.HookDrawPre, layer)
+}
+
+// DrawPost runs the lua_draw_hook_post hook onto layer. It implements
+// render.FrameHooks.
+func (h *luaHooks) DrawPost(layer *ebiten.Image) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.draw(lua.HookDrawPost, layer)
+}
+
+// draw runs a draw hook with the Cairo renderer targeting layer, so that
+// surfaces from cairo_xlib_surface_create draw onto the window. The caller
+// must hold h.mu.
+func (h *luaHooks) draw(hookType lua.HookType, layer *ebiten.Image) error {
+	renderer := h.cairo.Renderer()
+	renderer.SetScreen(layer)
+	defer renderer.SetScreen(nil)
+	return h.call(hookType)
+}
+
+// call runs a registered hook and records Lua metrics. Unregistered hooks
+// are a no-op. The caller must hold h.mu.
+func (h *luaHooks) call(hookType lua.HookType) error {
+	if !h.manager.IsRegistered(hookType) {
+		return nil
+	}
+
+	start := time.Now()
+	_, err := h.manager.Call(hookType)
+	if h.metrics != nil {
+		h.metrics.IncrementLuaExecutions()
+		h.metrics.RecordLuaLatency(time.Since(start))
+		if err != nil {
+			h.metrics.IncrementLuaErrors()
+		}
+	}
+	return err
+}
+
+// loadScript executes a single lua_load script.
+func (h *luaHooks) loadScript(script string) error {
+	if h.fsys != nil {
+		name := path.Clean(script)
+		if !path.IsAbs(name) && h.baseDir != "" {
+			name = path.Join(h.baseDir, name)
+		}
+		closure, err := h.runtime.LoadFileFromFS(h.fsys, name)
+		if err != nil {
+			return fmt.Errorf("lua_load: %w", err)
+		}
+		if _, err := h.runtime.Execute(closure); err != nil {
+			return fmt.Errorf("lua_load %s: %w", script, err)
+		}
+		return nil
+	}
+
+	name := expandHome(config.ExpandEnv(script))
+	if !filepath.IsAbs(name) && h.baseDir != "" {
+		name = filepath.Join(h.baseDir, name)
+	}
+	if _, err := h.runtime.ExecuteFile(name); err != nil {
+		return fmt.Errorf("lua_load: %w", err)
+	}
+	return nil
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
+
+// luaScriptsChanged reports whether the scripts or hooks differ between
+// two Lua configurations.
+func luaScriptsChanged(oldCfg, newCfg config.LuaConfig) bool {
+	return !slices.Equal(oldCfg.Load, newCfg.Load) ||
+		oldCfg.StartupHook != newCfg.StartupHook ||
+		oldCfg.ShutdownHook != newCfg.ShutdownHook ||
+		oldCfg.DrawHookPre != newCfg.DrawHookPre ||
+		oldCfg.DrawHookPost != newCfg.DrawHookPost
+}
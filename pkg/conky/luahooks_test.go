@@ -0,0 +1,319 @@
+package conky
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	rt "github.com/arnodel/golua/runtime"
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+const testHookScript = `
+calls = ""
+function conky_setup() calls = calls .. "startup;" end
+function conky_teardown() calls = calls .. "shutdown;" end
+function conky_ring(a, b) calls = calls .. "pre:" .. a .. b .. ";" end
+function conky_main() calls = calls .. "main;" end
+`
+
+func newTestLuaHooks(t *testing.T, cfg lua.RuntimeConfig, fsys fs.FS, baseDir string) (*luaHooks, *lua.ConkyRuntime) {
+	t.Helper()
+
+	runtime, err := lua.New(cfg)
+	if err != nil {
+		t.Fatalf("lua.New failed: %v", err)
+	}
+	t.Cleanup(func() { _ = runtime.Close() })
+
+	hooks, err := newLuaHooks(runtime, fsys, baseDir, NewMetrics())
+	if err != nil {
+		t.Fatalf("newLuaHooks failed: %v", err)
+	}
+	return hooks, runtime
+}
+
+func luaCalls(t *testing.T, runtime *lua.ConkyRuntime) string {
+	t.Helper()
+	s, _ := runtime.GetGlobal("calls").TryString()
+	return s
+}
+
+func TestLuaHooksLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hooks.lua"), []byte(testHookScript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), nil, dir)
+	err := hooks.Start(config.LuaConfig{
+		Load:         []string{"hooks.lua"},
+		StartupHook:  "setup",
+		ShutdownHook: "conky_teardown",
+		DrawHookPre:  "ring 4 cpu",
+	})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := hooks.Update(render.FrameInfo{}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := hooks.DrawPre(nil); err != nil {
+		t.Fatalf("DrawPre failed: %v", err)
+	}
+	if err := hooks.DrawPost(nil); err != nil {
+		t.Fatalf("DrawPost failed: %v", err)
+	}
+	if err := hooks.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	// A second shutdown must not run the hook again
+	if err := hooks.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	want := "startup;pre:4cpu;shutdown;"
+	if got := luaCalls(t, runtime); got != want {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+
+	snap := hooks.metrics.Snapshot()
+	if snap.LuaExecutions != 3 {
+		t.Errorf("LuaExecutions = %d, want 3", snap.LuaExecutions)
+	}
+}
+
+func TestLuaHooksDrawOntoLayer(t *testing.T) {
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), nil, "")
+	_, err := runtime.ExecuteString("ring", `
+function conky_ring()
+	if conky_window == nil then return end
+	surface = cairo_xlib_surface_create(conky_window.display, conky_window.drawable,
+		conky_window.visual, conky_window.width, conky_window.height)
+	local cr = cairo_create(surface)
+	cairo_arc(cr, 60, 40, 20, 0, 2 * math.pi)
+	cairo_stroke(cr)
+	cairo_destroy(cr)
+	window = conky_window.width .. "x" .. conky_window.height .. "+" ..
+		conky_window.text_start_x .. "+" .. conky_window.text_start_y .. " " ..
+		conky_window.text_width .. "x" .. conky_window.text_height
+end`)
+	if err != nil {
+		t.Fatalf("ExecuteString failed: %v", err)
+	}
+	if err := hooks.Start(config.LuaConfig{DrawHookPost: "ring"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	err = hooks.Update(render.FrameInfo{
+		Width: 120, Height: 80,
+		TextStartX: 5, TextStartY: 6, TextWidth: 100, TextHeight: 50,
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	layer := ebiten.NewImage(120, 80)
+	defer layer.Deallocate()
+	if err := hooks.DrawPost(layer); err != nil {
+		t.Fatalf("DrawPost failed: %v", err)
+	}
+
+	if got, _ := runtime.GetGlobal("window").TryString(); got != "120x80+5+6 100x50" {
+		t.Errorf("conky_window = %q, want %q", got, "120x80+5+6 100x50")
+	}
+
+	ud, ok := runtime.GetGlobal("surface").TryUserData()
+	if !ok {
+		t.Fatal("surface global is not userdata")
+	}
+	surface, ok := ud.Value().(*render.CairoSurface)
+	if !ok {
+		t.Fatalf("surface is %T, want *render.CairoSurface", ud.Value())
+	}
+	if surface.Image() != layer {
+		t.Error("cairo_xlib_surface_create surface does not draw onto the layer")
+	}
+	if hooks.cairo.Renderer().Screen() != nil {
+		t.Error("renderer screen still set after the draw hook")
+	}
+}
+
+func TestLuaHooksMainNotRunTwice(t *testing.T) {
+	fsys := fstest.MapFS{"theme/hooks.lua": {Data: []byte(testHookScript)}}
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), fsys, "theme")
+
+	err := hooks.Start(config.LuaConfig{
+		Load:         []string{"hooks.lua"},
+		DrawHookPost: "main",
+	})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	_ = hooks.Update(render.FrameInfo{})
+	_ = hooks.DrawPre(nil)
+	_ = hooks.DrawPost(nil)
+
+	if got := luaCalls(t, runtime); got != "main;" {
+		t.Errorf("calls = %q, want %q", got, "main;")
+	}
+}
+
+func TestLuaHooksMainNotImplicit(t *testing.T) {
+	fsys := fstest.MapFS{"hooks.lua": {Data: []byte(testHookScript)}}
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), fsys, "")
+
+	if err := hooks.Start(config.LuaConfig{Load: []string{"hooks.lua"}}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	_ = hooks.Update(render.FrameInfo{})
+	_ = hooks.DrawPre(nil)
+	_ = hooks.DrawPost(nil)
+	_ = hooks.DrawWithoutWindow()
+
+	// conky_main is only a function name; no setting binds it
+	if got := luaCalls(t, runtime); got != "" {
+		t.Errorf("calls = %q, want none", got)
+	}
+}
+
+func TestLuaHooksDrawWithoutWindow(t *testing.T) {
+	fsys := fstest.MapFS{"hooks.lua": {Data: []byte(testHookScript)}}
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), fsys, "")
+
+	err := hooks.Start(config.LuaConfig{
+		Load:         []string{"hooks.lua"},
+		DrawHookPre:  "ring 1 2",
+		DrawHookPost: "main",
+	})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := hooks.DrawWithoutWindow(); err != nil {
+		t.Fatalf("DrawWithoutWindow failed: %v", err)
+	}
+
+	if got := luaCalls(t, runtime); got != "pre:12;main;" {
+		t.Errorf("calls = %q, want %q", got, "pre:12;main;")
+	}
+}
+
+func TestLuaHooksErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hooks.lua"), []byte(testHookScript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), nil, dir)
+	err := hooks.Start(config.LuaConfig{
+		Load:        []string{"missing.lua", "hooks.lua"},
+		StartupHook: "setup",
+		DrawHookPre: "undefined_function",
+	})
+	if err == nil {
+		t.Fatal("expected error for missing script and undefined hook")
+	}
+
+	// The remaining script and hooks still load
+	if got := luaCalls(t, runtime); got != "startup;" {
+		t.Errorf("calls = %q, want %q", got, "startup;")
+	}
+}
+
+func TestLuaHooksCPULimit(t *testing.T) {
+	cfg := lua.DefaultConfig()
+	cfg.CPULimit = 100000
+	hooks, runtime := newTestLuaHooks(t, cfg, nil, "")
+
+	if _, err := runtime.ExecuteString("spin", "function conky_spin() while true do end end"); err != nil {
+		t.Fatalf("ExecuteString failed: %v", err)
+	}
+	if err := hooks.Start(config.LuaConfig{DrawHookPre: "spin"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := hooks.DrawPre(nil); err == nil {
+		t.Error("expected CPU limit error from runaway hook")
+	}
+	if snap := hooks.metrics.Snapshot(); snap.LuaErrors != 1 {
+		t.Errorf("LuaErrors = %d, want 1", snap.LuaErrors)
+	}
+}
+
+func TestLuaHooksReload(t *testing.T) {
+	hooks, runtime := newTestLuaHooks(t, lua.DefaultConfig(), nil, "")
+	if _, err := runtime.ExecuteString("setup", testHookScript); err != nil {
+		t.Fatalf("ExecuteString failed: %v", err)
+	}
+
+	if err := hooks.Start(config.LuaConfig{ShutdownHook: "teardown"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := hooks.Reload(config.LuaConfig{StartupHook: "setup"}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if got := luaCalls(t, runtime); got != "shutdown;startup;" {
+		t.Errorf("calls = %q, want %q", got, "shutdown;startup;")
+	}
+	if hooks.manager.IsRegistered(lua.HookShutdown) {
+		t.Error("shutdown hook still registered after reload")
+	}
+	if v := runtime.GetGlobal("conky_setup"); v.Type() != rt.FunctionType {
+		t.Error("conky_setup missing after reload")
+	}
+}
+
+func TestLuaScriptsChanged(t *testing.T) {
+	base := config.LuaConfig{Load: []string{"a.lua"}, DrawHookPre: "main", CPULimit: 1}
+
+	same := base
+	same.CPULimit = 2
+	if luaScriptsChanged(base, same) {
+		t.Error("limit change should not count as a script change")
+	}
+
+	changed := base
+	changed.Load = []string{"b.lua"}
+	if !luaScriptsChanged(base, changed) {
+		t.Error("expected change for different lua_load")
+	}
+
+	changed = base
+	changed.DrawHookPost = "post"
+	if !luaScriptsChanged(base, changed) {
+		t.Error("expected change for different draw hook")
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"~/.conky/rings.lua", filepath.Join(home, ".conky/rings.lua")},
+		{"~", home},
+		{"/abs/path.lua", "/abs/path.lua"},
+		{"~user/file.lua", "~user/file.lua"},
+		{"rel.lua", "rel.lua"},
+	}
+	for _, tt := range tests {
+		if got := expandHome(tt.input); got != tt.want {
+			t.Errorf("expandHome(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -10,6 +10,10 @@ import (
 // This can be overridden via Options.ShutdownTimeout.
 const DefaultShutdownTimeout = 5 * time.Second
 
+// DefaultHTTPHost is the address the out_to_http server listens on unless
+// Options.HTTPHost overrides it.
+const DefaultHTTPHost = "127.0.0.1"
+
 // Options configures the Conky instance behavior.
 type Options struct {
 	// UpdateInterval overrides the configuration file's update_interval.
@@ -51,6 +55,23 @@ type Options struct {
 	// Use ErrorTracker.SetAlertHandler() to receive alert notifications.
 	ErrorTracker *ErrorTracker
 
+	// MetricsAddr enables a Prometheus exporter serving the operational
+	// metrics and the collected system data at /metrics on this address
+	// (e.g. ":9101" or "127.0.0.1:9101"). Empty disables the exporter.
+	MetricsAddr string
+
+	// HTTPHost sets the host or IP address the out_to_http server listens
+	// on, with the port taken from http_port. Empty means DefaultHTTPHost,
+	// so the page and /api/v1/vars, which include process names and the top
+	// processes, are only reachable from this machine. Use "0.0.0.0" or "::"
+	// to serve other machines.
+	HTTPHost string
+
+	// HistoryLength sets the number of samples kept per metric for graphs,
+	// ${history_*} variables, conky_history() and Conky.History.
+	// Zero means use the default (120 samples).
+	HistoryLength int
+
 	// Platform sets a cross-platform monitoring provider.
 	// If nil, Linux-specific monitoring is used.
 	// Use cmd/conky-go platform wrapper to initialize this from internal/platform.
@@ -61,6 +82,11 @@ type Options struct {
 	// trigger an in-place config reload (via ReloadConfig) without restarting.
 	WatchConfig bool
 
+	// Policy restricts the commands and files available to the
+	// configuration. Set it when loading configurations from untrusted
+	// sources. Nil allows everything, as Conky does.
+	Policy *Policy
+
 	// WatchDebounce sets the debounce interval for file change events.
 	// Multiple rapid file modifications within this window trigger only
 	// a single reload. Zero means use the default (500ms).
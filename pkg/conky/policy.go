@@ -0,0 +1,155 @@
+package conky
+
+import (
+	"sync"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+)
+
+// ExecPolicy selects which shell commands a configuration may run.
+type ExecPolicy int
+
+const (
+	// ExecAllow runs any command, as Conky does.
+	ExecAllow ExecPolicy = iota
+	// ExecDeny runs no commands.
+	ExecDeny
+	// ExecAllowList runs only the commands in Policy.AllowedCommands.
+	ExecAllowList
+)
+
+// Policy restricts what a configuration from an untrusted source, such as
+// a theme shared by other users, can do. It applies to ${exec}, ${execi}
+// and the other exec variables, pre_exec, alert exec commands, ${image},
+// ${if_existing}, local mailboxes, the ${pid_*} variables showing another
+// process's command line, files or environment, lua_load scripts, Cairo
+// PNG files, file: password secrets and Lua's io, os and package
+// libraries. When a Policy restricts anything, env: password secrets are
+// refused and Lua configurations are parsed without io, os and package.
+//
+// Network access is not restricted: ${curl}, ${rss}, ${weather} and the
+// mail and MPD variables connect to any host the configuration names.
+//
+// Blocked operations fail and are reported as ErrorCategoryPolicy errors.
+type Policy struct {
+	// Exec selects which shell commands may run.
+	Exec ExecPolicy
+
+	// AllowedCommands lists the commands ExecAllowList runs. An entry
+	// matches a command line exactly, or the program of a command line
+	// without shell syntax such as pipes, redirections or quotes: "date"
+	// allows "date +%H:%M" but not "date; rm x".
+	AllowedCommands []string
+
+	// RestrictFiles limits file access to reading files under ReadRoots.
+	// No file can be written.
+	RestrictFiles bool
+
+	// ReadRoots lists the directories files may be read from when
+	// RestrictFiles is set, for example the theme's directory.
+	ReadRoots []string
+}
+
+// UntrustedPolicy returns a Policy that runs no commands and reads files
+// only under readRoots.
+func UntrustedPolicy(readRoots ...string) *Policy {
+	return &Policy{
+		Exec:          ExecDeny,
+		RestrictFiles: true,
+		ReadRoots:     readRoots,
+	}
+}
+
+// restricted reports whether p restricts anything. A nil Policy does not.
+func (p *Policy) restricted() bool {
+	return p != nil && (p.Exec != ExecAllow || p.RestrictFiles)
+}
+
+// luaPolicy converts p to the policy enforced by the Lua runtime.
+func (p *Policy) luaPolicy() lua.Policy {
+	if p == nil {
+		return lua.Policy{}
+	}
+	policy := lua.Policy{
+		AllowedCommands: p.AllowedCommands,
+		RestrictFiles:   p.RestrictFiles,
+		ReadRoots:       p.ReadRoots,
+	}
+	switch p.Exec {
+	case ExecAllow:
+		policy.Exec = lua.ExecAllowAll
+	case ExecAllowList:
+		policy.Exec = lua.ExecAllowListed
+	default:
+		policy.Exec = lua.ExecDenyAll
+	}
+	return policy
+}
+
+// newParser creates the configuration parser for opts: a sandboxed one
+// when the policy restricts anything.
+func newParser(opts *Options) (*config.Parser, error) {
+	if opts.Policy.restricted() {
+		return config.NewSandboxedParser()
+	}
+	return config.NewParser()
+}
+
+// maxReportedBlocks bounds the distinct blocked operations remembered to
+// report each only once to the error handler.
+const maxReportedBlocks = 1024
+
+// policyReporter records blocked operations. The first time an operation
+// is blocked it is reported like any other error, to the error handler and
+// as an EventError; repeats, such as a blocked ${exec} on every update,
+// are only recorded in the error tracker.
+type policyReporter struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// firstReport reports whether err has not been reported before.
+func (r *policyReporter) firstReport(err *lua.PolicyError) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := err.Op + "\x00" + err.Target
+	if r.seen[key] {
+		return false
+	}
+	if r.seen == nil || len(r.seen) >= maxReportedBlocks {
+		r.seen = make(map[string]bool)
+	}
+	r.seen[key] = true
+	return true
+}
+
+// recordPolicyError records an operation blocked by Options.Policy. First
+// reports are notified from a goroutine, since operations are blocked
+// while Start holds the instance lock to load Lua scripts.
+func (c *conkyImpl) recordPolicyError(err *lua.PolicyError) {
+	if c.policyReports.firstReport(err) {
+		go c.notifyCategorizedError(err, ErrorCategoryPolicy, SeverityWarning)
+		return
+	}
+	c.errorTracker.Record(NewCategorizedError(err, ErrorCategoryPolicy, SeverityWarning))
+}
+
+// allowedAlerts returns the alert rules of cfg with the exec commands the
+// policy does not allow removed.
+func (c *conkyImpl) allowedAlerts(cfg *config.Config) []config.AlertConfig {
+	if !c.opts.Policy.restricted() {
+		return cfg.Alerts
+	}
+	policy := c.opts.Policy.luaPolicy()
+	rules := make([]config.AlertConfig, len(cfg.Alerts))
+	for i, rc := range cfg.Alerts {
+		if rc.Exec != "" && !policy.AllowsCommand(rc.Exec) {
+			c.recordPolicyError(&lua.PolicyError{Op: "exec", Target: rc.Exec})
+			rc.Exec = ""
+		}
+		rules[i] = rc
+	}
+	return rules
+}
@@ -0,0 +1,118 @@
+package conky
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+)
+
+func TestPolicyBlocksUntrustedConfig(t *testing.T) {
+	theme := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	config := `# A shared theme
+TEXT
+${exec touch ` + marker + `}
+${if_existing ` + outside + `}found${else}missing${endif}
+`
+	tracker := NewErrorTracker(DefaultErrorTrackerConfig())
+	c, err := NewFromReader(strings.NewReader(config), FormatLegacy, &Options{
+		Headless:     true,
+		ErrorTracker: tracker,
+		Policy:       UntrustedPolicy(theme),
+	})
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	var handled atomic.Int32
+	c.SetErrorHandler(func(err error) {
+		if errors.Is(err, lua.ErrBlocked) {
+			handled.Add(1)
+		}
+	})
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer c.Stop()
+
+	impl := c.(*conkyImpl)
+	for range 3 {
+		var text []string
+		for _, line := range impl.textEval.Lines() {
+			text = append(text, line.Text)
+		}
+		if !slices.Contains(text, "missing") {
+			t.Errorf("text = %q, want the file outside the theme treated as missing", text)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the blocked command ran")
+	}
+
+	// Each blocked operation reaches the error handler once; repeats are
+	// only tracked
+	deadline := time.Now().Add(time.Second)
+	for handled.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := handled.Load(); n != 2 {
+		t.Errorf("error handler called %d times, want 2", n)
+	}
+	errs := tracker.RecentErrors(100)
+	if len(errs) < 6 {
+		t.Fatalf("recorded %d errors, want at least 6", len(errs))
+	}
+	for _, e := range errs {
+		if e.Category != ErrorCategoryPolicy || e.Severity != SeverityWarning {
+			t.Errorf("recorded %v %v, want a policy warning", e.Category, e.Severity)
+		}
+	}
+}
+
+func TestPolicyAllowedAlerts(t *testing.T) {
+	cfg := &config.Config{Alerts: []config.AlertConfig{
+		{Name: "notify", Condition: "cpu.total > 90", Exec: "notify-send hot"},
+		{Name: "shell", Condition: "cpu.total > 90", Exec: "notify-send hot; rm x"},
+	}}
+
+	c := &conkyImpl{opts: Options{}, errorTracker: NewErrorTracker(DefaultErrorTrackerConfig())}
+	if rules := c.allowedAlerts(cfg); rules[0].Exec == "" || rules[1].Exec == "" {
+		t.Errorf("allowedAlerts() without a policy = %+v, want commands kept", rules)
+	}
+
+	c.opts.Policy = &Policy{Exec: ExecAllowList, AllowedCommands: []string{"notify-send"}}
+	rules := c.allowedAlerts(cfg)
+	if rules[0].Exec != "notify-send hot" || rules[1].Exec != "" || rules[1].Name != "shell" {
+		t.Errorf("allowedAlerts() = %+v, want only the plain notify-send command kept", rules)
+	}
+	if cfg.Alerts[1].Exec == "" {
+		t.Error("allowedAlerts() modified the configuration")
+	}
+}
+
+func TestPolicyLuaPolicy(t *testing.T) {
+	var nilPolicy *Policy
+	if nilPolicy.restricted() || nilPolicy.luaPolicy().Exec != lua.ExecAllowAll {
+		t.Error("nil Policy restricts something")
+	}
+	if p := (&Policy{Exec: ExecAllowList}).luaPolicy(); p.Exec != lua.ExecAllowListed {
+		t.Errorf("ExecAllowList converted to %v", p.Exec)
+	}
+	p := UntrustedPolicy("/themes").luaPolicy()
+	if p.Exec != lua.ExecDenyAll || !p.RestrictFiles || len(p.ReadRoots) != 1 {
+		t.Errorf("UntrustedPolicy converted to %+v", p)
+	}
+}
@@ -0,0 +1,323 @@
+package conky
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+// promContentType is the content type of the Prometheus text format.
+const promContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// promExporter serves the operational metrics and the system monitor data
+// in the Prometheus text exposition format at /metrics, so an instance can
+// double as a lightweight node exporter. It is enabled by
+// Options.MetricsAddr.
+type promExporter struct {
+	metrics      *Metrics
+	errorTracker *ErrorTracker
+	monitor      *monitor.SystemMonitor
+
+	server *backgroundServer
+}
+
+// newPromExporter creates an exporter for the given sources. Any of them
+// may be nil, in which case its metrics are omitted.
+func newPromExporter(metrics *Metrics, errorTracker *ErrorTracker, sm *monitor.SystemMonitor) *promExporter {
+	return &promExporter{metrics: metrics, errorTracker: errorTracker, monitor: sm}
+}
+
+// Handler returns the HTTP handler serving /metrics.
+func (p *promExporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", p.serveMetrics)
+	return mux
+}
+
+// Start listens on addr and serves requests in the background.
+func (p *promExporter) Start(addr string) error {
+	server, err := startBackgroundServer(addr, p.Handler())
+	if err != nil {
+		return fmt.Errorf("metrics exporter: %w", err)
+	}
+	p.server = server
+	return nil
+}
+
+// Close stops the server.
+func (p *promExporter) Close() error {
+	if p.server == nil {
+		return nil
+	}
+	return p.server.Close()
+}
+
+// serveMetrics writes all metrics in the text exposition format.
+func (p *promExporter) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", promContentType)
+	pw := &promWriter{w: bufio.NewWriter(w)}
+	if p.metrics != nil {
+		p.writeOperational(pw)
+	}
+	if p.errorTracker != nil {
+		p.writeErrors(pw)
+	}
+	if p.monitor != nil {
+		p.writeSystem(pw)
+	}
+	_ = pw.w.Flush()
+}
+
+// writeOperational writes the counters, gauges and latencies from Metrics.
+func (p *promExporter) writeOperational(pw *promWriter) {
+	snap := p.metrics.Snapshot()
+
+	counters := []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"conky_starts_total", "Number of instance starts.", snap.Starts},
+		{"conky_stops_total", "Number of instance stops.", snap.Stops},
+		{"conky_restarts_total", "Number of instance restarts.", snap.Restarts},
+		{"conky_config_reloads_total", "Number of configuration reloads.", snap.ConfigReloads},
+		{"conky_update_cycles_total", "Number of completed update cycles.", snap.UpdateCycles},
+		{"conky_errors_total", "Number of runtime errors.", snap.ErrorsTotal},
+		{"conky_events_emitted_total", "Number of lifecycle events emitted.", snap.EventsEmitted},
+		{"conky_lua_executions_total", "Number of Lua hook executions.", snap.LuaExecutions},
+		{"conky_lua_errors_total", "Number of failed Lua hook executions.", snap.LuaErrors},
+		{"conky_remote_commands_total", "Number of remote commands executed.", snap.RemoteCommands},
+	}
+	for _, c := range counters {
+		pw.family(c.name, c.help, "counter")
+		pw.sample(c.name, nil, float64(c.value))
+	}
+
+	pw.family("conky_running", "Whether the instance is running (1) or not (0).", "gauge")
+	pw.sample("conky_running", nil, boolFloat(snap.Running))
+	pw.family("conky_active_monitors", "Number of active system monitors.", "gauge")
+	pw.sample("conky_active_monitors", nil, float64(snap.ActiveMonitors))
+
+	latencies := []struct {
+		name  string
+		help  string
+		value float64
+	}{
+		{"conky_update_latency_avg_seconds", "Average update cycle latency.", snap.UpdateLatencyAvg.Seconds()},
+		{"conky_lua_latency_avg_seconds", "Average Lua hook execution latency.", snap.LuaLatencyAvg.Seconds()},
+		{"conky_render_latency_avg_seconds", "Average frame render latency.", snap.RenderLatencyAvg.Seconds()},
+	}
+	for _, l := range latencies {
+		pw.family(l.name, l.help, "gauge")
+		pw.sample(l.name, nil, l.value)
+	}
+}
+
+// writeErrors writes the per-category error totals from the ErrorTracker.
+func (p *promExporter) writeErrors(pw *promWriter) {
+	stats := p.errorTracker.Stats()
+
+	pw.family("conky_tracked_errors_total", "Errors recorded by the error tracker, by category.", "counter")
+	for _, cc := range stats.TotalByCategory {
+		pw.sample("conky_tracked_errors_total", []string{"category", cc.Category.String()}, float64(cc.Count))
+	}
+	pw.family("conky_tracked_errors_retained", "Errors in the error tracker's retention window.", "gauge")
+	pw.sample("conky_tracked_errors_retained", nil, float64(stats.TotalErrors))
+}
+
+// writeSystem writes the collected system data as labelled gauges.
+func (p *promExporter) writeSystem(pw *promWriter) {
+	cpu := p.monitor.CPU()
+	pw.family("conky_cpu_usage_percent", "Total CPU usage.", "gauge")
+	pw.sample("conky_cpu_usage_percent", nil, cpu.UsagePercent)
+	pw.family("conky_cpu_core_usage_percent", "CPU usage per core.", "gauge")
+	for i, usage := range cpu.Cores {
+		pw.sample("conky_cpu_core_usage_percent", []string{"core", strconv.Itoa(i)}, usage)
+	}
+
+	mem := p.monitor.Memory()
+	memory := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"conky_memory_total_bytes", "Total physical memory.", mem.Total},
+		{"conky_memory_used_bytes", "Used physical memory.", mem.Used},
+		{"conky_memory_available_bytes", "Available physical memory.", mem.Available},
+		{"conky_swap_total_bytes", "Total swap space.", mem.SwapTotal},
+		{"conky_swap_used_bytes", "Used swap space.", mem.SwapUsed},
+	}
+	for _, m := range memory {
+		pw.family(m.name, m.help, "gauge")
+		pw.sample(m.name, nil, float64(m.value))
+	}
+
+	pw.family("conky_uptime_seconds", "System uptime.", "gauge")
+	pw.sample("conky_uptime_seconds", nil, p.monitor.Uptime().Seconds)
+
+	p.writeNetwork(pw, p.monitor.Network())
+	p.writeFilesystems(pw, p.monitor.Filesystem())
+	p.writeHwmon(pw, p.monitor.Hwmon())
+	p.writeBattery(pw, p.monitor.Battery())
+}
+
+// writeNetwork writes per-interface traffic counters and rates.
+func (p *promExporter) writeNetwork(pw *promWriter, net monitor.NetworkStats) {
+	names := sortedKeys(net.Interfaces)
+	series := []struct {
+		name  string
+		help  string
+		typ   string
+		value func(monitor.InterfaceStats) float64
+	}{
+		{"conky_network_receive_bytes_total", "Bytes received per interface.", "counter",
+			func(s monitor.InterfaceStats) float64 { return float64(s.RxBytes) }},
+		{"conky_network_transmit_bytes_total", "Bytes transmitted per interface.", "counter",
+			func(s monitor.InterfaceStats) float64 { return float64(s.TxBytes) }},
+		{"conky_network_receive_bytes_per_second", "Receive rate per interface.", "gauge",
+			func(s monitor.InterfaceStats) float64 { return s.RxBytesPerSec }},
+		{"conky_network_transmit_bytes_per_second", "Transmit rate per interface.", "gauge",
+			func(s monitor.InterfaceStats) float64 { return s.TxBytesPerSec }},
+	}
+	for _, s := range series {
+		pw.family(s.name, s.help, s.typ)
+		for _, name := range names {
+			pw.sample(s.name, []string{"interface", name}, s.value(net.Interfaces[name]))
+		}
+	}
+}
+
+// writeFilesystems writes per-mount capacity and usage.
+func (p *promExporter) writeFilesystems(pw *promWriter, fs monitor.FilesystemStats) {
+	mounts := sortedKeys(fs.Mounts)
+	series := []struct {
+		name  string
+		help  string
+		value func(monitor.MountStats) float64
+	}{
+		{"conky_filesystem_size_bytes", "Filesystem size per mount.",
+			func(m monitor.MountStats) float64 { return float64(m.Total) }},
+		{"conky_filesystem_used_bytes", "Used space per mount.",
+			func(m monitor.MountStats) float64 { return float64(m.Used) }},
+		{"conky_filesystem_avail_bytes", "Space available to unprivileged users per mount.",
+			func(m monitor.MountStats) float64 { return float64(m.Available) }},
+		{"conky_filesystem_usage_percent", "Space usage per mount.",
+			func(m monitor.MountStats) float64 { return m.UsagePercent }},
+	}
+	for _, s := range series {
+		pw.family(s.name, s.help, "gauge")
+		for _, mountPoint := range mounts {
+			m := fs.Mounts[mountPoint]
+			labels := []string{"mountpoint", mountPoint, "device", m.Device, "fstype", m.FSType}
+			pw.sample(s.name, labels, s.value(m))
+		}
+	}
+}
+
+// writeHwmon writes every hwmon temperature, fan, voltage, power and
+// current sensor.
+func (p *promExporter) writeHwmon(pw *promWriter, hwmon monitor.HwmonStats) {
+	devNames := sortedKeys(hwmon.Devices)
+
+	pw.family("conky_hwmon_temperature_celsius", "Hardware monitor temperature.", "gauge")
+	for _, devName := range devNames {
+		dev := hwmon.Devices[devName]
+		for _, sensor := range sortedKeys(dev.Temps) {
+			temp := dev.Temps[sensor]
+			labels := []string{"chip", devName, "chip_name", dev.Name, "sensor", sensor, "label", temp.Label}
+			pw.sample("conky_hwmon_temperature_celsius", labels, temp.InputCelsius)
+		}
+	}
+
+	families := []struct {
+		name, help string
+		sensors    func(monitor.HwmonDevice) map[string]monitor.HwmonSensor
+	}{
+		{"conky_hwmon_fan_rpm", "Hardware monitor fan speed.", func(d monitor.HwmonDevice) map[string]monitor.HwmonSensor { return d.Fans }},
+		{"conky_hwmon_voltage_volts", "Hardware monitor voltage.", func(d monitor.HwmonDevice) map[string]monitor.HwmonSensor { return d.Voltages }},
+		{"conky_hwmon_power_watts", "Hardware monitor power.", func(d monitor.HwmonDevice) map[string]monitor.HwmonSensor { return d.Power }},
+		{"conky_hwmon_current_amperes", "Hardware monitor current.", func(d monitor.HwmonDevice) map[string]monitor.HwmonSensor { return d.Currents }},
+	}
+	for _, f := range families {
+		pw.family(f.name, f.help, "gauge")
+		for _, devName := range devNames {
+			dev := hwmon.Devices[devName]
+			sensors := f.sensors(dev)
+			for _, sensor := range sortedKeys(sensors) {
+				labels := []string{"chip", devName, "chip_name", dev.Name, "sensor", sensor, "label", sensors[sensor].Label}
+				pw.sample(f.name, labels, sensors[sensor].Input)
+			}
+		}
+	}
+}
+
+// writeBattery writes per-battery charge and the AC adapter state.
+func (p *promExporter) writeBattery(pw *promWriter, bat monitor.BatteryStats) {
+	names := sortedKeys(bat.Batteries)
+
+	pw.family("conky_battery_capacity_percent", "Battery charge.", "gauge")
+	for _, name := range names {
+		pw.sample("conky_battery_capacity_percent", []string{"battery", name}, float64(bat.Batteries[name].Capacity))
+	}
+	pw.family("conky_battery_info", "Battery status, always 1.", "gauge")
+	for _, name := range names {
+		pw.sample("conky_battery_info", []string{"battery", name, "status", bat.Batteries[name].Status}, 1)
+	}
+	pw.family("conky_ac_online", "Whether AC power is connected (1) or not (0).", "gauge")
+	pw.sample("conky_ac_online", nil, boolFloat(bat.ACOnline))
+}
+
+// promWriter writes metric families in the Prometheus text format.
+type promWriter struct {
+	w *bufio.Writer
+}
+
+// family writes the HELP and TYPE lines of a metric family.
+func (pw *promWriter) family(name, help, typ string) {
+	fmt.Fprintf(pw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one sample. labels alternates label names and values.
+func (pw *promWriter) sample(name string, labels []string, value float64) {
+	pw.w.WriteString(name)
+	if len(labels) > 0 {
+		pw.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				pw.w.WriteByte(',')
+			}
+			fmt.Fprintf(pw.w, "%s=\"%s\"", labels[i], promLabelEscaper.Replace(labels[i+1]))
+		}
+		pw.w.WriteByte('}')
+	}
+	pw.w.WriteByte(' ')
+	pw.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	pw.w.WriteByte('\n')
+}
+
+// promLabelEscaper escapes label values as required by the text format.
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sortedKeys returns the keys of m in sorted order, so that series are
+// written in a stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// boolFloat converts b to 1 or 0.
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
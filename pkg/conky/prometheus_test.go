@@ -0,0 +1,124 @@
+package conky
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+func TestPromExporterOperationalMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.IncrementStarts()
+	metrics.IncrementUpdateCycles()
+	metrics.IncrementUpdateCycles()
+	metrics.SetRunning(true)
+	metrics.RecordUpdateLatency(250 * time.Millisecond)
+
+	tracker := NewErrorTracker(DefaultErrorTrackerConfig())
+	tracker.Record(NewCategorizedError(errors.New("script failed"), ErrorCategoryLua, SeverityError))
+
+	server := httptest.NewServer(newPromExporter(metrics, tracker, nil).Handler())
+	defer server.Close()
+
+	body, header := getBody(t, server.URL+"/metrics")
+	if ct := header.Get("Content-Type"); ct != promContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, promContentType)
+	}
+	for _, want := range []string{
+		"# TYPE conky_starts_total counter\nconky_starts_total 1\n",
+		"conky_update_cycles_total 2\n",
+		"# TYPE conky_running gauge\nconky_running 1\n",
+		"conky_update_latency_avg_seconds 0.25\n",
+		`conky_tracked_errors_total{category="lua"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "conky_cpu_usage_percent") {
+		t.Error("system metrics written without a monitor")
+	}
+}
+
+func TestPromExporterSystemMetrics(t *testing.T) {
+	sm := monitor.NewSystemMonitor(time.Second)
+	server := httptest.NewServer(newPromExporter(nil, nil, sm).Handler())
+	defer server.Close()
+
+	body, _ := getBody(t, server.URL+"/metrics")
+	for _, want := range []string{
+		"# TYPE conky_cpu_usage_percent gauge\n",
+		"# TYPE conky_cpu_core_usage_percent gauge\n",
+		"# TYPE conky_memory_total_bytes gauge\n",
+		"# TYPE conky_network_receive_bytes_total counter\n",
+		"# TYPE conky_filesystem_used_bytes gauge\n",
+		"# TYPE conky_hwmon_temperature_celsius gauge\n",
+		"# TYPE conky_hwmon_fan_rpm gauge\n",
+		"# TYPE conky_battery_capacity_percent gauge\n",
+		"conky_ac_online ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
+
+func TestPromWriterLabels(t *testing.T) {
+	var sb strings.Builder
+	pw := &promWriter{w: bufio.NewWriter(&sb)}
+	pw.sample("conky_test", []string{"a", `x"y`, "b", "c\\d\ne"}, 1.5)
+	pw.sample("conky_test", nil, 3)
+	_ = pw.w.Flush()
+
+	want := `conky_test{a="x\"y",b="c\\d\ne"} 1.5` + "\nconky_test 3\n"
+	if sb.String() != want {
+		t.Errorf("output = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestPromExporterAddrInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer busy.Close()
+
+	// Reserve a free port for the HTTP output
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	httpAddr := free.Addr().String()
+	_ = free.Close()
+	port := httpAddr[strings.LastIndex(httpAddr, ":")+1:]
+
+	opts := DefaultOptions()
+	opts.MetricsAddr = busy.Addr().String()
+	cfg := fmt.Sprintf("out_to_x no\nout_to_http yes\nhttp_port %s\nTEXT\nHello\n", port)
+	c, err := NewFromReader(strings.NewReader(cfg), "legacy", &opts)
+	if err != nil {
+		t.Fatalf("NewFromReader failed: %v", err)
+	}
+	if err := c.Start(); err == nil {
+		_ = c.Stop()
+		t.Fatal("Start succeeded with the metrics address in use")
+	}
+
+	impl := c.(*conkyImpl)
+	if impl.httpOutput != nil || impl.luaRuntime != nil || impl.luaAPI != nil {
+		t.Error("HTTP output or Lua runtime left open after the failed start")
+	}
+	// The HTTP output's port is free again
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		t.Fatalf("HTTP port still in use after the failed start: %v", err)
+	}
+	_ = ln.Close()
+}
@@ -35,8 +35,8 @@ func (gr *gameRunner) run(c *conkyImpl) {
 	height := c.cfg.Window.Height
 	title := c.opts.WindowTitle
 	interval := c.cfg.Display.UpdateInterval
-	textLines := c.cfg.Text.Template
-	textColor := c.cfg.Colors.Default
+	textEval := c.textEval
+	hooks := c.luaHooks
 	transparent := c.cfg.Window.Transparent
 	argbVisual := c.cfg.Window.ARGBVisual
 	argbValue := c.cfg.Window.ARGBValue
@@ -45,6 +45,7 @@ func (gr *gameRunner) run(c *conkyImpl) {
 	windowY := c.cfg.Window.Y
 	bgMode := c.cfg.Window.BackgroundMode
 	bgColour := c.cfg.Window.BackgroundColour
+	palette := configToRenderPalette(c.cfg.Colors)
 	ctx := c.ctx
 	logger := c.opts.Logger
 	c.mu.RUnlock()
@@ -82,11 +83,6 @@ func (gr *gameRunner) run(c *conkyImpl) {
 	// Convert config.BackgroundMode to render.BackgroundMode
 	renderBgMode := configToRenderBackgroundMode(bgMode)
 
-	// Default text color is white if not specified in config
-	if textColor == (color.RGBA{}) {
-		textColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
-	}
-
 	// Parse window hints into render config flags
 	undecorated, floating, skipTaskbar, skipPager := parseWindowHints(windowHints, logger)
 
@@ -107,27 +103,25 @@ func (gr *gameRunner) run(c *conkyImpl) {
 		WindowY:         windowY,
 		SkipTaskbar:     skipTaskbar,
 		SkipPager:       skipPager,
+		Colors:          palette,
 	}
 
 	// Create the game instance
 	gr.game = render.NewGame(renderConfig)
 	gr.game.SetDataProvider(c.monitor)
+	gr.game.SetHistorySource(graphHistory{monitor: c.monitor})
 	gr.game.SetContext(ctx)
 
-	// Set up initial text lines from configuration template
-	if len(textLines) > 0 {
-		lines := make([]render.TextLine, 0, len(textLines))
-		y := defaultTextStartY
-		for _, text := range textLines {
-			lines = append(lines, render.TextLine{
-				Text:  text,
-				X:     defaultTextStartX,
-				Y:     y,
-				Color: textColor,
-			})
-			y += defaultLineHeight
-		}
-		gr.game.SetLines(lines)
+	// Show the text template once up front, then let the game
+	// re-evaluate it on every update interval
+	if textEval != nil {
+		gr.game.SetLines(textEval.Preview())
+		gr.game.SetLineProvider(textEval)
+	}
+
+	// Run the Lua main and draw hooks on every update
+	if hooks != nil {
+		gr.game.SetFrameHooks(hooks)
 	}
 
 	// Run the Ebiten game loop (blocks until window close or context cancel)
@@ -149,6 +143,15 @@ func configToRenderBackgroundMode(mode config.BackgroundMode) render.BackgroundM
 	}
 }
 
+// configToRenderPalette converts the color0-color9 definitions into the
+// palette used by ${colorN} layout markers.
+func configToRenderPalette(colors config.ColorConfig) [10]color.RGBA {
+	return [10]color.RGBA{
+		colors.Color0, colors.Color1, colors.Color2, colors.Color3, colors.Color4,
+		colors.Color5, colors.Color6, colors.Color7, colors.Color8, colors.Color9,
+	}
+}
+
 // parseWindowHints converts config.WindowHint slice to individual render flags.
 // Returns: undecorated, floating (above), skipTaskbar, skipPager
 // Emits warnings via logger for unsupported hints (below, sticky).
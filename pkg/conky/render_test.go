@@ -1,6 +1,7 @@
 package conky
 
 import (
+	"image/color"
 	"testing"
 
 	"github.com/opd-ai/go-conky/internal/config"
@@ -253,3 +254,19 @@ func TestNewGameRunner(t *testing.T) {
 		t.Error("newGameRunner() should have nil game initially")
 	}
 }
+
+func TestConfigToRenderPalette(t *testing.T) {
+	colors := config.ColorConfig{
+		Color0: color.RGBA{R: 1, A: 255},
+		Color5: color.RGBA{G: 5, A: 255},
+		Color9: color.RGBA{B: 9, A: 255},
+	}
+
+	palette := configToRenderPalette(colors)
+	if palette[0] != colors.Color0 || palette[5] != colors.Color5 || palette[9] != colors.Color9 {
+		t.Errorf("configToRenderPalette() = %v, want color0/5/9 preserved", palette)
+	}
+	if palette[1] != (color.RGBA{}) {
+		t.Errorf("palette[1] = %v, want zero value for unset color", palette[1])
+	}
+}
@@ -0,0 +1,94 @@
+package conky
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+// serviceSettings is the part of the system monitor configured by the mail,
+// MPD and weather settings.
+type serviceSettings interface {
+	AddMailAccount(cfg monitor.MailConfig) error
+	RemoveMailAccount(name string)
+	SetMailSpool(path string)
+	SetMPDHost(host string)
+	SetMPDPort(port int)
+	SetMPDPassword(password string)
+	SetWeatherProvider(provider monitor.WeatherProvider)
+	SetWeatherUnits(units string)
+}
+
+// applyServices configures the mail spool, mail servers, MPD connection and
+// weather provider of cfg on mon. The imap and pop3 servers are monitored as accounts named
+// "imap" and "pop3", which ${imap_unseen} and the other mail variables read
+// when given no arguments. Passwords are resolved with secrets, which may
+// refuse env: and file: references. A server whose password cannot be resolved is left out and
+// reported in the returned error; the remaining settings are still applied.
+func applyServices(mon serviceSettings, cfg *config.Config, secrets config.SecretPolicy) error {
+	var errs []error
+
+	mon.SetMailSpool(cfg.Mail.Spool)
+
+	servers := []struct {
+		mailType string
+		server   *config.MailServerConfig
+	}{
+		{"imap", cfg.Mail.IMAP},
+		{"pop3", cfg.Mail.POP3},
+	}
+	for _, s := range servers {
+		mon.RemoveMailAccount(s.mailType)
+		if s.server == nil {
+			continue
+		}
+		password, err := secrets.Resolve(s.server.Password)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s password: %w", s.mailType, err))
+			continue
+		}
+		err = mon.AddMailAccount(monitor.MailConfig{
+			Name:     s.mailType,
+			Type:     s.mailType,
+			Host:     s.server.Host,
+			Port:     s.server.Port,
+			Username: s.server.User,
+			Password: password,
+			UseTLS:   s.server.TLS,
+			Folder:   s.server.Folder,
+			Interval: s.server.Interval,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.mailType, err))
+		}
+	}
+
+	host, port := cfg.MPD.Host, cfg.MPD.Port
+	if host == "" {
+		host = config.DefaultMPDHost
+	}
+	if port == 0 {
+		port = config.DefaultMPDPort
+	}
+	mon.SetMPDHost(host)
+	mon.SetMPDPort(port)
+	password, err := secrets.Resolve(cfg.MPD.Password)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("mpd_password: %w", err))
+		password = ""
+	}
+	mon.SetMPDPassword(password)
+
+	provider, err := monitor.NewWeatherProvider(cfg.Weather.Provider, cfg.Weather.URL)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("weather_provider: %w", err))
+	} else {
+		mon.SetWeatherProvider(provider)
+	}
+	mon.SetWeatherUnits(strings.ToLower(cfg.Weather.Units))
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,172 @@
+package conky
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+	"github.com/opd-ai/go-conky/internal/monitor"
+)
+
+// recordingServices records the settings applied by applyServices.
+type recordingServices struct {
+	accounts    map[string]monitor.MailConfig
+	removed     []string
+	spool       string
+	mpdHost     string
+	mpdPort     int
+	mpdPassword string
+	weather     monitor.WeatherProvider
+	units       string
+}
+
+func (s *recordingServices) AddMailAccount(cfg monitor.MailConfig) error {
+	s.accounts[cfg.Name] = cfg
+	return nil
+}
+
+func (s *recordingServices) RemoveMailAccount(name string) {
+	delete(s.accounts, name)
+	s.removed = append(s.removed, name)
+}
+
+func (s *recordingServices) SetMailSpool(path string)                     { s.spool = path }
+func (s *recordingServices) SetMPDHost(host string)                       { s.mpdHost = host }
+func (s *recordingServices) SetMPDPort(port int)                          { s.mpdPort = port }
+func (s *recordingServices) SetMPDPassword(password string)               { s.mpdPassword = password }
+func (s *recordingServices) SetWeatherProvider(p monitor.WeatherProvider) { s.weather = p }
+func (s *recordingServices) SetWeatherUnits(units string)                 { s.units = units }
+
+func TestApplyServices(t *testing.T) {
+	t.Setenv("TEST_IMAP_PASSWORD", "hunter2")
+	svc := &recordingServices{accounts: map[string]monitor.MailConfig{
+		"pop3": {Name: "pop3", Type: "pop3", Host: "old.example.com"},
+	}}
+
+	cfg := config.DefaultConfig()
+	cfg.Mail.Spool = "~/Maildir"
+	cfg.Mail.IMAP = &config.MailServerConfig{
+		Host:     "imap.example.com",
+		User:     "bob",
+		Password: "env:TEST_IMAP_PASSWORD",
+		Folder:   "Work",
+		Interval: 10 * time.Minute,
+		TLS:      true,
+	}
+	cfg.MPD = config.MPDConfig{Host: "music.local", Password: "secret"}
+	cfg.Weather = config.WeatherConfig{Provider: "open-meteo", URL: "http://weather.lan", Units: "Imperial"}
+
+	if err := applyServices(svc, &cfg, config.SecretPolicy{}); err != nil {
+		t.Fatalf("applyServices() error = %v", err)
+	}
+
+	want := monitor.MailConfig{
+		Name:     "imap",
+		Type:     "imap",
+		Host:     "imap.example.com",
+		Username: "bob",
+		Password: "hunter2",
+		UseTLS:   true,
+		Folder:   "Work",
+		Interval: 10 * time.Minute,
+	}
+	if got := svc.accounts["imap"]; got != want {
+		t.Errorf("imap account = %+v, want %+v", got, want)
+	}
+	if _, ok := svc.accounts["pop3"]; ok {
+		t.Error("pop3 account from the previous config was not removed")
+	}
+	if svc.spool != "~/Maildir" {
+		t.Errorf("spool = %q, want ~/Maildir", svc.spool)
+	}
+	if svc.mpdHost != "music.local" || svc.mpdPort != config.DefaultMPDPort || svc.mpdPassword != "secret" {
+		t.Errorf("mpd = %s:%d %q, want music.local:%d %q", svc.mpdHost, svc.mpdPort, svc.mpdPassword, config.DefaultMPDPort, "secret")
+	}
+	if svc.weather == nil || svc.weather.Name() != "open-meteo" || svc.units != monitor.WeatherImperial {
+		t.Errorf("weather = %v %q, want open-meteo imperial", svc.weather, svc.units)
+	}
+}
+
+func TestApplyServicesWeatherError(t *testing.T) {
+	svc := &recordingServices{accounts: map[string]monitor.MailConfig{}}
+
+	cfg := config.DefaultConfig()
+	cfg.Weather.Provider = "openweathermap"
+
+	err := applyServices(svc, &cfg, config.SecretPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "weather_provider") {
+		t.Errorf("applyServices() error = %v, want weather_provider error", err)
+	}
+	if svc.weather != nil {
+		t.Errorf("weather provider = %v, want unchanged", svc.weather)
+	}
+}
+
+func TestApplyServicesSecretError(t *testing.T) {
+	svc := &recordingServices{accounts: map[string]monitor.MailConfig{}}
+
+	cfg := config.DefaultConfig()
+	cfg.Mail.POP3 = &config.MailServerConfig{Host: "pop.example.com", User: "bob", Password: "env:TEST_UNSET_MAIL_PASSWORD"}
+	cfg.MPD.Host = "music.local"
+
+	err := applyServices(svc, &cfg, config.SecretPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "pop3 password") {
+		t.Errorf("applyServices() error = %v, want pop3 password error", err)
+	}
+	if _, ok := svc.accounts["pop3"]; ok {
+		t.Error("pop3 account added without a password")
+	}
+	if svc.mpdHost != "music.local" {
+		t.Errorf("mpd host = %q, want settings after the error applied", svc.mpdHost)
+	}
+}
+
+func TestApplyServicesSecretPolicy(t *testing.T) {
+	t.Setenv("TEST_MPD_PASSWORD", "hunter2")
+	theme := t.TempDir()
+	inside := filepath.Join(theme, "imap-pass")
+	outside := filepath.Join(t.TempDir(), "id_rsa")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	luaCfg := lua.DefaultConfig()
+	luaCfg.Policy = UntrustedPolicy(theme).luaPolicy()
+	runtime, err := lua.New(luaCfg)
+	if err != nil {
+		t.Fatalf("lua.New failed: %v", err)
+	}
+	defer runtime.Close()
+
+	svc := &recordingServices{accounts: map[string]monitor.MailConfig{}}
+	cfg := config.DefaultConfig()
+	cfg.Mail.IMAP = &config.MailServerConfig{Host: "imap.example.com", User: "bob", Password: "file:" + inside}
+	cfg.Mail.POP3 = &config.MailServerConfig{Host: "attacker.example", User: "bob", Password: "file:" + outside}
+	cfg.MPD.Password = "env:TEST_MPD_PASSWORD"
+
+	err = applyServices(svc, &cfg, runtime.SecretPolicy())
+	if !errors.Is(err, lua.ErrBlocked) {
+		t.Fatalf("applyServices() error = %v, want blocked secrets", err)
+	}
+	for _, want := range []string{"pop3 password", "mpd_password"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("applyServices() error = %v, want %s refused", err, want)
+		}
+	}
+	if got := svc.accounts["imap"].Password; got != "secret" {
+		t.Errorf("imap password = %q, want the secret under the read root", got)
+	}
+	if _, ok := svc.accounts["pop3"]; ok {
+		t.Error("pop3 account added with a secret outside the read roots")
+	}
+	if svc.mpdPassword != "" {
+		t.Errorf("mpd password = %q, want the env: secret refused", svc.mpdPassword)
+	}
+}
@@ -50,6 +50,10 @@ const (
 	EventError
 	// EventWarning is emitted when a non-fatal warning condition is detected.
 	EventWarning
+	// EventAlert is emitted when an alert rule fires.
+	EventAlert
+	// EventAlertResolved is emitted when a firing alert rule resolves.
+	EventAlertResolved
 )
 
 // String returns a human-readable representation of the event type.
@@ -67,6 +71,10 @@ func (e EventType) String() string {
 		return "error"
 	case EventWarning:
 		return "warning"
+	case EventAlert:
+		return "alert"
+	case EventAlertResolved:
+		return "alert_resolved"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,131 @@
+package conky
+
+import (
+	"image/color"
+	"strings"
+	"sync"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+// textEvaluator renders the conky.text template through the Conky Lua API.
+// It implements render.LineProvider so the Game re-evaluates the template
+// on each update interval and draws live values instead of raw
+// ${variable} placeholders.
+type textEvaluator struct {
+	api *lua.ConkyAPI
+
+	mu       sync.RWMutex
+	template *lua.Template // nil if conky.text is empty
+	color    color.RGBA
+	sinks    []textSink
+}
+
+// textSink receives the lines produced by every evaluation of conky.text,
+// for outputs other than the window such as out_to_console.
+type textSink interface {
+	WriteLines(lines []render.TextLine)
+}
+
+// Verify interface implementation at compile time.
+var _ render.LineProvider = (*textEvaluator)(nil)
+
+// newTextEvaluator creates a text evaluator for the given configuration.
+func newTextEvaluator(api *lua.ConkyAPI, cfg *config.Config) *textEvaluator {
+	te := &textEvaluator{api: api}
+	te.SetConfig(cfg)
+	return te
+}
+
+// SetConfig replaces the template, template0-template9 definitions and
+// default text color with those from cfg. The template lines are compiled
+// as one text so that conditionals may span lines, after the definitions
+// are set, since setting them restarts the ${if_updatenr} cycle.
+func (te *textEvaluator) SetConfig(cfg *config.Config) {
+	te.api.SetTemplates(cfg.Text.Templates)
+
+	var template *lua.Template
+	if len(cfg.Text.Template) > 0 {
+		template = te.api.Compile(strings.Join(cfg.Text.Template, "\n"))
+	}
+
+	te.mu.Lock()
+	te.template = template
+	te.color = defaultTextColor(cfg.Colors.Default)
+	te.mu.Unlock()
+}
+
+// SetSinks replaces the sinks that receive each evaluation.
+func (te *textEvaluator) SetSinks(sinks ...textSink) {
+	te.mu.Lock()
+	te.sinks = sinks
+	te.mu.Unlock()
+}
+
+// Lines evaluates the template and returns the laid-out text lines,
+// passing them to the configured sinks as well. Each call counts as one
+// update cycle for ${updates}.
+func (te *textEvaluator) Lines() []render.TextLine {
+	te.api.IncrementUpdates()
+
+	lines := te.evaluate(false)
+
+	te.mu.RLock()
+	sinks := te.sinks
+	te.mu.RUnlock()
+	for _, sink := range sinks {
+		sink.WriteLines(lines)
+	}
+	return lines
+}
+
+// Preview evaluates the template for showing text before the first update,
+// such as when the window opens or the configuration is reloaded. Unlike
+// Lines it counts no update cycle, writes nothing to the sinks and leaves
+// variables with side effects, such as ${exec}, ${execi}, ${curl} and
+// ${lua}, empty until the first update runs them.
+func (te *textEvaluator) Preview() []render.TextLine {
+	return te.evaluate(true)
+}
+
+// evaluate executes the template, or previews it if preview is set, and
+// lays out the resulting lines.
+func (te *textEvaluator) evaluate(preview bool) []render.TextLine {
+	te.mu.RLock()
+	template := te.template
+	textColor := te.color
+	te.mu.RUnlock()
+
+	var texts []string
+	if template != nil {
+		var text string
+		if preview {
+			text = template.Preview()
+		} else {
+			text = template.Execute()
+		}
+		texts = strings.Split(text, "\n")
+	}
+	lines := make([]render.TextLine, 0, len(texts))
+	y := defaultTextStartY
+	for _, text := range texts {
+		lines = append(lines, render.TextLine{
+			Text:  text,
+			X:     defaultTextStartX,
+			Y:     y,
+			Color: textColor,
+		})
+		y += defaultLineHeight
+	}
+	return lines
+}
+
+// defaultTextColor returns clr, or opaque white if clr is unset.
+func defaultTextColor(clr color.RGBA) color.RGBA {
+	if clr == (color.RGBA{}) {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	return clr
+}
@@ -0,0 +1,140 @@
+package conky
+
+import (
+	"image/color"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/go-conky/internal/config"
+	"github.com/opd-ai/go-conky/internal/lua"
+	"github.com/opd-ai/go-conky/internal/monitor"
+	"github.com/opd-ai/go-conky/internal/render"
+)
+
+func newTestTextEvaluator(t *testing.T, cfg *config.Config) *textEvaluator {
+	t.Helper()
+
+	runtime, err := lua.New(lua.DefaultConfig())
+	if err != nil {
+		t.Fatalf("lua.New failed: %v", err)
+	}
+	t.Cleanup(func() { _ = runtime.Close() })
+
+	api, err := lua.NewConkyAPI(runtime, monitor.NewSystemMonitor(time.Second))
+	if err != nil {
+		t.Fatalf("NewConkyAPI failed: %v", err)
+	}
+	t.Cleanup(func() { _ = api.Close() })
+
+	return newTextEvaluator(api, cfg)
+}
+
+func TestTextEvaluatorLines(t *testing.T) {
+	cfg := &config.Config{
+		Text: config.TextConfig{
+			Template: []string{"Year: ${time %Y}", "plain"},
+		},
+	}
+	te := newTestTextEvaluator(t, cfg)
+
+	lines := te.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	wantYear := "Year: " + strconv.Itoa(time.Now().Year())
+	if lines[0].Text != wantYear {
+		t.Errorf("lines[0].Text = %q, want %q", lines[0].Text, wantYear)
+	}
+	if lines[1].Text != "plain" {
+		t.Errorf("lines[1].Text = %q, want %q", lines[1].Text, "plain")
+	}
+	if lines[1].Y-lines[0].Y != defaultLineHeight {
+		t.Errorf("line spacing = %v, want %v", lines[1].Y-lines[0].Y, defaultLineHeight)
+	}
+	if lines[0].Color != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("default color = %v, want white", lines[0].Color)
+	}
+}
+
+func TestTextEvaluatorSetConfig(t *testing.T) {
+	te := newTestTextEvaluator(t, &config.Config{
+		Text: config.TextConfig{Template: []string{"old"}},
+	})
+
+	red := color.RGBA{R: 255, A: 255}
+	newCfg := &config.Config{
+		Text: config.TextConfig{
+			Template:  []string{"${template0 x}"},
+			Templates: [10]string{"new \\1"},
+		},
+		Colors: config.ColorConfig{Default: red},
+	}
+	te.SetConfig(newCfg)
+
+	lines := te.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	if lines[0].Text != "new x" {
+		t.Errorf("Text = %q, want %q", lines[0].Text, "new x")
+	}
+	if lines[0].Color != red {
+		t.Errorf("Color = %v, want %v", lines[0].Color, red)
+	}
+}
+
+func TestTextEvaluatorMultiLineConditional(t *testing.T) {
+	te := newTestTextEvaluator(t, &config.Config{
+		Text: config.TextConfig{
+			Template: []string{
+				"first",
+				"${if_existing /nonexistent/path}",
+				"hidden",
+				"${else}shown",
+				"${endif}last",
+			},
+		},
+	})
+
+	lines := te.Lines()
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	want := []string{"first", "shown", "last"}
+	if !slices.Equal(texts, want) {
+		t.Errorf("lines = %q, want %q", texts, want)
+	}
+}
+
+// recordingSink counts the evaluations written to it.
+type recordingSink struct{ writes int }
+
+func (s *recordingSink) WriteLines([]render.TextLine) { s.writes++ }
+
+func TestTextEvaluatorPreview(t *testing.T) {
+	te := newTestTextEvaluator(t, &config.Config{
+		Text: config.TextConfig{Template: []string{"updates ${updates}"}},
+	})
+	sink := &recordingSink{}
+	te.SetSinks(sink)
+
+	lines := te.Preview()
+	if len(lines) != 1 || lines[0].Text != "updates 0" {
+		t.Fatalf("Preview() = %+v, want one line %q", lines, "updates 0")
+	}
+	if sink.writes != 0 {
+		t.Errorf("Preview wrote to the sinks %d times, want 0", sink.writes)
+	}
+
+	lines = te.Lines()
+	if lines[0].Text != "updates 1" {
+		t.Errorf("Lines() after Preview = %q, want %q", lines[0].Text, "updates 1")
+	}
+	if sink.writes != 1 {
+		t.Errorf("Lines wrote to the sinks %d times, want 1", sink.writes)
+	}
+}
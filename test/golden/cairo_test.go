@@ -0,0 +1,250 @@
+//go:build golden
+
+// Package golden renders Lua Cairo scripts offscreen and compares the
+// results with reference images in testdata.
+//
+// Reading pixels back requires a running Ebiten game loop, so these tests
+// run inside one and need a display:
+//
+//	xvfb-run -a go test -tags=golden ./test/golden/
+//
+// Run with -update to rewrite the reference images from the current output
+// after an intended rendering change, and review the new images.
+package golden
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/opd-ai/go-conky/internal/lua"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images from the current output")
+
+// canvasSize is the width and height of every scene.
+const canvasSize = 32
+
+// tolerance is the largest per-channel difference accepted, which absorbs
+// rounding differences between GPUs.
+const tolerance = 3
+
+// game runs the tests from its first update, inside the game loop.
+type game struct {
+	m    *testing.M
+	code int
+}
+
+func (g *game) Update() error {
+	g.code = g.m.Run()
+	return ebiten.Termination
+}
+
+func (*game) Draw(*ebiten.Image) {}
+
+func (*game) Layout(int, int) (int, int) {
+	return canvasSize, canvasSize
+}
+
+func TestMain(m *testing.M) {
+	g := &game{m: m, code: 1}
+	if err := ebiten.RunGame(g); err != nil {
+		fmt.Fprintln(os.Stderr, "golden: running the game loop:", err)
+		os.Exit(1)
+	}
+	os.Exit(g.code)
+}
+
+// helpers defines the Lua functions the scenes build their sources with.
+const helpers = `
+local function surface(w, h, draw)
+	local s = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, w, h)
+	local c = cairo_create(s)
+	draw(c)
+	cairo_destroy(c)
+	return s
+end
+
+local function box(c, r, g, b, x, y, w, h)
+	cairo_set_source_rgb(c, r, g, b)
+	cairo_rectangle(c, x, y, w, h)
+	cairo_fill(c)
+end
+
+-- Album art stand-in: red, green, blue and white quadrants
+local function art(size)
+	local half = size / 2
+	return surface(size, size, function(c)
+		box(c, 1, 0, 0, 0, 0, half, half)
+		box(c, 0, 1, 0, half, 0, half, half)
+		box(c, 0, 0, 1, 0, half, half, half)
+		box(c, 1, 1, 1, half, half, half, half)
+	end)
+end
+`
+
+var scenes = []struct {
+	name   string
+	script string
+}{
+	{"source_surface", `
+		cairo_set_source_surface(cr, art(16), 8, 4)
+		cairo_paint(cr)`},
+	{"pattern_for_surface", `
+		cairo_set_source(cr, cairo_pattern_create_for_surface(art(16)))
+		cairo_paint(cr)`},
+	{"mask_album_art", `
+		cairo_set_source_surface(cr, art(32), 0, 0)
+		local disc = cairo_pattern_create_radial(16, 16, 12, 16, 16, 12)
+		cairo_pattern_add_color_stop_rgba(disc, 0, 1, 1, 1, 0)
+		cairo_pattern_add_color_stop_rgba(disc, 1, 1, 1, 1, 1)
+		cairo_mask(cr, disc)`},
+	{"mask_surface", `
+		local left = surface(16, 16, function(c) box(c, 1, 1, 1, 0, 0, 8, 16) end)
+		cairo_set_source_rgb(cr, 0, 0, 1)
+		cairo_mask_surface(cr, left, 8, 8)`},
+	{"mask_surface_art", `
+		local square = surface(16, 16, function(c) box(c, 1, 1, 1, 0, 0, 16, 16) end)
+		cairo_set_source_surface(cr, art(32), 0, 0)
+		cairo_mask_surface(cr, square, 8, 8)`},
+	{"group_to_source", `
+		cairo_push_group(cr)
+		box(cr, 1, 0, 0, 0, 0, 32, 32)
+		box(cr, 0, 0, 1, 8, 8, 16, 16)
+		cairo_pop_group_to_source(cr)
+		cairo_paint_with_alpha(cr, 0.5)`},
+	{"pop_group", `
+		cairo_push_group(cr)
+		box(cr, 0, 1, 0, 4, 4, 8, 8)
+		local group = cairo_pop_group(cr)
+		cairo_set_source(cr, group)
+		cairo_set_source_rgb(cr, 1, 1, 1)
+		cairo_paint(cr)
+		cairo_set_source(cr, group)
+		cairo_paint(cr)`},
+}
+
+func TestCairoGolden(t *testing.T) {
+	for _, scene := range scenes {
+		t.Run(scene.name, func(t *testing.T) {
+			got := render(t, scene.script)
+			path := filepath.Join("testdata", scene.name+".png")
+			if *update {
+				writePNG(t, path, got)
+				return
+			}
+			compare(t, got, readPNG(t, path))
+		})
+	}
+}
+
+// render runs script with cr drawing on a transparent canvas and returns
+// the canvas as read by cairo_image_surface_get_data.
+func render(t *testing.T, script string) *image.RGBA {
+	t.Helper()
+	runtime, err := lua.New(lua.DefaultConfig())
+	if err != nil {
+		t.Fatalf("creating runtime: %v", err)
+	}
+	defer runtime.Close()
+	if _, err := lua.NewCairoBindings(runtime); err != nil {
+		t.Fatalf("creating Cairo bindings: %v", err)
+	}
+
+	code := helpers + fmt.Sprintf(`
+		local cs = cairo_image_surface_create(CAIRO_FORMAT_ARGB32, %d, %d)
+		local cr = cairo_create(cs)
+		%s
+		cairo_destroy(cr)
+		assert(cairo_image_surface_get_width(cs) == %[1]d)
+		assert(cairo_image_surface_get_height(cs) == %[2]d)
+		return assert(cairo_image_surface_get_data(cs))`, canvasSize, canvasSize, script)
+	value, err := runtime.ExecuteString("scene", code)
+	if err != nil {
+		t.Fatalf("running scene: %v", err)
+	}
+	data, ok := value.TryString()
+	if !ok || len(data) != canvasSize*canvasSize*4 {
+		t.Fatalf("cairo_image_surface_get_data returned %d bytes, want %d", len(data), canvasSize*canvasSize*4)
+	}
+
+	// Convert premultiplied native-endian ARGB32 values to RGBA
+	img := image.NewRGBA(image.Rect(0, 0, canvasSize, canvasSize))
+	for i := 0; i < len(data); i += 4 {
+		argb := binary.NativeEndian.Uint32([]byte(data[i : i+4]))
+		img.Pix[i] = uint8(argb >> 16)
+		img.Pix[i+1] = uint8(argb >> 8)
+		img.Pix[i+2] = uint8(argb)
+		img.Pix[i+3] = uint8(argb >> 24)
+	}
+	return img
+}
+
+// compare reports the pixels of got that differ from want.
+func compare(t *testing.T, got, want *image.RGBA) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("size = %v, want %v", got.Bounds(), want.Bounds())
+	}
+	mismatches := 0
+	for y := 0; y < canvasSize; y++ {
+		for x := 0; x < canvasSize; x++ {
+			g, w := got.RGBAAt(x, y), want.RGBAAt(x, y)
+			if !similar(g, w) {
+				if mismatches < 5 {
+					t.Errorf("pixel (%d, %d) = %v, want %v", x, y, g, w)
+				}
+				mismatches++
+			}
+		}
+	}
+	if mismatches > 0 {
+		t.Errorf("%d pixels differ from the golden image", mismatches)
+	}
+}
+
+// similar reports whether every channel of a and b is within tolerance.
+func similar(a, b color.RGBA) bool {
+	near := func(x, y uint8) bool {
+		d := int(x) - int(y)
+		return d >= -tolerance && d <= tolerance
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+func readPNG(t *testing.T, path string) *image.RGBA {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("reading golden image (run with -update to create it): %v", err)
+	}
+	defer f.Close()
+	decoded, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	img := image.NewRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	return img
+}
+
+func writePNG(t *testing.T, path string, img *image.RGBA) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encoding %s: %v", path, err)
+	}
+}